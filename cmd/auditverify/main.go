@@ -0,0 +1,74 @@
+// Command auditverify checks the audit log hash chain and its signed
+// checkpoints, printing the report as JSON. It exits with status 1 when the
+// chain does not verify.
+//
+// Checkpoints are verified against -public-key, the hex-encoded Ed25519 key
+// the service logs at startup. Pin it somewhere the database and the service
+// configuration cannot change it, a key derived from the same configuration
+// would trust whoever rewrote the chain with it.
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/services"
+	"github.com/m1thrandir225/whoami/internal/util"
+)
+
+func main() {
+	configPath := flag.String("config", "..", "directory holding the .env file")
+	publicKeyHex := flag.String("public-key", "", "hex-encoded Ed25519 key checkpoints must be signed with")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	config, err := util.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	if *publicKeyHex == "" {
+		log.Fatalf("-public-key is required")
+	}
+	publicKey, err := hex.DecodeString(*publicKeyHex)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		log.Fatalf("-public-key must be a hex-encoded Ed25519 public key")
+	}
+
+	connPool, err := pgxpool.New(ctx, config.DBSource)
+	if err != nil {
+		log.Fatalf("Could not connect to database: %v", err)
+	}
+	defer connPool.Close()
+
+	auditChainService := services.NewAuditChainService(
+		repositories.NewAuditChainRepository(db.NewStore(connPool)),
+		nil,
+		publicKey,
+	)
+
+	report, err := auditChainService.Verify(ctx)
+	if err != nil {
+		log.Fatalf("failed to verify audit log chain: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("failed to write report: %v", err)
+	}
+
+	if !report.Valid {
+		connPool.Close()
+		os.Exit(1)
+	}
+}
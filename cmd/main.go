@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,6 +19,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/events"
+	"github.com/m1thrandir225/whoami/internal/grpcserver"
 	"github.com/m1thrandir225/whoami/internal/handlers"
 	"github.com/m1thrandir225/whoami/internal/mail"
 	"github.com/m1thrandir225/whoami/internal/oauth"
@@ -22,7 +28,10 @@ import (
 	"github.com/m1thrandir225/whoami/internal/security"
 	"github.com/m1thrandir225/whoami/internal/services"
 	"github.com/m1thrandir225/whoami/internal/util"
+	"github.com/m1thrandir225/whoami/pkg/pb/whoamiv1"
 	"github.com/m1thrandir225/whoami/pkg/redis"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -57,12 +66,38 @@ func main() {
 	/**
 	* Create rate limiter
 	 */
-	rateLimiter, err := security.NewRateLimiter(redisClient)
+	rateLimitFailureMode, err := security.ParseRateLimitFailureMode(config.RateLimitFailureMode)
+	if err != nil {
+		log.Fatalf("invalid rate limit failure mode: %v", err)
+	}
+	rateLimiter, err := security.NewRateLimiter(redisClient, rateLimitFailureMode)
 	if err != nil {
 		log.Fatalf("Could not create rate limiter: %v", err)
 	}
 	defer rateLimiter.Close()
 
+	if config.RateLimitPoliciesFile != "" {
+		rateLimitPolicies, err := security.LoadRateLimitPolicies(config.RateLimitPoliciesFile)
+		if err != nil {
+			log.Fatalf("Could not load rate limit policies: %v", err)
+		}
+		rateLimiter.SetPolicies(rateLimitPolicies)
+
+		reloadInterval := config.RateLimitPoliciesReloadInterval
+		if reloadInterval <= 0 {
+			reloadInterval = 30 * time.Second
+		}
+		go rateLimiter.WatchPolicies(ctx, config.RateLimitPoliciesFile, reloadInterval)
+	}
+
+	/**
+	* Create client IP resolver
+	 */
+	clientIPResolver, err := security.NewClientIPResolver(config.TrustedProxies, config.ClientIPHeader)
+	if err != nil {
+		log.Fatalf("invalid client IP configuration: %v", err)
+	}
+
 	/**
 	* Create token maker
 	 */
@@ -92,9 +127,17 @@ func main() {
 	passwordResetRepository := repositories.NewPasswordResetRepository(dbStore)
 	loginAttemptsRepository := repositories.NewLoginAttemptsRepository(dbStore)
 	auditLogsRepository := repositories.NewAuditLogsRepository(dbStore)
+	auditChainRepository := repositories.NewAuditChainRepository(dbStore)
 	userDevicesRepository := repositories.NewUserDevicesRepository(dbStore)
 	dataExportsRepository := repositories.NewDataExportsRepository(dbStore)
 	oauthAccountsRepository := repositories.NewOAuthAccountsRepository(dbStore)
+	applicationsRepository := repositories.NewApplicationsRepository(dbStore)
+	ipAccessRulesRepository := repositories.NewIPAccessRulesRepository(dbStore)
+	loginAlertsRepository := repositories.NewLoginAlertsRepository(dbStore)
+	webhooksRepository := repositories.NewWebhooksRepository(dbStore)
+	outboxRepository := repositories.NewOutboxRepository(dbStore)
+	securityDashboardRepository := repositories.NewSecurityDashboardRepository(dbStore)
+	captchaRequirementsRepository := repositories.NewCaptchaRequirementsRepository(dbStore)
 
 	/*
 	* OAuth Providers
@@ -122,11 +165,42 @@ func main() {
 	* Services
 	 */
 	userService := services.NewUserService(userRepository)
+
+	// Offline GeoIP lookups, a nil resolver finds nothing
+	var geoIPResolver *security.GeoIPResolver
+	if config.GeoIPCityDBPath != "" || config.GeoIPASNDBPath != "" {
+		geoIPResolver, err = security.NewGeoIPResolver(config.GeoIPCityDBPath, config.GeoIPASNDBPath)
+		if err != nil {
+			log.Fatalf("Could not open GeoIP databases: %v", err)
+		}
+		defer geoIPResolver.Close()
+	}
+
 	securityService := services.NewSecurityService(
 		loginAttemptsRepository,
 		suspiciousActivityRepository,
 		accountLockoutRepository,
 		userRepository,
+		mailService,
+		config.FrontendURL,
+		services.LockoutPolicy{
+			MaxFailures:      config.LockoutMaxFailures,
+			FailureWindow:    config.LockoutFailureWindow,
+			BaseDuration:     config.LockoutBaseDuration,
+			MaxDuration:      config.LockoutMaxDuration,
+			EscalationWindow: config.LockoutEscalationWindow,
+			PermanentAfter:   config.LockoutPermanentAfter,
+			DelayAfter:       config.LockoutDelayAfter,
+			BaseDelay:        config.LockoutBaseDelay,
+			MaxDelay:         config.LockoutMaxDelay,
+			HistoryRetention: config.LockoutHistoryRetention,
+		},
+		geoIPResolver,
+		services.TravelPolicy{
+			MaxSpeedKmh:   config.ImpossibleTravelSpeedKmh,
+			MinDistanceKm: config.ImpossibleTravelMinDistanceKm,
+		},
+		security.NewRedisLoginThrottle(redisClient),
 	)
 	passwordSecurityService := services.NewPasswordSecurityService(
 		passwordHistoryRepository,
@@ -140,14 +214,117 @@ func main() {
 	passwordResetService := services.NewPasswordResetService(
 		passwordResetRepository,
 		userRepository,
+		accountLockoutRepository,
 		passwordSecurityService,
 		mailService,
 		config.FrontendURL,
 	)
 	auditService := services.NewAuditService(auditLogsRepository)
 	tokenBlacklist := security.NewTokenBlacklist(redisClient)
-	sessionService := services.NewSessionService(redisClient, tokenBlacklist)
+
+	sessionStore, err := repositories.NewSessionStore(config.SessionStore, dbStore, redisClient, config.SessionCacheEnabled)
+	if err != nil {
+		log.Fatalf("invalid session store configuration: %v", err)
+	}
+
+	sessionTimeouts := services.SessionTimeouts{
+		Idle:     config.SessionIdleTimeout,
+		Absolute: config.SessionAbsoluteTimeout,
+	}
+	if sessionTimeouts.Absolute <= 0 {
+		sessionTimeouts.Absolute = config.RefreshTokenDuration
+	}
+	roleSessionTimeouts, err := services.ParseRoleSessionTimeouts(config.SessionRoleTimeouts)
+	if err != nil {
+		log.Fatalf("invalid session role timeouts: %v", err)
+	}
+	roleSessionLimits, err := services.ParseRoleSessionLimits(config.SessionRoleMaxActive)
+	if err != nil {
+		log.Fatalf("invalid session role limits: %v", err)
+	}
+	sessionLimits := services.SessionLimits{
+		MaxActive:     config.SessionMaxActive,
+		RoleMaxActive: roleSessionLimits,
+		Policy:        services.SessionLimitPolicy(config.SessionLimitPolicy),
+	}
+	switch sessionLimits.Policy {
+	case "":
+		sessionLimits.Policy = services.SessionLimitReject
+	case services.SessionLimitReject, services.SessionLimitEvictOldest:
+	default:
+		log.Fatalf("unknown session limit policy: %s", config.SessionLimitPolicy)
+	}
+	logoutTokenIssuer := config.LogoutTokenIssuer
+	if logoutTokenIssuer == "" {
+		logoutTokenIssuer = "whoami"
+	}
+	applicationService := services.NewApplicationService(applicationsRepository, logoutTokenIssuer)
+	sessionService := services.NewSessionService(sessionStore, tokenBlacklist, sessionTimeouts, roleSessionTimeouts, sessionLimits, security.NewRedisLocker(redisClient), applicationService)
 	userDevicesService := services.NewUserDevicesService(userDevicesRepository)
+	loginAlertService := services.NewLoginAlertService(
+		loginAlertsRepository,
+		userDevicesRepository,
+		userRepository,
+		sessionService,
+		securityService,
+		passwordResetService,
+		mailService,
+		config.FrontendURL,
+		config.LoginAlertTokenTTL,
+	)
+	suspiciousActivityService := services.NewSuspiciousActivityService(
+		suspiciousActivityRepository,
+		userRepository,
+		sessionService,
+		securityService,
+		passwordResetService,
+	)
+
+	ipLockoutPolicy := services.IPLockoutPolicy{
+		Window:            config.IPLockoutWindow,
+		Duration:          config.IPLockoutDuration,
+		MaxFailures:       config.IPLockoutMaxFailures,
+		MinAccounts:       config.IPLockoutMinAccounts,
+		SubnetMaxFailures: config.IPLockoutSubnetMaxFailures,
+	}
+	if ipLockoutPolicy.Window <= 0 {
+		ipLockoutPolicy.Window = 15 * time.Minute
+	}
+	if ipLockoutPolicy.Duration <= 0 {
+		ipLockoutPolicy.Duration = time.Hour
+	}
+	ipAccessService := services.NewIPAccessService(ipAccessRulesRepository, loginAttemptsRepository, ipLockoutPolicy)
+	if err := ipAccessService.Refresh(ctx); err != nil {
+		log.Fatalf("Could not load IP access rules: %v", err)
+	}
+	go ipAccessService.WatchRules(ctx, 30*time.Second)
+
+	// Failed logins only keep a keyed fingerprint of the password for the
+	// password detector, and only with a key of its own
+	var passwordFingerprinter *security.PasswordFingerprinter
+	if config.CredentialStuffingPasswordMaxEmails > 0 {
+		if config.PasswordFingerprintKey == "" {
+			log.Printf("PASSWORD_FINGERPRINT_KEY is not set, the credential stuffing password detector is disabled")
+		} else {
+			passwordFingerprinter = security.NewPasswordFingerprinter(config.PasswordFingerprintKey)
+		}
+	}
+	credentialStuffingService := services.NewCredentialStuffingService(
+		loginAttemptsRepository,
+		captchaRequirementsRepository,
+		suspiciousActivityRepository,
+		ipAccessService,
+		geoIPResolver,
+		passwordFingerprinter,
+		services.CredentialStuffingPolicy{
+			Window:             config.CredentialStuffingWindow,
+			CaptchaDuration:    config.CredentialStuffingCaptchaDuration,
+			IPMaxEmails:        config.CredentialStuffingIPMaxEmails,
+			ASNMinAttempts:     config.CredentialStuffingASNMinAttempts,
+			ASNMaxFailureRatio: config.CredentialStuffingASNMaxFailureRatio,
+			PasswordMaxEmails:  config.CredentialStuffingPasswordMaxEmails,
+		},
+	)
 
 	exportDir := "./exports"
 	if err := os.MkdirAll(exportDir, 0755); err != nil {
@@ -166,6 +343,83 @@ func main() {
 		userRepository,
 	)
 	oauthTempService := services.NewOAuthTempService(redisClient)
+	webhookService := services.NewWebhookService(webhooksRepository, config.WebhookTimeout)
+	securityDashboardService := services.NewSecurityDashboardService(securityDashboardRepository)
+
+	// Audit chain checkpoints are signed with their own key, never one that
+	// also guards something else. Without it nothing is checkpointed.
+	var auditCheckpointSigner *security.AuditCheckpointSigner
+	var auditCheckpointPublicKey ed25519.PublicKey
+	if config.AuditCheckpointKey != "" {
+		auditCheckpointSigner = security.NewAuditCheckpointSigner(config.AuditCheckpointKey)
+		auditCheckpointPublicKey = auditCheckpointSigner.PublicKey()
+		log.Printf("audit checkpoints are signed with public key %s", hex.EncodeToString(auditCheckpointPublicKey))
+	} else if config.AuditCheckpointInterval > 0 {
+		log.Fatalf("AUDIT_CHECKPOINT_KEY must be set when AUDIT_CHECKPOINT_INTERVAL is not 0")
+	}
+	auditChainService := services.NewAuditChainService(
+		auditChainRepository,
+		auditCheckpointSigner,
+		auditCheckpointPublicKey,
+	)
+
+	// Outbox relay to the message bus, events are discarded when none is configured
+	eventPublisher, err := events.NewEventPublisher(events.PublisherConfig{
+		Backend:           config.EventPublisher,
+		FilePath:          config.EventFilePath,
+		NATSURL:           config.NATSURL,
+		NATSSubjectPrefix: config.NATSSubjectPrefix,
+		KafkaBrokers:      config.KafkaBrokers,
+		KafkaTopic:        config.KafkaTopic,
+		RedisStream:       config.EventRedisStream,
+		RedisStreamMaxLen: config.EventRedisStreamMaxLen,
+	}, redisClient)
+	if err != nil {
+		log.Fatalf("Could not create event publisher: %v", err)
+	}
+	defer eventPublisher.Close()
+	outboxRelayService := services.NewOutboxRelayService(
+		outboxRepository,
+		eventPublisher,
+		config.OutboxPublishTimeout,
+		config.OutboxRetention,
+	)
+
+	// Lazy migration from the legacy identity store, only when configured
+	var legacyMigrationService services.LegacyMigrationService
+	if config.LegacyAuthURL != "" {
+		legacyMigrationService = services.NewLegacyMigrationService(
+			security.NewLegacyIdentityClient(config.LegacyAuthURL, config.LegacyAuthSecret, config.LegacyAuthTimeout),
+			userRepository,
+		)
+	}
+
+	// CAPTCHA challenges for risky auth requests, only when configured
+	var captchaService services.CaptchaService
+	if config.CaptchaProvider != "" {
+		captchaVerifier, err := security.NewCaptchaVerifier(config.CaptchaProvider, config.CaptchaSecret, config.CaptchaMinScore, config.CaptchaTimeout)
+		if err != nil {
+			log.Fatalf("Could not create captcha verifier: %v", err)
+		}
+		captchaService = services.NewCaptchaService(
+			captchaVerifier,
+			loginAttemptsRepository,
+			captchaRequirementsRepository,
+			geoIPResolver,
+			config.CaptchaSiteKey,
+			services.CaptchaPolicy{
+				Window:        config.CaptchaWindow,
+				EmailFailures: config.CaptchaEmailFailures,
+				IPFailures:    config.CaptchaIPFailures,
+			},
+		)
+	}
+
+	// Forward auth takes the access token cookie without a CSRF check, which is
+	// only safe while browsers keep the cookie off cross-site requests
+	if config.CookieAccessTokenEnabled && strings.EqualFold(config.CookieSameSite, "none") {
+		log.Fatalf("COOKIE_ACCESS_TOKEN_ENABLED cannot be combined with COOKIE_SAME_SITE=none")
+	}
 
 	/**
 	* Create HTTP handler
@@ -186,6 +440,17 @@ func main() {
 		sessionService,
 		rateLimiter,
 		oauthTempService,
+		legacyMigrationService,
+		applicationService,
+		ipAccessService,
+		captchaService,
+		geoIPResolver,
+		loginAlertService,
+		webhookService,
+		securityDashboardService,
+		credentialStuffingService,
+		suspiciousActivityService,
+		auditChainService,
 		config,
 	)
 
@@ -217,23 +482,114 @@ func main() {
 				if err := dataExportsService.CleanupExpiredExports(ctx); err != nil {
 					log.Printf("failed to cleanup expired exports: %v", err)
 				}
+				if err := ipAccessService.CleanupExpiredRules(ctx); err != nil {
+					log.Printf("failed to cleanup expired IP access rules: %v", err)
+				}
+				if err := securityService.CleanupExpiredLockouts(ctx); err != nil {
+					log.Printf("failed to prune lockout history: %v", err)
+				}
+				if err := loginAlertService.CleanupExpiredAlerts(ctx); err != nil {
+					log.Printf("failed to cleanup expired login alerts: %v", err)
+				}
+				if err := outboxRelayService.CleanupPublishedEvents(ctx); err != nil {
+					log.Printf("failed to cleanup published outbox events: %v", err)
+				}
+				if err := credentialStuffingService.CleanupExpiredRequirements(ctx); err != nil {
+					log.Printf("failed to cleanup expired captcha requirements: %v", err)
+				}
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := applicationService.ProcessPendingLogoutDeliveries(ctx); err != nil {
+					log.Printf("failed to process pending logout deliveries: %v", err)
+				}
+				if err := webhookService.ProcessPendingDeliveries(ctx); err != nil {
+					log.Printf("failed to process pending webhook deliveries: %v", err)
+				}
 			}
 		}
 	}()
 
+	go func() {
+		pollInterval := config.OutboxPollInterval
+		if pollInterval <= 0 {
+			pollInterval = time.Second
+		}
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Keep going while there is a backlog instead of waiting a tick per batch
+				for ctx.Err() == nil {
+					published, err := outboxRelayService.ProcessPendingEvents(ctx)
+					if err != nil {
+						log.Printf("failed to relay outbox events: %v", err)
+					}
+					if err != nil || published == 0 {
+						break
+					}
+				}
+			}
+		}
+	}()
+
+	if config.AuditCheckpointInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(config.AuditCheckpointInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if _, err := auditChainService.Checkpoint(ctx); err != nil {
+						log.Printf("failed to checkpoint audit log chain: %v", err)
+					}
+				}
+			}
+		}()
+	}
+
 	if config.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.Default()
 
+	// Keep gin's own ClientIP (used by its request logger) in line with the
+	// resolver the rest of the service relies on
+	if err := router.SetTrustedProxies(clientIPResolver.TrustedProxies()); err != nil {
+		log.Fatalf("invalid trusted proxies: %v", err)
+	}
+	if header := clientIPResolver.Header(); header != security.ClientIPHeaderNone {
+		router.RemoteIPHeaders = []string{header}
+	} else {
+		router.ForwardedByClientIP = false
+	}
+	router.Use(clientIPResolver.Middleware())
+
 	//Cors Setup
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     config.AllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", handlers.CSRFTokenHeaderKey},
 		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: false,
+		AllowCredentials: config.CookieAuthEnabled,
 		MaxAge:           12 * time.Hour,
 	}))
 
@@ -261,6 +617,71 @@ func main() {
 		}
 	}()
 
+	/**
+	* Start metrics server
+	 */
+	var metricsServer *http.Server
+	if config.MetricsAddress != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", promhttp.Handler())
+		metricsServer = &http.Server{
+			Addr:    config.MetricsAddress,
+			Handler: metricsMux,
+		}
+
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("listen (metrics): %s\n", err)
+			}
+		}()
+	} else if config.MetricsToken == "" {
+		log.Printf("METRICS_ADDRESS and METRICS_TOKEN are not set, metrics are not served")
+	}
+
+	/**
+	* Start gRPC server
+	 */
+	var grpcServer *grpc.Server
+	if config.GRPCPort != 0 {
+		grpcOptions := []grpc.ServerOption{
+			grpc.UnaryInterceptor(grpcserver.ServiceAuthInterceptor(config.GRPCServiceTokens)),
+		}
+		// Service tokens and the tokens being verified travel in the clear
+		// without TLS, so plaintext needs an explicit opt-in
+		switch {
+		case config.GRPCTLSCertFile != "":
+			grpcCredentials, err := grpcserver.NewServerCredentials(config.GRPCTLSCertFile, config.GRPCTLSKeyFile, config.GRPCClientCAFile)
+			if err != nil {
+				log.Fatalf("Could not create gRPC credentials: %v", err)
+			}
+			grpcOptions = append(grpcOptions, grpc.Creds(grpcCredentials))
+		case config.GRPCAllowInsecure:
+			log.Printf("gRPC is served without TLS because GRPC_ALLOW_INSECURE is set, never set it in production")
+		default:
+			log.Fatalf("GRPC_PORT is set without GRPC_TLS_CERT_FILE, set GRPC_ALLOW_INSECURE=true to serve gRPC without TLS in development")
+		}
+
+		grpcServer = grpc.NewServer(grpcOptions...)
+		whoamiv1.RegisterIdentityServiceServer(grpcServer, grpcserver.NewServer(
+			userService,
+			sessionService,
+			auditService,
+			tokenMaker,
+			tokenBlacklist,
+		))
+
+		grpcListener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", config.HTTPServerAddress, config.GRPCPort))
+		if err != nil {
+			log.Fatalf("Could not listen for gRPC: %v", err)
+		}
+
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				log.Fatalf("listen (gRPC): %s\n", err)
+			}
+		}()
+	}
+
 	/**
 	* Wait for shutdown signal
 	 */
@@ -275,4 +696,11 @@ func main() {
 	 */
 	_, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
+
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
 }
@@ -10,7 +10,7 @@ import {
 } from '@/components/ui/select'
 import auditService from '@/services/audit.service'
 import { AuditActions, AuditResourceTypes } from '@/types/models/audit_log'
-import { useQuery } from '@tanstack/react-query'
+import { useInfiniteQuery } from '@tanstack/react-query'
 import { createFileRoute } from '@tanstack/react-router'
 import { Activity, Lock, Mail, Shield, User } from 'lucide-react'
 import { useState } from 'react'
@@ -25,24 +25,26 @@ function RouteComponent() {
   )
   const [filterValue, setFilterValue] = useState('')
 
-  // Get audit logs based on filter
-  const { data: auditLogs, isLoading } = useQuery({
+  // Get audit logs based on filter, a page at a time
+  const {
+    data: auditLogPages,
+    isLoading,
+    hasNextPage,
+    fetchNextPage,
+    isFetchingNextPage,
+  } = useInfiniteQuery({
     queryKey: ['audit-logs', filter, filterValue],
-    queryFn: () => {
-      switch (filter) {
-        case 'action':
-          return filterValue
-            ? auditService.getAuditLogsByAction(filterValue)
-            : auditService.getRecentAuditLogs()
-        case 'resource':
-          return filterValue
-            ? auditService.getAuditLogsByResourceType(filterValue)
-            : auditService.getRecentAuditLogs()
-        default:
-          return auditService.getRecentAuditLogs()
-      }
-    },
+    queryFn: ({ pageParam }) =>
+      auditService.searchAuditLogs({
+        action: filter === 'action' && filterValue ? filterValue : undefined,
+        resource_type:
+          filter === 'resource' && filterValue ? filterValue : undefined,
+        cursor: pageParam,
+      }),
+    initialPageParam: undefined as string | undefined,
+    getNextPageParam: (lastPage) => lastPage.next_cursor ?? undefined,
   })
+  const auditLogs = auditLogPages?.pages.flatMap((page) => page.audit_logs)
 
   const getActionIcon = (action: string) => {
     if (action.includes('login') || action.includes('logout'))
@@ -150,7 +152,7 @@ function RouteComponent() {
         </CardContent>
       </Card>
       {/* Audit Logs */}
-      {!auditLogs?.audit_logs?.length ? (
+      {!auditLogs?.length ? (
         <Card>
           <CardContent className="flex items-center justify-center h-32">
             <p className="text-muted-foreground">No audit logs found.</p>
@@ -158,7 +160,7 @@ function RouteComponent() {
         </Card>
       ) : (
         <div className="flex flex-col gap-3 max-w-[1200px] max-h-[500px] overflow-y-auto p-6">
-          {auditLogs.audit_logs.map((log) => (
+          {auditLogs.map((log) => (
             <Card key={log.id} className="max-w-full">
               <CardContent className="pt-6 w-full">
                 <div className="flex items-start justify-between">
@@ -223,6 +225,15 @@ function RouteComponent() {
               </CardContent>
             </Card>
           ))}
+          {hasNextPage && (
+            <Button
+              variant="outline"
+              onClick={() => fetchNextPage()}
+              disabled={isFetchingNextPage}
+            >
+              {isFetchingNextPage ? 'Loading...' : 'Load more'}
+            </Button>
+          )}
         </div>
       )}
     </div>
@@ -68,7 +68,7 @@ function RouteComponent() {
 
   const { data: recentAuditLogs } = useQuery({
     queryKey: ['recent-audit-logs'],
-    queryFn: () => auditService.getRecentAuditLogs(),
+    queryFn: () => auditService.searchAuditLogs({ limit: 5 }),
     enabled: !!authStore.User,
   })
   return (
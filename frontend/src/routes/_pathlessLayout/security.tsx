@@ -53,16 +53,16 @@ function RouteComponent() {
     queryFn: exportsService.getDataExports,
   })
 
-  // Resolve suspicious activity mutation
-  const resolveActivityMutation = useMutation({
+  // Acknowledge suspicious activity mutation
+  const acknowledgeActivityMutation = useMutation({
     mutationFn: (activityId: number) =>
-      securityService.resolveSuspiciousActivity({ activity_id: activityId }),
+      securityService.acknowledgeSuspiciousActivity(activityId),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['suspicious-activities'] })
-      toast.success('Activity resolved successfully')
+      toast.success('Activity acknowledged')
     },
     onError: (error) => {
-      toast.error(error.message || 'Failed to resolve activity')
+      toast.error(error.message || 'Failed to acknowledge activity')
     },
   })
 
@@ -124,6 +124,21 @@ function RouteComponent() {
     }
   }
 
+  const getActivityStatusLabel = (status: string) => {
+    switch (status) {
+      case 'acknowledged':
+        return 'Acknowledged'
+      case 'confirmed_benign':
+        return 'Confirmed benign'
+      case 'confirmed_malicious':
+        return 'Confirmed malicious'
+      case 'resolved':
+        return 'Resolved'
+      default:
+        return 'Open'
+    }
+  }
+
   const getStatusIcon = (status: string) => {
     switch (status) {
       case 'completed':
@@ -199,10 +214,10 @@ function RouteComponent() {
                         <span className="font-medium">
                           {activity.activity_type}
                         </span>
-                        {activity.resolved && (
+                        {activity.status !== 'open' && (
                           <Badge variant="secondary">
                             <CheckCircle className="w-3 h-3 mr-1" />
-                            Resolved
+                            {getActivityStatusLabel(activity.status)}
                           </Badge>
                         )}
                       </div>
@@ -223,18 +238,18 @@ function RouteComponent() {
                       </div>
                     </div>
                   </div>
-                  {!activity.resolved && (
+                  {activity.status === 'open' && (
                     <Button
                       variant="outline"
                       size="sm"
                       onClick={() =>
-                        resolveActivityMutation.mutate(activity.id)
+                        acknowledgeActivityMutation.mutate(activity.id)
                       }
-                      disabled={resolveActivityMutation.isPending}
+                      disabled={acknowledgeActivityMutation.isPending}
                     >
-                      {resolveActivityMutation.isPending
-                        ? 'Resolving...'
-                        : 'Resolve'}
+                      {acknowledgeActivityMutation.isPending
+                        ? 'Acknowledging...'
+                        : 'Acknowledge'}
                     </Button>
                   )}
                 </div>
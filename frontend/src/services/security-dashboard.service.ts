@@ -0,0 +1,81 @@
+import config from '@/lib/config'
+import type {
+	DashboardRangeParams,
+	LockoutSeriesResponse,
+	LoginSeriesResponse,
+	RegistrationSeriesResponse,
+	SecurityDashboardSummaryResponse,
+	SuspiciousActivitySeriesResponse,
+	TopOffendersResponse,
+} from '@/types/models/security_dashboard'
+import { apiRequest } from './api.service'
+
+const dashboardAPIUrl = `${config.apiUrl}/admin/dashboard`
+
+// Drops unset filters so the backend falls back to its defaults
+const toQueryParams = (params?: DashboardRangeParams & { limit?: number }) =>
+	params
+		? Object.fromEntries(
+				Object.entries(params)
+					.filter(([, value]) => value !== undefined)
+					.map(([key, value]) => [key, String(value)]),
+			)
+		: undefined
+
+const securityDashboardService = {
+	getSummary: (params?: DashboardRangeParams) =>
+		apiRequest<SecurityDashboardSummaryResponse>({
+			headers: undefined,
+			protected: true,
+			method: 'GET',
+			params: toQueryParams(params),
+			url: `${dashboardAPIUrl}/summary`,
+		}),
+
+	getLogins: (params?: DashboardRangeParams) =>
+		apiRequest<LoginSeriesResponse>({
+			headers: undefined,
+			protected: true,
+			method: 'GET',
+			params: toQueryParams(params),
+			url: `${dashboardAPIUrl}/logins`,
+		}),
+
+	getTopOffenders: (params?: DashboardRangeParams & { limit?: number }) =>
+		apiRequest<TopOffendersResponse>({
+			headers: undefined,
+			protected: true,
+			method: 'GET',
+			params: toQueryParams(params),
+			url: `${dashboardAPIUrl}/top-offenders`,
+		}),
+
+	getLockouts: (params?: DashboardRangeParams) =>
+		apiRequest<LockoutSeriesResponse>({
+			headers: undefined,
+			protected: true,
+			method: 'GET',
+			params: toQueryParams(params),
+			url: `${dashboardAPIUrl}/lockouts`,
+		}),
+
+	getSuspiciousActivities: (params?: DashboardRangeParams) =>
+		apiRequest<SuspiciousActivitySeriesResponse>({
+			headers: undefined,
+			protected: true,
+			method: 'GET',
+			params: toQueryParams(params),
+			url: `${dashboardAPIUrl}/suspicious-activities`,
+		}),
+
+	getRegistrations: (params?: DashboardRangeParams) =>
+		apiRequest<RegistrationSeriesResponse>({
+			headers: undefined,
+			protected: true,
+			method: 'GET',
+			params: toQueryParams(params),
+			url: `${dashboardAPIUrl}/registrations`,
+		}),
+}
+
+export default securityDashboardService
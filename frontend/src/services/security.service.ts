@@ -2,7 +2,7 @@ import config from '@/lib/config'
 import { apiRequest } from './api.service'
 import type {
 	SuspiciousActivity,
-	ResolveSuspiciousActivityRequest,
+	AcknowledgeSuspiciousActivityResponse,
 } from '@/types/models/suspicious_activity'
 import type { GenericMessageResponse } from '@/types/api/generic.response'
 
@@ -18,14 +18,13 @@ const securityService = {
 			url: `${securityAPIUrl}/activities`,
 		}),
 
-	resolveSuspiciousActivity: (data: ResolveSuspiciousActivityRequest) =>
-		apiRequest<GenericMessageResponse>({
+	acknowledgeSuspiciousActivity: (activityId: number) =>
+		apiRequest<AcknowledgeSuspiciousActivityResponse>({
 			headers: undefined,
 			protected: true,
 			method: 'POST',
 			params: undefined,
-			url: `${securityAPIUrl}/activities/resolve`,
-			data,
+			url: `${securityAPIUrl}/activities/${activityId}/acknowledge`,
 		}),
 
 	cleanupExpiredLockouts: () =>
@@ -26,10 +26,6 @@ export type UpdatePasswordRequest = {
 	new_password: string
 }
 
-export type ResolveSuspiciousActivityRequest = {
-	activity_id: number
-}
-
 export type VerifyEmailRequest = {
 	token: string
 }
@@ -1,5 +1,20 @@
 export type AuditLogResponse = {
 	audit_logs: AuditLog[]
+	next_cursor: string | null
+}
+
+// Filters of GET /audit/logs, unset ones match every entry
+export type AuditLogSearchParams = {
+	user_id?: number
+	action?: string
+	resource_type?: string
+	resource_id?: number
+	ip?: string
+	from?: string
+	to?: string
+	details?: string
+	cursor?: string
+	limit?: number
 }
 export type AuditLog = {
 	id: number
@@ -11,6 +26,8 @@ export type AuditLog = {
 	user_agent: string | null
 	details: Record<string, any> | null
 	created_at: string | null
+	chain_seq: number
+	entry_hash: string
 }
 
 // Common audit actions
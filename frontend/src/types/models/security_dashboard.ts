@@ -0,0 +1,123 @@
+export type DashboardBucket = 'hour' | 'day' | 'week'
+
+// Query parameters shared by every dashboard endpoint. from and to take
+// RFC 3339 timestamps or YYYY-MM-DD dates, to is exclusive.
+export type DashboardRangeParams = {
+	from?: string
+	to?: string
+	bucket?: DashboardBucket
+}
+
+export type DashboardRange = {
+	from: string
+	to: string
+	bucket: DashboardBucket
+}
+
+export type LoginTotals = {
+	successful: number
+	failed: number
+	failure_rate: number
+}
+
+export type ActiveLockouts = {
+	accounts: number
+	permanent_accounts: number
+	ips: number
+}
+
+export type SeverityCounts = {
+	low: number
+	medium: number
+	high: number
+	critical: number
+	total: number
+}
+
+export type MFAAdoption = {
+	active_users: number
+	mfa_enabled: number
+	rate: number
+}
+
+export type SecurityDashboardSummary = {
+	range: DashboardRange
+	logins: LoginTotals
+	registrations: number
+	active_lockouts: ActiveLockouts
+	unresolved_suspicious_activities: SeverityCounts
+	mfa_adoption: MFAAdoption
+}
+
+export type SecurityDashboardSummaryResponse = {
+	summary: SecurityDashboardSummary
+}
+
+export type LoginAttemptBucket = {
+	bucket: string
+	successful: number
+	failed: number
+}
+
+export type LoginSeriesResponse = {
+	range: DashboardRange
+	series: LoginAttemptBucket[]
+	totals: LoginTotals
+}
+
+export type FailedLoginIP = {
+	ip_address: string
+	failed_attempts: number
+	distinct_emails: number
+	last_attempt_at: string
+}
+
+export type FailedLoginEmail = {
+	email: string
+	failed_attempts: number
+	distinct_ips: number
+	last_attempt_at: string
+}
+
+export type TopOffendersResponse = {
+	range: DashboardRange
+	ips: FailedLoginIP[]
+	emails: FailedLoginEmail[]
+}
+
+export type LockoutBucket = {
+	bucket: string
+	lockouts: number
+	permanent: number
+}
+
+export type LockoutSeriesResponse = {
+	range: DashboardRange
+	series: LockoutBucket[]
+	active: ActiveLockouts
+}
+
+export type SuspiciousActivityBucket = {
+	bucket: string
+	low: number
+	medium: number
+	high: number
+	critical: number
+}
+
+export type SuspiciousActivitySeriesResponse = {
+	range: DashboardRange
+	series: SuspiciousActivityBucket[]
+	unresolved: SeverityCounts
+}
+
+export type RegistrationBucket = {
+	bucket: string
+	registrations: number
+}
+
+export type RegistrationSeriesResponse = {
+	range: DashboardRange
+	series: RegistrationBucket[]
+	total: number
+}
@@ -7,10 +7,21 @@ export type SuspiciousActivity = {
 	description: string
 	metadata: Record<string, any> | null
 	severity: 'low' | 'medium' | 'high' | 'critical'
-	resolved: boolean | null
 	created_at: string | null
+	status: SuspiciousActivityStatus
+	assigned_to: number | null
+	resolved_by: number | null
+	resolved_at: string | null
+	updated_at: string
 }
 
-export type ResolveSuspiciousActivityRequest = {
-	activity_id: number
+export type SuspiciousActivityStatus =
+	| 'open'
+	| 'acknowledged'
+	| 'confirmed_benign'
+	| 'confirmed_malicious'
+	| 'resolved'
+
+export type AcknowledgeSuspiciousActivityResponse = {
+	activity: SuspiciousActivity
 }
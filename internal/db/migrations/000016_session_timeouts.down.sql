@@ -0,0 +1,3 @@
+ALTER TABLE sessions
+DROP COLUMN IF EXISTS idle_timeout_seconds,
+DROP COLUMN IF EXISTS absolute_expires_at;
@@ -0,0 +1,7 @@
+ALTER TABLE sessions
+ADD COLUMN absolute_expires_at TIMESTAMPTZ,
+ADD COLUMN idle_timeout_seconds INTEGER DEFAULT 0 NOT NULL;
+
+UPDATE sessions SET absolute_expires_at = expires_at;
+
+ALTER TABLE sessions ALTER COLUMN absolute_expires_at SET NOT NULL;
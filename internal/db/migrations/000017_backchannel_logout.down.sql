@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backchannel_logout_deliveries;
+DROP TABLE IF EXISTS applications;
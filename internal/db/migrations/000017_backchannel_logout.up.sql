@@ -0,0 +1,32 @@
+CREATE TABLE applications (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    client_id VARCHAR(64) NOT NULL UNIQUE,
+    logout_secret TEXT NOT NULL,
+    backchannel_logout_url TEXT DEFAULT '' NOT NULL,
+    active BOOLEAN DEFAULT TRUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_applications_updated_at BEFORE UPDATE ON applications
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE backchannel_logout_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    application_id BIGINT NOT NULL REFERENCES applications (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    session_id VARCHAR(64) NOT NULL,
+    jti UUID NOT NULL UNIQUE,
+    status VARCHAR(20) DEFAULT 'pending' NOT NULL,
+    attempts INTEGER DEFAULT 0 NOT NULL,
+    last_status_code INTEGER DEFAULT 0 NOT NULL,
+    last_error TEXT DEFAULT '' NOT NULL,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_backchannel_logout_deliveries_application_id ON backchannel_logout_deliveries (application_id, created_at DESC);
+CREATE INDEX idx_backchannel_logout_deliveries_user_id ON backchannel_logout_deliveries (user_id);
+CREATE INDEX idx_backchannel_logout_deliveries_due ON backchannel_logout_deliveries (next_attempt_at) WHERE status = 'pending';
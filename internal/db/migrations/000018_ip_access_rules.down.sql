@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_login_attempts_failed_ip_address;
+DROP TABLE IF EXISTS ip_access_rules;
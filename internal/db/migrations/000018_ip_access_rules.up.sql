@@ -0,0 +1,15 @@
+CREATE TABLE ip_access_rules (
+    id BIGSERIAL PRIMARY KEY,
+    network CIDR NOT NULL,
+    rule_type VARCHAR(20) NOT NULL CHECK (rule_type IN ('allow', 'deny', 'lockout')),
+    reason TEXT DEFAULT '' NOT NULL,
+    created_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_ip_access_rules_network ON ip_access_rules USING GIST (network inet_ops);
+CREATE INDEX idx_ip_access_rules_expires_at ON ip_access_rules (expires_at) WHERE expires_at IS NOT NULL;
+
+-- Failed-login velocity is counted per address and per subnet
+CREATE INDEX idx_login_attempts_failed_ip_address ON login_attempts (ip_address, created_at DESC) WHERE success = false;
@@ -0,0 +1,64 @@
+CREATE OR REPLACE FUNCTION cleanup_expired_data()
+RETURNS INTEGER AS $$
+DECLARE
+    deleted_count INTEGER := 0;
+BEGIN
+    DELETE FROM refresh_tokens WHERE expires_at < NOW();
+    GET DIAGNOSTICS deleted_count = ROW_COUNT;
+
+    DELETE FROM email_verifications WHERE expires_at < NOW();
+
+    DELETE FROM password_resets WHERE expires_at < NOW();
+
+    DELETE FROM account_lockouts WHERE expires_at < NOW();
+
+    DELETE FROM login_attempts WHERE created_at < NOW() - INTERVAL '90 days';
+
+    DELETE FROM password_history
+    WHERE id NOT IN (
+        SELECT id FROM (
+            SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC) as rn
+            FROM password_history
+        ) ranked WHERE rn <= 10
+    );
+
+    RETURN deleted_count;
+END;
+$$ language 'plpgsql';
+
+DROP INDEX IF EXISTS idx_login_attempts_email;
+DROP INDEX IF EXISTS idx_account_lockouts_unlock_token_hash;
+DROP INDEX IF EXISTS idx_account_lockouts_user_id;
+DROP INDEX IF EXISTS idx_account_lockouts_open;
+
+-- Closed lockouts had been deleted before this migration
+DELETE FROM account_lockouts WHERE unlocked_at IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION check_active_lockout()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM account_lockouts
+        WHERE user_id = NEW.user_id
+          AND lockout_type = NEW.lockout_type
+          AND expires_at > NOW()
+          AND (TG_OP = 'INSERT' OR id <> NEW.id)
+    ) THEN
+        RAISE EXCEPTION 'An active lockout of type "%" already exists for this user.', NEW.lockout_type;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_check_active_lockout BEFORE INSERT OR UPDATE ON account_lockouts FOR EACH ROW EXECUTE FUNCTION check_active_lockout();
+
+ALTER TABLE account_lockouts
+    DROP COLUMN unlock_method,
+    DROP COLUMN unlocked_by,
+    DROP COLUMN unlocked_at,
+    DROP COLUMN unlock_token_hash,
+    DROP COLUMN reason,
+    DROP COLUMN permanent,
+    DROP COLUMN level;
@@ -0,0 +1,53 @@
+ALTER TABLE account_lockouts
+    ADD COLUMN level INTEGER DEFAULT 1 NOT NULL,
+    ADD COLUMN permanent BOOLEAN DEFAULT FALSE NOT NULL,
+    ADD COLUMN reason TEXT DEFAULT '' NOT NULL,
+    ADD COLUMN unlock_token_hash VARCHAR(64) DEFAULT '' NOT NULL,
+    ADD COLUMN unlocked_at TIMESTAMPTZ,
+    ADD COLUMN unlocked_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    ADD COLUMN unlock_method VARCHAR(20) DEFAULT '' NOT NULL
+        CHECK (unlock_method IN ('', 'expired', 'admin', 'email'));
+
+-- Lockouts are now closed rather than deleted, so the history survives
+UPDATE account_lockouts
+SET unlocked_at = expires_at,
+    unlock_method = 'expired'
+WHERE expires_at <= NOW();
+
+-- The trigger raised whenever a second lockout was written while one was
+-- active. An open lockout is now unique per user and type, and inserts that
+-- collide with it are skipped instead.
+DROP TRIGGER IF EXISTS trigger_check_active_lockout ON account_lockouts;
+DROP FUNCTION IF EXISTS check_active_lockout();
+
+CREATE UNIQUE INDEX idx_account_lockouts_open ON account_lockouts (user_id, lockout_type) WHERE unlocked_at IS NULL;
+CREATE INDEX idx_account_lockouts_user_id ON account_lockouts (user_id, created_at DESC);
+CREATE INDEX idx_account_lockouts_unlock_token_hash ON account_lockouts (unlock_token_hash) WHERE unlock_token_hash <> '';
+
+CREATE INDEX idx_login_attempts_email ON login_attempts (email, created_at DESC);
+
+CREATE OR REPLACE FUNCTION cleanup_expired_data()
+RETURNS INTEGER AS $$
+DECLARE
+    deleted_count INTEGER := 0;
+BEGIN
+    DELETE FROM refresh_tokens WHERE expires_at < NOW();
+    GET DIAGNOSTICS deleted_count = ROW_COUNT;
+
+    DELETE FROM email_verifications WHERE expires_at < NOW();
+
+    DELETE FROM password_resets WHERE expires_at < NOW();
+
+    DELETE FROM login_attempts WHERE created_at < NOW() - INTERVAL '90 days';
+
+    DELETE FROM password_history
+    WHERE id NOT IN (
+        SELECT id FROM (
+            SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC) as rn
+            FROM password_history
+        ) ranked WHERE rn <= 10
+    );
+
+    RETURN deleted_count;
+END;
+$$ language 'plpgsql';
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_login_attempts_user_success;
+
+ALTER TABLE user_devices
+    DROP COLUMN IF EXISTS asn,
+    DROP COLUMN IF EXISTS city,
+    DROP COLUMN IF EXISTS country_code;
+
+ALTER TABLE login_attempts
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS asn,
+    DROP COLUMN IF EXISTS city,
+    DROP COLUMN IF EXISTS country_code;
@@ -0,0 +1,14 @@
+ALTER TABLE login_attempts
+    ADD COLUMN country_code VARCHAR(2),
+    ADD COLUMN city VARCHAR(100),
+    ADD COLUMN asn BIGINT,
+    ADD COLUMN latitude DOUBLE PRECISION,
+    ADD COLUMN longitude DOUBLE PRECISION;
+
+ALTER TABLE user_devices
+    ADD COLUMN country_code VARCHAR(2),
+    ADD COLUMN city VARCHAR(100),
+    ADD COLUMN asn BIGINT;
+
+-- Each login is compared with the user's previous successful ones
+CREATE INDEX idx_login_attempts_user_success ON login_attempts (user_id, created_at DESC) WHERE success = true;
@@ -0,0 +1,18 @@
+CREATE TABLE login_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    -- Sessions may live in Redis, so this is not a foreign key
+    session_id VARCHAR(64) NOT NULL,
+    device_name VARCHAR(200) DEFAULT '' NOT NULL,
+    ip_address INET NOT NULL,
+    location VARCHAR(200) DEFAULT '' NOT NULL,
+    new_device BOOLEAN NOT NULL,
+    new_location BOOLEAN NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    reported_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_login_alerts_user_id ON login_alerts (user_id);
+CREATE INDEX idx_login_alerts_expires_at ON login_alerts (expires_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+DROP INDEX IF EXISTS idx_account_lockouts_created_at;
+DROP INDEX IF EXISTS idx_suspicious_activities_unresolved;
+DROP INDEX IF EXISTS idx_suspicious_activities_created_at;
+DROP INDEX IF EXISTS idx_login_attempts_created_at;
@@ -0,0 +1,6 @@
+-- Range scans behind the admin security dashboard
+CREATE INDEX idx_login_attempts_created_at ON login_attempts (created_at);
+CREATE INDEX idx_suspicious_activities_created_at ON suspicious_activities (created_at);
+CREATE INDEX idx_suspicious_activities_unresolved ON suspicious_activities (severity, created_at) WHERE resolved IS NOT TRUE;
+CREATE INDEX idx_account_lockouts_created_at ON account_lockouts (created_at);
+CREATE INDEX idx_users_created_at ON users (created_at);
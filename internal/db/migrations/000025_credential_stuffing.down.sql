@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS captcha_requirements;
+
+DROP INDEX IF EXISTS idx_login_attempts_asn;
+DROP INDEX IF EXISTS idx_login_attempts_password_fingerprint;
+
+ALTER TABLE login_attempts
+    DROP COLUMN IF EXISTS password_fingerprint;
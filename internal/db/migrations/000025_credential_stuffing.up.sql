@@ -0,0 +1,21 @@
+-- Keyed fingerprint of the password a failed login tried, to spot one
+-- password sprayed across many accounts
+ALTER TABLE login_attempts
+    ADD COLUMN password_fingerprint VARCHAR(32);
+
+CREATE INDEX idx_login_attempts_password_fingerprint ON login_attempts (password_fingerprint, created_at DESC) WHERE password_fingerprint IS NOT NULL;
+CREATE INDEX idx_login_attempts_asn ON login_attempts (asn, created_at DESC) WHERE asn IS NOT NULL;
+
+-- Challenges forced on an address, an ASN or a password after a detection
+CREATE TABLE captcha_requirements (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('ip', 'asn', 'password')),
+    value VARCHAR(64) NOT NULL,
+    reason TEXT DEFAULT '' NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+
+    UNIQUE (scope, value)
+);
+
+CREATE INDEX idx_captcha_requirements_expires_at ON captcha_requirements (expires_at);
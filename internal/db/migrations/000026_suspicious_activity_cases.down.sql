@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS suspicious_activity_notes;
+
+DROP TRIGGER IF EXISTS update_suspicious_activities_updated_at ON suspicious_activities;
+
+DROP INDEX IF EXISTS idx_suspicious_activities_assigned_to;
+DROP INDEX IF EXISTS idx_suspicious_activities_status;
+DROP INDEX IF EXISTS idx_suspicious_activities_unresolved;
+
+ALTER TABLE suspicious_activities
+    ADD COLUMN resolved BOOLEAN DEFAULT FALSE;
+
+UPDATE suspicious_activities SET resolved = status NOT IN ('open', 'acknowledged');
+
+CREATE INDEX idx_suspicious_activities_unresolved ON suspicious_activities (severity, created_at) WHERE resolved IS NOT TRUE;
+
+ALTER TABLE suspicious_activities
+    DROP COLUMN updated_at,
+    DROP COLUMN resolved_at,
+    DROP COLUMN resolved_by,
+    DROP COLUMN assigned_to,
+    DROP COLUMN status;
@@ -0,0 +1,31 @@
+ALTER TABLE suspicious_activities
+    ADD COLUMN status VARCHAR(30) DEFAULT 'open' NOT NULL
+        CHECK (status IN ('open', 'acknowledged', 'confirmed_benign', 'confirmed_malicious', 'resolved')),
+    ADD COLUMN assigned_to BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    ADD COLUMN resolved_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    ADD COLUMN resolved_at TIMESTAMPTZ,
+    ADD COLUMN updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL;
+
+-- Who resolved earlier activities, and when, was never recorded
+UPDATE suspicious_activities SET status = 'resolved' WHERE resolved = true;
+
+-- Also drops idx_suspicious_activities_unresolved
+ALTER TABLE suspicious_activities
+    DROP COLUMN resolved;
+
+CREATE INDEX idx_suspicious_activities_unresolved ON suspicious_activities (severity, created_at) WHERE status IN ('open', 'acknowledged');
+CREATE INDEX idx_suspicious_activities_status ON suspicious_activities (status, created_at DESC);
+CREATE INDEX idx_suspicious_activities_assigned_to ON suspicious_activities (assigned_to, created_at DESC) WHERE assigned_to IS NOT NULL;
+
+CREATE TRIGGER update_suspicious_activities_updated_at BEFORE UPDATE ON suspicious_activities
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE suspicious_activity_notes (
+    id BIGSERIAL PRIMARY KEY,
+    activity_id BIGINT NOT NULL REFERENCES suspicious_activities (id) ON DELETE CASCADE,
+    author_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    note TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_suspicious_activity_notes_activity_id ON suspicious_activity_notes (activity_id, created_at);
@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS audit_log_checkpoints;
+
+DROP TRIGGER IF EXISTS append_audit_log_to_chain ON audit_logs;
+DROP FUNCTION IF EXISTS audit_log_chain_append();
+
+DROP TABLE IF EXISTS audit_log_chain;
+
+DROP INDEX IF EXISTS idx_audit_logs_chain_seq;
+DROP FUNCTION IF EXISTS audit_log_entry_hash(audit_logs);
+
+ALTER TABLE audit_logs
+    DROP COLUMN entry_hash,
+    DROP COLUMN prev_hash,
+    DROP COLUMN chain_seq;
+
+UPDATE audit_logs SET user_id = NULL WHERE user_id NOT IN (SELECT id FROM users);
+
+ALTER TABLE audit_logs
+    ADD CONSTRAINT audit_logs_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL;
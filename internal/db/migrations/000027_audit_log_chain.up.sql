@@ -0,0 +1,106 @@
+-- Audit history must not change when a user is deleted, user_id stays as a
+-- plain reference
+ALTER TABLE audit_logs
+    DROP CONSTRAINT IF EXISTS audit_logs_user_id_fkey;
+
+-- Every entry hashes its content together with the previous entry's hash, so
+-- editing, deleting or reordering entries breaks the chain from there on
+ALTER TABLE audit_logs
+    ADD COLUMN chain_seq BIGINT,
+    ADD COLUMN prev_hash BYTEA,
+    ADD COLUMN entry_hash BYTEA;
+
+-- The canonical form is length-prefixed fields joined by newlines, see
+-- security.AuditEntryHash which recomputes it
+CREATE OR REPLACE FUNCTION audit_log_entry_hash(entry audit_logs)
+RETURNS BYTEA AS $$
+    SELECT sha256(convert_to(string_agg(octet_length(field) || ':' || field, E'\n' ORDER BY position), 'UTF8'))
+    FROM unnest(ARRAY[
+        entry.chain_seq::text,
+        entry.id::text,
+        encode(entry.prev_hash, 'hex'),
+        COALESCE(entry.user_id::text, ''),
+        entry.action::text,
+        COALESCE(entry.resource_type::text, ''),
+        COALESCE(entry.resource_id::text, ''),
+        COALESCE(entry.ip_address::text, ''),
+        COALESCE(entry.user_agent, ''),
+        COALESCE(entry.details::text, ''),
+        COALESCE(to_char(entry.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'), '')
+    ]) WITH ORDINALITY AS fields (field, position)
+$$ LANGUAGE sql STABLE;
+
+-- The end of the chain, and where retention last cut its start off
+CREATE TABLE audit_log_chain (
+    id SMALLINT PRIMARY KEY CHECK (id = 1),
+    last_seq BIGINT NOT NULL,
+    last_hash BYTEA NOT NULL,
+    pruned_seq BIGINT DEFAULT 0 NOT NULL,
+    pruned_hash BYTEA NOT NULL
+);
+
+-- Chain existing entries in the order they were written
+DO $$
+DECLARE
+    entry audit_logs%ROWTYPE;
+    seq BIGINT := 0;
+    prev BYTEA := decode(repeat('00', 32), 'hex');
+BEGIN
+    FOR entry IN SELECT * FROM audit_logs ORDER BY id LOOP
+        seq := seq + 1;
+        entry.chain_seq := seq;
+        entry.prev_hash := prev;
+        entry.entry_hash := audit_log_entry_hash(entry);
+
+        UPDATE audit_logs
+        SET chain_seq = entry.chain_seq, prev_hash = entry.prev_hash, entry_hash = entry.entry_hash
+        WHERE id = entry.id;
+
+        prev := entry.entry_hash;
+    END LOOP;
+
+    INSERT INTO audit_log_chain (id, last_seq, last_hash, pruned_hash)
+    VALUES (1, seq, prev, decode(repeat('00', 32), 'hex'));
+END;
+$$;
+
+ALTER TABLE audit_logs
+    ALTER COLUMN chain_seq SET NOT NULL,
+    ALTER COLUMN prev_hash SET NOT NULL,
+    ALTER COLUMN entry_hash SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_audit_logs_chain_seq ON audit_logs (chain_seq);
+
+-- Appends run one at a time, holding the chain row until they commit. This
+-- covers every insert, log_password_change's included.
+CREATE OR REPLACE FUNCTION audit_log_chain_append()
+RETURNS TRIGGER AS $$
+DECLARE
+    head audit_log_chain%ROWTYPE;
+BEGIN
+    SELECT * INTO head FROM audit_log_chain WHERE id = 1 FOR UPDATE;
+
+    NEW.chain_seq := head.last_seq + 1;
+    NEW.prev_hash := head.last_hash;
+    NEW.entry_hash := audit_log_entry_hash(NEW);
+
+    UPDATE audit_log_chain SET last_seq = NEW.chain_seq, last_hash = NEW.entry_hash WHERE id = 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER append_audit_log_to_chain BEFORE INSERT ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_log_chain_append();
+
+-- Signed statements of the chain's end at a point in time. A rewritten chain
+-- no longer matches them.
+CREATE TABLE audit_log_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    chain_seq BIGINT NOT NULL,
+    entry_hash BYTEA NOT NULL,
+    public_key BYTEA NOT NULL,
+    signature BYTEA NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_audit_log_checkpoints_chain_seq ON audit_log_checkpoints (chain_seq);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_audit_logs_details;
+DROP INDEX IF EXISTS idx_audit_logs_ip_address;
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP INDEX IF EXISTS idx_audit_logs_resource;
+DROP INDEX IF EXISTS idx_audit_logs_action;
+DROP INDEX IF EXISTS idx_audit_logs_user_id;
@@ -0,0 +1,12 @@
+-- Audit log search filters on any combination of these and pages newest
+-- first by id
+CREATE INDEX idx_audit_logs_user_id ON audit_logs (user_id, id DESC);
+CREATE INDEX idx_audit_logs_action ON audit_logs (action, id DESC);
+CREATE INDEX idx_audit_logs_resource ON audit_logs (resource_type, resource_id, id DESC);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
+
+-- Lets a CIDR filter (ip_address <<= network) use an index
+CREATE INDEX idx_audit_logs_ip_address ON audit_logs USING gist (ip_address inet_ops);
+
+-- Detail filters are containment (details @> filter)
+CREATE INDEX idx_audit_logs_details ON audit_logs USING gin (details jsonb_path_ops);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+DROP INDEX IF EXISTS idx_outbox_events_aggregate_seq;
+CREATE INDEX idx_outbox_events_pending ON outbox_events (aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS aggregate_seq;
+
+DROP TABLE IF EXISTS outbox_aggregates;
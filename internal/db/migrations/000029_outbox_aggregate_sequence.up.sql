@@ -0,0 +1,31 @@
+-- The last event number handed out per aggregate. Enqueueing an event bumps
+-- it, which holds the row until the enqueueing transaction ends, so events
+-- for an aggregate are numbered in the order their transactions commit.
+CREATE TABLE outbox_aggregates (
+    aggregate_type VARCHAR(20) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    last_seq BIGINT NOT NULL,
+    PRIMARY KEY (aggregate_type, aggregate_id)
+);
+
+ALTER TABLE outbox_events ADD COLUMN aggregate_seq BIGINT;
+
+-- Number the events already written in id order, the best guess there is
+UPDATE outbox_events o
+SET aggregate_seq = numbered.seq
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY aggregate_type, aggregate_id ORDER BY id) AS seq
+    FROM outbox_events
+) numbered
+WHERE o.id = numbered.id;
+
+INSERT INTO outbox_aggregates (aggregate_type, aggregate_id, last_seq)
+SELECT aggregate_type, aggregate_id, MAX(aggregate_seq)
+FROM outbox_events
+GROUP BY aggregate_type, aggregate_id;
+
+ALTER TABLE outbox_events ALTER COLUMN aggregate_seq SET NOT NULL;
+
+DROP INDEX idx_outbox_events_pending;
+CREATE UNIQUE INDEX idx_outbox_events_aggregate_seq ON outbox_events (aggregate_type, aggregate_id, aggregate_seq);
+CREATE INDEX idx_outbox_events_pending ON outbox_events (aggregate_type, aggregate_id, aggregate_seq) WHERE published_at IS NULL;
@@ -0,0 +1,17 @@
+-- Keep the newest open ip lockout per user, the old index allows only one
+UPDATE account_lockouts l
+SET unlocked_at = NOW(),
+    unlock_method = 'expired'
+WHERE l.lockout_type = 'ip'
+AND l.unlocked_at IS NULL
+AND EXISTS (
+    SELECT 1 FROM account_lockouts newer
+    WHERE newer.user_id = l.user_id
+    AND newer.lockout_type = 'ip'
+    AND newer.unlocked_at IS NULL
+    AND newer.id > l.id
+);
+
+DROP INDEX IF EXISTS idx_account_lockouts_open_ip;
+DROP INDEX IF EXISTS idx_account_lockouts_open;
+CREATE UNIQUE INDEX idx_account_lockouts_open ON account_lockouts (user_id, lockout_type) WHERE unlocked_at IS NULL;
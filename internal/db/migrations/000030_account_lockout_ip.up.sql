@@ -0,0 +1,5 @@
+-- An ip lockout only locks the account for the address its failures came
+-- from, so one is open per user and address rather than per user and type
+DROP INDEX IF EXISTS idx_account_lockouts_open;
+CREATE UNIQUE INDEX idx_account_lockouts_open ON account_lockouts (user_id, lockout_type) WHERE unlocked_at IS NULL AND lockout_type <> 'ip';
+CREATE UNIQUE INDEX idx_account_lockouts_open_ip ON account_lockouts (user_id, ip_address) WHERE unlocked_at IS NULL AND lockout_type = 'ip';
@@ -0,0 +1,8 @@
+-- The tokens cannot be recovered from their hashes, end every live session
+UPDATE sessions
+SET is_active = FALSE,
+    revoked_at = NOW()
+WHERE is_active;
+
+ALTER TABLE sessions RENAME COLUMN access_token_hash TO access_token;
+ALTER TABLE sessions RENAME COLUMN refresh_token_hash TO refresh_token;
@@ -0,0 +1,8 @@
+-- Sessions keep only a SHA-256 of their tokens, the same hex digest the
+-- application computes, so hash the tokens of the sessions already stored
+ALTER TABLE sessions RENAME COLUMN access_token TO access_token_hash;
+ALTER TABLE sessions RENAME COLUMN refresh_token TO refresh_token_hash;
+
+UPDATE sessions
+SET access_token_hash = encode(sha256(convert_to(access_token_hash, 'UTF8')), 'hex'),
+    refresh_token_hash = encode(sha256(convert_to(refresh_token_hash, 'UTF8')), 'hex');
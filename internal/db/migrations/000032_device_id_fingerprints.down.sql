@@ -0,0 +1,2 @@
+-- Fingerprinted device IDs are still recognised, there is nothing to undo
+SELECT 1;
@@ -0,0 +1,12 @@
+-- Devices that sent no X-Device-ID were for a while keyed by their user agent
+-- alone. Key them by user agent and IP again, the fingerprint the application
+-- still recognises and turns into the device cookie on the next sign-in.
+UPDATE user_devices d
+SET device_id = left(encode(sha256(convert_to(COALESCE(d.user_agent, '') || '_' || host(d.ip_address), 'UTF8')), 'hex'), 32)
+WHERE d.ip_address IS NOT NULL
+AND d.device_id = left(encode(sha256(convert_to(COALESCE(d.user_agent, ''), 'UTF8')), 'hex'), 32)
+AND NOT EXISTS (
+    SELECT 1 FROM user_devices o
+    WHERE o.user_id = d.user_id
+    AND o.device_id = left(encode(sha256(convert_to(COALESCE(d.user_agent, '') || '_' || host(d.ip_address), 'UTF8')), 'hex'), 32)
+);
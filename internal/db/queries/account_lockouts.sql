@@ -3,22 +3,39 @@ INSERT INTO account_lockouts (
     user_id,
     ip_address,
     lockout_type,
-    expires_at
+    expires_at,
+    level,
+    permanent,
+    reason,
+    unlock_token_hash
 ) VALUES (
-    $1, $2, $3, $4
-) RETURNING *;
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT DO NOTHING
+RETURNING *;
 
 -- name: GetAccountLockoutByUserID :one
 SELECT * FROM account_lockouts
 WHERE user_id = $1
-AND expires_at > NOW()
+AND unlocked_at IS NULL
+AND (permanent OR expires_at > NOW())
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetAccountLockoutForLogin :one
+SELECT * FROM account_lockouts
+WHERE user_id = $1
+AND unlocked_at IS NULL
+AND (permanent OR expires_at > NOW())
+AND (lockout_type <> 'ip' OR ip_address = sqlc.arg(ip_address))
 ORDER BY created_at DESC
 LIMIT 1;
 
 -- name: GetAccountLockoutByIP :one
 SELECT * FROM account_lockouts
 WHERE ip_address = $1
-AND expires_at > NOW()
+AND unlocked_at IS NULL
+AND (permanent OR expires_at > NOW())
 ORDER BY created_at DESC
 LIMIT 1;
 
@@ -26,14 +43,47 @@ LIMIT 1;
 SELECT * FROM account_lockouts
 WHERE user_id = $1
 AND ip_address = $2
-AND expires_at > NOW()
+AND unlocked_at IS NULL
+AND (permanent OR expires_at > NOW())
 ORDER BY created_at DESC
 LIMIT 1;
 
--- name: DeleteExpiredLockouts :exec
-DELETE FROM account_lockouts
-WHERE expires_at <= NOW();
+-- name: GetAccountLockoutByUnlockTokenHash :one
+SELECT * FROM account_lockouts
+WHERE unlock_token_hash = $1
+AND unlock_token_hash <> ''
+AND unlocked_at IS NULL
+LIMIT 1;
+
+-- name: GetAccountLockoutsByUserID :many
+SELECT * FROM account_lockouts
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: CountRecentAccountLockouts :one
+SELECT COUNT(*) FROM account_lockouts
+WHERE user_id = $1
+AND created_at > sqlc.arg(since)::timestamptz;
+
+-- name: CloseExpiredAccountLockouts :exec
+UPDATE account_lockouts
+SET unlocked_at = expires_at,
+    unlock_method = 'expired'
+WHERE user_id = $1
+AND unlocked_at IS NULL
+AND permanent = FALSE
+AND expires_at <= NOW();
+
+-- name: UnlockAccountLockouts :many
+UPDATE account_lockouts
+SET unlocked_at = NOW(),
+    unlocked_by = $2,
+    unlock_method = $3
+WHERE user_id = $1
+AND unlocked_at IS NULL
+RETURNING *;
 
--- name: DeleteAccountLockoutByID :exec
+-- name: DeleteAccountLockoutHistoryBefore :exec
 DELETE FROM account_lockouts
-WHERE id = $1;
+WHERE unlocked_at < sqlc.arg(before)::timestamptz;
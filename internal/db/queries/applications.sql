@@ -0,0 +1,35 @@
+-- name: CreateApplication :one
+INSERT INTO applications (
+    name,
+    client_id,
+    logout_secret,
+    backchannel_logout_url
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetApplicationByID :one
+SELECT * FROM applications
+WHERE id = $1;
+
+-- name: ListApplications :many
+SELECT * FROM applications
+ORDER BY created_at DESC;
+
+-- name: ListBackchannelLogoutApplications :many
+SELECT * FROM applications
+WHERE active = TRUE
+AND backchannel_logout_url <> ''
+ORDER BY id;
+
+-- name: UpdateApplication :one
+UPDATE applications
+SET name = $2,
+    backchannel_logout_url = $3,
+    active = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteApplication :exec
+DELETE FROM applications
+WHERE id = $1;
@@ -0,0 +1,58 @@
+-- name: GetAuditLogChainHead :one
+SELECT * FROM audit_log_chain
+WHERE id = 1;
+
+-- name: GetAuditLogChainEntries :many
+SELECT
+    chain_seq,
+    id,
+    prev_hash,
+    entry_hash,
+    user_id,
+    action,
+    resource_type,
+    resource_id,
+    COALESCE(ip_address::text, '')::text AS ip_address,
+    user_agent,
+    COALESCE(details::text, '')::text AS details,
+    created_at
+FROM audit_logs
+WHERE chain_seq > @after_seq AND chain_seq <= @through_seq
+ORDER BY chain_seq
+LIMIT @row_limit;
+
+-- name: GetAuditLogPruneBoundary :one
+SELECT chain_seq, entry_hash FROM audit_logs
+WHERE chain_seq = (
+    SELECT MAX(chain_seq) FROM audit_logs
+    WHERE created_at < @before::timestamptz
+);
+
+-- name: DeleteAuditLogsThrough :exec
+DELETE FROM audit_logs
+WHERE chain_seq <= $1;
+
+-- name: RecordAuditLogChainPrune :exec
+UPDATE audit_log_chain
+SET pruned_seq = $1, pruned_hash = $2
+WHERE id = 1 AND pruned_seq < $1;
+
+-- name: CreateAuditLogCheckpoint :one
+INSERT INTO audit_log_checkpoints (
+    chain_seq,
+    entry_hash,
+    public_key,
+    signature
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetLatestAuditLogCheckpoint :one
+SELECT * FROM audit_log_checkpoints
+ORDER BY chain_seq DESC, id DESC
+LIMIT 1;
+
+-- name: GetAuditLogCheckpointsAfter :many
+SELECT * FROM audit_log_checkpoints
+WHERE chain_seq > $1
+ORDER BY chain_seq, id;
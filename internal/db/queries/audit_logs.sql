@@ -17,42 +17,16 @@ WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2;
 
--- name: GetAuditLogsByAction :many
+-- name: SearchAuditLogs :many
 SELECT * FROM audit_logs
-WHERE action = $1
-ORDER BY created_at DESC
-LIMIT $2;
-
--- name: GetAuditLogsByResourceType :many
-SELECT * FROM audit_logs
-WHERE resource_type = $1
-ORDER BY created_at DESC
-LIMIT $2;
-
--- name: GetAuditLogsByResourceID :many
-SELECT * FROM audit_logs
-WHERE resource_type = $1 AND resource_id = $2
-ORDER BY created_at DESC
-LIMIT $3;
-
--- name: GetAuditLogsByIP :many
-SELECT * FROM audit_logs
-WHERE ip_address = $1
-ORDER BY created_at DESC
-LIMIT $2;
-
--- name: GetAuditLogsByDateRange :many
-SELECT * FROM audit_logs
-WHERE created_at BETWEEN $1 AND $2
-ORDER BY created_at DESC
-LIMIT $3;
-
--- name: GetRecentAuditLogs :many
-SELECT * FROM audit_logs
-WHERE created_at > NOW() - INTERVAL '24 hours'
-ORDER BY created_at DESC
-LIMIT $1;
-
--- name: DeleteOldAuditLogs :exec
-DELETE FROM audit_logs
-WHERE created_at < NOW() - INTERVAL '90 days';
+WHERE (sqlc.narg(user_id)::bigint IS NULL OR user_id = sqlc.narg(user_id))
+AND (sqlc.narg(actions)::text[] IS NULL OR action = ANY(sqlc.narg(actions)::text[]))
+AND (sqlc.narg(resource_type)::text IS NULL OR resource_type = sqlc.narg(resource_type))
+AND (sqlc.narg(resource_id)::bigint IS NULL OR resource_id = sqlc.narg(resource_id))
+AND (sqlc.narg(network)::cidr IS NULL OR ip_address <<= sqlc.narg(network))
+AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+AND (sqlc.narg(details)::jsonb IS NULL OR details @> sqlc.narg(details))
+AND (sqlc.narg(before_id)::bigint IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT @row_limit;
@@ -0,0 +1,40 @@
+-- name: CreateBackchannelLogoutDelivery :one
+INSERT INTO backchannel_logout_deliveries (
+    application_id,
+    user_id,
+    session_id,
+    jti,
+    next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ClaimDueBackchannelLogoutDeliveries :many
+UPDATE backchannel_logout_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT id FROM backchannel_logout_deliveries
+    WHERE status = 'pending'
+    AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetBackchannelLogoutDeliveriesByApplicationID :many
+SELECT * FROM backchannel_logout_deliveries
+WHERE application_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: UpdateBackchannelLogoutDelivery :one
+UPDATE backchannel_logout_deliveries
+SET status = $2,
+    attempts = $3,
+    last_status_code = $4,
+    last_error = $5,
+    next_attempt_at = $6,
+    delivered_at = $7
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,28 @@
+-- name: UpsertCaptchaRequirement :one
+INSERT INTO captcha_requirements (
+    scope,
+    value,
+    reason,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (scope, value) DO UPDATE
+SET reason = EXCLUDED.reason,
+    expires_at = GREATEST(captcha_requirements.expires_at, EXCLUDED.expires_at)
+RETURNING *;
+
+-- name: HasActiveCaptchaRequirement :one
+SELECT EXISTS (
+    SELECT 1 FROM captcha_requirements
+    WHERE expires_at > NOW()
+    AND (
+        (scope = 'ip' AND value = sqlc.arg(ip_address)::text)
+        OR (scope = 'asn' AND value = sqlc.arg(asn)::text)
+        OR (scope = 'password' AND value = sqlc.arg(password_fingerprint)::text)
+    )
+);
+
+-- name: DeleteExpiredCaptchaRequirements :exec
+DELETE FROM captcha_requirements
+WHERE expires_at <= NOW();
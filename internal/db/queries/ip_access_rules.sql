@@ -0,0 +1,31 @@
+-- name: CreateIPAccessRule :one
+INSERT INTO ip_access_rules (
+    network,
+    rule_type,
+    reason,
+    created_by,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetIPAccessRuleByID :one
+SELECT * FROM ip_access_rules
+WHERE id = $1;
+
+-- name: ListIPAccessRules :many
+SELECT * FROM ip_access_rules
+ORDER BY created_at DESC;
+
+-- name: ListActiveIPAccessRules :many
+SELECT * FROM ip_access_rules
+WHERE expires_at IS NULL OR expires_at > NOW()
+ORDER BY id;
+
+-- name: DeleteIPAccessRule :exec
+DELETE FROM ip_access_rules
+WHERE id = $1;
+
+-- name: DeleteExpiredIPAccessRules :exec
+DELETE FROM ip_access_rules
+WHERE expires_at <= NOW();
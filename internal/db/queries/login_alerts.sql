@@ -0,0 +1,34 @@
+-- name: CreateLoginAlert :one
+INSERT INTO login_alerts (
+    user_id,
+    session_id,
+    device_name,
+    ip_address,
+    location,
+    new_device,
+    new_location,
+    token_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetLoginAlertByTokenHash :one
+SELECT * FROM login_alerts
+WHERE token_hash = $1;
+
+-- name: MarkLoginAlertReported :one
+UPDATE login_alerts
+SET reported_at = NOW()
+WHERE id = $1
+AND reported_at IS NULL
+RETURNING *;
+
+-- name: DeleteExpiredLoginAlerts :exec
+DELETE FROM login_alerts
+WHERE expires_at < NOW();
+
+-- name: ReleaseLoginAlertReport :exec
+UPDATE login_alerts
+SET reported_at = NULL
+WHERE id = $1;
@@ -5,9 +5,15 @@ INSERT INTO login_attempts (
     ip_address,
     user_agent,
     success,
-    failure_reason
+    failure_reason,
+    country_code,
+    city,
+    asn,
+    latitude,
+    longitude,
+    password_fingerprint
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 ) RETURNING *;
 
 -- name: GetLoginAttemptsByUserID :many
@@ -70,3 +76,80 @@ ORDER BY created_at DESC;
 -- name: DeleteOldLoginAttempts :exec
 DELETE FROM login_attempts
 WHERE created_at < NOW() - INTERVAL '90 days';
+
+-- name: GetFailedLoginVelocityByNetwork :one
+SELECT COUNT(*)::bigint AS failed_attempts,
+       COUNT(DISTINCT email)::bigint AS distinct_emails
+FROM login_attempts
+WHERE ip_address <<= sqlc.arg(network)::cidr
+AND success = false
+AND created_at > sqlc.arg(since);
+
+-- name: CountConsecutiveFailedLoginIPsByEmail :one
+SELECT COUNT(DISTINCT ip_address) FROM login_attempts
+WHERE email = sqlc.arg(email)
+AND success = false
+AND created_at > GREATEST(
+    sqlc.arg(since)::timestamptz,
+    COALESCE(
+        (SELECT MAX(last_success.created_at) FROM login_attempts last_success
+         WHERE last_success.email = sqlc.arg(email) AND last_success.success = true),
+        sqlc.arg(since)::timestamptz
+    )
+);
+
+-- name: CountConsecutiveFailedLoginsByEmail :one
+SELECT COUNT(*) FROM login_attempts
+WHERE email = sqlc.arg(email)
+AND success = false
+AND created_at > GREATEST(
+    sqlc.arg(since)::timestamptz,
+    COALESCE(
+        (SELECT MAX(last_success.created_at) FROM login_attempts last_success
+         WHERE last_success.email = sqlc.arg(email) AND last_success.success = true),
+        sqlc.arg(since)::timestamptz
+    )
+);
+
+-- name: GetLastSuccessfulLoginWithLocation :one
+SELECT * FROM login_attempts
+WHERE user_id = $1
+AND success = true
+AND latitude IS NOT NULL
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetLoginCountryHistory :one
+SELECT COUNT(*) FILTER (WHERE country_code = sqlc.arg(country_code))::bigint AS from_country,
+       COUNT(*)::bigint AS located
+FROM login_attempts
+WHERE user_id = sqlc.arg(user_id)
+AND success = true
+AND country_code IS NOT NULL;
+
+-- name: GetLoginOutcomesByASN :one
+SELECT COUNT(*)::bigint AS attempts,
+       COUNT(*) FILTER (WHERE success = false)::bigint AS failed_attempts
+FROM login_attempts
+WHERE asn = sqlc.arg(asn)
+AND created_at > sqlc.arg(since);
+
+-- name: GetPasswordFingerprintSpread :one
+SELECT COUNT(DISTINCT email)::bigint AS distinct_emails,
+       COUNT(DISTINCT ip_address)::bigint AS distinct_ips
+FROM login_attempts
+WHERE password_fingerprint = sqlc.arg(password_fingerprint)
+AND success = false
+AND created_at > sqlc.arg(since);
+
+-- name: GetPasswordFingerprintSprayers :many
+SELECT ip_address,
+       COUNT(DISTINCT email)::bigint AS distinct_emails
+FROM login_attempts
+WHERE password_fingerprint = sqlc.arg(password_fingerprint)
+AND success = false
+AND created_at > sqlc.arg(since)
+GROUP BY ip_address
+HAVING COUNT(DISTINCT email) >= sqlc.arg(min_emails)::bigint
+ORDER BY distinct_emails DESC
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,68 @@
+-- name: NextOutboxAggregateSeq :one
+INSERT INTO outbox_aggregates (
+    aggregate_type,
+    aggregate_id,
+    last_seq
+) VALUES (
+    $1, $2, 1
+)
+ON CONFLICT (aggregate_type, aggregate_id)
+DO UPDATE SET last_seq = outbox_aggregates.last_seq + 1
+RETURNING last_seq;
+
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (
+    event_id,
+    aggregate_type,
+    aggregate_id,
+    aggregate_seq,
+    event_type,
+    payload
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
+
+-- name: TryLockOutboxRelay :one
+SELECT pg_try_advisory_xact_lock(@lock_id::bigint);
+
+-- name: ClaimPendingOutboxEvents :many
+UPDATE outbox_events
+SET next_attempt_at = @lease_until
+WHERE id IN (
+    SELECT o.id FROM outbox_events o
+    WHERE o.published_at IS NULL
+    AND o.next_attempt_at <= NOW()
+    AND NOT EXISTS (
+        SELECT 1 FROM outbox_events earlier
+        WHERE earlier.aggregate_type = o.aggregate_type
+        AND earlier.aggregate_id = o.aggregate_id
+        AND earlier.published_at IS NULL
+        AND earlier.next_attempt_at > NOW()
+        AND earlier.aggregate_seq < o.aggregate_seq
+    )
+    ORDER BY o.id
+    LIMIT @row_limit
+)
+RETURNING *;
+
+-- name: MarkOutboxEventsPublished :exec
+UPDATE outbox_events
+SET published_at = NOW()
+WHERE id = ANY(@ids::bigint[]);
+
+-- name: ReleaseOutboxEvents :exec
+UPDATE outbox_events
+SET next_attempt_at = NOW()
+WHERE id = ANY(@ids::bigint[])
+AND published_at IS NULL;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = $3
+WHERE id = $1;
+
+-- name: DeletePublishedOutboxEventsBefore :exec
+DELETE FROM outbox_events
+WHERE published_at < @before::timestamptz;
@@ -0,0 +1,97 @@
+-- Aggregates for the admin security dashboard. Buckets are truncated in UTC,
+-- empty buckets are filled in by the caller.
+
+-- name: GetLoginAttemptTimeSeries :many
+SELECT date_trunc(@bucket_size::text, created_at AT TIME ZONE 'UTC')::timestamp AS bucket,
+    COUNT(*) FILTER (WHERE success)::bigint AS successful,
+    COUNT(*) FILTER (WHERE NOT success)::bigint AS failed
+FROM login_attempts
+WHERE created_at >= @from_time::timestamptz
+AND created_at < @to_time::timestamptz
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: GetTopFailedLoginIPs :many
+SELECT ip_address,
+    COUNT(*)::bigint AS failed_attempts,
+    COUNT(DISTINCT email)::bigint AS distinct_emails,
+    MAX(created_at)::timestamptz AS last_attempt_at
+FROM login_attempts
+WHERE success = false
+AND created_at >= @from_time::timestamptz
+AND created_at < @to_time::timestamptz
+GROUP BY ip_address
+ORDER BY failed_attempts DESC, ip_address
+LIMIT @row_limit;
+
+-- name: GetTopFailedLoginEmails :many
+SELECT email,
+    COUNT(*)::bigint AS failed_attempts,
+    COUNT(DISTINCT ip_address)::bigint AS distinct_ips,
+    MAX(created_at)::timestamptz AS last_attempt_at
+FROM login_attempts
+WHERE success = false
+AND created_at >= @from_time::timestamptz
+AND created_at < @to_time::timestamptz
+GROUP BY email
+ORDER BY failed_attempts DESC, email
+LIMIT @row_limit;
+
+-- name: GetLockoutTimeSeries :many
+SELECT date_trunc(@bucket_size::text, created_at AT TIME ZONE 'UTC')::timestamp AS bucket,
+    COUNT(*)::bigint AS lockouts,
+    COUNT(*) FILTER (WHERE permanent)::bigint AS permanent
+FROM account_lockouts
+WHERE created_at >= @from_time::timestamptz
+AND created_at < @to_time::timestamptz
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: CountActiveAccountLockouts :one
+SELECT COUNT(*)::bigint AS active,
+    COUNT(*) FILTER (WHERE permanent)::bigint AS permanent
+FROM account_lockouts
+WHERE unlocked_at IS NULL
+AND (permanent OR expires_at > NOW());
+
+-- name: CountActiveIPLockouts :one
+SELECT COUNT(*)::bigint FROM ip_access_rules
+WHERE rule_type = 'lockout'
+AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: GetSuspiciousActivityTimeSeries :many
+SELECT date_trunc(@bucket_size::text, created_at AT TIME ZONE 'UTC')::timestamp AS bucket,
+    COUNT(*) FILTER (WHERE severity = 'low')::bigint AS low,
+    COUNT(*) FILTER (WHERE severity = 'medium')::bigint AS medium,
+    COUNT(*) FILTER (WHERE severity = 'high')::bigint AS high,
+    COUNT(*) FILTER (WHERE severity = 'critical')::bigint AS critical
+FROM suspicious_activities
+WHERE created_at >= @from_time::timestamptz
+AND created_at < @to_time::timestamptz
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: CountUnresolvedSuspiciousActivitiesBySeverity :many
+SELECT COALESCE(severity, 'medium')::text AS severity,
+    COUNT(*)::bigint AS count
+FROM suspicious_activities
+WHERE status IN ('open', 'acknowledged')
+AND created_at >= @from_time::timestamptz
+AND created_at < @to_time::timestamptz
+GROUP BY 1
+ORDER BY 1;
+
+-- name: GetRegistrationTimeSeries :many
+SELECT date_trunc(@bucket_size::text, created_at AT TIME ZONE 'UTC')::timestamp AS bucket,
+    COUNT(*)::bigint AS registrations
+FROM users
+WHERE created_at >= @from_time::timestamptz
+AND created_at < @to_time::timestamptz
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: GetMFAAdoption :one
+SELECT COUNT(*)::bigint AS active_users,
+    COUNT(*) FILTER (WHERE (privacy_settings->>'two_factor_enabled')::boolean IS TRUE)::bigint AS mfa_enabled
+FROM users
+WHERE active = true;
@@ -0,0 +1,65 @@
+-- name: CreateSession :one
+INSERT INTO sessions (
+    id,
+    user_id,
+    access_token_hash,
+    refresh_token_hash,
+    device_info,
+    ip_address,
+    user_agent,
+    expires_at,
+    absolute_expires_at,
+    idle_timeout_seconds
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: GetSessionByID :one
+SELECT * FROM sessions
+WHERE id = $1
+AND is_active = TRUE
+AND expires_at > NOW();
+
+-- name: GetSessionByAccessTokenHash :one
+SELECT * FROM sessions
+WHERE access_token_hash = $1
+AND is_active = TRUE
+AND expires_at > NOW();
+
+-- name: GetSessionByRefreshTokenHash :one
+SELECT * FROM sessions
+WHERE refresh_token_hash = $1
+AND is_active = TRUE
+AND expires_at > NOW();
+
+-- name: GetActiveSessionsByUserID :many
+SELECT * FROM sessions
+WHERE user_id = $1
+AND is_active = TRUE
+AND expires_at > NOW()
+ORDER BY last_active_at DESC;
+
+-- name: UpdateSessionTokens :exec
+UPDATE sessions
+SET access_token_hash = $2,
+    refresh_token_hash = $3,
+    expires_at = $4,
+    last_active_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateSessionActivity :exec
+UPDATE sessions
+SET last_active_at = $2,
+    expires_at = $3
+WHERE id = $1;
+
+-- name: RevokeSession :exec
+UPDATE sessions
+SET is_active = FALSE,
+    revoked_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE expires_at < NOW() - INTERVAL '30 days'
+OR revoked_at < NOW() - INTERVAL '30 days';
@@ -23,16 +23,45 @@ WHERE ip_address = $1
 ORDER BY created_at DESC
 LIMIT $2;
 
--- name: GetUnresolvedSuspiciousActivities :many
+-- name: GetSuspiciousActivityByID :one
 SELECT * FROM suspicious_activities
-WHERE resolved = false
+WHERE id = $1;
+
+-- name: ListSuspiciousActivities :many
+SELECT * FROM suspicious_activities
+WHERE status = ANY(@statuses::text[])
+AND (sqlc.narg(assigned_to)::bigint IS NULL OR assigned_to = sqlc.narg(assigned_to))
 ORDER BY created_at DESC
-LIMIT $1;
+LIMIT @row_limit;
 
--- name: ResolveSuspiciousActivity :exec
+-- name: UpdateSuspiciousActivityStatus :one
 UPDATE suspicious_activities
-SET resolved = true
-WHERE id = $1;
+SET status = @new_status,
+    resolved_by = sqlc.narg(resolved_by),
+    resolved_at = sqlc.narg(resolved_at)
+WHERE id = @id
+AND status = @current_status
+RETURNING *;
+
+-- name: AssignSuspiciousActivity :one
+UPDATE suspicious_activities
+SET assigned_to = sqlc.narg(assigned_to)
+WHERE id = @id
+RETURNING *;
+
+-- name: CreateSuspiciousActivityNote :one
+INSERT INTO suspicious_activity_notes (
+    activity_id,
+    author_id,
+    note
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetSuspiciousActivityNotes :many
+SELECT * FROM suspicious_activity_notes
+WHERE activity_id = $1
+ORDER BY created_at, id;
 
 -- name: GetSuspiciousActivityCountByUser :one
 SELECT COUNT(*) FROM suspicious_activities
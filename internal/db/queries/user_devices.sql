@@ -6,9 +6,12 @@ INSERT INTO user_devices (
     device_type,
     user_agent,
     ip_address,
-    trusted
+    trusted,
+    country_code,
+    city,
+    asn
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 ) RETURNING *;
 
 -- name: GetUserDevicesByUserID :many
@@ -52,3 +55,13 @@ UPDATE user_devices
 SET trusted = $3
 WHERE id = $1 AND user_id = $2
 RETURNING *;
+
+-- name: UpdateUserDeviceLocation :one
+UPDATE user_devices
+SET ip_address = $2,
+    country_code = $3,
+    city = $4,
+    asn = $5,
+    last_used_at = NOW()
+WHERE id = $1
+RETURNING *;
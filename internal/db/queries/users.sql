@@ -26,6 +26,11 @@ WHERE email = $1;
 SELECT * FROM users
 WHERE username = $1;
 
+-- name: GetUsersByIDs :many
+SELECT * FROM users
+WHERE id = ANY(@ids::bigint[])
+ORDER BY id;
+
 -- name: VerifyUserEmail :exec
 UPDATE users
 SET email_verified = TRUE, updated_at = NOW()
@@ -0,0 +1,56 @@
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    endpoint_id,
+    event_id,
+    event_type,
+    payload,
+    next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetWebhookDeliveryByID :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending'
+    AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetWebhookDeliveriesByEndpointID :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = @endpoint_id
+AND (@status::text = '' OR status = @status::text)
+ORDER BY created_at DESC
+LIMIT @row_limit;
+
+-- name: UpdateWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = $3,
+    last_status_code = $4,
+    last_error = $5,
+    next_attempt_at = $6,
+    delivered_at = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: RequeueWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending',
+    attempts = 0,
+    last_status_code = 0,
+    last_error = '',
+    next_attempt_at = $2,
+    delivered_at = NULL
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,42 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+    url,
+    description,
+    secret,
+    event_types
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhookEndpointByID :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+ORDER BY id;
+
+-- name: ListWebhookEndpointsForEvent :many
+SELECT * FROM webhook_endpoints
+WHERE active = TRUE
+AND event_types @> ARRAY[@event_type::text]
+ORDER BY id;
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = $2,
+    description = $3,
+    event_types = $4,
+    active = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateWebhookEndpointSecret :one
+UPDATE webhook_endpoints
+SET secret = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :exec
+DELETE FROM webhook_endpoints
+WHERE id = $1;
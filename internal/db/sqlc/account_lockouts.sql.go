@@ -9,24 +9,69 @@ import (
 	"context"
 	"net/netip"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const closeExpiredAccountLockouts = `-- name: CloseExpiredAccountLockouts :exec
+UPDATE account_lockouts
+SET unlocked_at = expires_at,
+    unlock_method = 'expired'
+WHERE user_id = $1
+AND unlocked_at IS NULL
+AND permanent = FALSE
+AND expires_at <= NOW()
+`
+
+func (q *Queries) CloseExpiredAccountLockouts(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, closeExpiredAccountLockouts, userID)
+	return err
+}
+
+const countRecentAccountLockouts = `-- name: CountRecentAccountLockouts :one
+SELECT COUNT(*) FROM account_lockouts
+WHERE user_id = $1
+AND created_at > $2::timestamptz
+`
+
+type CountRecentAccountLockoutsParams struct {
+	UserID int64     `json:"user_id"`
+	Since  time.Time `json:"since"`
+}
+
+func (q *Queries) CountRecentAccountLockouts(ctx context.Context, arg CountRecentAccountLockoutsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentAccountLockouts, arg.UserID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAccountLockout = `-- name: CreateAccountLockout :one
 INSERT INTO account_lockouts (
     user_id,
     ip_address,
     lockout_type,
-    expires_at
+    expires_at,
+    level,
+    permanent,
+    reason,
+    unlock_token_hash
 ) VALUES (
-    $1, $2, $3, $4
-) RETURNING id, user_id, ip_address, lockout_type, expires_at, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT DO NOTHING
+RETURNING id, user_id, ip_address, lockout_type, expires_at, created_at, level, permanent, reason, unlock_token_hash, unlocked_at, unlocked_by, unlock_method
 `
 
 type CreateAccountLockoutParams struct {
-	UserID      int64       `json:"user_id"`
-	IpAddress   *netip.Addr `json:"ip_address"`
-	LockoutType string      `json:"lockout_type"`
-	ExpiresAt   time.Time   `json:"expires_at"`
+	UserID          int64       `json:"user_id"`
+	IpAddress       *netip.Addr `json:"ip_address"`
+	LockoutType     string      `json:"lockout_type"`
+	ExpiresAt       time.Time   `json:"expires_at"`
+	Level           int32       `json:"level"`
+	Permanent       bool        `json:"permanent"`
+	Reason          string      `json:"reason"`
+	UnlockTokenHash string      `json:"unlock_token_hash"`
 }
 
 func (q *Queries) CreateAccountLockout(ctx context.Context, arg CreateAccountLockoutParams) (AccountLockout, error) {
@@ -35,6 +80,10 @@ func (q *Queries) CreateAccountLockout(ctx context.Context, arg CreateAccountLoc
 		arg.IpAddress,
 		arg.LockoutType,
 		arg.ExpiresAt,
+		arg.Level,
+		arg.Permanent,
+		arg.Reason,
+		arg.UnlockTokenHash,
 	)
 	var i AccountLockout
 	err := row.Scan(
@@ -44,34 +93,32 @@ func (q *Queries) CreateAccountLockout(ctx context.Context, arg CreateAccountLoc
 		&i.LockoutType,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.Level,
+		&i.Permanent,
+		&i.Reason,
+		&i.UnlockTokenHash,
+		&i.UnlockedAt,
+		&i.UnlockedBy,
+		&i.UnlockMethod,
 	)
 	return i, err
 }
 
-const deleteAccountLockoutByID = `-- name: DeleteAccountLockoutByID :exec
-DELETE FROM account_lockouts
-WHERE id = $1
-`
-
-func (q *Queries) DeleteAccountLockoutByID(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteAccountLockoutByID, id)
-	return err
-}
-
-const deleteExpiredLockouts = `-- name: DeleteExpiredLockouts :exec
+const deleteAccountLockoutHistoryBefore = `-- name: DeleteAccountLockoutHistoryBefore :exec
 DELETE FROM account_lockouts
-WHERE expires_at <= NOW()
+WHERE unlocked_at < $1::timestamptz
 `
 
-func (q *Queries) DeleteExpiredLockouts(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, deleteExpiredLockouts)
+func (q *Queries) DeleteAccountLockoutHistoryBefore(ctx context.Context, before time.Time) error {
+	_, err := q.db.Exec(ctx, deleteAccountLockoutHistoryBefore, before)
 	return err
 }
 
 const getAccountLockoutByIP = `-- name: GetAccountLockoutByIP :one
-SELECT id, user_id, ip_address, lockout_type, expires_at, created_at FROM account_lockouts
+SELECT id, user_id, ip_address, lockout_type, expires_at, created_at, level, permanent, reason, unlock_token_hash, unlocked_at, unlocked_by, unlock_method FROM account_lockouts
 WHERE ip_address = $1
-AND expires_at > NOW()
+AND unlocked_at IS NULL
+AND (permanent OR expires_at > NOW())
 ORDER BY created_at DESC
 LIMIT 1
 `
@@ -86,15 +133,52 @@ func (q *Queries) GetAccountLockoutByIP(ctx context.Context, ipAddress *netip.Ad
 		&i.LockoutType,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.Level,
+		&i.Permanent,
+		&i.Reason,
+		&i.UnlockTokenHash,
+		&i.UnlockedAt,
+		&i.UnlockedBy,
+		&i.UnlockMethod,
+	)
+	return i, err
+}
+
+const getAccountLockoutByUnlockTokenHash = `-- name: GetAccountLockoutByUnlockTokenHash :one
+SELECT id, user_id, ip_address, lockout_type, expires_at, created_at, level, permanent, reason, unlock_token_hash, unlocked_at, unlocked_by, unlock_method FROM account_lockouts
+WHERE unlock_token_hash = $1
+AND unlock_token_hash <> ''
+AND unlocked_at IS NULL
+LIMIT 1
+`
+
+func (q *Queries) GetAccountLockoutByUnlockTokenHash(ctx context.Context, unlockTokenHash string) (AccountLockout, error) {
+	row := q.db.QueryRow(ctx, getAccountLockoutByUnlockTokenHash, unlockTokenHash)
+	var i AccountLockout
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IpAddress,
+		&i.LockoutType,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Level,
+		&i.Permanent,
+		&i.Reason,
+		&i.UnlockTokenHash,
+		&i.UnlockedAt,
+		&i.UnlockedBy,
+		&i.UnlockMethod,
 	)
 	return i, err
 }
 
 const getAccountLockoutByUserAndIP = `-- name: GetAccountLockoutByUserAndIP :one
-SELECT id, user_id, ip_address, lockout_type, expires_at, created_at FROM account_lockouts
+SELECT id, user_id, ip_address, lockout_type, expires_at, created_at, level, permanent, reason, unlock_token_hash, unlocked_at, unlocked_by, unlock_method FROM account_lockouts
 WHERE user_id = $1
 AND ip_address = $2
-AND expires_at > NOW()
+AND unlocked_at IS NULL
+AND (permanent OR expires_at > NOW())
 ORDER BY created_at DESC
 LIMIT 1
 `
@@ -114,14 +198,22 @@ func (q *Queries) GetAccountLockoutByUserAndIP(ctx context.Context, arg GetAccou
 		&i.LockoutType,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.Level,
+		&i.Permanent,
+		&i.Reason,
+		&i.UnlockTokenHash,
+		&i.UnlockedAt,
+		&i.UnlockedBy,
+		&i.UnlockMethod,
 	)
 	return i, err
 }
 
 const getAccountLockoutByUserID = `-- name: GetAccountLockoutByUserID :one
-SELECT id, user_id, ip_address, lockout_type, expires_at, created_at FROM account_lockouts
+SELECT id, user_id, ip_address, lockout_type, expires_at, created_at, level, permanent, reason, unlock_token_hash, unlocked_at, unlocked_by, unlock_method FROM account_lockouts
 WHERE user_id = $1
-AND expires_at > NOW()
+AND unlocked_at IS NULL
+AND (permanent OR expires_at > NOW())
 ORDER BY created_at DESC
 LIMIT 1
 `
@@ -136,6 +228,145 @@ func (q *Queries) GetAccountLockoutByUserID(ctx context.Context, userID int64) (
 		&i.LockoutType,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.Level,
+		&i.Permanent,
+		&i.Reason,
+		&i.UnlockTokenHash,
+		&i.UnlockedAt,
+		&i.UnlockedBy,
+		&i.UnlockMethod,
 	)
 	return i, err
 }
+
+const getAccountLockoutForLogin = `-- name: GetAccountLockoutForLogin :one
+SELECT id, user_id, ip_address, lockout_type, expires_at, created_at, level, permanent, reason, unlock_token_hash, unlocked_at, unlocked_by, unlock_method FROM account_lockouts
+WHERE user_id = $1
+AND unlocked_at IS NULL
+AND (permanent OR expires_at > NOW())
+AND (lockout_type <> 'ip' OR ip_address = $2)
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetAccountLockoutForLoginParams struct {
+	UserID    int64       `json:"user_id"`
+	IpAddress *netip.Addr `json:"ip_address"`
+}
+
+func (q *Queries) GetAccountLockoutForLogin(ctx context.Context, arg GetAccountLockoutForLoginParams) (AccountLockout, error) {
+	row := q.db.QueryRow(ctx, getAccountLockoutForLogin, arg.UserID, arg.IpAddress)
+	var i AccountLockout
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IpAddress,
+		&i.LockoutType,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Level,
+		&i.Permanent,
+		&i.Reason,
+		&i.UnlockTokenHash,
+		&i.UnlockedAt,
+		&i.UnlockedBy,
+		&i.UnlockMethod,
+	)
+	return i, err
+}
+
+const getAccountLockoutsByUserID = `-- name: GetAccountLockoutsByUserID :many
+SELECT id, user_id, ip_address, lockout_type, expires_at, created_at, level, permanent, reason, unlock_token_hash, unlocked_at, unlocked_by, unlock_method FROM account_lockouts
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type GetAccountLockoutsByUserIDParams struct {
+	UserID int64 `json:"user_id"`
+	Limit  int32 `json:"limit"`
+}
+
+func (q *Queries) GetAccountLockoutsByUserID(ctx context.Context, arg GetAccountLockoutsByUserIDParams) ([]AccountLockout, error) {
+	rows, err := q.db.Query(ctx, getAccountLockoutsByUserID, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountLockout{}
+	for rows.Next() {
+		var i AccountLockout
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.IpAddress,
+			&i.LockoutType,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.Level,
+			&i.Permanent,
+			&i.Reason,
+			&i.UnlockTokenHash,
+			&i.UnlockedAt,
+			&i.UnlockedBy,
+			&i.UnlockMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unlockAccountLockouts = `-- name: UnlockAccountLockouts :many
+UPDATE account_lockouts
+SET unlocked_at = NOW(),
+    unlocked_by = $2,
+    unlock_method = $3
+WHERE user_id = $1
+AND unlocked_at IS NULL
+RETURNING id, user_id, ip_address, lockout_type, expires_at, created_at, level, permanent, reason, unlock_token_hash, unlocked_at, unlocked_by, unlock_method
+`
+
+type UnlockAccountLockoutsParams struct {
+	UserID       int64       `json:"user_id"`
+	UnlockedBy   pgtype.Int8 `json:"unlocked_by"`
+	UnlockMethod string      `json:"unlock_method"`
+}
+
+func (q *Queries) UnlockAccountLockouts(ctx context.Context, arg UnlockAccountLockoutsParams) ([]AccountLockout, error) {
+	rows, err := q.db.Query(ctx, unlockAccountLockouts, arg.UserID, arg.UnlockedBy, arg.UnlockMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountLockout{}
+	for rows.Next() {
+		var i AccountLockout
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.IpAddress,
+			&i.LockoutType,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.Level,
+			&i.Permanent,
+			&i.Reason,
+			&i.UnlockTokenHash,
+			&i.UnlockedAt,
+			&i.UnlockedBy,
+			&i.UnlockMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
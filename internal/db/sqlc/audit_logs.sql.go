@@ -24,7 +24,7 @@ INSERT INTO audit_logs (
     details
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at
+) RETURNING id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at, chain_seq, prev_hash, entry_hash
 `
 
 type CreateAuditLogParams struct {
@@ -58,234 +58,15 @@ func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams)
 		&i.UserAgent,
 		&i.Details,
 		&i.CreatedAt,
+		&i.ChainSeq,
+		&i.PrevHash,
+		&i.EntryHash,
 	)
 	return i, err
 }
 
-const deleteOldAuditLogs = `-- name: DeleteOldAuditLogs :exec
-DELETE FROM audit_logs
-WHERE created_at < NOW() - INTERVAL '90 days'
-`
-
-func (q *Queries) DeleteOldAuditLogs(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, deleteOldAuditLogs)
-	return err
-}
-
-const getAuditLogsByAction = `-- name: GetAuditLogsByAction :many
-SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at FROM audit_logs
-WHERE action = $1
-ORDER BY created_at DESC
-LIMIT $2
-`
-
-type GetAuditLogsByActionParams struct {
-	Action string `json:"action"`
-	Limit  int32  `json:"limit"`
-}
-
-func (q *Queries) GetAuditLogsByAction(ctx context.Context, arg GetAuditLogsByActionParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getAuditLogsByAction, arg.Action, arg.Limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []AuditLog{}
-	for rows.Next() {
-		var i AuditLog
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Action,
-			&i.ResourceType,
-			&i.ResourceID,
-			&i.IpAddress,
-			&i.UserAgent,
-			&i.Details,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getAuditLogsByDateRange = `-- name: GetAuditLogsByDateRange :many
-SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at FROM audit_logs
-WHERE created_at BETWEEN $1 AND $2
-ORDER BY created_at DESC
-LIMIT $3
-`
-
-type GetAuditLogsByDateRangeParams struct {
-	CreatedAt   *time.Time `json:"created_at"`
-	CreatedAt_2 *time.Time `json:"created_at_2"`
-	Limit       int32      `json:"limit"`
-}
-
-func (q *Queries) GetAuditLogsByDateRange(ctx context.Context, arg GetAuditLogsByDateRangeParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getAuditLogsByDateRange, arg.CreatedAt, arg.CreatedAt_2, arg.Limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []AuditLog{}
-	for rows.Next() {
-		var i AuditLog
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Action,
-			&i.ResourceType,
-			&i.ResourceID,
-			&i.IpAddress,
-			&i.UserAgent,
-			&i.Details,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getAuditLogsByIP = `-- name: GetAuditLogsByIP :many
-SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at FROM audit_logs
-WHERE ip_address = $1
-ORDER BY created_at DESC
-LIMIT $2
-`
-
-type GetAuditLogsByIPParams struct {
-	IpAddress *netip.Addr `json:"ip_address"`
-	Limit     int32       `json:"limit"`
-}
-
-func (q *Queries) GetAuditLogsByIP(ctx context.Context, arg GetAuditLogsByIPParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getAuditLogsByIP, arg.IpAddress, arg.Limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []AuditLog{}
-	for rows.Next() {
-		var i AuditLog
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Action,
-			&i.ResourceType,
-			&i.ResourceID,
-			&i.IpAddress,
-			&i.UserAgent,
-			&i.Details,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getAuditLogsByResourceID = `-- name: GetAuditLogsByResourceID :many
-SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at FROM audit_logs
-WHERE resource_type = $1 AND resource_id = $2
-ORDER BY created_at DESC
-LIMIT $3
-`
-
-type GetAuditLogsByResourceIDParams struct {
-	ResourceType pgtype.Text `json:"resource_type"`
-	ResourceID   pgtype.Int8 `json:"resource_id"`
-	Limit        int32       `json:"limit"`
-}
-
-func (q *Queries) GetAuditLogsByResourceID(ctx context.Context, arg GetAuditLogsByResourceIDParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getAuditLogsByResourceID, arg.ResourceType, arg.ResourceID, arg.Limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []AuditLog{}
-	for rows.Next() {
-		var i AuditLog
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Action,
-			&i.ResourceType,
-			&i.ResourceID,
-			&i.IpAddress,
-			&i.UserAgent,
-			&i.Details,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getAuditLogsByResourceType = `-- name: GetAuditLogsByResourceType :many
-SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at FROM audit_logs
-WHERE resource_type = $1
-ORDER BY created_at DESC
-LIMIT $2
-`
-
-type GetAuditLogsByResourceTypeParams struct {
-	ResourceType pgtype.Text `json:"resource_type"`
-	Limit        int32       `json:"limit"`
-}
-
-func (q *Queries) GetAuditLogsByResourceType(ctx context.Context, arg GetAuditLogsByResourceTypeParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getAuditLogsByResourceType, arg.ResourceType, arg.Limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []AuditLog{}
-	for rows.Next() {
-		var i AuditLog
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Action,
-			&i.ResourceType,
-			&i.ResourceID,
-			&i.IpAddress,
-			&i.UserAgent,
-			&i.Details,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const getAuditLogsByUserID = `-- name: GetAuditLogsByUserID :many
-SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at FROM audit_logs
+SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at, chain_seq, prev_hash, entry_hash FROM audit_logs
 WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -315,6 +96,9 @@ func (q *Queries) GetAuditLogsByUserID(ctx context.Context, arg GetAuditLogsByUs
 			&i.UserAgent,
 			&i.Details,
 			&i.CreatedAt,
+			&i.ChainSeq,
+			&i.PrevHash,
+			&i.EntryHash,
 		); err != nil {
 			return nil, err
 		}
@@ -326,15 +110,47 @@ func (q *Queries) GetAuditLogsByUserID(ctx context.Context, arg GetAuditLogsByUs
 	return items, nil
 }
 
-const getRecentAuditLogs = `-- name: GetRecentAuditLogs :many
-SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at FROM audit_logs
-WHERE created_at > NOW() - INTERVAL '24 hours'
-ORDER BY created_at DESC
-LIMIT $1
+const searchAuditLogs = `-- name: SearchAuditLogs :many
+SELECT id, user_id, action, resource_type, resource_id, ip_address, user_agent, details, created_at, chain_seq, prev_hash, entry_hash FROM audit_logs
+WHERE ($1::bigint IS NULL OR user_id = $1)
+AND ($2::text[] IS NULL OR action = ANY($2::text[]))
+AND ($3::text IS NULL OR resource_type = $3)
+AND ($4::bigint IS NULL OR resource_id = $4)
+AND ($5::cidr IS NULL OR ip_address <<= $5)
+AND ($6::timestamptz IS NULL OR created_at >= $6)
+AND ($7::timestamptz IS NULL OR created_at < $7)
+AND ($8::jsonb IS NULL OR details @> $8)
+AND ($9::bigint IS NULL OR id < $9)
+ORDER BY id DESC
+LIMIT $10
 `
 
-func (q *Queries) GetRecentAuditLogs(ctx context.Context, limit int32) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getRecentAuditLogs, limit)
+type SearchAuditLogsParams struct {
+	UserID       pgtype.Int8   `json:"user_id"`
+	Actions      []string      `json:"actions"`
+	ResourceType pgtype.Text   `json:"resource_type"`
+	ResourceID   pgtype.Int8   `json:"resource_id"`
+	Network      *netip.Prefix `json:"network"`
+	FromTime     *time.Time    `json:"from_time"`
+	ToTime       *time.Time    `json:"to_time"`
+	Details      []byte        `json:"details"`
+	BeforeID     pgtype.Int8   `json:"before_id"`
+	RowLimit     int32         `json:"row_limit"`
+}
+
+func (q *Queries) SearchAuditLogs(ctx context.Context, arg SearchAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, searchAuditLogs,
+		arg.UserID,
+		arg.Actions,
+		arg.ResourceType,
+		arg.ResourceID,
+		arg.Network,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Details,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -352,6 +168,9 @@ func (q *Queries) GetRecentAuditLogs(ctx context.Context, limit int32) ([]AuditL
 			&i.UserAgent,
 			&i.Details,
 			&i.CreatedAt,
+			&i.ChainSeq,
+			&i.PrevHash,
+			&i.EntryHash,
 		); err != nil {
 			return nil, err
 		}
@@ -8,10 +8,63 @@ package db
 import (
 	"context"
 	"net/netip"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countConsecutiveFailedLoginIPsByEmail = `-- name: CountConsecutiveFailedLoginIPsByEmail :one
+SELECT COUNT(DISTINCT ip_address) FROM login_attempts
+WHERE email = $1
+AND success = false
+AND created_at > GREATEST(
+    $2::timestamptz,
+    COALESCE(
+        (SELECT MAX(last_success.created_at) FROM login_attempts last_success
+         WHERE last_success.email = $1 AND last_success.success = true),
+        $2::timestamptz
+    )
+)
+`
+
+type CountConsecutiveFailedLoginIPsByEmailParams struct {
+	Email string    `json:"email"`
+	Since time.Time `json:"since"`
+}
+
+func (q *Queries) CountConsecutiveFailedLoginIPsByEmail(ctx context.Context, arg CountConsecutiveFailedLoginIPsByEmailParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countConsecutiveFailedLoginIPsByEmail, arg.Email, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countConsecutiveFailedLoginsByEmail = `-- name: CountConsecutiveFailedLoginsByEmail :one
+SELECT COUNT(*) FROM login_attempts
+WHERE email = $1
+AND success = false
+AND created_at > GREATEST(
+    $2::timestamptz,
+    COALESCE(
+        (SELECT MAX(last_success.created_at) FROM login_attempts last_success
+         WHERE last_success.email = $1 AND last_success.success = true),
+        $2::timestamptz
+    )
+)
+`
+
+type CountConsecutiveFailedLoginsByEmailParams struct {
+	Email string    `json:"email"`
+	Since time.Time `json:"since"`
+}
+
+func (q *Queries) CountConsecutiveFailedLoginsByEmail(ctx context.Context, arg CountConsecutiveFailedLoginsByEmailParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countConsecutiveFailedLoginsByEmail, arg.Email, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createLoginAttempt = `-- name: CreateLoginAttempt :one
 INSERT INTO login_attempts (
     user_id,
@@ -19,19 +72,31 @@ INSERT INTO login_attempts (
     ip_address,
     user_agent,
     success,
-    failure_reason
+    failure_reason,
+    country_code,
+    city,
+    asn,
+    latitude,
+    longitude,
+    password_fingerprint
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
-) RETURNING id, user_id, email, ip_address, user_agent, success, failure_reason, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint
 `
 
 type CreateLoginAttemptParams struct {
-	UserID        pgtype.Int8 `json:"user_id"`
-	Email         string      `json:"email"`
-	IpAddress     netip.Addr  `json:"ip_address"`
-	UserAgent     *string     `json:"user_agent"`
-	Success       bool        `json:"success"`
-	FailureReason pgtype.Text `json:"failure_reason"`
+	UserID              pgtype.Int8   `json:"user_id"`
+	Email               string        `json:"email"`
+	IpAddress           netip.Addr    `json:"ip_address"`
+	UserAgent           *string       `json:"user_agent"`
+	Success             bool          `json:"success"`
+	FailureReason       pgtype.Text   `json:"failure_reason"`
+	CountryCode         pgtype.Text   `json:"country_code"`
+	City                pgtype.Text   `json:"city"`
+	Asn                 pgtype.Int8   `json:"asn"`
+	Latitude            pgtype.Float8 `json:"latitude"`
+	Longitude           pgtype.Float8 `json:"longitude"`
+	PasswordFingerprint pgtype.Text   `json:"password_fingerprint"`
 }
 
 func (q *Queries) CreateLoginAttempt(ctx context.Context, arg CreateLoginAttemptParams) (LoginAttempt, error) {
@@ -42,6 +107,12 @@ func (q *Queries) CreateLoginAttempt(ctx context.Context, arg CreateLoginAttempt
 		arg.UserAgent,
 		arg.Success,
 		arg.FailureReason,
+		arg.CountryCode,
+		arg.City,
+		arg.Asn,
+		arg.Latitude,
+		arg.Longitude,
+		arg.PasswordFingerprint,
 	)
 	var i LoginAttempt
 	err := row.Scan(
@@ -53,6 +124,12 @@ func (q *Queries) CreateLoginAttempt(ctx context.Context, arg CreateLoginAttempt
 		&i.Success,
 		&i.FailureReason,
 		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
+		&i.Latitude,
+		&i.Longitude,
+		&i.PasswordFingerprint,
 	)
 	return i, err
 }
@@ -68,7 +145,7 @@ func (q *Queries) DeleteOldLoginAttempts(ctx context.Context) error {
 }
 
 const getFailedLoginAttemptsByEmail = `-- name: GetFailedLoginAttemptsByEmail :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE email = $1 AND success = false
 ORDER BY created_at DESC
 LIMIT $2
@@ -97,6 +174,12 @@ func (q *Queries) GetFailedLoginAttemptsByEmail(ctx context.Context, arg GetFail
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
@@ -109,7 +192,7 @@ func (q *Queries) GetFailedLoginAttemptsByEmail(ctx context.Context, arg GetFail
 }
 
 const getFailedLoginAttemptsByIP = `-- name: GetFailedLoginAttemptsByIP :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE ip_address = $1 AND success = false
 ORDER BY created_at DESC
 LIMIT $2
@@ -138,6 +221,12 @@ func (q *Queries) GetFailedLoginAttemptsByIP(ctx context.Context, arg GetFailedL
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
@@ -150,7 +239,7 @@ func (q *Queries) GetFailedLoginAttemptsByIP(ctx context.Context, arg GetFailedL
 }
 
 const getFailedLoginAttemptsByUserID = `-- name: GetFailedLoginAttemptsByUserID :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE user_id = $1 AND success = false
 ORDER BY created_at DESC
 LIMIT $2
@@ -179,6 +268,12 @@ func (q *Queries) GetFailedLoginAttemptsByUserID(ctx context.Context, arg GetFai
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
@@ -190,8 +285,65 @@ func (q *Queries) GetFailedLoginAttemptsByUserID(ctx context.Context, arg GetFai
 	return items, nil
 }
 
+const getFailedLoginVelocityByNetwork = `-- name: GetFailedLoginVelocityByNetwork :one
+SELECT COUNT(*)::bigint AS failed_attempts,
+       COUNT(DISTINCT email)::bigint AS distinct_emails
+FROM login_attempts
+WHERE ip_address <<= $1::cidr
+AND success = false
+AND created_at > $2
+`
+
+type GetFailedLoginVelocityByNetworkParams struct {
+	Network netip.Prefix `json:"network"`
+	Since   *time.Time   `json:"since"`
+}
+
+type GetFailedLoginVelocityByNetworkRow struct {
+	FailedAttempts int64 `json:"failed_attempts"`
+	DistinctEmails int64 `json:"distinct_emails"`
+}
+
+func (q *Queries) GetFailedLoginVelocityByNetwork(ctx context.Context, arg GetFailedLoginVelocityByNetworkParams) (GetFailedLoginVelocityByNetworkRow, error) {
+	row := q.db.QueryRow(ctx, getFailedLoginVelocityByNetwork, arg.Network, arg.Since)
+	var i GetFailedLoginVelocityByNetworkRow
+	err := row.Scan(&i.FailedAttempts, &i.DistinctEmails)
+	return i, err
+}
+
+const getLastSuccessfulLoginWithLocation = `-- name: GetLastSuccessfulLoginWithLocation :one
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
+WHERE user_id = $1
+AND success = true
+AND latitude IS NOT NULL
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastSuccessfulLoginWithLocation(ctx context.Context, userID pgtype.Int8) (LoginAttempt, error) {
+	row := q.db.QueryRow(ctx, getLastSuccessfulLoginWithLocation, userID)
+	var i LoginAttempt
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Success,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
+		&i.Latitude,
+		&i.Longitude,
+		&i.PasswordFingerprint,
+	)
+	return i, err
+}
+
 const getLoginAttemptsByEmail = `-- name: GetLoginAttemptsByEmail :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE email = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -220,6 +372,12 @@ func (q *Queries) GetLoginAttemptsByEmail(ctx context.Context, arg GetLoginAttem
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
@@ -232,7 +390,7 @@ func (q *Queries) GetLoginAttemptsByEmail(ctx context.Context, arg GetLoginAttem
 }
 
 const getLoginAttemptsByIP = `-- name: GetLoginAttemptsByIP :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE ip_address = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -261,6 +419,12 @@ func (q *Queries) GetLoginAttemptsByIP(ctx context.Context, arg GetLoginAttempts
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
@@ -273,7 +437,7 @@ func (q *Queries) GetLoginAttemptsByIP(ctx context.Context, arg GetLoginAttempts
 }
 
 const getLoginAttemptsByUserID = `-- name: GetLoginAttemptsByUserID :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -302,6 +466,12 @@ func (q *Queries) GetLoginAttemptsByUserID(ctx context.Context, arg GetLoginAtte
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
@@ -313,8 +483,135 @@ func (q *Queries) GetLoginAttemptsByUserID(ctx context.Context, arg GetLoginAtte
 	return items, nil
 }
 
+const getLoginCountryHistory = `-- name: GetLoginCountryHistory :one
+SELECT COUNT(*) FILTER (WHERE country_code = $1)::bigint AS from_country,
+       COUNT(*)::bigint AS located
+FROM login_attempts
+WHERE user_id = $2
+AND success = true
+AND country_code IS NOT NULL
+`
+
+type GetLoginCountryHistoryParams struct {
+	CountryCode pgtype.Text `json:"country_code"`
+	UserID      pgtype.Int8 `json:"user_id"`
+}
+
+type GetLoginCountryHistoryRow struct {
+	FromCountry int64 `json:"from_country"`
+	Located     int64 `json:"located"`
+}
+
+func (q *Queries) GetLoginCountryHistory(ctx context.Context, arg GetLoginCountryHistoryParams) (GetLoginCountryHistoryRow, error) {
+	row := q.db.QueryRow(ctx, getLoginCountryHistory, arg.CountryCode, arg.UserID)
+	var i GetLoginCountryHistoryRow
+	err := row.Scan(&i.FromCountry, &i.Located)
+	return i, err
+}
+
+const getLoginOutcomesByASN = `-- name: GetLoginOutcomesByASN :one
+SELECT COUNT(*)::bigint AS attempts,
+       COUNT(*) FILTER (WHERE success = false)::bigint AS failed_attempts
+FROM login_attempts
+WHERE asn = $1
+AND created_at > $2
+`
+
+type GetLoginOutcomesByASNParams struct {
+	Asn   pgtype.Int8 `json:"asn"`
+	Since *time.Time  `json:"since"`
+}
+
+type GetLoginOutcomesByASNRow struct {
+	Attempts       int64 `json:"attempts"`
+	FailedAttempts int64 `json:"failed_attempts"`
+}
+
+func (q *Queries) GetLoginOutcomesByASN(ctx context.Context, arg GetLoginOutcomesByASNParams) (GetLoginOutcomesByASNRow, error) {
+	row := q.db.QueryRow(ctx, getLoginOutcomesByASN, arg.Asn, arg.Since)
+	var i GetLoginOutcomesByASNRow
+	err := row.Scan(&i.Attempts, &i.FailedAttempts)
+	return i, err
+}
+
+const getPasswordFingerprintSpread = `-- name: GetPasswordFingerprintSpread :one
+SELECT COUNT(DISTINCT email)::bigint AS distinct_emails,
+       COUNT(DISTINCT ip_address)::bigint AS distinct_ips
+FROM login_attempts
+WHERE password_fingerprint = $1
+AND success = false
+AND created_at > $2
+`
+
+type GetPasswordFingerprintSpreadParams struct {
+	PasswordFingerprint pgtype.Text `json:"password_fingerprint"`
+	Since               *time.Time  `json:"since"`
+}
+
+type GetPasswordFingerprintSpreadRow struct {
+	DistinctEmails int64 `json:"distinct_emails"`
+	DistinctIps    int64 `json:"distinct_ips"`
+}
+
+func (q *Queries) GetPasswordFingerprintSpread(ctx context.Context, arg GetPasswordFingerprintSpreadParams) (GetPasswordFingerprintSpreadRow, error) {
+	row := q.db.QueryRow(ctx, getPasswordFingerprintSpread, arg.PasswordFingerprint, arg.Since)
+	var i GetPasswordFingerprintSpreadRow
+	err := row.Scan(&i.DistinctEmails, &i.DistinctIps)
+	return i, err
+}
+
+const getPasswordFingerprintSprayers = `-- name: GetPasswordFingerprintSprayers :many
+SELECT ip_address,
+       COUNT(DISTINCT email)::bigint AS distinct_emails
+FROM login_attempts
+WHERE password_fingerprint = $1
+AND success = false
+AND created_at > $2
+GROUP BY ip_address
+HAVING COUNT(DISTINCT email) >= $3::bigint
+ORDER BY distinct_emails DESC
+LIMIT $4
+`
+
+type GetPasswordFingerprintSprayersParams struct {
+	PasswordFingerprint pgtype.Text `json:"password_fingerprint"`
+	Since               *time.Time  `json:"since"`
+	MinEmails           int64       `json:"min_emails"`
+	RowLimit            int32       `json:"row_limit"`
+}
+
+type GetPasswordFingerprintSprayersRow struct {
+	IpAddress      netip.Addr `json:"ip_address"`
+	DistinctEmails int64      `json:"distinct_emails"`
+}
+
+func (q *Queries) GetPasswordFingerprintSprayers(ctx context.Context, arg GetPasswordFingerprintSprayersParams) ([]GetPasswordFingerprintSprayersRow, error) {
+	rows, err := q.db.Query(ctx, getPasswordFingerprintSprayers,
+		arg.PasswordFingerprint,
+		arg.Since,
+		arg.MinEmails,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetPasswordFingerprintSprayersRow{}
+	for rows.Next() {
+		var i GetPasswordFingerprintSprayersRow
+		if err := rows.Scan(&i.IpAddress, &i.DistinctEmails); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRecentFailedAttemptsByEmail = `-- name: GetRecentFailedAttemptsByEmail :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE email = $1
 AND success = false
 AND created_at > NOW() - INTERVAL '24 hours'
@@ -339,6 +636,12 @@ func (q *Queries) GetRecentFailedAttemptsByEmail(ctx context.Context, email stri
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
@@ -351,7 +654,7 @@ func (q *Queries) GetRecentFailedAttemptsByEmail(ctx context.Context, email stri
 }
 
 const getRecentFailedAttemptsByIP = `-- name: GetRecentFailedAttemptsByIP :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE ip_address = $1
 AND success = false
 AND created_at > NOW() - INTERVAL '24 hours'
@@ -376,6 +679,12 @@ func (q *Queries) GetRecentFailedAttemptsByIP(ctx context.Context, ipAddress net
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
@@ -388,7 +697,7 @@ func (q *Queries) GetRecentFailedAttemptsByIP(ctx context.Context, ipAddress net
 }
 
 const getRecentFailedAttemptsByUserID = `-- name: GetRecentFailedAttemptsByUserID :many
-SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts
+SELECT id, user_id, email, ip_address, user_agent, success, failure_reason, created_at, country_code, city, asn, latitude, longitude, password_fingerprint FROM login_attempts
 WHERE user_id = $1
 AND success = false
 AND created_at > NOW() - INTERVAL '24 hours'
@@ -413,6 +722,12 @@ func (q *Queries) GetRecentFailedAttemptsByUserID(ctx context.Context, userID pg
 			&i.Success,
 			&i.FailureReason,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PasswordFingerprint,
 		); err != nil {
 			return nil, err
 		}
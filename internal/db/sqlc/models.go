@@ -8,16 +8,35 @@ import (
 	"net/netip"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type AccountLockout struct {
-	ID          int64       `json:"id"`
-	UserID      int64       `json:"user_id"`
-	IpAddress   *netip.Addr `json:"ip_address"`
-	LockoutType string      `json:"lockout_type"`
-	ExpiresAt   time.Time   `json:"expires_at"`
-	CreatedAt   *time.Time  `json:"created_at"`
+	ID              int64       `json:"id"`
+	UserID          int64       `json:"user_id"`
+	IpAddress       *netip.Addr `json:"ip_address"`
+	LockoutType     string      `json:"lockout_type"`
+	ExpiresAt       time.Time   `json:"expires_at"`
+	CreatedAt       *time.Time  `json:"created_at"`
+	Level           int32       `json:"level"`
+	Permanent       bool        `json:"permanent"`
+	Reason          string      `json:"reason"`
+	UnlockTokenHash string      `json:"unlock_token_hash"`
+	UnlockedAt      *time.Time  `json:"unlocked_at"`
+	UnlockedBy      pgtype.Int8 `json:"unlocked_by"`
+	UnlockMethod    string      `json:"unlock_method"`
+}
+
+type Application struct {
+	ID                   int64     `json:"id"`
+	Name                 string    `json:"name"`
+	ClientID             string    `json:"client_id"`
+	LogoutSecret         string    `json:"logout_secret"`
+	BackchannelLogoutUrl string    `json:"backchannel_logout_url"`
+	Active               bool      `json:"active"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
 }
 
 type AuditLog struct {
@@ -30,6 +49,50 @@ type AuditLog struct {
 	UserAgent    *string     `json:"user_agent"`
 	Details      []byte      `json:"details"`
 	CreatedAt    *time.Time  `json:"created_at"`
+	ChainSeq     int64       `json:"chain_seq"`
+	PrevHash     []byte      `json:"prev_hash"`
+	EntryHash    []byte      `json:"entry_hash"`
+}
+
+type AuditLogChain struct {
+	ID         int16  `json:"id"`
+	LastSeq    int64  `json:"last_seq"`
+	LastHash   []byte `json:"last_hash"`
+	PrunedSeq  int64  `json:"pruned_seq"`
+	PrunedHash []byte `json:"pruned_hash"`
+}
+
+type AuditLogCheckpoint struct {
+	ID        int64     `json:"id"`
+	ChainSeq  int64     `json:"chain_seq"`
+	EntryHash []byte    `json:"entry_hash"`
+	PublicKey []byte    `json:"public_key"`
+	Signature []byte    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BackchannelLogoutDelivery struct {
+	ID             int64      `json:"id"`
+	ApplicationID  int64      `json:"application_id"`
+	UserID         int64      `json:"user_id"`
+	SessionID      string     `json:"session_id"`
+	Jti            uuid.UUID  `json:"jti"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	LastStatusCode int32      `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+type CaptchaRequirement struct {
+	ID        int64     `json:"id"`
+	Scope     string    `json:"scope"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type DataExport struct {
@@ -53,15 +116,46 @@ type EmailVerification struct {
 	UsedAt    *time.Time `json:"used_at"`
 }
 
+type IpAccessRule struct {
+	ID        int64        `json:"id"`
+	Network   netip.Prefix `json:"network"`
+	RuleType  string       `json:"rule_type"`
+	Reason    string       `json:"reason"`
+	CreatedBy pgtype.Int8  `json:"created_by"`
+	ExpiresAt *time.Time   `json:"expires_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type LoginAlert struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	SessionID   string     `json:"session_id"`
+	DeviceName  string     `json:"device_name"`
+	IpAddress   netip.Addr `json:"ip_address"`
+	Location    string     `json:"location"`
+	NewDevice   bool       `json:"new_device"`
+	NewLocation bool       `json:"new_location"`
+	TokenHash   string     `json:"token_hash"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ReportedAt  *time.Time `json:"reported_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
 type LoginAttempt struct {
-	ID            int64       `json:"id"`
-	UserID        pgtype.Int8 `json:"user_id"`
-	Email         string      `json:"email"`
-	IpAddress     netip.Addr  `json:"ip_address"`
-	UserAgent     *string     `json:"user_agent"`
-	Success       bool        `json:"success"`
-	FailureReason pgtype.Text `json:"failure_reason"`
-	CreatedAt     *time.Time  `json:"created_at"`
+	ID                  int64         `json:"id"`
+	UserID              pgtype.Int8   `json:"user_id"`
+	Email               string        `json:"email"`
+	IpAddress           netip.Addr    `json:"ip_address"`
+	UserAgent           *string       `json:"user_agent"`
+	Success             bool          `json:"success"`
+	FailureReason       pgtype.Text   `json:"failure_reason"`
+	CreatedAt           *time.Time    `json:"created_at"`
+	CountryCode         pgtype.Text   `json:"country_code"`
+	City                pgtype.Text   `json:"city"`
+	Asn                 pgtype.Int8   `json:"asn"`
+	Latitude            pgtype.Float8 `json:"latitude"`
+	Longitude           pgtype.Float8 `json:"longitude"`
+	PasswordFingerprint pgtype.Text   `json:"password_fingerprint"`
 }
 
 type OauthAccount struct {
@@ -79,6 +173,27 @@ type OauthAccount struct {
 	UpdatedAt      *time.Time  `json:"updated_at"`
 }
 
+type OutboxAggregate struct {
+	AggregateType string `json:"aggregate_type"`
+	AggregateID   string `json:"aggregate_id"`
+	LastSeq       int64  `json:"last_seq"`
+}
+
+type OutboxEvent struct {
+	ID            int64      `json:"id"`
+	EventID       uuid.UUID  `json:"event_id"`
+	AggregateType string     `json:"aggregate_type"`
+	AggregateID   string     `json:"aggregate_id"`
+	EventType     string     `json:"event_type"`
+	Payload       []byte     `json:"payload"`
+	Attempts      int32      `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	PublishedAt   *time.Time `json:"published_at"`
+	AggregateSeq  int64      `json:"aggregate_seq"`
+}
+
 type PasswordHistory struct {
 	ID           int64      `json:"id"`
 	UserID       int64      `json:"user_id"`
@@ -108,6 +223,23 @@ type RefreshToken struct {
 	LastUsedAt *time.Time `json:"last_used_at"`
 }
 
+type Session struct {
+	ID                 string      `json:"id"`
+	UserID             int64       `json:"user_id"`
+	AccessTokenHash    string      `json:"access_token_hash"`
+	RefreshTokenHash   string      `json:"refresh_token_hash"`
+	DeviceInfo         []byte      `json:"device_info"`
+	IpAddress          *netip.Addr `json:"ip_address"`
+	UserAgent          string      `json:"user_agent"`
+	IsActive           bool        `json:"is_active"`
+	CreatedAt          time.Time   `json:"created_at"`
+	LastActiveAt       time.Time   `json:"last_active_at"`
+	ExpiresAt          time.Time   `json:"expires_at"`
+	RevokedAt          *time.Time  `json:"revoked_at"`
+	AbsoluteExpiresAt  time.Time   `json:"absolute_expires_at"`
+	IdleTimeoutSeconds int32       `json:"idle_timeout_seconds"`
+}
+
 type SuspiciousActivity struct {
 	ID           int64       `json:"id"`
 	UserID       pgtype.Int8 `json:"user_id"`
@@ -117,8 +249,20 @@ type SuspiciousActivity struct {
 	Description  pgtype.Text `json:"description"`
 	Metadata     []byte      `json:"metadata"`
 	Severity     pgtype.Text `json:"severity"`
-	Resolved     pgtype.Bool `json:"resolved"`
 	CreatedAt    *time.Time  `json:"created_at"`
+	Status       string      `json:"status"`
+	AssignedTo   pgtype.Int8 `json:"assigned_to"`
+	ResolvedBy   pgtype.Int8 `json:"resolved_by"`
+	ResolvedAt   *time.Time  `json:"resolved_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+type SuspiciousActivityNote struct {
+	ID         int64       `json:"id"`
+	ActivityID int64       `json:"activity_id"`
+	AuthorID   pgtype.Int8 `json:"author_id"`
+	Note       string      `json:"note"`
+	CreatedAt  time.Time   `json:"created_at"`
 }
 
 type User struct {
@@ -137,16 +281,19 @@ type User struct {
 }
 
 type UserDevice struct {
-	ID         int64       `json:"id"`
-	UserID     int64       `json:"user_id"`
-	DeviceID   string      `json:"device_id"`
-	DeviceName pgtype.Text `json:"device_name"`
-	DeviceType pgtype.Text `json:"device_type"`
-	UserAgent  *string     `json:"user_agent"`
-	IpAddress  *netip.Addr `json:"ip_address"`
-	Trusted    pgtype.Bool `json:"trusted"`
-	LastUsedAt *time.Time  `json:"last_used_at"`
-	CreatedAt  *time.Time  `json:"created_at"`
+	ID          int64       `json:"id"`
+	UserID      int64       `json:"user_id"`
+	DeviceID    string      `json:"device_id"`
+	DeviceName  pgtype.Text `json:"device_name"`
+	DeviceType  pgtype.Text `json:"device_type"`
+	UserAgent   *string     `json:"user_agent"`
+	IpAddress   *netip.Addr `json:"ip_address"`
+	Trusted     pgtype.Bool `json:"trusted"`
+	LastUsedAt  *time.Time  `json:"last_used_at"`
+	CreatedAt   *time.Time  `json:"created_at"`
+	CountryCode pgtype.Text `json:"country_code"`
+	City        pgtype.Text `json:"city"`
+	Asn         pgtype.Int8 `json:"asn"`
 }
 
 type UserProfile struct {
@@ -162,3 +309,29 @@ type UserProfile struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	EndpointID     int64      `json:"endpoint_id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	LastStatusCode int32      `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+type WebhookEndpoint struct {
+	ID          int64     `json:"id"`
+	Url         string    `json:"url"`
+	Description string    `json:"description"`
+	Secret      string    `json:"secret"`
+	EventTypes  []string  `json:"event_types"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
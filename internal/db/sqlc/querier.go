@@ -7,77 +7,130 @@ package db
 import (
 	"context"
 	"net/netip"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	ActivateUser(ctx context.Context, id int64) error
+	AssignSuspiciousActivity(ctx context.Context, arg AssignSuspiciousActivityParams) (SuspiciousActivity, error)
 	CheckPasswordInHistory(ctx context.Context, arg CheckPasswordInHistoryParams) (int64, error)
+	ClaimDueBackchannelLogoutDeliveries(ctx context.Context, arg ClaimDueBackchannelLogoutDeliveriesParams) ([]BackchannelLogoutDelivery, error)
+	ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ClaimPendingOutboxEvents(ctx context.Context, arg ClaimPendingOutboxEventsParams) ([]OutboxEvent, error)
 	CleanupExpiredRefreshTokens(ctx context.Context) error
+	CloseExpiredAccountLockouts(ctx context.Context, userID int64) error
+	CountActiveAccountLockouts(ctx context.Context) (CountActiveAccountLockoutsRow, error)
+	CountActiveIPLockouts(ctx context.Context) (int64, error)
+	CountConsecutiveFailedLoginIPsByEmail(ctx context.Context, arg CountConsecutiveFailedLoginIPsByEmailParams) (int64, error)
+	CountConsecutiveFailedLoginsByEmail(ctx context.Context, arg CountConsecutiveFailedLoginsByEmailParams) (int64, error)
+	CountRecentAccountLockouts(ctx context.Context, arg CountRecentAccountLockoutsParams) (int64, error)
+	CountUnresolvedSuspiciousActivitiesBySeverity(ctx context.Context, arg CountUnresolvedSuspiciousActivitiesBySeverityParams) ([]CountUnresolvedSuspiciousActivitiesBySeverityRow, error)
 	CreateAccountLockout(ctx context.Context, arg CreateAccountLockoutParams) (AccountLockout, error)
+	CreateApplication(ctx context.Context, arg CreateApplicationParams) (Application, error)
 	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
+	CreateAuditLogCheckpoint(ctx context.Context, arg CreateAuditLogCheckpointParams) (AuditLogCheckpoint, error)
+	CreateBackchannelLogoutDelivery(ctx context.Context, arg CreateBackchannelLogoutDeliveryParams) (BackchannelLogoutDelivery, error)
 	CreateDataExport(ctx context.Context, arg CreateDataExportParams) (DataExport, error)
 	CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) (EmailVerification, error)
+	CreateIPAccessRule(ctx context.Context, arg CreateIPAccessRuleParams) (IpAccessRule, error)
+	CreateLoginAlert(ctx context.Context, arg CreateLoginAlertParams) (LoginAlert, error)
 	CreateLoginAttempt(ctx context.Context, arg CreateLoginAttemptParams) (LoginAttempt, error)
 	CreateOAuthAccount(ctx context.Context, arg CreateOAuthAccountParams) (OauthAccount, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error
 	CreatePasswordHistory(ctx context.Context, arg CreatePasswordHistoryParams) (PasswordHistory, error)
 	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
 	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateSuspiciousActivity(ctx context.Context, arg CreateSuspiciousActivityParams) (SuspiciousActivity, error)
+	CreateSuspiciousActivityNote(ctx context.Context, arg CreateSuspiciousActivityNoteParams) (SuspiciousActivityNote, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateUserDevice(ctx context.Context, arg CreateUserDeviceParams) (UserDevice, error)
 	CreateUserProfile(ctx context.Context, arg CreateUserProfileParams) (UserProfile, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
 	DeactivateUser(ctx context.Context, id int64) error
-	DeleteAccountLockoutByID(ctx context.Context, id int64) error
+	DeleteAccountLockoutHistoryBefore(ctx context.Context, before time.Time) error
 	DeleteAllUserDevices(ctx context.Context, userID int64) error
+	DeleteApplication(ctx context.Context, id int64) error
+	DeleteAuditLogsThrough(ctx context.Context, chainSeq int64) error
 	DeleteDataExport(ctx context.Context, arg DeleteDataExportParams) error
+	DeleteExpiredCaptchaRequirements(ctx context.Context) error
 	DeleteExpiredDataExports(ctx context.Context) error
-	DeleteExpiredLockouts(ctx context.Context) error
+	DeleteExpiredIPAccessRules(ctx context.Context) error
+	DeleteExpiredLoginAlerts(ctx context.Context) error
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+	DeleteIPAccessRule(ctx context.Context, id int64) error
 	DeleteOAuthAccount(ctx context.Context, arg DeleteOAuthAccountParams) error
 	DeleteOAuthAccountByProvider(ctx context.Context, arg DeleteOAuthAccountByProviderParams) error
-	DeleteOldAuditLogs(ctx context.Context) error
 	DeleteOldLoginAttempts(ctx context.Context) error
 	DeleteOldPasswordHistory(ctx context.Context, userID int64) error
+	DeletePublishedOutboxEventsBefore(ctx context.Context, before time.Time) error
 	DeleteUnusedPasswordResets(ctx context.Context, userID int64) error
 	DeleteUnverifiedTokens(ctx context.Context, userID int64) error
 	DeleteUserDevice(ctx context.Context, arg DeleteUserDeviceParams) error
+	DeleteWebhookEndpoint(ctx context.Context, id int64) error
 	GetAccountLockoutByIP(ctx context.Context, ipAddress *netip.Addr) (AccountLockout, error)
+	GetAccountLockoutByUnlockTokenHash(ctx context.Context, unlockTokenHash string) (AccountLockout, error)
 	GetAccountLockoutByUserAndIP(ctx context.Context, arg GetAccountLockoutByUserAndIPParams) (AccountLockout, error)
 	GetAccountLockoutByUserID(ctx context.Context, userID int64) (AccountLockout, error)
+	GetAccountLockoutForLogin(ctx context.Context, arg GetAccountLockoutForLoginParams) (AccountLockout, error)
+	GetAccountLockoutsByUserID(ctx context.Context, arg GetAccountLockoutsByUserIDParams) ([]AccountLockout, error)
 	GetActiveRefreshTokensByUser(ctx context.Context, userID int64) ([]RefreshToken, error)
-	GetAuditLogsByAction(ctx context.Context, arg GetAuditLogsByActionParams) ([]AuditLog, error)
-	GetAuditLogsByDateRange(ctx context.Context, arg GetAuditLogsByDateRangeParams) ([]AuditLog, error)
-	GetAuditLogsByIP(ctx context.Context, arg GetAuditLogsByIPParams) ([]AuditLog, error)
-	GetAuditLogsByResourceID(ctx context.Context, arg GetAuditLogsByResourceIDParams) ([]AuditLog, error)
-	GetAuditLogsByResourceType(ctx context.Context, arg GetAuditLogsByResourceTypeParams) ([]AuditLog, error)
+	GetActiveSessionsByUserID(ctx context.Context, userID int64) ([]Session, error)
+	GetApplicationByID(ctx context.Context, id int64) (Application, error)
+	GetAuditLogChainEntries(ctx context.Context, arg GetAuditLogChainEntriesParams) ([]GetAuditLogChainEntriesRow, error)
+	GetAuditLogChainHead(ctx context.Context) (AuditLogChain, error)
+	GetAuditLogCheckpointsAfter(ctx context.Context, chainSeq int64) ([]AuditLogCheckpoint, error)
+	GetAuditLogPruneBoundary(ctx context.Context, before time.Time) (GetAuditLogPruneBoundaryRow, error)
 	GetAuditLogsByUserID(ctx context.Context, arg GetAuditLogsByUserIDParams) ([]AuditLog, error)
+	GetBackchannelLogoutDeliveriesByApplicationID(ctx context.Context, arg GetBackchannelLogoutDeliveriesByApplicationIDParams) ([]BackchannelLogoutDelivery, error)
 	GetDataExportByID(ctx context.Context, arg GetDataExportByIDParams) (DataExport, error)
 	GetDataExportsByUserID(ctx context.Context, userID int64) ([]DataExport, error)
 	GetEmailVerificationByToken(ctx context.Context, tokenHash string) (EmailVerification, error)
 	GetFailedLoginAttemptsByEmail(ctx context.Context, arg GetFailedLoginAttemptsByEmailParams) ([]LoginAttempt, error)
 	GetFailedLoginAttemptsByIP(ctx context.Context, arg GetFailedLoginAttemptsByIPParams) ([]LoginAttempt, error)
 	GetFailedLoginAttemptsByUserID(ctx context.Context, arg GetFailedLoginAttemptsByUserIDParams) ([]LoginAttempt, error)
+	GetFailedLoginVelocityByNetwork(ctx context.Context, arg GetFailedLoginVelocityByNetworkParams) (GetFailedLoginVelocityByNetworkRow, error)
+	GetIPAccessRuleByID(ctx context.Context, id int64) (IpAccessRule, error)
+	GetLastSuccessfulLoginWithLocation(ctx context.Context, userID pgtype.Int8) (LoginAttempt, error)
+	GetLatestAuditLogCheckpoint(ctx context.Context) (AuditLogCheckpoint, error)
+	GetLockoutTimeSeries(ctx context.Context, arg GetLockoutTimeSeriesParams) ([]GetLockoutTimeSeriesRow, error)
+	GetLoginAlertByTokenHash(ctx context.Context, tokenHash string) (LoginAlert, error)
+	GetLoginAttemptTimeSeries(ctx context.Context, arg GetLoginAttemptTimeSeriesParams) ([]GetLoginAttemptTimeSeriesRow, error)
 	GetLoginAttemptsByEmail(ctx context.Context, arg GetLoginAttemptsByEmailParams) ([]LoginAttempt, error)
 	GetLoginAttemptsByIP(ctx context.Context, arg GetLoginAttemptsByIPParams) ([]LoginAttempt, error)
 	GetLoginAttemptsByUserID(ctx context.Context, arg GetLoginAttemptsByUserIDParams) ([]LoginAttempt, error)
+	GetLoginCountryHistory(ctx context.Context, arg GetLoginCountryHistoryParams) (GetLoginCountryHistoryRow, error)
+	GetLoginOutcomesByASN(ctx context.Context, arg GetLoginOutcomesByASNParams) (GetLoginOutcomesByASNRow, error)
+	GetMFAAdoption(ctx context.Context) (GetMFAAdoptionRow, error)
 	GetOAuthAccountByEmail(ctx context.Context, arg GetOAuthAccountByEmailParams) (OauthAccount, error)
 	GetOAuthAccountByID(ctx context.Context, arg GetOAuthAccountByIDParams) (OauthAccount, error)
 	GetOAuthAccountByProvider(ctx context.Context, arg GetOAuthAccountByProviderParams) (OauthAccount, error)
 	GetOAuthAccountsByUserID(ctx context.Context, userID int64) ([]OauthAccount, error)
+	GetPasswordFingerprintSprayers(ctx context.Context, arg GetPasswordFingerprintSprayersParams) ([]GetPasswordFingerprintSprayersRow, error)
+	GetPasswordFingerprintSpread(ctx context.Context, arg GetPasswordFingerprintSpreadParams) (GetPasswordFingerprintSpreadRow, error)
 	GetPasswordHistoryByUserID(ctx context.Context, arg GetPasswordHistoryByUserIDParams) ([]PasswordHistory, error)
 	GetPasswordResetByToken(ctx context.Context, tokenHash string) (PasswordReset, error)
 	GetPendingDataExports(ctx context.Context) ([]DataExport, error)
-	GetRecentAuditLogs(ctx context.Context, limit int32) ([]AuditLog, error)
 	GetRecentFailedAttemptsByEmail(ctx context.Context, email string) ([]LoginAttempt, error)
 	GetRecentFailedAttemptsByIP(ctx context.Context, ipAddress netip.Addr) ([]LoginAttempt, error)
 	GetRecentFailedAttemptsByUserID(ctx context.Context, userID pgtype.Int8) ([]LoginAttempt, error)
 	GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error)
+	GetRegistrationTimeSeries(ctx context.Context, arg GetRegistrationTimeSeriesParams) ([]GetRegistrationTimeSeriesRow, error)
+	GetSessionByAccessTokenHash(ctx context.Context, accessTokenHash string) (Session, error)
+	GetSessionByID(ctx context.Context, id string) (Session, error)
+	GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (Session, error)
 	GetSuspiciousActivitiesByIP(ctx context.Context, arg GetSuspiciousActivitiesByIPParams) ([]SuspiciousActivity, error)
 	GetSuspiciousActivitiesByUserID(ctx context.Context, arg GetSuspiciousActivitiesByUserIDParams) ([]SuspiciousActivity, error)
+	GetSuspiciousActivityByID(ctx context.Context, id int64) (SuspiciousActivity, error)
 	GetSuspiciousActivityCountByIP(ctx context.Context, ipAddress netip.Addr) (int64, error)
 	GetSuspiciousActivityCountByUser(ctx context.Context, userID pgtype.Int8) (int64, error)
-	GetUnresolvedSuspiciousActivities(ctx context.Context, limit int32) ([]SuspiciousActivity, error)
+	GetSuspiciousActivityNotes(ctx context.Context, activityID int64) ([]SuspiciousActivityNote, error)
+	GetSuspiciousActivityTimeSeries(ctx context.Context, arg GetSuspiciousActivityTimeSeriesParams) ([]GetSuspiciousActivityTimeSeriesRow, error)
+	GetTopFailedLoginEmails(ctx context.Context, arg GetTopFailedLoginEmailsParams) ([]GetTopFailedLoginEmailsRow, error)
+	GetTopFailedLoginIPs(ctx context.Context, arg GetTopFailedLoginIPsParams) ([]GetTopFailedLoginIPsRow, error)
 	GetUnusedPasswordResets(ctx context.Context, userID int64) ([]PasswordReset, error)
 	GetUnverifiedVerifications(ctx context.Context, userID int64) ([]EmailVerification, error)
 	GetUser(ctx context.Context, id int64) (User, error)
@@ -88,26 +141,59 @@ type Querier interface {
 	GetUserDevicesByUserID(ctx context.Context, userID int64) ([]UserDevice, error)
 	GetUserProfile(ctx context.Context, userID int64) (UserProfile, error)
 	GetUserWithProfile(ctx context.Context, id int64) (GetUserWithProfileRow, error)
+	GetUsersByIDs(ctx context.Context, ids []int64) ([]User, error)
+	GetWebhookDeliveriesByEndpointID(ctx context.Context, arg GetWebhookDeliveriesByEndpointIDParams) ([]WebhookDelivery, error)
+	GetWebhookDeliveryByID(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetWebhookEndpointByID(ctx context.Context, id int64) (WebhookEndpoint, error)
+	HasActiveCaptchaRequirement(ctx context.Context, arg HasActiveCaptchaRequirementParams) (bool, error)
 	IncrementPasswordResetCounter(ctx context.Context, id int64) error
+	ListActiveIPAccessRules(ctx context.Context) ([]IpAccessRule, error)
+	ListApplications(ctx context.Context) ([]Application, error)
+	ListBackchannelLogoutApplications(ctx context.Context) ([]Application, error)
+	ListIPAccessRules(ctx context.Context) ([]IpAccessRule, error)
+	ListSuspiciousActivities(ctx context.Context, arg ListSuspiciousActivitiesParams) ([]SuspiciousActivity, error)
+	ListWebhookEndpoints(ctx context.Context) ([]WebhookEndpoint, error)
+	ListWebhookEndpointsForEvent(ctx context.Context, eventType string) ([]WebhookEndpoint, error)
 	MarkDeviceAsTrusted(ctx context.Context, arg MarkDeviceAsTrustedParams) (UserDevice, error)
 	MarkEmailVerificationAsUsed(ctx context.Context, id int64) error
 	MarkEmailVerified(ctx context.Context, id int64) error
+	MarkLoginAlertReported(ctx context.Context, id int64) (LoginAlert, error)
+	MarkOutboxEventsPublished(ctx context.Context, ids []int64) error
 	MarkPasswordResetAsUsed(ctx context.Context, id int64) error
-	ResolveSuspiciousActivity(ctx context.Context, id int64) error
+	NextOutboxAggregateSeq(ctx context.Context, arg NextOutboxAggregateSeqParams) (int64, error)
+	RecordAuditLogChainPrune(ctx context.Context, arg RecordAuditLogChainPruneParams) error
+	RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error
+	ReleaseLoginAlertReport(ctx context.Context, id int64) error
+	ReleaseOutboxEvents(ctx context.Context, ids []int64) error
+	RequeueWebhookDelivery(ctx context.Context, arg RequeueWebhookDeliveryParams) (WebhookDelivery, error)
 	RevokeAllUserRefreshTokens(ctx context.Context, userID int64) error
 	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+	RevokeSession(ctx context.Context, id string) error
+	SearchAuditLogs(ctx context.Context, arg SearchAuditLogsParams) ([]AuditLog, error)
+	TryLockOutboxRelay(ctx context.Context, lockID int64) (bool, error)
+	UnlockAccountLockouts(ctx context.Context, arg UnlockAccountLockoutsParams) ([]AccountLockout, error)
+	UpdateApplication(ctx context.Context, arg UpdateApplicationParams) (Application, error)
+	UpdateBackchannelLogoutDelivery(ctx context.Context, arg UpdateBackchannelLogoutDeliveryParams) (BackchannelLogoutDelivery, error)
 	UpdateDataExportFile(ctx context.Context, arg UpdateDataExportFileParams) (DataExport, error)
 	UpdateDataExportStatus(ctx context.Context, arg UpdateDataExportStatusParams) (DataExport, error)
 	UpdateLastLogin(ctx context.Context, id int64) error
 	UpdateOAuthAccount(ctx context.Context, arg UpdateOAuthAccountParams) (OauthAccount, error)
 	UpdateOAuthTokens(ctx context.Context, arg UpdateOAuthTokensParams) (OauthAccount, error)
 	UpdateRefreshTokenLastUsed(ctx context.Context, tokenHash string) error
+	UpdateSessionActivity(ctx context.Context, arg UpdateSessionActivityParams) error
+	UpdateSessionTokens(ctx context.Context, arg UpdateSessionTokensParams) error
+	UpdateSuspiciousActivityStatus(ctx context.Context, arg UpdateSuspiciousActivityStatusParams) (SuspiciousActivity, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) error
 	UpdateUserDevice(ctx context.Context, arg UpdateUserDeviceParams) (UserDevice, error)
 	UpdateUserDeviceLastUsed(ctx context.Context, arg UpdateUserDeviceLastUsedParams) (UserDevice, error)
+	UpdateUserDeviceLocation(ctx context.Context, arg UpdateUserDeviceLocationParams) (UserDevice, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
 	UpdateUserPrivacySettings(ctx context.Context, arg UpdateUserPrivacySettingsParams) error
 	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (WebhookDelivery, error)
+	UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointParams) (WebhookEndpoint, error)
+	UpdateWebhookEndpointSecret(ctx context.Context, arg UpdateWebhookEndpointSecretParams) (WebhookEndpoint, error)
+	UpsertCaptchaRequirement(ctx context.Context, arg UpsertCaptchaRequirementParams) (CaptchaRequirement, error)
 	VerifyUserEmail(ctx context.Context, id int64) error
 }
 
@@ -1,9 +1,16 @@
 package db
 
-import "github.com/jackc/pgx/v5/pgxpool"
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
 
 type Store interface {
 	Querier
+	// ExecTx runs fn in a transaction and commits it when fn returns nil.
+	ExecTx(ctx context.Context, fn func(Querier) error) error
 }
 
 type SQLStore struct {
@@ -17,3 +24,19 @@ func NewStore(connPool *pgxpool.Pool) *SQLStore {
 		Queries:  New(connPool),
 	}
 }
+
+func (store *SQLStore) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := store.connPool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(store.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rollback err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
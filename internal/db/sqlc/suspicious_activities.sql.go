@@ -8,10 +8,45 @@ package db
 import (
 	"context"
 	"net/netip"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const assignSuspiciousActivity = `-- name: AssignSuspiciousActivity :one
+UPDATE suspicious_activities
+SET assigned_to = $1
+WHERE id = $2
+RETURNING id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, created_at, status, assigned_to, resolved_by, resolved_at, updated_at
+`
+
+type AssignSuspiciousActivityParams struct {
+	AssignedTo pgtype.Int8 `json:"assigned_to"`
+	ID         int64       `json:"id"`
+}
+
+func (q *Queries) AssignSuspiciousActivity(ctx context.Context, arg AssignSuspiciousActivityParams) (SuspiciousActivity, error) {
+	row := q.db.QueryRow(ctx, assignSuspiciousActivity, arg.AssignedTo, arg.ID)
+	var i SuspiciousActivity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ActivityType,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Description,
+		&i.Metadata,
+		&i.Severity,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AssignedTo,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const createSuspiciousActivity = `-- name: CreateSuspiciousActivity :one
 INSERT INTO suspicious_activities (
     user_id,
@@ -23,7 +58,7 @@ INSERT INTO suspicious_activities (
     severity
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, resolved, created_at
+) RETURNING id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, created_at, status, assigned_to, resolved_by, resolved_at, updated_at
 `
 
 type CreateSuspiciousActivityParams struct {
@@ -56,14 +91,47 @@ func (q *Queries) CreateSuspiciousActivity(ctx context.Context, arg CreateSuspic
 		&i.Description,
 		&i.Metadata,
 		&i.Severity,
-		&i.Resolved,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AssignedTo,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createSuspiciousActivityNote = `-- name: CreateSuspiciousActivityNote :one
+INSERT INTO suspicious_activity_notes (
+    activity_id,
+    author_id,
+    note
+) VALUES (
+    $1, $2, $3
+) RETURNING id, activity_id, author_id, note, created_at
+`
+
+type CreateSuspiciousActivityNoteParams struct {
+	ActivityID int64       `json:"activity_id"`
+	AuthorID   pgtype.Int8 `json:"author_id"`
+	Note       string      `json:"note"`
+}
+
+func (q *Queries) CreateSuspiciousActivityNote(ctx context.Context, arg CreateSuspiciousActivityNoteParams) (SuspiciousActivityNote, error) {
+	row := q.db.QueryRow(ctx, createSuspiciousActivityNote, arg.ActivityID, arg.AuthorID, arg.Note)
+	var i SuspiciousActivityNote
+	err := row.Scan(
+		&i.ID,
+		&i.ActivityID,
+		&i.AuthorID,
+		&i.Note,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getSuspiciousActivitiesByIP = `-- name: GetSuspiciousActivitiesByIP :many
-SELECT id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, resolved, created_at FROM suspicious_activities
+SELECT id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, created_at, status, assigned_to, resolved_by, resolved_at, updated_at FROM suspicious_activities
 WHERE ip_address = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -92,8 +160,12 @@ func (q *Queries) GetSuspiciousActivitiesByIP(ctx context.Context, arg GetSuspic
 			&i.Description,
 			&i.Metadata,
 			&i.Severity,
-			&i.Resolved,
 			&i.CreatedAt,
+			&i.Status,
+			&i.AssignedTo,
+			&i.ResolvedBy,
+			&i.ResolvedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -106,7 +178,7 @@ func (q *Queries) GetSuspiciousActivitiesByIP(ctx context.Context, arg GetSuspic
 }
 
 const getSuspiciousActivitiesByUserID = `-- name: GetSuspiciousActivitiesByUserID :many
-SELECT id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, resolved, created_at FROM suspicious_activities
+SELECT id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, created_at, status, assigned_to, resolved_by, resolved_at, updated_at FROM suspicious_activities
 WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -135,8 +207,12 @@ func (q *Queries) GetSuspiciousActivitiesByUserID(ctx context.Context, arg GetSu
 			&i.Description,
 			&i.Metadata,
 			&i.Severity,
-			&i.Resolved,
 			&i.CreatedAt,
+			&i.Status,
+			&i.AssignedTo,
+			&i.ResolvedBy,
+			&i.ResolvedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -148,6 +224,33 @@ func (q *Queries) GetSuspiciousActivitiesByUserID(ctx context.Context, arg GetSu
 	return items, nil
 }
 
+const getSuspiciousActivityByID = `-- name: GetSuspiciousActivityByID :one
+SELECT id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, created_at, status, assigned_to, resolved_by, resolved_at, updated_at FROM suspicious_activities
+WHERE id = $1
+`
+
+func (q *Queries) GetSuspiciousActivityByID(ctx context.Context, id int64) (SuspiciousActivity, error) {
+	row := q.db.QueryRow(ctx, getSuspiciousActivityByID, id)
+	var i SuspiciousActivity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ActivityType,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Description,
+		&i.Metadata,
+		&i.Severity,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AssignedTo,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getSuspiciousActivityCountByIP = `-- name: GetSuspiciousActivityCountByIP :one
 SELECT COUNT(*) FROM suspicious_activities
 WHERE ip_address = $1
@@ -174,15 +277,54 @@ func (q *Queries) GetSuspiciousActivityCountByUser(ctx context.Context, userID p
 	return count, err
 }
 
-const getUnresolvedSuspiciousActivities = `-- name: GetUnresolvedSuspiciousActivities :many
-SELECT id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, resolved, created_at FROM suspicious_activities
-WHERE resolved = false
+const getSuspiciousActivityNotes = `-- name: GetSuspiciousActivityNotes :many
+SELECT id, activity_id, author_id, note, created_at FROM suspicious_activity_notes
+WHERE activity_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) GetSuspiciousActivityNotes(ctx context.Context, activityID int64) ([]SuspiciousActivityNote, error) {
+	rows, err := q.db.Query(ctx, getSuspiciousActivityNotes, activityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SuspiciousActivityNote{}
+	for rows.Next() {
+		var i SuspiciousActivityNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.AuthorID,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSuspiciousActivities = `-- name: ListSuspiciousActivities :many
+SELECT id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, created_at, status, assigned_to, resolved_by, resolved_at, updated_at FROM suspicious_activities
+WHERE status = ANY($1::text[])
+AND ($2::bigint IS NULL OR assigned_to = $2)
 ORDER BY created_at DESC
-LIMIT $1
+LIMIT $3
 `
 
-func (q *Queries) GetUnresolvedSuspiciousActivities(ctx context.Context, limit int32) ([]SuspiciousActivity, error) {
-	rows, err := q.db.Query(ctx, getUnresolvedSuspiciousActivities, limit)
+type ListSuspiciousActivitiesParams struct {
+	Statuses   []string    `json:"statuses"`
+	AssignedTo pgtype.Int8 `json:"assigned_to"`
+	RowLimit   int32       `json:"row_limit"`
+}
+
+func (q *Queries) ListSuspiciousActivities(ctx context.Context, arg ListSuspiciousActivitiesParams) ([]SuspiciousActivity, error) {
+	rows, err := q.db.Query(ctx, listSuspiciousActivities, arg.Statuses, arg.AssignedTo, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -199,8 +341,12 @@ func (q *Queries) GetUnresolvedSuspiciousActivities(ctx context.Context, limit i
 			&i.Description,
 			&i.Metadata,
 			&i.Severity,
-			&i.Resolved,
 			&i.CreatedAt,
+			&i.Status,
+			&i.AssignedTo,
+			&i.ResolvedBy,
+			&i.ResolvedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -212,13 +358,48 @@ func (q *Queries) GetUnresolvedSuspiciousActivities(ctx context.Context, limit i
 	return items, nil
 }
 
-const resolveSuspiciousActivity = `-- name: ResolveSuspiciousActivity :exec
+const updateSuspiciousActivityStatus = `-- name: UpdateSuspiciousActivityStatus :one
 UPDATE suspicious_activities
-SET resolved = true
-WHERE id = $1
+SET status = $1,
+    resolved_by = $2,
+    resolved_at = $3
+WHERE id = $4
+AND status = $5
+RETURNING id, user_id, activity_type, ip_address, user_agent, description, metadata, severity, created_at, status, assigned_to, resolved_by, resolved_at, updated_at
 `
 
-func (q *Queries) ResolveSuspiciousActivity(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, resolveSuspiciousActivity, id)
-	return err
+type UpdateSuspiciousActivityStatusParams struct {
+	NewStatus     string      `json:"new_status"`
+	ResolvedBy    pgtype.Int8 `json:"resolved_by"`
+	ResolvedAt    *time.Time  `json:"resolved_at"`
+	ID            int64       `json:"id"`
+	CurrentStatus string      `json:"current_status"`
+}
+
+func (q *Queries) UpdateSuspiciousActivityStatus(ctx context.Context, arg UpdateSuspiciousActivityStatusParams) (SuspiciousActivity, error) {
+	row := q.db.QueryRow(ctx, updateSuspiciousActivityStatus,
+		arg.NewStatus,
+		arg.ResolvedBy,
+		arg.ResolvedAt,
+		arg.ID,
+		arg.CurrentStatus,
+	)
+	var i SuspiciousActivity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ActivityType,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Description,
+		&i.Metadata,
+		&i.Severity,
+		&i.CreatedAt,
+		&i.Status,
+		&i.AssignedTo,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
@@ -21,20 +21,26 @@ INSERT INTO user_devices (
     device_type,
     user_agent,
     ip_address,
-    trusted
+    trusted,
+    country_code,
+    city,
+    asn
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at, country_code, city, asn
 `
 
 type CreateUserDeviceParams struct {
-	UserID     int64       `json:"user_id"`
-	DeviceID   string      `json:"device_id"`
-	DeviceName pgtype.Text `json:"device_name"`
-	DeviceType pgtype.Text `json:"device_type"`
-	UserAgent  *string     `json:"user_agent"`
-	IpAddress  *netip.Addr `json:"ip_address"`
-	Trusted    pgtype.Bool `json:"trusted"`
+	UserID      int64       `json:"user_id"`
+	DeviceID    string      `json:"device_id"`
+	DeviceName  pgtype.Text `json:"device_name"`
+	DeviceType  pgtype.Text `json:"device_type"`
+	UserAgent   *string     `json:"user_agent"`
+	IpAddress   *netip.Addr `json:"ip_address"`
+	Trusted     pgtype.Bool `json:"trusted"`
+	CountryCode pgtype.Text `json:"country_code"`
+	City        pgtype.Text `json:"city"`
+	Asn         pgtype.Int8 `json:"asn"`
 }
 
 func (q *Queries) CreateUserDevice(ctx context.Context, arg CreateUserDeviceParams) (UserDevice, error) {
@@ -46,6 +52,9 @@ func (q *Queries) CreateUserDevice(ctx context.Context, arg CreateUserDevicePara
 		arg.UserAgent,
 		arg.IpAddress,
 		arg.Trusted,
+		arg.CountryCode,
+		arg.City,
+		arg.Asn,
 	)
 	var i UserDevice
 	err := row.Scan(
@@ -59,6 +68,9 @@ func (q *Queries) CreateUserDevice(ctx context.Context, arg CreateUserDevicePara
 		&i.Trusted,
 		&i.LastUsedAt,
 		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
 	)
 	return i, err
 }
@@ -89,7 +101,7 @@ func (q *Queries) DeleteUserDevice(ctx context.Context, arg DeleteUserDevicePara
 }
 
 const getUserDeviceByDeviceID = `-- name: GetUserDeviceByDeviceID :one
-SELECT id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at FROM user_devices
+SELECT id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at, country_code, city, asn FROM user_devices
 WHERE user_id = $1 AND device_id = $2
 `
 
@@ -112,12 +124,15 @@ func (q *Queries) GetUserDeviceByDeviceID(ctx context.Context, arg GetUserDevice
 		&i.Trusted,
 		&i.LastUsedAt,
 		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
 	)
 	return i, err
 }
 
 const getUserDeviceByID = `-- name: GetUserDeviceByID :one
-SELECT id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at FROM user_devices
+SELECT id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at, country_code, city, asn FROM user_devices
 WHERE id = $1 AND user_id = $2
 `
 
@@ -140,12 +155,15 @@ func (q *Queries) GetUserDeviceByID(ctx context.Context, arg GetUserDeviceByIDPa
 		&i.Trusted,
 		&i.LastUsedAt,
 		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
 	)
 	return i, err
 }
 
 const getUserDevicesByUserID = `-- name: GetUserDevicesByUserID :many
-SELECT id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at FROM user_devices
+SELECT id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at, country_code, city, asn FROM user_devices
 WHERE user_id = $1
 ORDER BY last_used_at DESC
 `
@@ -170,6 +188,9 @@ func (q *Queries) GetUserDevicesByUserID(ctx context.Context, userID int64) ([]U
 			&i.Trusted,
 			&i.LastUsedAt,
 			&i.CreatedAt,
+			&i.CountryCode,
+			&i.City,
+			&i.Asn,
 		); err != nil {
 			return nil, err
 		}
@@ -185,7 +206,7 @@ const markDeviceAsTrusted = `-- name: MarkDeviceAsTrusted :one
 UPDATE user_devices
 SET trusted = $3
 WHERE id = $1 AND user_id = $2
-RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at
+RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at, country_code, city, asn
 `
 
 type MarkDeviceAsTrustedParams struct {
@@ -208,6 +229,9 @@ func (q *Queries) MarkDeviceAsTrusted(ctx context.Context, arg MarkDeviceAsTrust
 		&i.Trusted,
 		&i.LastUsedAt,
 		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
 	)
 	return i, err
 }
@@ -219,7 +243,7 @@ SET device_name = $3,
     user_agent = $5,
     trusted = $6
 WHERE id = $1 AND user_id = $2
-RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at
+RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at, country_code, city, asn
 `
 
 type UpdateUserDeviceParams struct {
@@ -252,6 +276,9 @@ func (q *Queries) UpdateUserDevice(ctx context.Context, arg UpdateUserDevicePara
 		&i.Trusted,
 		&i.LastUsedAt,
 		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
 	)
 	return i, err
 }
@@ -260,7 +287,7 @@ const updateUserDeviceLastUsed = `-- name: UpdateUserDeviceLastUsed :one
 UPDATE user_devices
 SET last_used_at = $2
 WHERE id = $1
-RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at
+RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at, country_code, city, asn
 `
 
 type UpdateUserDeviceLastUsedParams struct {
@@ -282,6 +309,55 @@ func (q *Queries) UpdateUserDeviceLastUsed(ctx context.Context, arg UpdateUserDe
 		&i.Trusted,
 		&i.LastUsedAt,
 		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
+	)
+	return i, err
+}
+
+const updateUserDeviceLocation = `-- name: UpdateUserDeviceLocation :one
+UPDATE user_devices
+SET ip_address = $2,
+    country_code = $3,
+    city = $4,
+    asn = $5,
+    last_used_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, device_id, device_name, device_type, user_agent, ip_address, trusted, last_used_at, created_at, country_code, city, asn
+`
+
+type UpdateUserDeviceLocationParams struct {
+	ID          int64       `json:"id"`
+	IpAddress   *netip.Addr `json:"ip_address"`
+	CountryCode pgtype.Text `json:"country_code"`
+	City        pgtype.Text `json:"city"`
+	Asn         pgtype.Int8 `json:"asn"`
+}
+
+func (q *Queries) UpdateUserDeviceLocation(ctx context.Context, arg UpdateUserDeviceLocationParams) (UserDevice, error) {
+	row := q.db.QueryRow(ctx, updateUserDeviceLocation,
+		arg.ID,
+		arg.IpAddress,
+		arg.CountryCode,
+		arg.City,
+		arg.Asn,
+	)
+	var i UserDevice
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.DeviceID,
+		&i.DeviceName,
+		&i.DeviceType,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.Trusted,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.CountryCode,
+		&i.City,
+		&i.Asn,
 	)
 	return i, err
 }
@@ -207,6 +207,45 @@ func (q *Queries) GetUserWithProfile(ctx context.Context, id int64) (GetUserWith
 	return i, err
 }
 
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id, email, username, password_hash, email_verified, active, role, privacy_settings, last_login_at, password_changed_at, created_at, updated_at FROM users
+WHERE id = ANY($1::bigint[])
+ORDER BY id
+`
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []int64) ([]User, error) {
+	rows, err := q.db.Query(ctx, getUsersByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Username,
+			&i.PasswordHash,
+			&i.EmailVerified,
+			&i.Active,
+			&i.Role,
+			&i.PrivacySettings,
+			&i.LastLoginAt,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markEmailVerified = `-- name: MarkEmailVerified :exec
 UPDATE users
 SET email_verified = TRUE, updated_at = NOW()
@@ -3,25 +3,66 @@ package domain
 import "time"
 
 type AccountLockout struct {
-	ID          int64  `json:"id"`
-	UserID      int64  `json:"user_id"`
-	IPAddress   string `json:"-"`
-	LockoutType string `json:"lockout_type"`
-	ExpiresAt   time.Time
-	CreatedAt   *time.Time
+	ID           int64  `json:"id"`
+	UserID       int64  `json:"user_id"`
+	IPAddress    string `json:"-"`
+	LockoutType  string `json:"lockout_type"`
+	ExpiresAt    time.Time
+	CreatedAt    *time.Time
+	Level        int32      `json:"level"`
+	Permanent    bool       `json:"permanent"`
+	Reason       string     `json:"reason"`
+	UnlockedAt   *time.Time `json:"unlocked_at"`
+	UnlockedBy   *int64     `json:"unlocked_by"`
+	UnlockMethod string     `json:"unlock_method"`
 }
 
+// LockoutType says which logins a lockout turns away. Addresses are blocked
+// for every account with IPAccessRule.
 type LockoutType string
 
 const (
+	// LockoutTypeAccount locks the account for every address
 	LockoutTypeAccount LockoutType = "account"
-	LockoutTypeIP      LockoutType = "ip"
-	LockoutTypeBoth    LockoutType = "both"
+	// LockoutTypeIP locks the account only for IPAddress, the one address
+	// its failures came from, so the owner can still sign in elsewhere
+	LockoutTypeIP LockoutType = "ip"
+	// LockoutTypeBoth locks the account for every address because of what
+	// was done from IPAddress, such as a sign-in the owner reported
+	LockoutTypeBoth LockoutType = "both"
+)
+
+// How a lockout ended. Expired lockouts are closed lazily, the next time the
+// account fails a login.
+const (
+	UnlockMethodExpired = "expired"
+	UnlockMethodAdmin   = "admin"
+	UnlockMethodEmail   = "email"
 )
 
 type CreateAccountLockoutAction struct {
-	UserID      int64
-	IPAddress   string
-	LockoutType LockoutType
-	ExpiresAt   string
+	UserID          int64
+	IPAddress       string
+	LockoutType     LockoutType
+	ExpiresAt       string
+	Level           int32
+	Permanent       bool
+	Reason          string
+	UnlockTokenHash string
+}
+
+type UnlockAccountAction struct {
+	UserID       int64
+	UnlockedBy   *int64
+	UnlockMethod string
+}
+
+// FailedLoginResult tells the caller how to respond to a failed login.
+type FailedLoginResult struct {
+	// FailedAttempts counts consecutive failures for the email, this one included.
+	FailedAttempts int64
+	// Delay is how long the next attempt for the email has to wait.
+	Delay time.Duration
+	// Lockout is set when this failure locked the account.
+	Lockout *AccountLockout
 }
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Application is a relying application that accepts whoami tokens and wants
+// to hear about logouts so it can end its own sessions.
+type Application struct {
+	ID                   int64     `json:"id"`
+	Name                 string    `json:"name"`
+	ClientID             string    `json:"client_id"`
+	LogoutSecret         string    `json:"-"`
+	BackchannelLogoutURL string    `json:"backchannel_logout_url"`
+	Active               bool      `json:"active"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+type CreateApplicationAction struct {
+	Name                 string
+	ClientID             string
+	LogoutSecret         string
+	BackchannelLogoutURL string
+}
+
+type UpdateApplicationAction struct {
+	ID                   int64
+	Name                 string
+	BackchannelLogoutURL string
+	Active               bool
+}
+
+type BackchannelLogoutDelivery struct {
+	ID             int64      `json:"id"`
+	ApplicationID  int64      `json:"application_id"`
+	UserID         int64      `json:"user_id"`
+	SessionID      string     `json:"session_id"`
+	JTI            uuid.UUID  `json:"jti"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	LastStatusCode int32      `json:"last_status_code"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type CreateBackchannelLogoutDeliveryAction struct {
+	ApplicationID int64
+	UserID        int64
+	SessionID     string
+	JTI           uuid.UUID
+	NextAttemptAt time.Time
+}
+
+type UpdateBackchannelLogoutDeliveryAction struct {
+	ID             int64
+	Status         string
+	Attempts       int32
+	LastStatusCode int32
+	LastError      string
+	NextAttemptAt  time.Time
+	DeliveredAt    *time.Time
+}
+
+const (
+	BackchannelLogoutStatusPending   = "pending"
+	BackchannelLogoutStatusDelivered = "delivered"
+	BackchannelLogoutStatusFailed    = "failed"
+)
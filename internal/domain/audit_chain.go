@@ -0,0 +1,88 @@
+package domain
+
+import "time"
+
+// Problems an audit chain verification reports.
+const (
+	// AuditChainIssueGap is a run of entries missing from the middle of the
+	// chain.
+	AuditChainIssueGap = "gap"
+	// AuditChainIssueTruncated is a run of entries missing from its end.
+	AuditChainIssueTruncated = "truncated"
+	// AuditChainIssueModified is an entry whose content no longer matches
+	// its hash.
+	AuditChainIssueModified = "modified"
+	// AuditChainIssueBrokenLink is an entry that does not follow the one
+	// before it, which was rehashed or put in its place.
+	AuditChainIssueBrokenLink = "broken_link"
+	// AuditChainIssueCheckpointMismatch is a checkpoint the chain no longer
+	// agrees with.
+	AuditChainIssueCheckpointMismatch = "checkpoint_mismatch"
+	// AuditChainIssueInvalidSignature is a checkpoint not signed by the
+	// trusted checkpoint key.
+	AuditChainIssueInvalidSignature = "invalid_signature"
+)
+
+// AuditChainEntry is an audit log entry as the hash chain covers it. Every
+// entry hashes its fields together with the previous entry's hash, in
+// ChainSeq order starting from an all zero hash.
+type AuditChainEntry struct {
+	ChainSeq     int64
+	ID           int64
+	PrevHash     []byte
+	EntryHash    []byte
+	UserID       *int64
+	Action       string
+	ResourceType *string
+	ResourceID   *int64
+	// IPAddress and Details are in their Postgres text form
+	IPAddress string
+	UserAgent *string
+	Details   string
+	CreatedAt *time.Time
+}
+
+// AuditChainHead is the end of the chain, and where retention last cut its
+// start off.
+type AuditChainHead struct {
+	LastSeq    int64
+	LastHash   []byte
+	PrunedSeq  int64
+	PrunedHash []byte
+}
+
+// AuditCheckpoint is a signed statement that the chain entry ChainSeq had
+// EntryHash. Hashes, keys and signatures are hex encoded.
+type AuditCheckpoint struct {
+	ID        int64     `json:"id"`
+	ChainSeq  int64     `json:"chain_seq"`
+	EntryHash string    `json:"entry_hash"`
+	PublicKey string    `json:"public_key"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditChainIssue struct {
+	Kind     string `json:"kind"`
+	ChainSeq int64  `json:"chain_seq"`
+	// ThroughSeq ends the run of entries a gap or truncation covers
+	ThroughSeq   int64  `json:"through_seq,omitempty"`
+	AuditLogID   *int64 `json:"audit_log_id,omitempty"`
+	CheckpointID *int64 `json:"checkpoint_id,omitempty"`
+	Detail       string `json:"detail"`
+}
+
+// AuditChainReport is the outcome of walking the chain from the first entry
+// retention kept to the end it had when the walk started.
+type AuditChainReport struct {
+	Valid              bool              `json:"valid"`
+	FromSeq            int64             `json:"from_seq"`
+	ThroughSeq         int64             `json:"through_seq"`
+	EntriesChecked     int64             `json:"entries_checked"`
+	CheckpointsChecked int               `json:"checkpoints_checked"`
+	LatestCheckpoint   *AuditCheckpoint  `json:"latest_checkpoint"`
+	Issues             []AuditChainIssue `json:"issues"`
+	// IssuesTruncated is set when there were more issues than are listed
+	IssuesTruncated bool      `json:"issues_truncated"`
+	VerifiedAt      time.Time `json:"verified_at"`
+}
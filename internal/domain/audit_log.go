@@ -2,6 +2,7 @@ package domain
 
 import (
 	"encoding/json"
+	"net/netip"
 	"time"
 )
 
@@ -15,6 +16,36 @@ type AuditLog struct {
 	UserAgent    *string         `json:"user_agent"`
 	Details      json.RawMessage `json:"details"`
 	CreatedAt    *time.Time      `json:"created_at"`
+	// ChainSeq and EntryHash place the entry in the audit chain, see
+	// AuditChainEntry.
+	ChainSeq  int64  `json:"chain_seq"`
+	EntryHash string `json:"entry_hash"`
+}
+
+// AuditLogFilter narrows an audit log search, unset fields match every entry.
+type AuditLogFilter struct {
+	UserID       *int64
+	Actions      []string
+	ResourceType *string
+	ResourceID   *int64
+	// Network matches entries from any address in it, a single address is
+	// a /32 or /128.
+	Network *netip.Prefix
+	// From is inclusive, To exclusive
+	From *time.Time
+	To   *time.Time
+	// Details matches entries whose details contain this JSON object.
+	Details json.RawMessage
+	// Cursor continues from the next_cursor of a previous page.
+	Cursor string
+	Limit  int32
+}
+
+// AuditLogPage is a page of search results, newest first. NextCursor is nil
+// on the last page.
+type AuditLogPage struct {
+	AuditLogs  []AuditLog `json:"audit_logs"`
+	NextCursor *string    `json:"next_cursor"`
 }
 
 type CreateAuditLogAction struct {
@@ -32,6 +63,7 @@ const (
 	AuditActionUserLogin          = "user_login"
 	AuditActionUserLogout         = "user_logout"
 	AuditActionUserRegister       = "user_register"
+	AuditActionUserMigrate        = "user_migrate"
 	AuditActionUserUpdate         = "user_update"
 	AuditActionUserDeactivate     = "user_deactivate"
 	AuditActionUserActivate       = "user_activate"
@@ -43,19 +75,41 @@ const (
 	AuditActionSessionRevoke      = "session_revoke"
 	AuditActionSessionRevokeAll   = "session_revoke_all"
 	AuditActionAccountLockout     = "account_lockout"
+	AuditActionAccountUnlock      = "account_unlock"
+	AuditActionSignInReported     = "sign_in_reported"
 	AuditActionSuspiciousActivity = "suspicious_activity"
 	AuditActionDataExport         = "data_export"
 	AuditActionPrivacySettings    = "privacy_settings"
+	AuditActionApplicationCreate  = "application_create"
+	AuditActionApplicationUpdate  = "application_update"
+	AuditActionApplicationDelete  = "application_delete"
+	AuditActionIPLockout          = "ip_lockout"
+	AuditActionCredentialStuffing = "credential_stuffing"
+	AuditActionIPRuleCreate       = "ip_rule_create"
+	AuditActionIPRuleDelete       = "ip_rule_delete"
+	AuditActionWebhookCreate      = "webhook_create"
+	AuditActionWebhookUpdate      = "webhook_update"
+	AuditActionWebhookDelete      = "webhook_delete"
+	AuditActionWebhookRotate      = "webhook_rotate_secret"
+	AuditActionWebhookRedeliver   = "webhook_redeliver"
+	AuditActionAuditVerify        = "audit_verify"
+	AuditActionAuditCheckpoint    = "audit_checkpoint"
+	AuditActionAuditCleanup       = "audit_cleanup"
 )
 
 // Common resource types
 const (
-	AuditResourceTypeUser     = "user"
-	AuditResourceTypeSession  = "session"
-	AuditResourceTypePassword = "password"
-	AuditResourceTypeEmail    = "email"
-	AuditResourceTypeAccount  = "account"
-	AuditResourceTypeData     = "data"
-	AuditResourceTypePrivacy  = "privacy"
-	AuditResourceTypeDevice   = "device"
+	AuditResourceTypeUser        = "user"
+	AuditResourceTypeSession     = "session"
+	AuditResourceTypePassword    = "password"
+	AuditResourceTypeEmail       = "email"
+	AuditResourceTypeAccount     = "account"
+	AuditResourceTypeData        = "data"
+	AuditResourceTypePrivacy     = "privacy"
+	AuditResourceTypeDevice      = "device"
+	AuditResourceTypeApplication = "application"
+	AuditResourceTypeIPRule      = "ip_rule"
+	AuditResourceTypeWebhook     = "webhook"
+	AuditResourceTypeActivity    = "suspicious_activity"
+	AuditResourceTypeAuditLog    = "audit_log"
 )
@@ -0,0 +1,77 @@
+package domain
+
+import "time"
+
+// Scopes a CAPTCHA requirement can apply to. Values are the address, the ASN
+// number or the password fingerprint.
+const (
+	CaptchaScopeIP       = "ip"
+	CaptchaScopeASN      = "asn"
+	CaptchaScopePassword = "password"
+)
+
+// Credential stuffing detectors, also used as the activity type of the
+// suspicious activity each one records.
+const (
+	// CredentialStuffingIP fires when one address fails logins for many
+	// distinct emails.
+	CredentialStuffingIP = "credential_stuffing_ip"
+	// CredentialStuffingASN fires when most logins from one network
+	// operator fail.
+	CredentialStuffingASN = "credential_stuffing_asn"
+	// CredentialStuffingPassword fires when one password is tried against
+	// many distinct emails.
+	CredentialStuffingPassword = "password_spray"
+)
+
+// CaptchaRequirement forces a challenge on every login matching its scope
+// until it expires, on top of the failure counts CaptchaPolicy looks at.
+type CaptchaRequirement struct {
+	ID        int64     `json:"id"`
+	Scope     string    `json:"scope"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateCaptchaRequirementAction struct {
+	Scope     string
+	Value     string
+	Reason    string
+	ExpiresAt time.Time
+}
+
+// CaptchaSubject is what a login is matched against CAPTCHA requirements by.
+// Empty fields match nothing.
+type CaptchaSubject struct {
+	IPAddress           string
+	ASN                 string
+	PasswordFingerprint string
+}
+
+// LoginOutcomes counts recent logins from a network operator.
+type LoginOutcomes struct {
+	Attempts       int64
+	FailedAttempts int64
+}
+
+// PasswordSpread counts the accounts and addresses a password was recently
+// tried from in failed logins.
+type PasswordSpread struct {
+	DistinctEmails int64
+	DistinctIPs    int64
+}
+
+// CredentialStuffingDetection is one detector firing on a failed login, with
+// what was done about it.
+type CredentialStuffingDetection struct {
+	Detector string
+	Reason   string
+	// Lockouts are the addresses the detection locked out, if any.
+	Lockouts []IPAccessRule
+	// Captcha is the requirement the detection placed.
+	Captcha *CaptchaRequirement
+	// Activity is the critical suspicious activity recorded for it.
+	Activity *SuspiciousActivity
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "math"
+
+// GeoLocation is the approximate location of an address. Coordinates are
+// only meaningful when HasCoordinates is set, and ASN is 0 when unknown.
+type GeoLocation struct {
+	CountryCode    string  `json:"country_code,omitempty"`
+	Country        string  `json:"country,omitempty"`
+	City           string  `json:"city,omitempty"`
+	Latitude       float64 `json:"latitude,omitempty"`
+	Longitude      float64 `json:"longitude,omitempty"`
+	HasCoordinates bool    `json:"-"`
+	ASN            int64   `json:"asn,omitempty"`
+	ASOrganization string  `json:"as_organization,omitempty"`
+}
+
+// String describes the location for people, e.g. "Skopje, North Macedonia".
+func (l *GeoLocation) String() string {
+	if l == nil {
+		return ""
+	}
+
+	country := l.Country
+	if country == "" {
+		country = l.CountryCode
+	}
+	switch {
+	case l.City != "" && country != "":
+		return l.City + ", " + country
+	case country != "":
+		return country
+	default:
+		return l.City
+	}
+}
+
+// DistanceKm is the great-circle distance between two located points.
+func (l *GeoLocation) DistanceKm(other *GeoLocation) float64 {
+	const earthRadiusKm = 6371.0
+
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRadians(other.Latitude - l.Latitude)
+	dLon := toRadians(other.Longitude - l.Longitude)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(l.Latitude))*math.Cos(toRadians(other.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
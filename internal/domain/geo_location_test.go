@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeoLocationDistanceKm(t *testing.T) {
+	london := &GeoLocation{Latitude: 51.5074, Longitude: -0.1278, HasCoordinates: true}
+	paris := &GeoLocation{Latitude: 48.8566, Longitude: 2.3522, HasCoordinates: true}
+	newYork := &GeoLocation{Latitude: 40.7128, Longitude: -74.0060, HasCoordinates: true}
+	sydney := &GeoLocation{Latitude: -33.8688, Longitude: 151.2093, HasCoordinates: true}
+
+	tests := []struct {
+		name     string
+		from, to *GeoLocation
+		want     float64
+	}{
+		{name: "same place", from: london, to: london, want: 0},
+		{name: "London to Paris", from: london, to: paris, want: 344},
+		{name: "London to New York", from: london, to: newYork, want: 5570},
+		{name: "New York to London is symmetric", from: newYork, to: london, want: 5570},
+		{name: "across the antimeridian and equator", from: newYork, to: sydney, want: 15989},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Within 1% of the published great-circle distance
+			if got := tt.from.DistanceKm(tt.to); math.Abs(got-tt.want) > tt.want*0.01+0.001 {
+				t.Errorf("DistanceKm() = %.1f, want about %.0f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"net/netip"
+	"time"
+)
+
+type IPAccessRuleType string
+
+const (
+	// IPAccessRuleAllow exempts a network from deny rules and automatic
+	// lockouts.
+	IPAccessRuleAllow IPAccessRuleType = "allow"
+	// IPAccessRuleDeny blocks a network until an admin removes the rule or it
+	// expires.
+	IPAccessRuleDeny IPAccessRuleType = "deny"
+	// IPAccessRuleLockout is created automatically when failed logins from a
+	// network cross the velocity thresholds.
+	IPAccessRuleLockout IPAccessRuleType = "lockout"
+)
+
+type IPAccessRule struct {
+	ID        int64            `json:"id"`
+	Network   netip.Prefix     `json:"network"`
+	RuleType  IPAccessRuleType `json:"rule_type"`
+	Reason    string           `json:"reason"`
+	CreatedBy *int64           `json:"created_by"`
+	ExpiresAt *time.Time       `json:"expires_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Active reports whether the rule still applies at now.
+func (r IPAccessRule) Active(now time.Time) bool {
+	return r.ExpiresAt == nil || r.ExpiresAt.After(now)
+}
+
+type CreateIPAccessRuleAction struct {
+	Network   netip.Prefix
+	RuleType  IPAccessRuleType
+	Reason    string
+	CreatedBy *int64
+	ExpiresAt *time.Time
+}
+
+// LoginVelocity summarises recent failed logins from a network.
+type LoginVelocity struct {
+	FailedAttempts int64
+	DistinctEmails int64
+}
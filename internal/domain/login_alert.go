@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// LoginAlert is a sign-in from a new device or location that the user was
+// emailed about, with a link to report it if it was not them.
+type LoginAlert struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	SessionID   string     `json:"session_id"`
+	DeviceName  string     `json:"device_name"`
+	IPAddress   string     `json:"ip_address"`
+	Location    string     `json:"location"`
+	NewDevice   bool       `json:"new_device"`
+	NewLocation bool       `json:"new_location"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ReportedAt  *time.Time `json:"reported_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type CreateLoginAlertAction struct {
+	UserID      int64
+	SessionID   string
+	DeviceName  string
+	IPAddress   string
+	Location    string
+	NewDevice   bool
+	NewLocation bool
+	TokenHash   string
+	ExpiresAt   time.Time
+}
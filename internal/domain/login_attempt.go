@@ -3,14 +3,15 @@ package domain
 import "time"
 
 type LoginAttempt struct {
-	ID            int64      `json:"id"`
-	UserID        *int64     `json:"user_id"`
-	Email         string     `json:"email"`
-	IPAddress     string     `json:"ip_address"`
-	UserAgent     *string    `json:"user_agent"`
-	Success       bool       `json:"success"`
-	FailureReason *string    `json:"failure_reason"`
-	CreatedAt     *time.Time `json:"created_at"`
+	ID            int64        `json:"id"`
+	UserID        *int64       `json:"user_id"`
+	Email         string       `json:"email"`
+	IPAddress     string       `json:"ip_address"`
+	UserAgent     *string      `json:"user_agent"`
+	Success       bool         `json:"success"`
+	FailureReason *string      `json:"failure_reason"`
+	Location      *GeoLocation `json:"location,omitempty"`
+	CreatedAt     *time.Time   `json:"created_at"`
 }
 
 type CreateLoginAttemptAction struct {
@@ -20,4 +21,23 @@ type CreateLoginAttemptAction struct {
 	UserAgent     *string
 	Success       bool
 	FailureReason *string
+	Location      *GeoLocation
+	// PasswordFingerprint is kept for failed logins only, see
+	// security.PasswordFingerprinter.
+	PasswordFingerprint string
+}
+
+// LoginCountryHistory counts a user's successful logins with a known
+// country, and how many of them came from one particular country.
+type LoginCountryHistory struct {
+	FromCountry int64
+	Located     int64
+}
+
+// SuccessfulLoginResult describes where a successful login came from.
+// NewLocation is set for a country none of the user's earlier located logins
+// came from.
+type SuccessfulLoginResult struct {
+	Location    *GeoLocation
+	NewLocation bool
 }
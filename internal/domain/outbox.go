@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types written to the outbox in the same transaction as the change
+// they describe, and relayed to the message bus.
+const (
+	OutboxEventUserCreated                = "user.created"
+	OutboxEventUserUpdated                = "user.updated"
+	OutboxEventUserPrivacySettingsUpdated = "user.privacy_settings_updated"
+	OutboxEventUserEmailVerified          = "user.email_verified"
+	OutboxEventUserPasswordChanged        = "user.password_changed"
+	OutboxEventUserDeactivated            = "user.deactivated"
+	OutboxEventUserActivated              = "user.activated"
+	OutboxEventAccountLocked              = "security.account_locked"
+	OutboxEventAccountUnlocked            = "security.account_unlocked"
+	OutboxEventSuspiciousActivity         = "security.suspicious_activity"
+)
+
+// Aggregates events are ordered by. Events for the same aggregate are
+// published in the order they were written.
+const (
+	OutboxAggregateUser = "user"
+	OutboxAggregateIP   = "ip"
+)
+
+// OutboxEvent is the envelope published to the message bus. ID stays the same
+// across redeliveries so consumers can drop duplicates. AggregateSequence
+// numbers the aggregate's events without gaps, in the order they committed.
+type OutboxEvent struct {
+	ID                uuid.UUID       `json:"id"`
+	Sequence          int64           `json:"sequence"`
+	Type              string          `json:"type"`
+	AggregateType     string          `json:"aggregate_type"`
+	AggregateID       string          `json:"aggregate_id"`
+	AggregateSequence int64           `json:"aggregate_sequence"`
+	CreatedAt         time.Time       `json:"created_at"`
+	Data              json.RawMessage `json:"data"`
+	Attempts          int32           `json:"-"`
+}
+
+// Key identifies the aggregate the event belongs to, e.g. "user:42". Bus
+// backends partition on it to keep per-aggregate order.
+func (e OutboxEvent) Key() string {
+	return e.AggregateType + ":" + e.AggregateID
+}
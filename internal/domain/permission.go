@@ -0,0 +1,43 @@
+package domain
+
+type Permission string
+
+const (
+	PermissionUsersRead       Permission = "users:read"
+	PermissionUsersWrite      Permission = "users:write"
+	PermissionUsersDeactivate Permission = "users:deactivate"
+	PermissionSessionsRevoke  Permission = "sessions:revoke"
+	PermissionAuditRead       Permission = "audit:read"
+	PermissionSecurityManage  Permission = "security:manage"
+)
+
+// RolePermissions lists what each role may do across services that defer
+// authorization to whoami.
+var RolePermissions = map[UserRole][]Permission{
+	RoleUser: {
+		PermissionUsersRead,
+	},
+	RoleMod: {
+		PermissionUsersRead,
+		PermissionUsersDeactivate,
+		PermissionSessionsRevoke,
+		PermissionAuditRead,
+	},
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersWrite,
+		PermissionUsersDeactivate,
+		PermissionSessionsRevoke,
+		PermissionAuditRead,
+		PermissionSecurityManage,
+	},
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, granted := range RolePermissions[UserRole(role)] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
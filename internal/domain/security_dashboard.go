@@ -0,0 +1,96 @@
+package domain
+
+import "time"
+
+// Bucket sizes for dashboard time series. Buckets start on UTC boundaries,
+// weeks on Mondays.
+const (
+	DashboardBucketHour = "hour"
+	DashboardBucketDay  = "day"
+	DashboardBucketWeek = "week"
+)
+
+// DashboardRange is the half-open interval [From, To) a dashboard query
+// covers, split into Bucket sized steps for time series.
+type DashboardRange struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Bucket string    `json:"bucket"`
+}
+
+type LoginAttemptBucket struct {
+	Bucket     time.Time `json:"bucket"`
+	Successful int64     `json:"successful"`
+	Failed     int64     `json:"failed"`
+}
+
+type LoginTotals struct {
+	Successful  int64   `json:"successful"`
+	Failed      int64   `json:"failed"`
+	FailureRate float64 `json:"failure_rate"`
+}
+
+type FailedLoginIP struct {
+	IPAddress      string    `json:"ip_address"`
+	FailedAttempts int64     `json:"failed_attempts"`
+	DistinctEmails int64     `json:"distinct_emails"`
+	LastAttemptAt  time.Time `json:"last_attempt_at"`
+}
+
+type FailedLoginEmail struct {
+	Email          string    `json:"email"`
+	FailedAttempts int64     `json:"failed_attempts"`
+	DistinctIPs    int64     `json:"distinct_ips"`
+	LastAttemptAt  time.Time `json:"last_attempt_at"`
+}
+
+type LockoutBucket struct {
+	Bucket    time.Time `json:"bucket"`
+	Lockouts  int64     `json:"lockouts"`
+	Permanent int64     `json:"permanent"`
+}
+
+// ActiveLockouts counts lockouts in force right now, regardless of range.
+type ActiveLockouts struct {
+	Accounts          int64 `json:"accounts"`
+	PermanentAccounts int64 `json:"permanent_accounts"`
+	IPs               int64 `json:"ips"`
+}
+
+type SuspiciousActivityBucket struct {
+	Bucket   time.Time `json:"bucket"`
+	Low      int64     `json:"low"`
+	Medium   int64     `json:"medium"`
+	High     int64     `json:"high"`
+	Critical int64     `json:"critical"`
+}
+
+type SeverityCounts struct {
+	Low      int64 `json:"low"`
+	Medium   int64 `json:"medium"`
+	High     int64 `json:"high"`
+	Critical int64 `json:"critical"`
+	Total    int64 `json:"total"`
+}
+
+type RegistrationBucket struct {
+	Bucket        time.Time `json:"bucket"`
+	Registrations int64     `json:"registrations"`
+}
+
+// MFAAdoption is the share of active users with two-factor authentication
+// turned on in their settings.
+type MFAAdoption struct {
+	ActiveUsers int64   `json:"active_users"`
+	MFAEnabled  int64   `json:"mfa_enabled"`
+	Rate        float64 `json:"rate"`
+}
+
+type SecurityDashboardSummary struct {
+	Range                          DashboardRange `json:"range"`
+	Logins                         LoginTotals    `json:"logins"`
+	Registrations                  int64          `json:"registrations"`
+	ActiveLockouts                 ActiveLockouts `json:"active_lockouts"`
+	UnresolvedSuspiciousActivities SeverityCounts `json:"unresolved_suspicious_activities"`
+	MFAAdoption                    MFAAdoption    `json:"mfa_adoption"`
+}
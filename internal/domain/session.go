@@ -3,14 +3,17 @@ package domain
 import "time"
 
 type Session struct {
-	ID           string            `json:"id"`
-	UserID       int64             `json:"user_id"`
-	Token        string            `json:"token"`         //current access token
-	RefreshToken string            `json:"refresh_token"` // refresh token
-	DeviceInfo   map[string]string `json:"device_info"`
-	IPAddress    string            `json:"ip_address"`
-	UserAgent    string            `json:"user_agent"`
-	CreatedAt    time.Time         `json:"created_at"`
-	LastActive   time.Time         `json:"last_active"`
-	IsActive     bool              `json:"is_active"`
+	ID                string            `json:"id"`
+	UserID            int64             `json:"user_id"`
+	TokenHash         string            `json:"token_hash"`         // security.HashToken of the current access token
+	RefreshTokenHash  string            `json:"refresh_token_hash"` // security.HashToken of the refresh token
+	DeviceInfo        map[string]string `json:"device_info"`
+	IPAddress         string            `json:"ip_address"`
+	UserAgent         string            `json:"user_agent"`
+	CreatedAt         time.Time         `json:"created_at"`
+	LastActive        time.Time         `json:"last_active"`
+	ExpiresAt         time.Time         `json:"expires_at"`          // idle deadline, capped by AbsoluteExpiresAt
+	AbsoluteExpiresAt time.Time         `json:"absolute_expires_at"` // hard deadline, never extended
+	IdleTimeout       time.Duration     `json:"idle_timeout"`
+	IsActive          bool              `json:"is_active"`
 }
@@ -20,8 +20,90 @@ type SuspiciousActivity struct {
 	Description  string     `json:"description"`
 	Metadata     []byte     `json:"metadata"`
 	Severity     string     `json:"severity"`
-	Resolved     *bool      `json:"resolved"`
 	CreatedAt    *time.Time `json:"created_at"`
+	// Status tracks the activity as a case, see SuspiciousActivityStatus.
+	Status     SuspiciousActivityStatus `json:"status"`
+	AssignedTo *int64                   `json:"assigned_to"`
+	ResolvedBy *int64                   `json:"resolved_by"`
+	ResolvedAt *time.Time               `json:"resolved_at"`
+	UpdatedAt  time.Time                `json:"updated_at"`
+}
+
+// SuspiciousActivityStatus is where an activity stands in review. Users may
+// acknowledge their own open activities, everything else is up to the
+// security team.
+type SuspiciousActivityStatus string
+
+const (
+	ActivityStatusOpen               SuspiciousActivityStatus = "open"
+	ActivityStatusAcknowledged       SuspiciousActivityStatus = "acknowledged"
+	ActivityStatusConfirmedBenign    SuspiciousActivityStatus = "confirmed_benign"
+	ActivityStatusConfirmedMalicious SuspiciousActivityStatus = "confirmed_malicious"
+	ActivityStatusResolved           SuspiciousActivityStatus = "resolved"
+)
+
+func (s SuspiciousActivityStatus) Valid() bool {
+	switch s {
+	case ActivityStatusOpen, ActivityStatusAcknowledged, ActivityStatusConfirmedBenign, ActivityStatusConfirmedMalicious, ActivityStatusResolved:
+		return true
+	}
+	return false
+}
+
+// Closed reports whether the activity needs no further review.
+func (s SuspiciousActivityStatus) Closed() bool {
+	switch s {
+	case ActivityStatusConfirmedBenign, ActivityStatusConfirmedMalicious, ActivityStatusResolved:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an activity may move from s to next. Closed
+// activities can only be resolved or reopened.
+func (s SuspiciousActivityStatus) CanTransitionTo(next SuspiciousActivityStatus) bool {
+	switch s {
+	case ActivityStatusOpen:
+		return next == ActivityStatusAcknowledged || next.Closed()
+	case ActivityStatusAcknowledged:
+		return next.Closed()
+	case ActivityStatusConfirmedBenign, ActivityStatusConfirmedMalicious:
+		return next == ActivityStatusResolved || next == ActivityStatusOpen
+	case ActivityStatusResolved:
+		return next == ActivityStatusOpen
+	}
+	return false
+}
+
+type SuspiciousActivityNote struct {
+	ID         int64     `json:"id"`
+	ActivityID int64     `json:"activity_id"`
+	AuthorID   *int64    `json:"author_id"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SuspiciousActivityCase is an activity together with its review notes.
+type SuspiciousActivityCase struct {
+	Activity SuspiciousActivity       `json:"activity"`
+	Notes    []SuspiciousActivityNote `json:"notes"`
+}
+
+type SuspiciousActivityFilter struct {
+	// Statuses defaults to the activities still under review.
+	Statuses   []SuspiciousActivityStatus
+	AssignedTo *int64
+	Limit      int32
+}
+
+type UpdateSuspiciousActivityStatusAction struct {
+	ActivityID int64
+	From       SuspiciousActivityStatus
+	To         SuspiciousActivityStatus
+	// ResolvedBy is recorded when To closes the activity.
+	ResolvedBy *int64
+	// Note is added to the activity along with the change, when set.
+	Note string
 }
 
 type CreateSuspiciousActivityAction struct {
@@ -33,3 +115,24 @@ type CreateSuspiciousActivityAction struct {
 	Metadata     []byte
 	Severity     *Severity
 }
+
+// ReviewSuspiciousActivityAction is a security team decision on an activity.
+// RevokeSessions and ForcePasswordReset act on the activity's account and
+// are only allowed when confirming it as malicious.
+type ReviewSuspiciousActivityAction struct {
+	ActivityID         int64
+	ReviewerID         int64
+	Status             SuspiciousActivityStatus
+	Note               string
+	RevokeSessions     bool
+	ForcePasswordReset bool
+}
+
+type SuspiciousActivityReview struct {
+	Activity        *SuspiciousActivity `json:"activity"`
+	SessionsRevoked bool                `json:"sessions_revoked"`
+	// Lockout is set when forcing a password reset locked the account, it is
+	// lifted once the reset completes.
+	Lockout           *AccountLockout `json:"lockout,omitempty"`
+	PasswordResetSent bool            `json:"password_reset_sent"`
+}
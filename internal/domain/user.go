@@ -32,3 +32,13 @@ type CreateUserAction struct {
 	Username        *string          `json:"username"`
 	PrivacySettings *PrivacySettings `json:"privacy_settings"`
 }
+
+// MigrateLegacyUserAction holds everything written for a user moved over from
+// the legacy identity store. The audit entry is completed with the new user's
+// ID.
+type MigrateLegacyUserAction struct {
+	User          CreateUserAction
+	EmailVerified bool
+	Profile       *CreateUserProfileAction
+	Audit         CreateAuditLogAction
+}
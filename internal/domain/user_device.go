@@ -3,16 +3,17 @@ package domain
 import "time"
 
 type UserDevice struct {
-	ID         int64      `json:"id"`
-	UserID     int64      `json:"user_id"`
-	DeviceID   string     `json:"device_id"`
-	DeviceName string     `json:"device_name"`
-	DeviceType string     `json:"device_type"`
-	UserAgent  string     `json:"user_agent"`
-	IPAddress  string     `json:"ip_address"`
-	Trusted    bool       `json:"trusted"`
-	LastUsedAt time.Time  `json:"last_used_at"`
-	CreatedAt  *time.Time `json:"created_at"`
+	ID         int64        `json:"id"`
+	UserID     int64        `json:"user_id"`
+	DeviceID   string       `json:"device_id"`
+	DeviceName string       `json:"device_name"`
+	DeviceType string       `json:"device_type"`
+	UserAgent  string       `json:"user_agent"`
+	IPAddress  string       `json:"ip_address"`
+	Trusted    bool         `json:"trusted"`
+	Location   *GeoLocation `json:"location,omitempty"`
+	LastUsedAt time.Time    `json:"last_used_at"`
+	CreatedAt  *time.Time   `json:"created_at"`
 }
 
 type CreateUserDeviceAction struct {
@@ -23,6 +24,7 @@ type CreateUserDeviceAction struct {
 	UserAgent  string
 	IPAddress  string
 	Trusted    bool
+	Location   *GeoLocation
 }
 
 type UpdateUserDeviceAction struct {
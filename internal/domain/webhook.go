@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types downstream services can subscribe to.
+const (
+	WebhookEventUserRegistered      = "user.registered"
+	WebhookEventUserEmailVerified   = "user.email_verified"
+	WebhookEventUserPasswordChanged = "user.password_changed"
+	WebhookEventUserLockedOut       = "user.locked_out"
+	WebhookEventUserDeactivated     = "user.deactivated"
+)
+
+var WebhookEventTypes = []string{
+	WebhookEventUserRegistered,
+	WebhookEventUserEmailVerified,
+	WebhookEventUserPasswordChanged,
+	WebhookEventUserLockedOut,
+	WebhookEventUserDeactivated,
+}
+
+func IsWebhookEventType(eventType string) bool {
+	return slices.Contains(WebhookEventTypes, eventType)
+}
+
+// WebhookEndpoint is a URL that receives signed POSTs for the event types it
+// subscribed to.
+type WebhookEndpoint struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Secret      string    `json:"-"`
+	EventTypes  []string  `json:"event_types"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateWebhookEndpointAction struct {
+	URL         string
+	Description string
+	Secret      string
+	EventTypes  []string
+}
+
+type UpdateWebhookEndpointAction struct {
+	ID          int64
+	URL         string
+	Description string
+	EventTypes  []string
+	Active      bool
+}
+
+// WebhookEvent is the body POSTed to endpoints. ID stays the same across
+// retries and redeliveries so receivers can discard duplicates.
+type WebhookEvent struct {
+	ID        uuid.UUID              `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	EndpointID     int64           `json:"endpoint_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	LastStatusCode int32           `json:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+type CreateWebhookDeliveryAction struct {
+	EndpointID    int64
+	EventID       uuid.UUID
+	EventType     string
+	Payload       []byte
+	NextAttemptAt time.Time
+}
+
+type UpdateWebhookDeliveryAction struct {
+	ID             int64
+	Status         string
+	Attempts       int32
+	LastStatusCode int32
+	LastError      string
+	NextAttemptAt  time.Time
+	DeliveredAt    *time.Time
+}
+
+// A delivery that runs out of attempts is dead-lettered. It stays in the log
+// until an admin redelivers it.
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusDead      = "dead"
+)
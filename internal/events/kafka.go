@@ -0,0 +1,59 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher writes every event to one topic, keyed by aggregate so all
+// events for a user land on the same partition in order.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string, topic string) (*KafkaPublisher, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("kafka event publisher requires at least one broker")
+	}
+	if topic == "" {
+		topic = "whoami.events"
+	}
+
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// The relay publishes one event at a time and waits for the ack,
+			// so batching would only add latency
+			BatchSize:    1,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.Key()),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: HeaderEventID, Value: []byte(event.ID.String())},
+			{Key: HeaderEventType, Value: []byte(event.Type)},
+		},
+	})
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSPublisher publishes to JetStream on <prefix>.<event type>, e.g.
+// whoami.events.user.created. A stream must capture those subjects, publishes
+// fail until one does. The event ID is sent as Nats-Msg-Id so the stream's
+// duplicate window drops redeliveries.
+type NATSPublisher struct {
+	conn          *nats.Conn
+	js            jetstream.JetStream
+	subjectPrefix string
+}
+
+func NewNATSPublisher(url, subjectPrefix string) (*NATSPublisher, error) {
+	if url == "" {
+		return nil, errors.New("nats event publisher requires a url")
+	}
+	if subjectPrefix == "" {
+		subjectPrefix = "whoami.events"
+	}
+
+	conn, err := nats.Connect(url, nats.Name("whoami-outbox-relay"))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &NATSPublisher{
+		conn:          conn,
+		js:            js,
+		subjectPrefix: subjectPrefix,
+	}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.subjectPrefix + "." + event.Type)
+	msg.Data = body
+	msg.Header.Set(HeaderEventType, event.Type)
+	msg.Header.Set(HeaderEventKey, event.Key())
+
+	_, err = p.js.PublishMsg(ctx, msg, jetstream.WithMsgID(event.ID.String()))
+	return err
+}
+
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
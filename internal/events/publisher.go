@@ -0,0 +1,84 @@
+// Package events relays outbox events to a message bus.
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// Supported EVENT_PUBLISHER values.
+const (
+	PublisherNone   = "none"
+	PublisherStdout = "stdout"
+	PublisherFile   = "file"
+	PublisherNATS   = "nats"
+	PublisherKafka  = "kafka"
+	PublisherRedis  = "redis"
+)
+
+// Headers set on messages by backends that support them.
+const (
+	HeaderEventID   = "Whoami-Event-Id"
+	HeaderEventType = "Whoami-Event-Type"
+	HeaderEventKey  = "Whoami-Event-Key"
+)
+
+// EventPublisher hands outbox events to a message bus. Publish returns only
+// once the bus has accepted the event, an error means it may or may not
+// have been delivered and the relay will try again.
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.OutboxEvent) error
+	Close() error
+}
+
+type PublisherConfig struct {
+	Backend           string
+	FilePath          string
+	NATSURL           string
+	NATSSubjectPrefix string
+	KafkaBrokers      []string
+	KafkaTopic        string
+	RedisStream       string
+	RedisStreamMaxLen int64
+}
+
+// NewEventPublisher builds the publisher named by cfg.Backend. The Redis
+// backend reuses redisClient.
+func NewEventPublisher(cfg PublisherConfig, redisClient *redis.Client) (EventPublisher, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", PublisherNone:
+		return NewDiscardPublisher(), nil
+	case PublisherStdout:
+		return NewStdoutPublisher(), nil
+	case PublisherFile:
+		return NewFilePublisher(cfg.FilePath)
+	case PublisherNATS:
+		return NewNATSPublisher(cfg.NATSURL, cfg.NATSSubjectPrefix)
+	case PublisherKafka:
+		return NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic)
+	case PublisherRedis:
+		return NewRedisStreamPublisher(redisClient, cfg.RedisStream, cfg.RedisStreamMaxLen)
+	default:
+		return nil, fmt.Errorf("unsupported event publisher %q", cfg.Backend)
+	}
+}
+
+// DiscardPublisher drops every event. It lets the relay keep the outbox
+// drained when no bus is configured.
+type DiscardPublisher struct{}
+
+func NewDiscardPublisher() *DiscardPublisher {
+	return &DiscardPublisher{}
+}
+
+func (p *DiscardPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	return nil
+}
+
+func (p *DiscardPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStreamPublisher appends events to a single stream, which keeps them
+// in publish order. A positive maxLen trims the stream to roughly that many
+// entries.
+type RedisStreamPublisher struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+func NewRedisStreamPublisher(client *redis.Client, stream string, maxLen int64) (*RedisStreamPublisher, error) {
+	if client == nil {
+		return nil, errors.New("redis event publisher requires a redis client")
+	}
+	if stream == "" {
+		stream = "whoami:events"
+	}
+
+	return &RedisStreamPublisher{
+		client: client,
+		stream: stream,
+		maxLen: maxLen,
+	}, nil
+}
+
+func (p *RedisStreamPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	args := &redis.XAddArgs{
+		Stream: p.stream,
+		Values: map[string]interface{}{
+			"id":      event.ID.String(),
+			"type":    event.Type,
+			"key":     event.Key(),
+			"payload": body,
+		},
+	}
+	if p.maxLen > 0 {
+		args.MaxLen = p.maxLen
+		args.Approx = true
+	}
+
+	return p.client.XAdd(ctx, args).Err()
+}
+
+// Close leaves the shared client open, main owns it.
+func (p *RedisStreamPublisher) Close() error {
+	return nil
+}
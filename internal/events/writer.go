@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// WriterPublisher writes each event as a line of JSON. It is meant for local
+// development, where stdout or a file stands in for the bus.
+type WriterPublisher struct {
+	mu     sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+func NewStdoutPublisher() *WriterPublisher {
+	return &WriterPublisher{writer: os.Stdout}
+}
+
+// NewFilePublisher appends events to the file at path, creating it if needed.
+func NewFilePublisher(path string) (*WriterPublisher, error) {
+	if path == "" {
+		return nil, errors.New("file event publisher requires a file path")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WriterPublisher{writer: file, closer: file}, nil
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.writer.Write(line)
+	return err
+}
+
+func (p *WriterPublisher) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
@@ -0,0 +1,226 @@
+// Package grpcserver exposes the services layer to internal callers over gRPC
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/security"
+	"github.com/m1thrandir225/whoami/internal/services"
+	"github.com/m1thrandir225/whoami/pkg/pb/whoamiv1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const maxBatchGetUsers = 100
+
+type Server struct {
+	whoamiv1.UnimplementedIdentityServiceServer
+
+	userService    services.UserService
+	sessionService services.SessionService
+	auditService   services.AuditService
+	tokenMaker     security.TokenMaker
+	tokenBlacklist security.TokenBlacklist
+}
+
+func NewServer(
+	userService services.UserService,
+	sessionService services.SessionService,
+	auditService services.AuditService,
+	tokenMaker security.TokenMaker,
+	tokenBlacklist security.TokenBlacklist,
+) *Server {
+	return &Server{
+		userService:    userService,
+		sessionService: sessionService,
+		auditService:   auditService,
+		tokenMaker:     tokenMaker,
+		tokenBlacklist: tokenBlacklist,
+	}
+}
+
+func (s *Server) VerifyToken(ctx context.Context, req *whoamiv1.VerifyTokenRequest) (*whoamiv1.VerifyTokenResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "access_token is required")
+	}
+
+	isBlacklisted, err := s.tokenBlacklist.IsTokenBlacklisted(ctx, req.GetAccessToken())
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to check token blacklist: %v", err)
+	}
+	if isBlacklisted {
+		return nil, status.Error(codes.Unauthenticated, "token is blacklisted")
+	}
+
+	payload, err := s.tokenMaker.VerifyToken(req.GetAccessToken())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	// The session may have hit its idle or absolute timeout before the token did
+	if err := s.sessionService.UpdateSessionActivity(ctx, req.GetAccessToken()); err != nil {
+		return nil, sessionError(err)
+	}
+
+	session, err := s.sessionService.GetSession(ctx, req.GetAccessToken())
+	if err != nil {
+		return nil, sessionError(err)
+	}
+
+	user, err := s.userService.GetUserByID(ctx, payload.UserID)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	if !user.Active {
+		return nil, status.Error(codes.PermissionDenied, "account is deactivated")
+	}
+
+	return &whoamiv1.VerifyTokenResponse{
+		User:             toProtoUser(user),
+		SessionId:        session.ID,
+		TokenExpiresAt:   timestamppb.New(payload.ExpiredAt),
+		SessionExpiresAt: timestamppb.New(session.ExpiresAt),
+	}, nil
+}
+
+func (s *Server) GetUser(ctx context.Context, req *whoamiv1.GetUserRequest) (*whoamiv1.GetUserResponse, error) {
+	user, err := s.userService.GetUserByID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	return &whoamiv1.GetUserResponse{
+		User: toProtoUser(user),
+	}, nil
+}
+
+func (s *Server) BatchGetUsers(ctx context.Context, req *whoamiv1.BatchGetUsersRequest) (*whoamiv1.BatchGetUsersResponse, error) {
+	if len(req.GetUserIds()) > maxBatchGetUsers {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d user_ids may be requested at once", maxBatchGetUsers)
+	}
+
+	users, err := s.userService.GetUsersByIDs(ctx, req.GetUserIds())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get users: %v", err)
+	}
+
+	found := make(map[int64]bool, len(users))
+	response := &whoamiv1.BatchGetUsersResponse{
+		Users: make([]*whoamiv1.User, len(users)),
+	}
+	for i := range users {
+		found[users[i].ID] = true
+		response.Users[i] = toProtoUser(&users[i])
+	}
+
+	for _, id := range req.GetUserIds() {
+		if !found[id] {
+			found[id] = true
+			response.MissingUserIds = append(response.MissingUserIds, id)
+		}
+	}
+
+	return response, nil
+}
+
+func (s *Server) RevokeSession(ctx context.Context, req *whoamiv1.RevokeSessionRequest) (*whoamiv1.RevokeSessionResponse, error) {
+	var (
+		session *domain.Session
+		err     error
+	)
+	switch target := req.GetTarget().(type) {
+	case *whoamiv1.RevokeSessionRequest_SessionId:
+		session, err = s.sessionService.GetSessionByID(ctx, target.SessionId)
+	case *whoamiv1.RevokeSessionRequest_AccessToken:
+		session, err = s.sessionService.GetSession(ctx, target.AccessToken)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "session_id or access_token is required")
+	}
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+
+	if err := s.sessionService.RevokeSession(ctx, session.ID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+	}
+
+	s.auditService.LogSystemAction(ctx, domain.AuditActionSessionRevoke, domain.AuditResourceTypeSession, session.UserID, auditRequest(ctx), map[string]interface{}{
+		"user_id":    session.UserID,
+		"session_id": session.ID,
+		"reason":     req.GetReason(),
+		"source":     "grpc",
+		"success":    true,
+	})
+
+	return &whoamiv1.RevokeSessionResponse{}, nil
+}
+
+func (s *Server) CheckPermission(ctx context.Context, req *whoamiv1.CheckPermissionRequest) (*whoamiv1.CheckPermissionResponse, error) {
+	if req.GetPermission() == "" {
+		return nil, status.Error(codes.InvalidArgument, "permission is required")
+	}
+
+	user, err := s.userService.GetUserByID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	return &whoamiv1.CheckPermissionResponse{
+		Allowed: user.Active && domain.HasPermission(user.Role, domain.Permission(req.GetPermission())),
+		Role:    user.Role,
+	}, nil
+}
+
+func toProtoUser(user *domain.User) *whoamiv1.User {
+	protoUser := &whoamiv1.User{
+		Id:            user.ID,
+		Email:         user.Email,
+		Username:      user.Username,
+		EmailVerified: user.EmailVerified,
+		Role:          user.Role,
+		Active:        user.Active,
+		CreatedAt:     timestamppb.New(user.CreatedAt),
+	}
+	if user.LastLoginAt != nil {
+		protoUser.LastLoginAt = timestamppb.New(*user.LastLoginAt)
+	}
+	return protoUser
+}
+
+// sessionError keeps store outages apart from sessions that are gone.
+func sessionError(err error) error {
+	if errors.Is(err, services.ErrSessionExpired) || errors.Is(err, services.ErrSessionNotFound) {
+		return status.Error(codes.Unauthenticated, "session expired or revoked")
+	}
+	return status.Errorf(codes.Unavailable, "failed to check session: %v", err)
+}
+
+func userError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return status.Error(codes.NotFound, "user not found")
+	}
+	return status.Errorf(codes.Internal, "failed to get user: %v", err)
+}
+
+// auditRequest adapts the gRPC peer and metadata to the *http.Request the
+// audit service reads the client IP and user agent from.
+func auditRequest(ctx context.Context) *http.Request {
+	r := &http.Request{Header: http.Header{}}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.RemoteAddr = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
+			r.Header.Set("User-Agent", userAgent[0])
+		}
+	}
+	return r
+}
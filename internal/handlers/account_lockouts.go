@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/services"
+)
+
+// UnlockAccountWithToken serves the unlock link emailed when an account is
+// locked permanently.
+func (h *HTTPHandler) UnlockAccountWithToken(ctx *gin.Context) {
+	var req unlockAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	lockout, err := h.securityService.UnlockAccountWithToken(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUnlockToken) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, lockout.UserID, domain.AuditActionAccountUnlock, domain.AuditResourceTypeAccount, lockout.ID, ctx.Request, map[string]interface{}{
+		"method":  domain.UnlockMethodEmail,
+		"success": true,
+	})
+
+	ctx.JSON(http.StatusOK, messageResponse("Account unlocked successfully"))
+}
+
+func (h *HTTPHandler) UnlockUserAccount(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	lockouts, err := h.securityService.UnlockAccount(ctx, userID, payload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if len(lockouts) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errors.New("account is not locked")))
+		return
+	}
+
+	for _, lockout := range lockouts {
+		h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionAccountUnlock, domain.AuditResourceTypeAccount, lockout.ID, ctx.Request, map[string]interface{}{
+			"user_id":   userID,
+			"method":    domain.UnlockMethodAdmin,
+			"permanent": lockout.Permanent,
+			"success":   true,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"lockouts": lockouts,
+	})
+}
+
+func (h *HTTPHandler) GetUserLockoutHistory(ctx *gin.Context) {
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	lockouts, err := h.securityService.GetLockoutHistory(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"lockouts": lockouts,
+	})
+}
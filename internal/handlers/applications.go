@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+func (h *HTTPHandler) CreateApplication(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	var req createApplicationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	application, logoutSecret, err := h.applicationService.CreateApplication(ctx, req.Name, req.BackchannelLogoutURL)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionApplicationCreate, domain.AuditResourceTypeApplication, application.ID, ctx.Request, map[string]interface{}{
+		"name":                   application.Name,
+		"client_id":              application.ClientID,
+		"backchannel_logout_url": application.BackchannelLogoutURL,
+		"success":                true,
+	})
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"application":   application,
+		"logout_secret": logoutSecret,
+	})
+}
+
+func (h *HTTPHandler) ListApplications(ctx *gin.Context) {
+	applications, err := h.applicationService.ListApplications(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"applications": applications,
+	})
+}
+
+func (h *HTTPHandler) GetApplication(ctx *gin.Context) {
+	applicationID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	application, err := h.applicationService.GetApplication(ctx, applicationID)
+	if err != nil {
+		ctx.JSON(applicationErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"application": application,
+	})
+}
+
+func (h *HTTPHandler) UpdateApplication(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	applicationID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateApplicationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	application, err := h.applicationService.UpdateApplication(ctx, domain.UpdateApplicationAction{
+		ID:                   applicationID,
+		Name:                 req.Name,
+		BackchannelLogoutURL: req.BackchannelLogoutURL,
+		Active:               *req.Active,
+	})
+	if err != nil {
+		ctx.JSON(applicationErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionApplicationUpdate, domain.AuditResourceTypeApplication, application.ID, ctx.Request, map[string]interface{}{
+		"name":                   application.Name,
+		"backchannel_logout_url": application.BackchannelLogoutURL,
+		"active":                 application.Active,
+		"success":                true,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"application": application,
+	})
+}
+
+func (h *HTTPHandler) DeleteApplication(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	applicationID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := h.applicationService.DeleteApplication(ctx, applicationID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionApplicationDelete, domain.AuditResourceTypeApplication, applicationID, ctx.Request, map[string]interface{}{
+		"success": true,
+	})
+
+	ctx.JSON(http.StatusOK, messageResponse("Application deleted successfully"))
+}
+
+func (h *HTTPHandler) GetApplicationLogoutDeliveries(ctx *gin.Context) {
+	applicationID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	limit := int32(50) // Default limit
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
+			limit = int32(l)
+		}
+	}
+
+	deliveries, err := h.applicationService.GetLogoutDeliveries(ctx, applicationID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+	})
+}
+
+func applicationErrorStatus(err error) int {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
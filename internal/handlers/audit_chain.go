@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// VerifyAuditLogChain walks the whole audit chain. The verification is itself
+// audited, so the next one covers an entry more.
+func (h *HTTPHandler) VerifyAuditLogChain(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	report, err := h.auditChainService.Verify(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionAuditVerify, domain.AuditResourceTypeAuditLog, report.ThroughSeq, ctx.Request, map[string]interface{}{
+		"valid":           report.Valid,
+		"from_seq":        report.FromSeq,
+		"through_seq":     report.ThroughSeq,
+		"entries_checked": report.EntriesChecked,
+		"issues":          len(report.Issues),
+		"success":         true,
+	})
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// CreateAuditLogCheckpoint signs the current end of the audit chain without
+// waiting for the scheduled checkpoint.
+func (h *HTTPHandler) CreateAuditLogCheckpoint(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	checkpoint, err := h.auditChainService.Checkpoint(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if checkpoint != nil {
+		h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionAuditCheckpoint, domain.AuditResourceTypeAuditLog, checkpoint.ID, ctx.Request, map[string]interface{}{
+			"chain_seq": checkpoint.ChainSeq,
+			"success":   true,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"checkpoint": checkpoint,
+	})
+}
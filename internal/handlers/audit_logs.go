@@ -1,200 +1,160 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"net/netip"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/services"
 )
 
-func (h *HTTPHandler) GetAuditLogsByUserID(ctx *gin.Context) {
-	userID, err := strconv.ParseInt(ctx.Param("user_id"), 10, 64)
+// SearchAuditLogs combines ?user_id=&action=&resource_type=&resource_id=
+// &ip=&from=&to=&details=&cursor=&limit= into a single search. Users without
+// the audit:read permission only see entries about themselves.
+func (h *HTTPHandler) SearchAuditLogs(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	limit := int32(50) // Default limit
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
-			limit = int32(l)
-		}
-	}
-
-	logs, err := h.auditService.GetAuditLogsByUserID(ctx, userID, limit)
+	filter, err := parseAuditLogFilter(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{
-		"audit_logs": logs,
-	})
-}
-
-func (h *HTTPHandler) GetAuditLogsByAction(ctx *gin.Context) {
-	action := ctx.Param("action")
-	if action == "" {
-		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("action is required")))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	limit := int32(50) // Default limit
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
-			limit = int32(l)
-		}
-	}
-
-	logs, err := h.auditService.GetAuditLogsByAction(ctx, action, limit)
+	user, err := h.userService.GetUserByID(ctx, payload.UserID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ErrUnauthorized))
 		return
 	}
-
-	ctx.JSON(http.StatusOK, gin.H{
-		"audit_logs": logs,
-	})
-}
-
-func (h *HTTPHandler) GetAuditLogsByResourceType(ctx *gin.Context) {
-	resourceType := ctx.Param("resource_type")
-	if resourceType == "" {
-		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("resource_type is required")))
-		return
-	}
-
-	limit := int32(50) // Default limit
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
-			limit = int32(l)
+	if !user.Active || !domain.HasPermission(user.Role, domain.PermissionAuditRead) {
+		if filter.UserID != nil && *filter.UserID != user.ID {
+			ctx.JSON(http.StatusForbidden, errorResponse(ErrForbidden))
+			return
 		}
+		filter.UserID = &user.ID
 	}
 
-	logs, err := h.auditService.GetAuditLogsByResourceType(ctx, resourceType, limit)
+	page, err := h.auditService.SearchAuditLogs(ctx, filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidAuditLogFilter) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"audit_logs": logs,
-	})
+	ctx.JSON(http.StatusOK, page)
 }
 
-func (h *HTTPHandler) GetAuditLogsByResourceID(ctx *gin.Context) {
-	resourceType := ctx.Param("resource_type")
-	if resourceType == "" {
-		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("resource_type is required")))
-		return
+func parseAuditLogFilter(ctx *gin.Context) (domain.AuditLogFilter, error) {
+	filter := domain.AuditLogFilter{
+		Cursor: ctx.Query("cursor"),
 	}
 
-	resourceID, err := strconv.ParseInt(ctx.Param("resource_id"), 10, 64)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return
+	if userID := ctx.Query("user_id"); userID != "" {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			return filter, errors.New("user_id must be a user ID")
+		}
+		filter.UserID = &id
 	}
 
-	limit := int32(50) // Default limit
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
-			limit = int32(l)
+	if actions := ctx.Query("action"); actions != "" {
+		for _, action := range strings.Split(actions, ",") {
+			filter.Actions = append(filter.Actions, strings.TrimSpace(action))
 		}
 	}
 
-	logs, err := h.auditService.GetAuditLogsByResourceID(ctx, resourceType, resourceID, limit)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+	if resourceType := ctx.Query("resource_type"); resourceType != "" {
+		filter.ResourceType = &resourceType
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"audit_logs": logs,
-	})
-}
-
-func (h *HTTPHandler) GetAuditLogsByIP(ctx *gin.Context) {
-	ipAddress := ctx.Param("ip")
-	if ipAddress == "" {
-		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("ip address is required")))
-		return
+	if resourceID := ctx.Query("resource_id"); resourceID != "" {
+		id, err := strconv.ParseInt(resourceID, 10, 64)
+		if err != nil {
+			return filter, errors.New("resource_id must be a number")
+		}
+		filter.ResourceID = &id
 	}
 
-	limit := int32(50) // Default limit
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
-			limit = int32(l)
+	if ip := ctx.Query("ip"); ip != "" {
+		network, err := netip.ParsePrefix(ip)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(ip)
+			if addrErr != nil {
+				return filter, errors.New("ip must be an IP address or CIDR range")
+			}
+			network = netip.PrefixFrom(addr, addr.BitLen())
 		}
+		filter.Network = &network
 	}
 
-	logs, err := h.auditService.GetAuditLogsByIP(ctx, ipAddress, limit)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+	if from := ctx.Query("from"); from != "" {
+		t, err := parseAuditLogTime(from)
+		if err != nil {
+			return filter, errors.New("from must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.From = &t
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"audit_logs": logs,
-	})
-}
-
-func (h *HTTPHandler) GetAuditLogsByDateRange(ctx *gin.Context) {
-	startDate := ctx.Query("start_date")
-	if startDate == "" {
-		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("start_date is required")))
-		return
+	if to := ctx.Query("to"); to != "" {
+		t, err := parseAuditLogTime(to)
+		if err != nil {
+			return filter, errors.New("to must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.To = &t
 	}
 
-	endDate := ctx.Query("end_date")
-	if endDate == "" {
-		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("end_date is required")))
-		return
+	if details := ctx.Query("details"); details != "" {
+		filter.Details = json.RawMessage(details)
 	}
 
-	limit := int32(50) // Default limit
 	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
-			limit = int32(l)
+		l, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || l < 1 {
+			return filter, errors.New("limit must be a positive number")
 		}
+		filter.Limit = int32(l)
 	}
 
-	logs, err := h.auditService.GetAuditLogsByDateRange(ctx, startDate, endDate, limit)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{
-		"audit_logs": logs,
-	})
+	return filter, nil
 }
 
-func (h *HTTPHandler) GetRecentAuditLogs(ctx *gin.Context) {
-	limit := int32(50) // Default limit
-	if limitStr := ctx.Query("limit"); limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
-			limit = int32(l)
-		}
+func parseAuditLogTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
 	}
+	return time.Parse(time.DateOnly, value)
+}
 
-	logs, err := h.auditService.GetRecentAuditLogs(ctx, limit)
+// CleanupOldAuditLogs prunes entries past retention from the start of the
+// audit chain. It moves where the chain begins, so it is an admin action.
+func (h *HTTPHandler) CleanupOldAuditLogs(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"audit_logs": logs,
-	})
-}
-
-func (h *HTTPHandler) CleanupOldAuditLogs(ctx *gin.Context) {
-	err := h.auditService.CleanupOldAuditLogs(ctx)
+	err = h.auditService.CleanupOldAuditLogs(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionAuditCleanup, domain.AuditResourceTypeAuditLog, 0, ctx.Request, map[string]interface{}{
+		"success": true,
+	})
+
 	ctx.JSON(http.StatusOK, messageResponse("Old audit logs cleaned up successfully"))
 }
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+func TestParseAuditLogFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		wantErr bool
+		check   func(t *testing.T, filter domain.AuditLogFilter)
+	}{
+		{
+			name:  "no filters",
+			query: url.Values{},
+			check: func(t *testing.T, filter domain.AuditLogFilter) {
+				if !reflect.DeepEqual(filter, domain.AuditLogFilter{}) {
+					t.Errorf("parseAuditLogFilter() = %+v, want an empty filter", filter)
+				}
+			},
+		},
+		{
+			name: "every filter combined",
+			query: url.Values{
+				"user_id":       {"7"},
+				"action":        {"user_login, user_logout"},
+				"resource_type": {"user"},
+				"resource_id":   {"9"},
+				"ip":            {"203.0.113.0/24"},
+				"from":          {"2026-03-01"},
+				"to":            {"2026-03-02T12:00:00+02:00"},
+				"details":       {`{"success":false}`},
+				"cursor":        {"NDI"},
+				"limit":         {"25"},
+			},
+			check: func(t *testing.T, filter domain.AuditLogFilter) {
+				if filter.UserID == nil || *filter.UserID != 7 {
+					t.Errorf("user_id = %v, want 7", filter.UserID)
+				}
+				if !slices.Equal(filter.Actions, []string{"user_login", "user_logout"}) {
+					t.Errorf("actions = %q, want user_login and user_logout", filter.Actions)
+				}
+				if filter.ResourceType == nil || *filter.ResourceType != "user" || filter.ResourceID == nil || *filter.ResourceID != 9 {
+					t.Errorf("resource = %v/%v, want user/9", filter.ResourceType, filter.ResourceID)
+				}
+				if filter.Network == nil || filter.Network.String() != "203.0.113.0/24" {
+					t.Errorf("network = %v, want 203.0.113.0/24", filter.Network)
+				}
+				if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); filter.From == nil || !filter.From.Equal(want) {
+					t.Errorf("from = %v, want %v", filter.From, want)
+				}
+				if want := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC); filter.To == nil || !filter.To.Equal(want) {
+					t.Errorf("to = %v, want %v", filter.To, want)
+				}
+				if string(filter.Details) != `{"success":false}` || filter.Cursor != "NDI" || filter.Limit != 25 {
+					t.Errorf("details/cursor/limit = %s/%s/%d, want {\"success\":false}/NDI/25", filter.Details, filter.Cursor, filter.Limit)
+				}
+			},
+		},
+		{
+			name:  "single address becomes a host network",
+			query: url.Values{"ip": {"2001:db8::1"}},
+			check: func(t *testing.T, filter domain.AuditLogFilter) {
+				if filter.Network == nil || filter.Network.String() != "2001:db8::1/128" {
+					t.Errorf("network = %v, want 2001:db8::1/128", filter.Network)
+				}
+			},
+		},
+		{name: "user_id not a number", query: url.Values{"user_id": {"me"}}, wantErr: true},
+		{name: "resource_id not a number", query: url.Values{"resource_id": {"x"}}, wantErr: true},
+		{name: "invalid ip", query: url.Values{"ip": {"203.0.113"}}, wantErr: true},
+		{name: "invalid from", query: url.Values{"from": {"yesterday"}}, wantErr: true},
+		{name: "invalid to", query: url.Values{"to": {"03/02/2026"}}, wantErr: true},
+		{name: "zero limit", query: url.Values{"limit": {"0"}}, wantErr: true},
+		{name: "limit too large for int32", query: url.Values{"limit": {"4294967296"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/audit-logs?"+tt.query.Encode(), nil)
+
+			filter, err := parseAuditLogFilter(ctx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("parseAuditLogFilter() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAuditLogFilter() error = %v", err)
+			}
+			tt.check(t, filter)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/metrics"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+// captchaChallenge is an error body that also tells the client which widget
+// to render before retrying.
+func (h *HTTPHandler) captchaChallenge(err error) gin.H {
+	return gin.H{
+		"error":            err.Error(),
+		"captcha_required": true,
+		"captcha_provider": h.captchaService.Provider(),
+		"captcha_site_key": h.captchaService.SiteKey(),
+	}
+}
+
+// captchaRequired reports whether requests for email from the client, trying
+// the password with passwordFingerprint, need a solved challenge. Lookups
+// that fail let the request through, the same as when no provider is
+// configured.
+func (h *HTTPHandler) captchaRequired(ctx *gin.Context, email, passwordFingerprint string) bool {
+	if h.captchaService == nil {
+		return false
+	}
+
+	required, err := h.captchaService.Required(ctx, email, security.GetClientIP(ctx), passwordFingerprint)
+	if err != nil {
+		fmt.Printf("Warning: failed to evaluate captcha risk: %v\n", err)
+		return false
+	}
+	return required
+}
+
+// checkCaptcha answers 428 with a challenge when one is required and token
+// does not solve it. It returns false once it has written a response.
+func (h *HTTPHandler) checkCaptcha(ctx *gin.Context, endpoint, email, passwordFingerprint, token string) bool {
+	if !h.captchaRequired(ctx, email, passwordFingerprint) {
+		return true
+	}
+
+	if token == "" {
+		metrics.CaptchaChallenges.WithLabelValues(endpoint, "required").Inc()
+		ctx.JSON(http.StatusPreconditionRequired, h.captchaChallenge(ErrCaptchaRequired))
+		return false
+	}
+
+	if err := h.captchaService.Verify(ctx, token, security.GetClientIP(ctx)); err != nil {
+		if errors.Is(err, security.ErrCaptchaFailed) {
+			metrics.CaptchaChallenges.WithLabelValues(endpoint, "failed").Inc()
+			ctx.JSON(http.StatusPreconditionRequired, h.captchaChallenge(err))
+			return false
+		}
+
+		metrics.CaptchaChallenges.WithLabelValues(endpoint, "error").Inc()
+		fmt.Printf("Warning: failed to verify captcha: %v\n", err)
+		ctx.JSON(http.StatusServiceUnavailable, errorResponse(ErrCaptchaUnavailable))
+		return false
+	}
+
+	metrics.CaptchaChallenges.WithLabelValues(endpoint, "passed").Inc()
+	return true
+}
+
+// invalidCredentials is the failed-login body, flagging when the next attempt
+// will need a challenge so the client can render it up front.
+func (h *HTTPHandler) invalidCredentials(ctx *gin.Context, email, passwordFingerprint string) gin.H {
+	if h.captchaRequired(ctx, email, passwordFingerprint) {
+		return h.captchaChallenge(ErrInvalidCredentials)
+	}
+	return errorResponse(ErrInvalidCredentials)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+const (
+	AccessTokenCookieName  = "whoami_access_token"
+	RefreshTokenCookieName = "whoami_refresh_token"
+	CSRFTokenCookieName    = "whoami_csrf_token"
+	CSRFTokenHeaderKey     = "X-CSRF-Token"
+
+	refreshTokenCookiePath       = "/api/v1/refresh"
+	defaultAccessTokenCookiePath = "/api/v1"
+	deviceIDCookiePath           = "/api/v1"
+
+	// deviceIDCookieLifetime keeps a browser's device ID long past any session
+	deviceIDCookieLifetime = 365 * 24 * time.Hour
+)
+
+var ErrInvalidCSRFToken = errors.New("missing or invalid CSRF token")
+
+func cookieSameSite(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+// accessTokenCookiePath is COOKIE_ACCESS_TOKEN_PATH, or /api/v1. Forward
+// auth only sees the cookie when the browser sends it to the guarded apps,
+// so those deployments widen the path and COOKIE_DOMAIN.
+func (h *HTTPHandler) accessTokenCookiePath() string {
+	if h.config.CookieAccessTokenPath != "" {
+		return h.config.CookieAccessTokenPath
+	}
+	return defaultAccessTokenCookiePath
+}
+
+func (h *HTTPHandler) setCookie(ctx *gin.Context, name, value, path string, expiresAt time.Time, httpOnly bool) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.config.CookieDomain,
+		Expires:  expiresAt,
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: cookieSameSite(h.config.CookieSameSite),
+	})
+}
+
+func (h *HTTPHandler) clearCookie(ctx *gin.Context, name, path string, httpOnly bool) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     path,
+		Domain:   h.config.CookieDomain,
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: cookieSameSite(h.config.CookieSameSite),
+	})
+}
+
+// setAuthCookies writes the token cookies when cookie mode is enabled and
+// reports which tokens were moved out of the JSON body. The CSRF cookie is
+// readable by the SPA, which echoes it back in the X-CSRF-Token header.
+func (h *HTTPHandler) setAuthCookies(ctx *gin.Context, accessToken string, accessExpiresAt time.Time, refreshToken string, refreshExpiresAt time.Time) (accessInCookie, refreshInCookie bool) {
+	if !h.config.CookieAuthEnabled {
+		return false, false
+	}
+
+	h.setCookie(ctx, RefreshTokenCookieName, refreshToken, refreshTokenCookiePath, refreshExpiresAt, true)
+
+	csrfToken, err := generateCSRFToken()
+	if err != nil {
+		return false, true
+	}
+	h.setCookie(ctx, CSRFTokenCookieName, csrfToken, "/", refreshExpiresAt, false)
+
+	if !h.config.CookieAccessTokenEnabled {
+		return false, true
+	}
+
+	h.setCookie(ctx, AccessTokenCookieName, accessToken, h.accessTokenCookiePath(), accessExpiresAt, true)
+	return true, true
+}
+
+// setDeviceCookie hands a browser the device ID it was just given, so its next
+// sign-in is recognised. The ID is no credential, so it is sent at least with
+// lax same-site rules and reaches the OAuth callback, which the provider
+// redirects to.
+func (h *HTTPHandler) setDeviceCookie(ctx *gin.Context, deviceInfo *security.DeviceInfo) {
+	if !deviceInfo.NewDeviceID {
+		return
+	}
+
+	sameSite := cookieSameSite(h.config.CookieSameSite)
+	if sameSite == http.SameSiteStrictMode {
+		sameSite = http.SameSiteLaxMode
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     security.DeviceIDCookieName,
+		Value:    deviceInfo.DeviceID,
+		Path:     deviceIDCookiePath,
+		Domain:   h.config.CookieDomain,
+		MaxAge:   int(deviceIDCookieLifetime.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: sameSite,
+	})
+}
+
+func (h *HTTPHandler) clearAuthCookies(ctx *gin.Context) {
+	if !h.config.CookieAuthEnabled {
+		return
+	}
+
+	h.clearCookie(ctx, RefreshTokenCookieName, refreshTokenCookiePath, true)
+	h.clearCookie(ctx, AccessTokenCookieName, h.accessTokenCookiePath(), true)
+	h.clearCookie(ctx, CSRFTokenCookieName, "/", false)
+}
+
+func generateCSRFToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func isStateChangingMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// validateCSRF implements the double-submit check: the X-CSRF-Token header
+// must match the CSRF cookie, which a cross-site page cannot read.
+func validateCSRF(ctx *gin.Context) error {
+	cookieToken, err := ctx.Cookie(CSRFTokenCookieName)
+	if err != nil || cookieToken == "" {
+		return ErrInvalidCSRFToken
+	}
+
+	headerToken := ctx.GetHeader(CSRFTokenHeaderKey)
+	if subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 {
+		return ErrInvalidCSRFToken
+	}
+
+	return nil
+}
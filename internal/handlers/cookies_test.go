@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCookieTestContext(method string, cookies map[string]string, headers map[string]string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(method, "/api/v1/me", nil)
+	for name, value := range cookies {
+		ctx.Request.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	for name, value := range headers {
+		ctx.Request.Header.Set(name, value)
+	}
+	return ctx
+}
+
+func TestValidateCSRF(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name    string
+		cookie  string
+		header  string
+		wantErr bool
+	}{
+		{name: "matching cookie and header", cookie: token, header: token},
+		{name: "missing cookie", header: token, wantErr: true},
+		{name: "neither cookie nor header", wantErr: true},
+		{name: "missing header", cookie: token, wantErr: true},
+		{name: "mismatched header", cookie: token, header: token[:31] + "0", wantErr: true},
+		{name: "header is a prefix of the cookie", cookie: token, header: token[:16], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cookies := map[string]string{}
+			if tt.cookie != "" {
+				cookies[CSRFTokenCookieName] = tt.cookie
+			}
+			headers := map[string]string{}
+			if tt.header != "" {
+				headers[CSRFTokenHeaderKey] = tt.header
+			}
+
+			err := validateCSRF(newCookieTestContext(http.MethodPost, cookies, headers))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCSRFToken) {
+					t.Errorf("validateCSRF() error = %v, want ErrInvalidCSRFToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("validateCSRF() error = %v", err)
+			}
+		})
+	}
+}
+
+// A cookie-authenticated write without the CSRF header is refused before the
+// token is looked at.
+func TestAuthenticateRequestRejectsCookieWriteWithoutCSRF(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			ctx := newCookieTestContext(method, map[string]string{
+				AccessTokenCookieName: "access-token",
+				CSRFTokenCookieName:   "csrf-token",
+			}, nil)
+
+			_, _, status, err := authenticateRequest(ctx, nil, nil, nil, true, true)
+			if status != http.StatusForbidden || !errors.Is(err, ErrInvalidCSRFToken) {
+				t.Errorf("authenticateRequest() = %d, %v; want %d, ErrInvalidCSRFToken", status, err, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestIsStateChangingMethod(t *testing.T) {
+	tests := map[string]bool{
+		http.MethodGet:     false,
+		http.MethodHead:    false,
+		http.MethodOptions: false,
+		http.MethodPost:    true,
+		http.MethodPut:     true,
+		http.MethodPatch:   true,
+		http.MethodDelete:  true,
+	}
+
+	for method, want := range tests {
+		if got := isStateChangingMethod(method); got != want {
+			t.Errorf("isStateChangingMethod(%s) = %v, want %v", method, got, want)
+		}
+	}
+}
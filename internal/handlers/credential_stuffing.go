@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// detectCredentialStuffing runs the cross-account detectors after a failed
+// login and audits what they did. Like evaluateIPLockout it only logs
+// failures, the login already failed.
+func (h *HTTPHandler) detectCredentialStuffing(ctx *gin.Context, clientIP, userAgent, passwordFingerprint string) {
+	detections, err := h.credentialStuffingService.Evaluate(ctx, clientIP, userAgent, passwordFingerprint)
+	if err != nil {
+		fmt.Printf("Warning: failed to evaluate credential stuffing for %s: %v\n", clientIP, err)
+	}
+
+	for _, detection := range detections {
+		details := map[string]interface{}{
+			"detector": detection.Detector,
+			"reason":   detection.Reason,
+			"success":  true,
+		}
+		if detection.Captcha != nil {
+			details["captcha_scope"] = detection.Captcha.Scope
+			details["captcha_expires_at"] = detection.Captcha.ExpiresAt
+		}
+
+		var activityID int64
+		if detection.Activity != nil {
+			activityID = detection.Activity.ID
+		}
+		h.auditService.LogSystemAction(ctx, domain.AuditActionCredentialStuffing, domain.AuditResourceTypeAccount, activityID, ctx.Request, details)
+
+		for _, lockout := range detection.Lockouts {
+			h.auditService.LogSystemAction(ctx, domain.AuditActionIPLockout, domain.AuditResourceTypeIPRule, lockout.ID, ctx.Request, map[string]interface{}{
+				"network":    lockout.Network.String(),
+				"reason":     lockout.Reason,
+				"expires_at": lockout.ExpiresAt,
+				"success":    true,
+			})
+		}
+	}
+}
@@ -3,15 +3,22 @@ package handlers
 import "errors"
 
 var (
-	ErrInvalidCredentials  = errors.New("invalid credentials")
-	ErrUnauthorized        = errors.New("unauthorized")
-	ErrNotFound            = errors.New("not found")
-	ErrInternalServer      = errors.New("internal server error")
-	ErrBadRequest          = errors.New("bad request")
-	ErrUnprocessableEntity = errors.New("unprocessable entity")
-	ErrConflict            = errors.New("conflict")
-	ErrTooManyRequests     = errors.New("too many requests")
-	ErrNotImplemented      = errors.New("not implemented")
-	ErrInvalidToken        = errors.New("invalid token")
-	ErrExpiredToken        = errors.New("expired token")
+	ErrInvalidCredentials      = errors.New("invalid credentials")
+	ErrUnauthorized            = errors.New("unauthorized")
+	ErrForbidden               = errors.New("insufficient permissions")
+	ErrNotFound                = errors.New("not found")
+	ErrInternalServer          = errors.New("internal server error")
+	ErrBadRequest              = errors.New("bad request")
+	ErrUnprocessableEntity     = errors.New("unprocessable entity")
+	ErrConflict                = errors.New("conflict")
+	ErrTooManyRequests         = errors.New("too many requests")
+	ErrTooManyFailedLogins     = errors.New("too many failed logins, try again later")
+	ErrNotImplemented          = errors.New("not implemented")
+	ErrInvalidToken            = errors.New("invalid token")
+	ErrExpiredToken            = errors.New("expired token")
+	ErrSignedOutElsewhere      = errors.New("signed out elsewhere: this session was ended by a newer login")
+	ErrIPBlocked               = errors.New("access from your network is blocked")
+	ErrCaptchaRequired         = errors.New("captcha required")
+	ErrCaptchaUnavailable      = errors.New("captcha verification is unavailable, try again later")
+	ErrSessionStoreUnavailable = errors.New("sessions cannot be checked right now, try again later")
 )
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ForwardAuthUserIDHeader    = "X-User-Id"
+	ForwardAuthUserEmailHeader = "X-User-Email"
+	ForwardAuthUserRoleHeader  = "X-User-Role"
+)
+
+// VerifyAuth is a forward-auth endpoint for reverse proxies (Traefik
+// ForwardAuth, nginx auth_request) and Envoy's ext_authz HTTP service. It
+// accepts any method and sub-path, since Envoy forwards the original request
+// line, and answers 200 with identity headers for the upstream, 401 for a
+// missing or invalid credential, or 403 for a disabled account.
+//
+// The access token cookie is honoured without the CSRF header because the
+// guarded apps know nothing about it; the cookie's SameSite attribute is what
+// protects them from cross-site requests. The browser only sends the cookie to
+// the guarded apps when COOKIE_ACCESS_TOKEN_PATH and COOKIE_DOMAIN cover them.
+func (h *HTTPHandler) VerifyAuth(ctx *gin.Context) {
+	accessTokenCookie := h.config.CookieAuthEnabled && h.config.CookieAccessTokenEnabled
+
+	payload, _, status, err := authenticateRequest(ctx, h.tokenMaker, h.tokenBlacklist, h.sessionService, accessTokenCookie, false)
+	if err != nil {
+		if status == http.StatusUnauthorized {
+			ctx.Header("WWW-Authenticate", `Bearer realm="whoami"`)
+		}
+		ctx.AbortWithStatusJSON(status, errorResponse(err))
+		return
+	}
+
+	user, err := h.userService.GetUserByID(ctx, payload.UserID)
+	if err != nil {
+		ctx.Header("WWW-Authenticate", `Bearer realm="whoami"`)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ErrUnauthorized))
+		return
+	}
+
+	if !user.Active {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errors.New("account is deactivated")))
+		return
+	}
+
+	ctx.Header(ForwardAuthUserIDHeader, strconv.FormatInt(user.ID, 10))
+	ctx.Header(ForwardAuthUserEmailHeader, user.Email)
+	ctx.Header(ForwardAuthUserRoleHeader, user.Role)
+	ctx.Status(http.StatusOK)
+}
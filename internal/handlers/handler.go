@@ -14,22 +14,33 @@ type OAuthProviders struct {
 }
 
 type HTTPHandler struct {
-	userService             services.UserService
-	securityService         services.SecurityService
-	passwordSecurityService services.PasswordSecurityService
-	passwordResetService    services.PasswordResetService
-	emailService            services.EmailService
-	auditService            services.AuditService
-	userDevicesService      services.UserDevicesService
-	dataExportsService      services.DataExportsService
-	oauthService            services.OAuthService
-	oauthProviders          OAuthProviders
-	tokenMaker              security.TokenMaker
-	tokenBlacklist          security.TokenBlacklist
-	sessionService          services.SessionService
-	config                  *util.Config
-	rateLimiter             *security.RateLimiter
-	oauthTempService        services.OAuthTempService
+	userService               services.UserService
+	securityService           services.SecurityService
+	passwordSecurityService   services.PasswordSecurityService
+	passwordResetService      services.PasswordResetService
+	emailService              services.EmailService
+	auditService              services.AuditService
+	userDevicesService        services.UserDevicesService
+	dataExportsService        services.DataExportsService
+	oauthService              services.OAuthService
+	oauthProviders            OAuthProviders
+	tokenMaker                security.TokenMaker
+	tokenBlacklist            security.TokenBlacklist
+	sessionService            services.SessionService
+	config                    *util.Config
+	rateLimiter               *security.RateLimiter
+	oauthTempService          services.OAuthTempService
+	legacyMigrationService    services.LegacyMigrationService
+	applicationService        services.ApplicationService
+	ipAccessService           services.IPAccessService
+	captchaService            services.CaptchaService
+	geoIP                     *security.GeoIPResolver
+	loginAlertService         services.LoginAlertService
+	webhookService            services.WebhookService
+	securityDashboardService  services.SecurityDashboardService
+	credentialStuffingService services.CredentialStuffingService
+	suspiciousActivityService services.SuspiciousActivityService
+	auditChainService         services.AuditChainService
 }
 
 func NewHTTPHandler(
@@ -48,24 +59,46 @@ func NewHTTPHandler(
 	sessionService services.SessionService,
 	rateLimiter *security.RateLimiter,
 	oauthTempService services.OAuthTempService,
+	legacyMigrationService services.LegacyMigrationService,
+	applicationService services.ApplicationService,
+	ipAccessService services.IPAccessService,
+	captchaService services.CaptchaService,
+	geoIP *security.GeoIPResolver,
+	loginAlertService services.LoginAlertService,
+	webhookService services.WebhookService,
+	securityDashboardService services.SecurityDashboardService,
+	credentialStuffingService services.CredentialStuffingService,
+	suspiciousActivityService services.SuspiciousActivityService,
+	auditChainService services.AuditChainService,
 	config util.Config,
 ) *HTTPHandler {
 	return &HTTPHandler{
-		userService:             userService,
-		securityService:         securityService,
-		passwordSecurityService: passwordSecurityService,
-		passwordResetService:    passwordResetService,
-		emailService:            emailService,
-		auditService:            auditService,
-		userDevicesService:      userDevicesService,
-		dataExportsService:      dataExportsService,
-		oauthService:            oauthService,
-		oauthProviders:          oauthProviders,
-		tokenMaker:              tokenMaker,
-		tokenBlacklist:          tokenBlacklist,
-		sessionService:          sessionService,
-		config:                  &config,
-		rateLimiter:             rateLimiter,
-		oauthTempService:        oauthTempService,
+		userService:               userService,
+		securityService:           securityService,
+		passwordSecurityService:   passwordSecurityService,
+		passwordResetService:      passwordResetService,
+		emailService:              emailService,
+		auditService:              auditService,
+		userDevicesService:        userDevicesService,
+		dataExportsService:        dataExportsService,
+		oauthService:              oauthService,
+		oauthProviders:            oauthProviders,
+		tokenMaker:                tokenMaker,
+		tokenBlacklist:            tokenBlacklist,
+		sessionService:            sessionService,
+		config:                    &config,
+		rateLimiter:               rateLimiter,
+		oauthTempService:          oauthTempService,
+		legacyMigrationService:    legacyMigrationService,
+		applicationService:        applicationService,
+		ipAccessService:           ipAccessService,
+		captchaService:            captchaService,
+		geoIP:                     geoIP,
+		loginAlertService:         loginAlertService,
+		webhookService:            webhookService,
+		securityDashboardService:  securityDashboardService,
+		credentialStuffingService: credentialStuffingService,
+		suspiciousActivityService: suspiciousActivityService,
+		auditChainService:         auditChainService,
 	}
 }
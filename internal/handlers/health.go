@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/security"
 )
 
 func (h *HTTPHandler) HealthCheck(ctx *gin.Context) {
@@ -21,10 +22,25 @@ func (h *HTTPHandler) HealthCheck(ctx *gin.Context) {
 		services["redis"] = "not configured"
 	}
 
+	status := "healthy"
+	var rateLimiterStatus *security.RateLimiterStatus
+	if h.rateLimiter != nil {
+		limiterStatus := h.rateLimiter.Status()
+		rateLimiterStatus = &limiterStatus
+		services["rate_limiter"] = "healthy"
+		// Still 200 so a Redis outage does not pull every instance out of
+		// the load balancer; the failure modes keep serving traffic
+		if limiterStatus.Degraded {
+			services["rate_limiter"] = "degraded"
+			status = "degraded"
+		}
+	}
+
 	response := healthResponse{
-		Status:    "healthy",
-		Services:  services,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:      status,
+		Services:    services,
+		RateLimiter: rateLimiterStatus,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
 	}
 
 	ctx.JSON(http.StatusOK, response)
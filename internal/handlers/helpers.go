@@ -1,5 +1,28 @@
 package handlers
 
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
 type UriID struct {
 	ID string `uri:"id" binding:"required"`
 }
+
+// extractDeviceInfo is security.ExtractDeviceInfo with the client's location
+// filled in, when a GeoIP database is configured.
+func (h *HTTPHandler) extractDeviceInfo(ctx *gin.Context) *security.DeviceInfo {
+	deviceInfo := security.ExtractDeviceInfo(ctx)
+	deviceInfo.Location = h.geoIP.Lookup(deviceInfo.IPAddress)
+	return deviceInfo
+}
+
+// publishUserEvent sends a webhook event about userID to the endpoints
+// subscribed to eventType.
+func (h *HTTPHandler) publishUserEvent(ctx *gin.Context, eventType string, userID int64, data map[string]interface{}) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["user_id"] = userID
+	h.webhookService.Publish(ctx, eventType, data)
+}
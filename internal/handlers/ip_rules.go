@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/security"
+	"github.com/m1thrandir225/whoami/internal/services"
+)
+
+func (h *HTTPHandler) CreateIPRule(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	var req createIPRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	network, err := security.ParseNetwork(strings.TrimSpace(req.Network))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rule, err := h.ipAccessService.CreateRule(ctx, domain.CreateIPAccessRuleAction{
+		Network:   network,
+		RuleType:  domain.IPAccessRuleType(req.RuleType),
+		Reason:    req.Reason,
+		CreatedBy: &payload.UserID,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		ctx.JSON(ipRuleErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionIPRuleCreate, domain.AuditResourceTypeIPRule, rule.ID, ctx.Request, map[string]interface{}{
+		"network":    rule.Network.String(),
+		"rule_type":  rule.RuleType,
+		"reason":     rule.Reason,
+		"expires_at": rule.ExpiresAt,
+		"success":    true,
+	})
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"rule": rule,
+	})
+}
+
+func (h *HTTPHandler) ListIPRules(ctx *gin.Context) {
+	rules, err := h.ipAccessService.ListRules(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+	})
+}
+
+func (h *HTTPHandler) DeleteIPRule(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	ruleID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rule, err := h.ipAccessService.DeleteRule(ctx, ruleID)
+	if err != nil {
+		ctx.JSON(ipRuleErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionIPRuleDelete, domain.AuditResourceTypeIPRule, rule.ID, ctx.Request, map[string]interface{}{
+		"network":   rule.Network.String(),
+		"rule_type": rule.RuleType,
+		"success":   true,
+	})
+
+	ctx.JSON(http.StatusOK, messageResponse("IP rule deleted successfully"))
+}
+
+// evaluateIPLockout runs after a failed login and audits any lockout it
+// causes. Failures are logged rather than surfaced, the login already failed.
+func (h *HTTPHandler) evaluateIPLockout(ctx *gin.Context, clientIP string) {
+	lockouts, err := h.ipAccessService.EvaluateFailedLogins(ctx, clientIP)
+	if err != nil {
+		fmt.Printf("Warning: failed to evaluate IP lockout for %s: %v\n", clientIP, err)
+	}
+
+	for _, lockout := range lockouts {
+		h.auditService.LogSystemAction(ctx, domain.AuditActionIPLockout, domain.AuditResourceTypeIPRule, lockout.ID, ctx.Request, map[string]interface{}{
+			"network":    lockout.Network.String(),
+			"reason":     lockout.Reason,
+			"expires_at": lockout.ExpiresAt,
+			"success":    true,
+		})
+	}
+}
+
+func ipRuleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidIPAccessRule):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/security"
+	"github.com/m1thrandir225/whoami/internal/services"
+)
+
+// signInAlertTimeout bounds the lookups and mail for one sign-in alert.
+const signInAlertTimeout = 30 * time.Second
+
+// notifySignIn emails the user about a sign-in from a new device or location.
+// It runs after the session exists so the alert can name it, and in the
+// background so a slow mail server neither holds up nor fails the sign-in.
+func (h *HTTPHandler) notifySignIn(ctx *gin.Context, user *domain.User, accessToken string, deviceInfo *security.DeviceInfo, newDevice, newLocation bool) {
+	if !newDevice && !newLocation {
+		return
+	}
+
+	go func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, signInAlertTimeout)
+		defer cancel()
+
+		var sessionID string
+		if session, err := h.sessionService.GetSession(ctx, accessToken); err == nil {
+			sessionID = session.ID
+		} else {
+			fmt.Printf("Warning: failed to find session for sign-in alert: %v\n", err)
+		}
+
+		if err := h.loginAlertService.NotifySignIn(ctx, user, sessionID, deviceInfo, newDevice, newLocation); err != nil {
+			fmt.Printf("Warning: failed to send sign-in alert to user %d: %v\n", user.ID, err)
+		}
+	}(context.WithoutCancel(ctx.Request.Context()))
+}
+
+// ReportSignIn serves the "this wasn't me" link from the new sign-in email.
+func (h *HTTPHandler) ReportSignIn(ctx *gin.Context) {
+	var req reportSignInRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	alert, lockout, err := h.loginAlertService.ReportSignIn(ctx, req.Token, security.GetClientIP(ctx), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLoginAlertToken) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, alert.UserID, domain.AuditActionSignInReported, domain.AuditResourceTypeAccount, alert.UserID, ctx.Request, map[string]interface{}{
+		"alert_id":    alert.ID,
+		"session_id":  alert.SessionID,
+		"device_name": alert.DeviceName,
+		"ip_address":  alert.IPAddress,
+		"success":     true,
+	})
+
+	if lockout != nil {
+		h.auditService.LogUserAction(ctx, alert.UserID, domain.AuditActionAccountLockout, domain.AuditResourceTypeAccount, lockout.ID, ctx.Request, map[string]interface{}{
+			"level":     lockout.Level,
+			"permanent": lockout.Permanent,
+			"reason":    lockout.Reason,
+			"success":   true,
+		})
+		h.publishUserEvent(ctx, domain.WebhookEventUserLockedOut, alert.UserID, map[string]interface{}{
+			"level":      lockout.Level,
+			"permanent":  lockout.Permanent,
+			"expires_at": lockout.ExpiresAt,
+			"reason":     lockout.Reason,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, messageResponse("The sign-in was reported. We signed it out, locked your account and emailed you a link to reset your password"))
+}
@@ -1,67 +1,177 @@
 package handlers
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/metrics"
 	"github.com/m1thrandir225/whoami/internal/security"
+	"github.com/m1thrandir225/whoami/internal/services"
 )
 
 const (
 	AuthorizationHeaderKey  = "Authorization"
 	AuthorizationTypeBearer = "bearer"
 	AuthorizationPayloadKey = "authorization_payload"
+	AccessTokenKey          = "access_token"
 )
 
-func AuthMiddleware(tokenMaker security.TokenMaker, tokenBlacklist security.TokenBlacklist) gin.HandlerFunc {
+// AuthMiddleware authenticates requests with a bearer token. When
+// accessTokenCookie is set, a request without an Authorization header may use
+// the access token cookie instead, and must then pass the CSRF check on
+// state-changing methods.
+func AuthMiddleware(tokenMaker security.TokenMaker, tokenBlacklist security.TokenBlacklist, sessionService services.SessionService, accessTokenCookie bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authorizationHeader := ctx.GetHeader(AuthorizationHeaderKey)
-		if len(authorizationHeader) == 0 {
-			err := errors.New("authorization header is required")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		payload, accessToken, status, err := authenticateRequest(ctx, tokenMaker, tokenBlacklist, sessionService, accessTokenCookie, true)
+		if err != nil {
+			ctx.AbortWithStatusJSON(status, errorResponse(err))
 			return
 		}
 
-		fields := strings.Fields(authorizationHeader)
-		if len(fields) < 2 {
-			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
-			return
-		}
+		ctx.Set(AuthorizationPayloadKey, payload)
+		ctx.Set(AccessTokenKey, accessToken)
+		ctx.Next()
+	}
+}
 
-		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != AuthorizationTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
+// RequirePermission must run after AuthMiddleware. It rejects callers whose
+// role does not grant the permission or whose account is deactivated.
+func RequirePermission(userService services.UserService, permission domain.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload, err := GetCurrentUserPayload(ctx)
+		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
 
-		accessToken := fields[1]
-		isBlacklisted, err := tokenBlacklist.IsTokenBlacklisted(ctx, accessToken)
+		user, err := userService.GetUserByID(ctx, payload.UserID)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ErrUnauthorized))
 			return
 		}
 
-		if isBlacklisted {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errors.New("token is blacklisted")))
+		if !user.Active || !domain.HasPermission(user.Role, permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(ErrForbidden))
 			return
 		}
 
-		payload, err := tokenMaker.VerifyToken(accessToken)
-		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Next()
+	}
+}
+
+// MetricsAuthMiddleware lets through only requests bearing token.
+func MetricsAuthMiddleware(token string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fields := strings.Fields(ctx.GetHeader(AuthorizationHeaderKey))
+		if len(fields) != 2 || strings.ToLower(fields[0]) != AuthorizationTypeBearer ||
+			subtle.ConstantTimeCompare([]byte(fields[1]), []byte(token)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ErrUnauthorized))
 			return
 		}
 
-		ctx.Set(AuthorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
 
+// IPAccessMiddleware rejects requests from networks on the deny list or under
+// an automatic lockout, unless an allow rule covers them. It relies on the
+// client address resolved by security.ClientIPResolver.
+func IPAccessMiddleware(ipAccessService services.IPAccessService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		rule := ipAccessService.CheckIP(security.GetClientIP(ctx))
+		if rule == nil {
+			ctx.Next()
+			return
+		}
+
+		metrics.IPAccessBlocked.WithLabelValues(string(rule.RuleType)).Inc()
+		if rule.ExpiresAt != nil {
+			retryAfter := int64(math.Ceil(time.Until(*rule.ExpiresAt).Seconds()))
+			ctx.Header("Retry-After", strconv.FormatInt(max(retryAfter, 1), 10))
+		}
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(ErrIPBlocked))
+	}
+}
+
+// authenticateRequest resolves and validates the caller's access token. On
+// failure it returns the HTTP status the caller should respond with.
+func authenticateRequest(
+	ctx *gin.Context,
+	tokenMaker security.TokenMaker,
+	tokenBlacklist security.TokenBlacklist,
+	sessionService services.SessionService,
+	accessTokenCookie bool,
+	checkCSRF bool,
+) (*security.Payload, string, int, error) {
+	authorizationHeader := ctx.GetHeader(AuthorizationHeaderKey)
+	if len(authorizationHeader) == 0 && accessTokenCookie {
+		if cookieToken, err := ctx.Cookie(AccessTokenCookieName); err == nil && cookieToken != "" {
+			if checkCSRF && isStateChangingMethod(ctx.Request.Method) {
+				if err := validateCSRF(ctx); err != nil {
+					return nil, "", http.StatusForbidden, err
+				}
+			}
+			authorizationHeader = AuthorizationTypeBearer + " " + cookieToken
+		}
+	}
+
+	if len(authorizationHeader) == 0 {
+		return nil, "", http.StatusUnauthorized, errors.New("authorization header is required")
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		return nil, "", http.StatusUnauthorized, errors.New("invalid authorization header format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != AuthorizationTypeBearer {
+		return nil, "", http.StatusUnauthorized, fmt.Errorf("unsupported authorization type %s", authorizationType)
+	}
+
+	accessToken := fields[1]
+	isBlacklisted, err := tokenBlacklist.IsTokenBlacklisted(ctx, accessToken)
+	if err != nil {
+		log.Printf("failed to check token blacklist: %v", err)
+		return nil, "", http.StatusServiceUnavailable, ErrSessionStoreUnavailable
+	}
+
+	if isBlacklisted {
+		err := errors.New("token is blacklisted")
+		if reason, _ := tokenBlacklist.GetBlacklistReason(ctx, accessToken); reason == security.BlacklistReasonSignedOutElsewhere {
+			err = ErrSignedOutElsewhere
+		}
+		return nil, "", http.StatusUnauthorized, err
+	}
+
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, "", http.StatusUnauthorized, err
+	}
+
+	// The session may have hit its idle or absolute timeout before the token
+	// did. A store outage is not the caller's fault and must not sign every
+	// user out.
+	if err := sessionService.UpdateSessionActivity(ctx, accessToken); err != nil {
+		if errors.Is(err, services.ErrSessionExpired) || errors.Is(err, services.ErrSessionNotFound) {
+			return nil, "", http.StatusUnauthorized, errors.New("session expired or revoked")
+		}
+		log.Printf("failed to check session: %v", err)
+		return nil, "", http.StatusServiceUnavailable, ErrSessionStoreUnavailable
+	}
+
+	return payload, accessToken, http.StatusOK, nil
+}
+
 func GetCurrentUserPayload(ctx *gin.Context) (*security.Payload, error) {
 	payload, exists := ctx.Get(AuthorizationPayloadKey)
 	if !exists {
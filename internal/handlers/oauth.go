@@ -9,7 +9,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/m1thrandir225/whoami/internal/domain"
 	"github.com/m1thrandir225/whoami/internal/oauth"
-	"github.com/m1thrandir225/whoami/internal/security"
 	"github.com/m1thrandir225/whoami/internal/services"
 )
 
@@ -143,8 +142,8 @@ func (h *HTTPHandler) OAuthCallback(ctx *gin.Context) {
 		return
 	}
 
-	deviceInfo := security.ExtractDeviceInfo(ctx)
-	device, err := h.userDevicesService.GetOrCreateDevice(ctx, user.ID, deviceInfo)
+	deviceInfo := h.extractDeviceInfo(ctx)
+	device, newDevice, err := h.userDevicesService.GetOrCreateDevice(ctx, user.ID, deviceInfo)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		device = &domain.UserDevice{
@@ -153,20 +152,18 @@ func (h *HTTPHandler) OAuthCallback(ctx *gin.Context) {
 			DeviceType: deviceInfo.DeviceType,
 		}
 	}
+	h.setDeviceCookie(ctx, deviceInfo)
 
-	deviceInfoMap := map[string]string{
-		"device_id":   deviceInfo.DeviceID,
-		"device_name": deviceInfo.DeviceName,
-		"device_type": deviceInfo.DeviceType,
-		"user_agent":  deviceInfo.UserAgent,
-		"ip_address":  deviceInfo.IPAddress,
-	}
+	deviceInfoMap := deviceInfo.SessionInfo()
 
-	if err := h.sessionService.CreateSession(ctx, user.ID, accessToken, refreshToken, deviceInfoMap); err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	if err := h.createSession(ctx, user, accessToken, refreshToken, deviceInfoMap); err != nil {
+		ctx.JSON(sessionErrorStatus(err), errorResponse(err))
 		return
 	}
 
+	// OAuth sign-ins keep no login history to compare locations against
+	h.notifySignIn(ctx, user, accessToken, deviceInfo, newDevice, false)
+
 	// Log successful OAuth authentication
 	h.auditService.LogUserAction(ctx, user.ID, "oauth_authentication", domain.AuditResourceTypeUser, user.ID, ctx.Request, map[string]interface{}{
 		"provider":      provider,
@@ -242,6 +239,14 @@ func (h *HTTPHandler) ExchangeTempOAuthToken(ctx *gin.Context) {
 		Device:                device,
 	}
 
+	accessInCookie, refreshInCookie := h.setAuthCookies(ctx, authData.AccessToken, authData.AccessTokenExpiresAt, authData.RefreshToken, authData.RefreshTokenExpiresAt)
+	if accessInCookie {
+		response.AccessToken = ""
+	}
+	if refreshInCookie {
+		response.RefreshToken = ""
+	}
+
 	ctx.JSON(http.StatusOK, response)
 }
 
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
 )
 
 func (h *HTTPHandler) RequestPasswordReset(ctx *gin.Context) {
@@ -13,6 +14,10 @@ func (h *HTTPHandler) RequestPasswordReset(ctx *gin.Context) {
 		return
 	}
 
+	if !h.checkCaptcha(ctx, "password_reset", req.Email, "", req.CaptchaToken) {
+		return
+	}
+
 	// Request password reset (this will send email if user exists)
 	if err := h.passwordResetService.RequestPasswordReset(ctx, req.Email); err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -31,11 +36,16 @@ func (h *HTTPHandler) ResetPassword(ctx *gin.Context) {
 	}
 
 	// Reset the password
-	if err := h.passwordResetService.ResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+	reset, err := h.passwordResetService.ResetPassword(ctx, req.Token, req.NewPassword)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
+	h.publishUserEvent(ctx, domain.WebhookEventUserPasswordChanged, reset.UserID, map[string]interface{}{
+		"method": "reset",
+	})
+
 	ctx.JSON(http.StatusOK, messageResponse("Password reset successfully"))
 }
 
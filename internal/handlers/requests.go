@@ -1,17 +1,23 @@
 package handlers
 
-import "github.com/m1thrandir225/whoami/internal/domain"
+import (
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
 
 type registerRequest struct {
 	Email           string                  `json:"email"`
 	Password        string                  `json:"password"`
 	Username        *string                 `json:"username"`
 	PrivacySettings *domain.PrivacySettings `json:"privacy_settings"`
+	CaptchaToken    string                  `json:"captcha_token"`
 }
 
 type loginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email        string `json:"email"`
+	Password     string `json:"password"`
+	CaptchaToken string `json:"captcha_token"`
 }
 
 type updateUserRequest struct {
@@ -28,16 +34,13 @@ type updatePasswordRequest struct {
 	NewPassword     string `json:"new_password"`
 }
 
-type resolveSuspiciousActivityRequest struct {
-	ActivityID int64 `json:"activity_id" binding:"required"`
-}
-
 type verifyEmailRequest struct {
 	Token string `json:"token" binding:"required"`
 }
 
 type requestPasswordResetRequest struct {
-	Email string `json:"email" binding:"required"`
+	Email        string `json:"email" binding:"required"`
+	CaptchaToken string `json:"captcha_token"`
 }
 
 type resetPasswordRequest struct {
@@ -71,3 +74,58 @@ type verifyResetOTPRequest struct {
 	Token string `json:"token" binding:"required"`
 	OTP   string `json:"otp" binding:"required"`
 }
+
+type unlockAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type reportSignInRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type createApplicationRequest struct {
+	Name                 string `json:"name" binding:"required"`
+	BackchannelLogoutURL string `json:"backchannel_logout_url"`
+}
+
+type updateApplicationRequest struct {
+	Name                 string `json:"name" binding:"required"`
+	BackchannelLogoutURL string `json:"backchannel_logout_url"`
+	Active               *bool  `json:"active" binding:"required"`
+}
+
+type createWebhookEndpointRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description string   `json:"description" binding:"max=200"`
+	EventTypes  []string `json:"event_types" binding:"required"`
+}
+
+type updateWebhookEndpointRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description string   `json:"description" binding:"max=200"`
+	EventTypes  []string `json:"event_types" binding:"required"`
+	Active      *bool    `json:"active" binding:"required"`
+}
+
+type createIPRuleRequest struct {
+	Network   string     `json:"network" binding:"required"`
+	RuleType  string     `json:"rule_type" binding:"required,oneof=allow deny"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type assignSuspiciousActivityRequest struct {
+	// AssigneeID unassigns the activity when null
+	AssigneeID *int64 `json:"assignee_id"`
+}
+
+type reviewSuspiciousActivityRequest struct {
+	Status             string `json:"status" binding:"required"`
+	Note               string `json:"note" binding:"max=2000"`
+	RevokeSessions     bool   `json:"revoke_sessions"`
+	ForcePasswordReset bool   `json:"force_password_reset"`
+}
+
+type addSuspiciousActivityNoteRequest struct {
+	Note string `json:"note" binding:"required,max=2000"`
+}
@@ -5,30 +5,31 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/security"
 )
 
 type registerResponse struct {
 	User                  domain.User        `json:"user"`
-	AccessToken           string             `json:"access_token"`
+	AccessToken           string             `json:"access_token,omitempty"`
 	AccessTokenExpiresAt  time.Time          `json:"access_token_expires_at"`
-	RefreshToken          string             `json:"refresh_token"`
+	RefreshToken          string             `json:"refresh_token,omitempty"`
 	RefreshTokenExpiresAt time.Time          `json:"refresh_token_expires_at"`
 	Device                *domain.UserDevice `json:"device"`
 }
 
 type loginResponse struct {
 	User                  domain.User        `json:"user"`
-	AccessToken           string             `json:"access_token"`
+	AccessToken           string             `json:"access_token,omitempty"`
 	AccessTokenExpiresAt  time.Time          `json:"access_token_expires_at"`
-	RefreshToken          string             `json:"refresh_token"`
+	RefreshToken          string             `json:"refresh_token,omitempty"`
 	RefreshTokenExpiresAt time.Time          `json:"refresh_token_expires_at"`
 	Device                *domain.UserDevice `json:"device"`
 }
 
 type refreshTokenResponse struct {
-	AccessToken           string    `json:"access_token"`
+	AccessToken           string    `json:"access_token,omitempty"`
 	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
-	RefreshToken          string    `json:"refresh_token"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
 	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
@@ -41,7 +42,8 @@ func messageResponse(message string) gin.H {
 }
 
 type healthResponse struct {
-	Status    string            `json:"status"`
-	Services  map[string]string `json:"services"`
-	Timestamp string            `json:"timestamp"`
+	Status      string                      `json:"status"`
+	Services    map[string]string           `json:"services"`
+	RateLimiter *security.RateLimiterStatus `json:"rate_limiter,omitempty"`
+	Timestamp   string                      `json:"timestamp"`
 }
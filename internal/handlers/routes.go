@@ -2,26 +2,31 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/m1thrandir225/whoami/internal/security"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func SetupRoutes(router *gin.Engine, handler *HTTPHandler) {
+	router.Use(IPAccessMiddleware(handler.ipAccessService))
+
 	router.GET("/health", handler.HealthCheck)
+	// Metrics are public only behind their token, see METRICS_ADDRESS for
+	// the internal listener
+	if handler.config.MetricsToken != "" {
+		router.GET("/metrics", MetricsAuthMiddleware(handler.config.MetricsToken), gin.WrapH(promhttp.Handler()))
+	}
 
 	apiV1 := router.Group("/api/v1")
 	{
-		apiV1.POST("/register",
-			handler.rateLimiter.RateLimitMiddleware(security.RegistrationRateLimit),
-			handler.Register)
-		apiV1.POST("/login",
-			handler.rateLimiter.RateLimitMiddleware(security.AuthRateLimit),
-			handler.Login)
-		apiV1.POST("/refresh",
-			handler.rateLimiter.RateLimitMiddleware(security.AuthRateLimit),
-			handler.RefreshToken)
+		// Rate limit policies are matched against the route pattern, see
+		// security.DefaultRateLimitPolicies and RATE_LIMIT_POLICIES_FILE
+		apiV1.Use(handler.rateLimiter.PolicyMiddleware())
+
+		apiV1.POST("/register", handler.Register)
+		apiV1.POST("/login", handler.Login)
+		apiV1.POST("/refresh", handler.RefreshToken)
 
 		passwordReset := apiV1.Group("/password-reset")
-		passwordReset.Use(handler.rateLimiter.RateLimitMiddleware(security.PasswordResetRateLimit))
 		{
 			passwordReset.POST("/request", handler.RequestPasswordReset)
 			passwordReset.POST("/verify", handler.VerifyResetToken)
@@ -29,6 +34,10 @@ func SetupRoutes(router *gin.Engine, handler *HTTPHandler) {
 			passwordReset.POST("/reset", handler.ResetPassword)
 		}
 
+		// Forward-auth for reverse proxies; Envoy ext_authz appends the original path
+		apiV1.Any("/auth/verify", handler.VerifyAuth)
+		apiV1.Any("/auth/verify/*path", handler.VerifyAuth)
+
 		oauth := apiV1.Group("/oauth")
 		{
 			oauth.GET("/login/:provider", handler.OAuthLogin)
@@ -37,8 +46,8 @@ func SetupRoutes(router *gin.Engine, handler *HTTPHandler) {
 		}
 
 		protected := apiV1.Group("/")
-		protected.Use(AuthMiddleware(handler.tokenMaker, handler.tokenBlacklist))
-		protected.Use(handler.rateLimiter.UserRateLimitMiddleware(security.DefaultRateLimit))
+		protected.Use(AuthMiddleware(handler.tokenMaker, handler.tokenBlacklist, handler.sessionService, handler.config.CookieAuthEnabled && handler.config.CookieAccessTokenEnabled))
+		protected.Use(handler.rateLimiter.UserPolicyMiddleware())
 		{
 			protected.GET("/me", handler.GetCurrentUser)
 			protected.POST("/logout", handler.Logout)
@@ -63,20 +72,13 @@ func SetupRoutes(router *gin.Engine, handler *HTTPHandler) {
 			security := protected.Group("/security")
 			{
 				security.GET("/activities", handler.GetSuspiciousActivities)
-				security.POST("/activities/resolve", handler.ResolveSuspiciousActivity)
+				security.POST("/activities/:id/acknowledge", handler.AcknowledgeSuspiciousActivity)
 				security.POST("/cleanup", handler.CleanupExpiredLockouts)
 			}
 
 			audit := protected.Group("/audit")
 			{
-				audit.GET("/user/:user_id", handler.GetAuditLogsByUserID)
-				audit.GET("/action/:action", handler.GetAuditLogsByAction)
-				audit.GET("/resource/:resource_type", handler.GetAuditLogsByResourceType)
-				audit.GET("/resource/:resource_type/:resource_id", handler.GetAuditLogsByResourceID)
-				audit.GET("/ip/:ip", handler.GetAuditLogsByIP)
-				audit.GET("/date-range", handler.GetAuditLogsByDateRange)
-				audit.GET("/recent", handler.GetRecentAuditLogs)
-				audit.POST("/cleanup", handler.CleanupOldAuditLogs)
+				audit.GET("/logs", handler.SearchAuditLogs)
 			}
 
 			// User Devices routes
@@ -106,15 +108,90 @@ func SetupRoutes(router *gin.Engine, handler *HTTPHandler) {
 				oauth.GET("/accounts", handler.GetOAuthAccounts)
 				oauth.DELETE("/unlink/:provider", handler.UnlinkOAuthAccount)
 			}
+
+			admin := protected.Group("/admin")
+			admin.Use(RequirePermission(handler.userService, domain.PermissionSecurityManage))
+			{
+				applications := admin.Group("/applications")
+				{
+					applications.POST("", handler.CreateApplication)
+					applications.GET("", handler.ListApplications)
+					applications.GET("/:id", handler.GetApplication)
+					applications.PUT("/:id", handler.UpdateApplication)
+					applications.DELETE("/:id", handler.DeleteApplication)
+					applications.GET("/:id/logout-deliveries", handler.GetApplicationLogoutDeliveries)
+				}
+
+				webhooks := admin.Group("/webhooks")
+				{
+					webhooks.POST("", handler.CreateWebhookEndpoint)
+					webhooks.GET("", handler.ListWebhookEndpoints)
+					webhooks.GET("/:id", handler.GetWebhookEndpoint)
+					webhooks.PUT("/:id", handler.UpdateWebhookEndpoint)
+					webhooks.DELETE("/:id", handler.DeleteWebhookEndpoint)
+					webhooks.POST("/:id/rotate-secret", handler.RotateWebhookSecret)
+					webhooks.GET("/:id/deliveries", handler.GetWebhookDeliveries)
+				}
+
+				webhookDeliveries := admin.Group("/webhook-deliveries")
+				{
+					webhookDeliveries.GET("/:id", handler.GetWebhookDelivery)
+					webhookDeliveries.POST("/:id/redeliver", handler.RedeliverWebhook)
+				}
+
+				users := admin.Group("/users")
+				{
+					users.POST("/:id/unlock", handler.UnlockUserAccount)
+					users.GET("/:id/lockouts", handler.GetUserLockoutHistory)
+				}
+
+				ipRules := admin.Group("/ip-rules")
+				{
+					ipRules.POST("", handler.CreateIPRule)
+					ipRules.GET("", handler.ListIPRules)
+					ipRules.DELETE("/:id", handler.DeleteIPRule)
+				}
+
+				suspiciousActivities := admin.Group("/suspicious-activities")
+				{
+					suspiciousActivities.GET("", handler.ListSuspiciousActivityCases)
+					suspiciousActivities.GET("/:id", handler.GetSuspiciousActivityCase)
+					suspiciousActivities.PUT("/:id/assignee", handler.AssignSuspiciousActivity)
+					suspiciousActivities.POST("/:id/status", handler.ReviewSuspiciousActivity)
+					suspiciousActivities.POST("/:id/notes", handler.AddSuspiciousActivityNote)
+				}
+
+				// Aggregates for the security dashboard, filtered by ?from=&to=&bucket=
+				dashboard := admin.Group("/dashboard")
+				{
+					dashboard.GET("/summary", handler.GetSecurityDashboardSummary)
+					dashboard.GET("/logins", handler.GetSecurityDashboardLogins)
+					dashboard.GET("/top-offenders", handler.GetSecurityDashboardTopOffenders)
+					dashboard.GET("/lockouts", handler.GetSecurityDashboardLockouts)
+					dashboard.GET("/suspicious-activities", handler.GetSecurityDashboardSuspiciousActivities)
+					dashboard.GET("/registrations", handler.GetSecurityDashboardRegistrations)
+				}
+
+				auditChain := admin.Group("/audit")
+				{
+					auditChain.GET("/verify", handler.VerifyAuditLogChain)
+					auditChain.POST("/checkpoints", handler.CreateAuditLogCheckpoint)
+					auditChain.POST("/cleanup", handler.CleanupOldAuditLogs)
+				}
+			}
 		}
 
 		email := apiV1.Group("/email")
-		email.Use(handler.rateLimiter.RateLimitMiddleware(security.PasswordResetRateLimit))
 		{
 			email.POST("/verify", handler.VerifyEmail)
 			email.POST("/resend", handler.ResendVerificationEmail)
 		}
 
+		// Unlock link from the permanent lockout email
+		apiV1.POST("/account/unlock", handler.UnlockAccountWithToken)
+		// "This wasn't me" link from the new sign-in email
+		apiV1.POST("/account/secure", handler.ReportSignIn)
+
 		// DDevelopment only routes
 		if handler.config.Environment == "development" {
 			dev := apiV1.Group("/dev")
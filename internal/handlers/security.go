@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/m1thrandir225/whoami/internal/domain"
@@ -33,34 +34,35 @@ func (h *HTTPHandler) GetSuspiciousActivities(ctx *gin.Context) {
 	})
 }
 
-func (h *HTTPHandler) ResolveSuspiciousActivity(ctx *gin.Context) {
-	_, err := GetCurrentUserPayload(ctx)
+// AcknowledgeSuspiciousActivity lets users mark an open activity on their
+// own account as seen.
+func (h *HTTPHandler) AcknowledgeSuspiciousActivity(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	var req resolveSuspiciousActivityRequest
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	activityID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	err = h.securityService.ResolveSuspiciousActivity(ctx, req.ActivityID)
+	activity, err := h.suspiciousActivityService.Acknowledge(ctx, payload.UserID, activityID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(suspiciousActivityErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	// Log suspicious activity resolution
-	h.auditService.LogSystemAction(ctx, domain.AuditActionSuspiciousActivity, domain.AuditResourceTypeAccount, req.ActivityID, ctx.Request, map[string]interface{}{
-		"activity_id": req.ActivityID,
-		"action":      "resolve",
-		"success":     true,
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionSuspiciousActivity, domain.AuditResourceTypeActivity, activity.ID, ctx.Request, map[string]interface{}{
+		"action":  "acknowledge",
+		"success": true,
 	})
 
-	ctx.JSON(http.StatusOK, messageResponse("Activity resolved successfully"))
+	ctx.JSON(http.StatusOK, gin.H{
+		"activity": activity,
+	})
 }
 
 func (h *HTTPHandler) CleanupExpiredLockouts(ctx *gin.Context) {
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// dashboardRange reads the from, to and bucket query parameters. It writes
+// the error response itself and returns false when they are invalid.
+func (h *HTTPHandler) dashboardRange(ctx *gin.Context) (domain.DashboardRange, bool) {
+	r, err := h.securityDashboardService.ParseRange(ctx.Query("from"), ctx.Query("to"), ctx.Query("bucket"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return r, false
+	}
+	return r, true
+}
+
+func (h *HTTPHandler) GetSecurityDashboardSummary(ctx *gin.Context) {
+	r, ok := h.dashboardRange(ctx)
+	if !ok {
+		return
+	}
+
+	summary, err := h.securityDashboardService.GetSummary(ctx, r)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+	})
+}
+
+func (h *HTTPHandler) GetSecurityDashboardLogins(ctx *gin.Context) {
+	r, ok := h.dashboardRange(ctx)
+	if !ok {
+		return
+	}
+
+	series, totals, err := h.securityDashboardService.GetLoginSeries(ctx, r)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"range":  r,
+		"series": series,
+		"totals": totals,
+	})
+}
+
+func (h *HTTPHandler) GetSecurityDashboardTopOffenders(ctx *gin.Context) {
+	r, ok := h.dashboardRange(ctx)
+	if !ok {
+		return
+	}
+
+	limit := int32(10) // Default limit
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		l, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || l < 1 || l > 100 {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("limit must be between 1 and 100")))
+			return
+		}
+		limit = int32(l)
+	}
+
+	ips, emails, err := h.securityDashboardService.GetTopOffenders(ctx, r, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"range":  r,
+		"ips":    ips,
+		"emails": emails,
+	})
+}
+
+func (h *HTTPHandler) GetSecurityDashboardLockouts(ctx *gin.Context) {
+	r, ok := h.dashboardRange(ctx)
+	if !ok {
+		return
+	}
+
+	series, active, err := h.securityDashboardService.GetLockoutSeries(ctx, r)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"range":  r,
+		"series": series,
+		"active": active,
+	})
+}
+
+func (h *HTTPHandler) GetSecurityDashboardSuspiciousActivities(ctx *gin.Context) {
+	r, ok := h.dashboardRange(ctx)
+	if !ok {
+		return
+	}
+
+	series, unresolved, err := h.securityDashboardService.GetSuspiciousActivitySeries(ctx, r)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"range":      r,
+		"series":     series,
+		"unresolved": unresolved,
+	})
+}
+
+func (h *HTTPHandler) GetSecurityDashboardRegistrations(ctx *gin.Context) {
+	r, ok := h.dashboardRange(ctx)
+	if !ok {
+		return
+	}
+
+	series, total, err := h.securityDashboardService.GetRegistrationSeries(ctx, r)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"range":  r,
+		"series": series,
+		"total":  total,
+	})
+}
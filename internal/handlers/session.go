@@ -6,8 +6,36 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/security"
+	"github.com/m1thrandir225/whoami/internal/services"
 )
 
+// createSession starts a session for a freshly authenticated user and audits
+// any sessions signed out to stay within the concurrent session limit.
+func (h *HTTPHandler) createSession(ctx *gin.Context, user *domain.User, accessToken, refreshToken string, deviceInfo map[string]string) error {
+	evicted, err := h.sessionService.CreateSession(ctx, user.ID, user.Role, accessToken, refreshToken, deviceInfo)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range evicted {
+		h.auditService.LogUserAction(ctx, user.ID, domain.AuditActionSessionRevoke, domain.AuditResourceTypeSession, user.ID, ctx.Request, map[string]interface{}{
+			"session_id": session.ID,
+			"reason":     security.BlacklistReasonSignedOutElsewhere,
+			"success":    true,
+		})
+	}
+
+	return nil
+}
+
+func sessionErrorStatus(err error) int {
+	if errors.Is(err, services.ErrSessionLimitReached) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *HTTPHandler) GetUserSessions(ctx *gin.Context) {
 	payload, err := GetCurrentUserPayload(ctx)
 	if err != nil {
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/services"
+)
+
+// ListSuspiciousActivityCases serves the security team's review queue,
+// filtered by ?status=open,acknowledged&assigned_to=&limit=
+func (h *HTTPHandler) ListSuspiciousActivityCases(ctx *gin.Context) {
+	var filter domain.SuspiciousActivityFilter
+
+	if statuses := ctx.Query("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			filter.Statuses = append(filter.Statuses, domain.SuspiciousActivityStatus(strings.TrimSpace(status)))
+		}
+	}
+
+	if assignedTo := ctx.Query("assigned_to"); assignedTo != "" {
+		id, err := strconv.ParseInt(assignedTo, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("assigned_to must be a user ID")))
+			return
+		}
+		filter.AssignedTo = &id
+	}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		l, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || l < 1 {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("limit must be a positive number")))
+			return
+		}
+		filter.Limit = int32(l)
+	}
+
+	activities, err := h.suspiciousActivityService.ListActivities(ctx, filter)
+	if err != nil {
+		ctx.JSON(suspiciousActivityErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"activities": activities,
+	})
+}
+
+func (h *HTTPHandler) GetSuspiciousActivityCase(ctx *gin.Context) {
+	activityID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	activityCase, err := h.suspiciousActivityService.GetCase(ctx, activityID)
+	if err != nil {
+		ctx.JSON(suspiciousActivityErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, activityCase)
+}
+
+func (h *HTTPHandler) AssignSuspiciousActivity(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	activityID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req assignSuspiciousActivityRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	activity, err := h.suspiciousActivityService.Assign(ctx, activityID, req.AssigneeID)
+	if err != nil {
+		ctx.JSON(suspiciousActivityErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionSuspiciousActivity, domain.AuditResourceTypeActivity, activity.ID, ctx.Request, map[string]interface{}{
+		"action":      "assign",
+		"assignee_id": req.AssigneeID,
+		"success":     true,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"activity": activity,
+	})
+}
+
+func (h *HTTPHandler) AddSuspiciousActivityNote(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	activityID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req addSuspiciousActivityNoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	note, err := h.suspiciousActivityService.AddNote(ctx, activityID, payload.UserID, req.Note)
+	if err != nil {
+		ctx.JSON(suspiciousActivityErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionSuspiciousActivity, domain.AuditResourceTypeActivity, activityID, ctx.Request, map[string]interface{}{
+		"action":  "add_note",
+		"note_id": note.ID,
+		"success": true,
+	})
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"note": note,
+	})
+}
+
+// ReviewSuspiciousActivity records the security team's decision on an
+// activity. Confirming it as malicious can also sign the account out
+// everywhere and force a password reset.
+func (h *HTTPHandler) ReviewSuspiciousActivity(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	activityID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req reviewSuspiciousActivityRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	review, err := h.suspiciousActivityService.Review(ctx, domain.ReviewSuspiciousActivityAction{
+		ActivityID:         activityID,
+		ReviewerID:         payload.UserID,
+		Status:             domain.SuspiciousActivityStatus(req.Status),
+		Note:               req.Note,
+		RevokeSessions:     req.RevokeSessions,
+		ForcePasswordReset: req.ForcePasswordReset,
+	})
+
+	// The status may have changed even when a response to it failed after
+	if review != nil {
+		h.auditReview(ctx, payload.UserID, review)
+	}
+	if err != nil {
+		ctx.JSON(suspiciousActivityErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+func (h *HTTPHandler) auditReview(ctx *gin.Context, reviewerID int64, review *domain.SuspiciousActivityReview) {
+	activity := review.Activity
+
+	h.auditService.LogUserAction(ctx, reviewerID, domain.AuditActionSuspiciousActivity, domain.AuditResourceTypeActivity, activity.ID, ctx.Request, map[string]interface{}{
+		"action":              "review",
+		"status":              activity.Status,
+		"user_id":             activity.UserID,
+		"sessions_revoked":    review.SessionsRevoked,
+		"password_reset_sent": review.PasswordResetSent,
+		"success":             true,
+	})
+
+	if review.SessionsRevoked {
+		h.auditService.LogUserAction(ctx, reviewerID, domain.AuditActionSessionRevokeAll, domain.AuditResourceTypeSession, activity.UserID, ctx.Request, map[string]interface{}{
+			"action":      "revoke_all_sessions",
+			"activity_id": activity.ID,
+			"success":     true,
+		})
+	}
+
+	if lockout := review.Lockout; lockout != nil {
+		h.auditService.LogUserAction(ctx, reviewerID, domain.AuditActionAccountLockout, domain.AuditResourceTypeAccount, lockout.ID, ctx.Request, map[string]interface{}{
+			"user_id":     activity.UserID,
+			"activity_id": activity.ID,
+			"level":       lockout.Level,
+			"permanent":   lockout.Permanent,
+			"reason":      lockout.Reason,
+			"success":     true,
+		})
+		h.publishUserEvent(ctx, domain.WebhookEventUserLockedOut, activity.UserID, map[string]interface{}{
+			"level":      lockout.Level,
+			"permanent":  lockout.Permanent,
+			"expires_at": lockout.ExpiresAt,
+			"reason":     lockout.Reason,
+		})
+	}
+}
+
+func suspiciousActivityErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrActivityStatusConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidActivityStatus),
+		errors.Is(err, services.ErrInvalidActivityAssignee),
+		errors.Is(err, services.ErrInvalidActivityResponse),
+		errors.Is(err, services.ErrEmptyActivityNote):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -3,15 +3,18 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/m1thrandir225/whoami/internal/domain"
 	"github.com/m1thrandir225/whoami/internal/security"
+	"github.com/m1thrandir225/whoami/internal/services"
 	"github.com/m1thrandir225/whoami/internal/util"
 )
 
@@ -23,6 +26,10 @@ func (h *HTTPHandler) Register(ctx *gin.Context) {
 		return
 	}
 
+	if !h.checkCaptcha(ctx, "register", "", "", requestData.CaptchaToken) {
+		return
+	}
+
 	// Validate password for new user
 	if err := h.passwordSecurityService.ValidateNewUserPassword(ctx, requestData.Password); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
@@ -69,25 +76,21 @@ func (h *HTTPHandler) Register(ctx *gin.Context) {
 	}
 
 	// Extract device information
-	deviceInfo := security.ExtractDeviceInfo(ctx)
+	deviceInfo := h.extractDeviceInfo(ctx)
 
 	// Create device for new user
-	device, err := h.userDevicesService.GetOrCreateDevice(ctx, user.ID, deviceInfo)
+	device, _, err := h.userDevicesService.GetOrCreateDevice(ctx, user.ID, deviceInfo)
 	if err != nil {
 		// Log error but don't fail the registration
 		fmt.Printf("Warning: Failed to create device record: %v\n", err)
 	}
+	h.setDeviceCookie(ctx, deviceInfo)
 
 	// Create session with device info
-	deviceInfoMap := map[string]string{
-		"device_id":   deviceInfo.DeviceID,
-		"device_name": deviceInfo.DeviceName,
-		"device_type": deviceInfo.DeviceType,
-		"user_agent":  deviceInfo.UserAgent,
-		"ip_address":  deviceInfo.IPAddress,
-	}
+	deviceInfoMap := deviceInfo.SessionInfo()
 
-	if err := h.sessionService.CreateSession(ctx, user.ID, accessToken, refreshToken, deviceInfoMap); err != nil {
+	// The account exists either way, a failed session only means signing in again
+	if err := h.createSession(ctx, user, accessToken, refreshToken, deviceInfoMap); err != nil {
 		fmt.Printf("Warning: Failed to create session: %v\n", err)
 	}
 
@@ -96,6 +99,10 @@ func (h *HTTPHandler) Register(ctx *gin.Context) {
 		"email":   user.Email,
 		"success": true,
 	})
+	h.publishUserEvent(ctx, domain.WebhookEventUserRegistered, user.ID, map[string]interface{}{
+		"email":    user.Email,
+		"username": user.Username,
+	})
 
 	response := registerResponse{
 		User:                  *user,
@@ -105,6 +112,15 @@ func (h *HTTPHandler) Register(ctx *gin.Context) {
 		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 		Device:                device,
 	}
+
+	accessInCookie, refreshInCookie := h.setAuthCookies(ctx, accessToken, accessPayload.ExpiredAt, refreshToken, refreshPayload.ExpiredAt)
+	if accessInCookie {
+		response.AccessToken = ""
+	}
+	if refreshInCookie {
+		response.RefreshToken = ""
+	}
+
 	ctx.JSON(http.StatusOK, response)
 }
 
@@ -116,14 +132,49 @@ func (h *HTTPHandler) Login(ctx *gin.Context) {
 		return
 	}
 
+	passwordFingerprint := h.credentialStuffingService.Fingerprint(requestData.Password)
+	if !h.checkCaptcha(ctx, "login", requestData.Email, passwordFingerprint, requestData.CaptchaToken) {
+		return
+	}
+
+	// Earlier failures space out the attempts for this email. Early ones are
+	// turned away before the password is looked at.
+	wait, err := h.securityService.ReserveLoginAttempt(ctx, requestData.Email)
+	if err != nil {
+		log.Printf("Warning: failed to reserve login attempt: %v", err)
+	}
+	if wait > 0 {
+		retryAfter := int64(math.Ceil(wait.Seconds()))
+		ctx.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       ErrTooManyFailedLogins.Error(),
+			"retry_after": retryAfter,
+		})
+		return
+	}
+
 	clientIP := security.GetClientIP(ctx)
 	userAgent := ctx.GetHeader("User-Agent")
 
 	user, err := h.userService.GetUserByEmail(ctx, requestData.Email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("Warning: failed to look up user for login: %v", err)
+		ctx.JSON(http.StatusInternalServerError, errorResponse(ErrInternalServer))
+		return
+	}
+	if err != nil && h.legacyMigrationService != nil {
+		// Unknown locally, give the legacy identity store a chance to vouch for the credentials
+		user, err = h.migrateLegacyUser(ctx, requestData.Email, requestData.Password)
+	}
 	if err != nil {
 		// Record failed login attempt even if user doesn't exist
 		// This prevents user enumeration attacks
-		h.securityService.RecordFailedLogin(ctx, 0, requestData.Email, clientIP, userAgent)
+		_, err := h.securityService.RecordFailedLogin(ctx, 0, requestData.Email, passwordFingerprint, clientIP, userAgent)
+		if err != nil {
+			fmt.Printf("Warning: failed to record failed login: %v\n", err)
+		}
+		h.evaluateIPLockout(ctx, clientIP)
+		h.detectCredentialStuffing(ctx, clientIP, userAgent, passwordFingerprint)
 
 		// Log failed login attempt
 		h.auditService.LogAnonymousAction(ctx, domain.AuditActionUserLogin, domain.AuditResourceTypeUser, 0, ctx.Request, map[string]interface{}{
@@ -132,7 +183,7 @@ func (h *HTTPHandler) Login(ctx *gin.Context) {
 			"reason":  "user_not_found",
 		})
 
-		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("invalid credentials")))
+		ctx.JSON(http.StatusUnauthorized, h.invalidCredentials(ctx, requestData.Email, passwordFingerprint))
 		return
 	}
 
@@ -145,7 +196,12 @@ func (h *HTTPHandler) Login(ctx *gin.Context) {
 	// Verify password
 	if err := util.ComparePassword(user.Password, requestData.Password); err != nil {
 		// Record failed login attempt
-		h.securityService.RecordFailedLogin(ctx, user.ID, requestData.Email, clientIP, userAgent)
+		failedLogin, err := h.securityService.RecordFailedLogin(ctx, user.ID, requestData.Email, passwordFingerprint, clientIP, userAgent)
+		if err != nil {
+			fmt.Printf("Warning: failed to record failed login: %v\n", err)
+		}
+		h.evaluateIPLockout(ctx, clientIP)
+		h.detectCredentialStuffing(ctx, clientIP, userAgent, passwordFingerprint)
 
 		// Log failed login attempt
 		h.auditService.LogUserAction(ctx, user.ID, domain.AuditActionUserLogin, domain.AuditResourceTypeUser, user.ID, ctx.Request, map[string]interface{}{
@@ -154,12 +210,32 @@ func (h *HTTPHandler) Login(ctx *gin.Context) {
 			"reason":  "invalid_password",
 		})
 
-		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("invalid credentials")))
+		if failedLogin != nil && failedLogin.Lockout != nil {
+			lockout := failedLogin.Lockout
+			h.auditService.LogUserAction(ctx, user.ID, domain.AuditActionAccountLockout, domain.AuditResourceTypeAccount, lockout.ID, ctx.Request, map[string]interface{}{
+				"lockout_type": lockout.LockoutType,
+				"level":        lockout.Level,
+				"permanent":    lockout.Permanent,
+				"expires_at":   lockout.ExpiresAt,
+				"reason":       lockout.Reason,
+				"success":      true,
+			})
+			h.publishUserEvent(ctx, domain.WebhookEventUserLockedOut, user.ID, map[string]interface{}{
+				"email":      user.Email,
+				"level":      lockout.Level,
+				"permanent":  lockout.Permanent,
+				"expires_at": lockout.ExpiresAt,
+				"reason":     lockout.Reason,
+			})
+		}
+
+		ctx.JSON(http.StatusUnauthorized, h.invalidCredentials(ctx, requestData.Email, passwordFingerprint))
 		return
 	}
 
 	// Record successful login
-	if err := h.securityService.RecordSuccessfulLogin(ctx, user.ID, requestData.Email, clientIP, userAgent); err != nil {
+	successfulLogin, err := h.securityService.RecordSuccessfulLogin(ctx, user.ID, requestData.Email, clientIP, userAgent)
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -189,14 +265,15 @@ func (h *HTTPHandler) Login(ctx *gin.Context) {
 	}
 
 	// Extract device information
-	deviceInfo := security.ExtractDeviceInfo(ctx)
+	deviceInfo := h.extractDeviceInfo(ctx)
 
 	// Create or get device
-	device, err := h.userDevicesService.GetOrCreateDevice(ctx, user.ID, deviceInfo)
+	device, newDevice, err := h.userDevicesService.GetOrCreateDevice(ctx, user.ID, deviceInfo)
 	if err != nil {
 		// Log error but don't fail the login
 		fmt.Printf("Warning: Failed to create device record: %v\n", err)
 	}
+	h.setDeviceCookie(ctx, deviceInfo)
 
 	// Update device last used time
 	if device != nil {
@@ -206,18 +283,15 @@ func (h *HTTPHandler) Login(ctx *gin.Context) {
 	}
 
 	// Create session with device info
-	deviceInfoMap := map[string]string{
-		"device_id":   deviceInfo.DeviceID,
-		"device_name": deviceInfo.DeviceName,
-		"device_type": deviceInfo.DeviceType,
-		"user_agent":  deviceInfo.UserAgent,
-		"ip_address":  deviceInfo.IPAddress,
-	}
+	deviceInfoMap := deviceInfo.SessionInfo()
 
-	if err := h.sessionService.CreateSession(ctx, user.ID, accessToken, refreshToken, deviceInfoMap); err != nil {
-		fmt.Printf("Warning: Failed to create session: %v\n", err)
+	if err := h.createSession(ctx, user, accessToken, refreshToken, deviceInfoMap); err != nil {
+		ctx.JSON(sessionErrorStatus(err), errorResponse(err))
+		return
 	}
 
+	h.notifySignIn(ctx, user, accessToken, deviceInfo, newDevice, successfulLogin.NewLocation)
+
 	response := loginResponse{
 		User:                  *user,
 		AccessToken:           accessToken,
@@ -227,9 +301,30 @@ func (h *HTTPHandler) Login(ctx *gin.Context) {
 		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 	}
 
+	accessInCookie, refreshInCookie := h.setAuthCookies(ctx, accessToken, accessPayload.ExpiredAt, refreshToken, refreshPayload.ExpiredAt)
+	if accessInCookie {
+		response.AccessToken = ""
+	}
+	if refreshInCookie {
+		response.RefreshToken = ""
+	}
+
 	ctx.JSON(http.StatusOK, response)
 }
 
+func (h *HTTPHandler) migrateLegacyUser(ctx *gin.Context, email, password string) (*domain.User, error) {
+	// The migration is recorded in the audit log along with the new user
+	user, err := h.legacyMigrationService.MigrateUser(ctx, email, password, ctx.Request)
+	if err != nil {
+		if !errors.Is(err, security.ErrLegacyCredentialsRejected) {
+			log.Printf("Warning: Failed to migrate legacy user: %v", err)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (h *HTTPHandler) GetCurrentUser(ctx *gin.Context) {
 	userPayload, err := GetCurrentUserPayload(ctx)
 	if err != nil {
@@ -277,6 +372,7 @@ func (h *HTTPHandler) DeactivateUser(ctx *gin.Context) {
 		"user_id": userID,
 		"success": true,
 	})
+	h.publishUserEvent(ctx, domain.WebhookEventUserDeactivated, userID, nil)
 
 	ctx.Status(http.StatusOK)
 }
@@ -395,13 +491,11 @@ func (h *HTTPHandler) Logout(ctx *gin.Context) {
 		return
 	}
 
-	authorizationHeader := ctx.GetHeader("authorization")
-	fields := strings.Fields(authorizationHeader)
-	if len(fields) < 2 {
+	token := ctx.GetString(AccessTokenKey)
+	if token == "" {
 		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("invalid authorization header")))
 		return
 	}
-	token := fields[1]
 
 	// Revoke the session by token (this will also blacklist the token)
 	if err := h.sessionService.RevokeSessionByToken(ctx, token); err != nil {
@@ -415,16 +509,38 @@ func (h *HTTPHandler) Logout(ctx *gin.Context) {
 		"success": true,
 	})
 
+	h.clearAuthCookies(ctx)
+
 	ctx.JSON(http.StatusOK, messageResponse("Logged out successfully"))
 }
 
 func (h *HTTPHandler) RefreshToken(ctx *gin.Context) {
 	var req refreshTokenRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	if err := ctx.ShouldBindJSON(&req); err != nil && !(h.config.CookieAuthEnabled && errors.Is(err, io.EOF)) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
+	// In cookie mode the refresh token arrives as a cookie, which a cross-site
+	// form could also trigger, so it must be paired with the CSRF header.
+	if req.RefreshToken == "" && h.config.CookieAuthEnabled {
+		cookieToken, err := ctx.Cookie(RefreshTokenCookieName)
+		if err != nil || cookieToken == "" {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("refresh token is required")))
+			return
+		}
+		if err := validateCSRF(ctx); err != nil {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		req.RefreshToken = cookieToken
+	}
+
+	if req.RefreshToken == "" {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("refresh token is required")))
+		return
+	}
+
 	payload, err := h.tokenMaker.VerifyToken(req.RefreshToken)
 	if err != nil {
 		// Log failed token refresh
@@ -438,9 +554,14 @@ func (h *HTTPHandler) RefreshToken(ctx *gin.Context) {
 		return
 	}
 
+	if reason, _ := h.tokenBlacklist.GetBlacklistReason(ctx, req.RefreshToken); reason == security.BlacklistReasonSignedOutElsewhere {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(ErrSignedOutElsewhere))
+		return
+	}
+
 	currentSession, err := h.sessionService.GetSessionByRefreshToken(ctx, req.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
@@ -457,6 +578,10 @@ func (h *HTTPHandler) RefreshToken(ctx *gin.Context) {
 	}
 
 	if err := h.sessionService.UpdateSessionTokens(ctx, currentSession.ID, accessToken, refreshToken); err != nil {
+		if errors.Is(err, services.ErrSessionExpired) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -473,6 +598,14 @@ func (h *HTTPHandler) RefreshToken(ctx *gin.Context) {
 		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 	}
 
+	accessInCookie, refreshInCookie := h.setAuthCookies(ctx, accessToken, accessPayload.ExpiredAt, refreshToken, refreshPayload.ExpiredAt)
+	if accessInCookie {
+		response.AccessToken = ""
+	}
+	if refreshInCookie {
+		response.RefreshToken = ""
+	}
+
 	ctx.JSON(http.StatusOK, response)
 }
 
@@ -483,7 +616,8 @@ func (h *HTTPHandler) VerifyEmail(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.emailService.VerifyEmailToken(ctx, req.Token); err != nil {
+	user, err := h.emailService.VerifyEmailToken(ctx, req.Token)
+	if err != nil {
 		// Log failed email verification
 		h.auditService.LogAnonymousAction(ctx, domain.AuditActionEmailVerify, domain.AuditResourceTypeEmail, 0, ctx.Request, map[string]interface{}{
 			"token":   req.Token,
@@ -500,6 +634,9 @@ func (h *HTTPHandler) VerifyEmail(ctx *gin.Context) {
 		"token":   req.Token,
 		"success": true,
 	})
+	h.publishUserEvent(ctx, domain.WebhookEventUserEmailVerified, user.ID, map[string]interface{}{
+		"email": user.Email,
+	})
 
 	ctx.JSON(http.StatusOK, messageResponse("Email verified successfully"))
 }
@@ -570,6 +707,10 @@ func (h *HTTPHandler) UpdatePassword(ctx *gin.Context) {
 		"email":   user.Email,
 		"success": true,
 	})
+	h.publishUserEvent(ctx, domain.WebhookEventUserPasswordChanged, payload.UserID, map[string]interface{}{
+		"email":  user.Email,
+		"method": "update",
+	})
 
 	ctx.JSON(http.StatusOK, messageResponse("Password updated successfully"))
 }
@@ -608,6 +749,10 @@ func (h *HTTPHandler) SetPassword(ctx *gin.Context) {
 		"user_id": payload.UserID,
 		"success": true,
 	})
+	h.publishUserEvent(ctx, domain.WebhookEventUserPasswordChanged, payload.UserID, map[string]interface{}{
+		"email":  user.Email,
+		"method": "set",
+	})
 
 	ctx.JSON(http.StatusOK, messageResponse("Password set successfully"))
 }
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+func (h *HTTPHandler) CreateWebhookEndpoint(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	var req createWebhookEndpointRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, secret, err := h.webhookService.CreateEndpoint(ctx, req.URL, req.Description, req.EventTypes)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionWebhookCreate, domain.AuditResourceTypeWebhook, endpoint.ID, ctx.Request, map[string]interface{}{
+		"url":         endpoint.URL,
+		"event_types": endpoint.EventTypes,
+		"success":     true,
+	})
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"webhook": endpoint,
+		"secret":  secret,
+	})
+}
+
+func (h *HTTPHandler) ListWebhookEndpoints(ctx *gin.Context) {
+	endpoints, err := h.webhookService.ListEndpoints(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"webhooks":    endpoints,
+		"event_types": domain.WebhookEventTypes,
+	})
+}
+
+func (h *HTTPHandler) GetWebhookEndpoint(ctx *gin.Context) {
+	endpointID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, err := h.webhookService.GetEndpoint(ctx, endpointID)
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"webhook": endpoint,
+	})
+}
+
+func (h *HTTPHandler) UpdateWebhookEndpoint(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	endpointID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateWebhookEndpointRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(ctx, domain.UpdateWebhookEndpointAction{
+		ID:          endpointID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		Active:      *req.Active,
+	})
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionWebhookUpdate, domain.AuditResourceTypeWebhook, endpoint.ID, ctx.Request, map[string]interface{}{
+		"url":         endpoint.URL,
+		"event_types": endpoint.EventTypes,
+		"active":      endpoint.Active,
+		"success":     true,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"webhook": endpoint,
+	})
+}
+
+func (h *HTTPHandler) RotateWebhookSecret(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	endpointID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, secret, err := h.webhookService.RotateEndpointSecret(ctx, endpointID)
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionWebhookRotate, domain.AuditResourceTypeWebhook, endpoint.ID, ctx.Request, map[string]interface{}{
+		"success": true,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"webhook": endpoint,
+		"secret":  secret,
+	})
+}
+
+func (h *HTTPHandler) DeleteWebhookEndpoint(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	endpointID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(ctx, endpointID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionWebhookDelete, domain.AuditResourceTypeWebhook, endpointID, ctx.Request, map[string]interface{}{
+		"success": true,
+	})
+
+	ctx.JSON(http.StatusOK, messageResponse("Webhook deleted successfully"))
+}
+
+func (h *HTTPHandler) GetWebhookDeliveries(ctx *gin.Context) {
+	endpointID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	status := ctx.Query("status")
+	switch status {
+	case "", domain.WebhookDeliveryStatusPending, domain.WebhookDeliveryStatusDelivered, domain.WebhookDeliveryStatusDead:
+	default:
+		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("status must be pending, delivered or dead")))
+		return
+	}
+
+	limit := int32(50) // Default limit
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
+			limit = int32(l)
+		}
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(ctx, endpointID, status, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+	})
+}
+
+func (h *HTTPHandler) GetWebhookDelivery(ctx *gin.Context) {
+	deliveryID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+	})
+}
+
+func (h *HTTPHandler) RedeliverWebhook(ctx *gin.Context) {
+	payload, err := GetCurrentUserPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(ctx, deliveryID)
+	if err != nil {
+		ctx.JSON(webhookErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	h.auditService.LogUserAction(ctx, payload.UserID, domain.AuditActionWebhookRedeliver, domain.AuditResourceTypeWebhook, delivery.EndpointID, ctx.Request, map[string]interface{}{
+		"delivery_id": delivery.ID,
+		"event_id":    delivery.EventID,
+		"event_type":  delivery.EventType,
+		"success":     true,
+	})
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"delivery": delivery,
+	})
+}
+
+func webhookErrorStatus(err error) int {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,82 @@
+// Package metrics defines the Prometheus collectors exposed on /metrics
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "whoami"
+
+var (
+	// CaptchaChallenges counts challenge checks on requests that needed one.
+	CaptchaChallenges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "captcha",
+		Name:      "challenges_total",
+		Help:      "Captcha checks on risky auth requests, by endpoint and result.",
+	}, []string{"endpoint", "result"})
+
+	// CredentialStuffingDetections counts detector firings on failed logins.
+	CredentialStuffingDetections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "credential_stuffing",
+		Name:      "detections_total",
+		Help:      "Credential stuffing detections on failed logins, by detector.",
+	}, []string{"detector"})
+
+	// IPAccessBlocked counts requests rejected by IP access rules.
+	IPAccessBlocked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ip_access",
+		Name:      "blocked_total",
+		Help:      "Requests rejected by IP deny rules or automatic lockouts.",
+	}, []string{"rule_type"})
+
+	// OutboxPublishAttempts counts attempts to hand outbox events to the bus.
+	OutboxPublishAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "publish_attempts_total",
+		Help:      "Outbox events handed to the event publisher, by event type and result (published or retry).",
+	}, []string{"event_type", "result"})
+
+	// RateLimitBackendErrors counts rate limit checks that could not reach Redis.
+	RateLimitBackendErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rate_limit",
+		Name:      "backend_errors_total",
+		Help:      "Rate limit checks that failed because Redis was unavailable.",
+	})
+
+	// RateLimitDegraded is 1 while rate limiting is running on its failure mode.
+	RateLimitDegraded = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "rate_limit",
+		Name:      "degraded",
+		Help:      "Whether rate limiting is degraded because Redis is unavailable (1) or not (0).",
+	})
+
+	// RateLimitFallbackDecisions counts requests decided by a policy failure mode.
+	RateLimitFallbackDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rate_limit",
+		Name:      "fallback_decisions_total",
+		Help:      "Requests decided by a failure mode while Redis was unavailable.",
+	}, []string{"mode", "result"})
+
+	// RateLimitRejections counts requests rejected with 429.
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rate_limit",
+		Name:      "rejections_total",
+		Help:      "Requests rejected by rate limit policies.",
+	}, []string{"policy"})
+
+	WebhookDeliveryAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "delivery_attempts_total",
+		Help:      "Webhook delivery attempts, by event type and result (delivered, retry or dead).",
+	}, []string{"event_type", "result"})
+)
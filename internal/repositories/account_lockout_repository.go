@@ -5,17 +5,27 @@ import (
 	"net/netip"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
 	"github.com/m1thrandir225/whoami/internal/domain"
 )
 
 type AccountLockoutRepository interface {
+	// CreateLockout returns pgx.ErrNoRows when the user already has an open
+	// lockout of the same type, or for ip lockouts, for the same address.
 	CreateLockout(ctx context.Context, req domain.CreateAccountLockoutAction) (*domain.AccountLockout, error)
 	GetLockoutByUserID(ctx context.Context, userID int64) (*domain.AccountLockout, error)
+	// GetLockoutForLogin returns the open lockout that turns away a login to
+	// the user from ipAddress: any account-wide one, or an ip lockout for it.
+	GetLockoutForLogin(ctx context.Context, userID int64, ipAddress string) (*domain.AccountLockout, error)
 	GetLockoutByIP(ctx context.Context, ipAddress string) (*domain.AccountLockout, error)
 	GetLockoutByUserAndIP(ctx context.Context, userID int64, ipAddress string) (*domain.AccountLockout, error)
-	DeleteExpiredLockouts(ctx context.Context) error
-	DeleteLockoutByID(ctx context.Context, id int64) error
+	GetLockoutByUnlockTokenHash(ctx context.Context, tokenHash string) (*domain.AccountLockout, error)
+	GetLockoutsByUserID(ctx context.Context, userID int64, limit int32) ([]domain.AccountLockout, error)
+	CountRecentLockouts(ctx context.Context, userID int64, since time.Time) (int64, error)
+	CloseExpiredLockouts(ctx context.Context, userID int64) error
+	UnlockAccount(ctx context.Context, req domain.UnlockAccountAction) ([]domain.AccountLockout, error)
+	DeleteLockoutHistoryBefore(ctx context.Context, before time.Time) error
 }
 
 type accountLockoutRepository struct {
@@ -38,11 +48,38 @@ func (r *accountLockoutRepository) CreateLockout(ctx context.Context, req domain
 		return nil, err
 	}
 
-	dbLockout, err := r.store.CreateAccountLockout(ctx, db.CreateAccountLockoutParams{
-		UserID:      req.UserID,
-		IpAddress:   &parsedIP,
-		LockoutType: string(req.LockoutType),
-		ExpiresAt:   expiresAt,
+	level := req.Level
+	if level < 1 {
+		level = 1
+	}
+
+	var dbLockout db.AccountLockout
+	err = r.store.ExecTx(ctx, func(q db.Querier) error {
+		var err error
+		dbLockout, err = q.CreateAccountLockout(ctx, db.CreateAccountLockoutParams{
+			UserID:          req.UserID,
+			IpAddress:       &parsedIP,
+			LockoutType:     string(req.LockoutType),
+			ExpiresAt:       expiresAt,
+			Level:           level,
+			Permanent:       req.Permanent,
+			Reason:          req.Reason,
+			UnlockTokenHash: req.UnlockTokenHash,
+		})
+		if err != nil {
+			return err
+		}
+
+		return enqueueUserEvent(ctx, q, dbLockout.UserID, domain.OutboxEventAccountLocked, map[string]interface{}{
+			"user_id":      dbLockout.UserID,
+			"lockout_id":   dbLockout.ID,
+			"lockout_type": dbLockout.LockoutType,
+			"level":        dbLockout.Level,
+			"permanent":    dbLockout.Permanent,
+			"reason":       dbLockout.Reason,
+			"ip_address":   req.IPAddress,
+			"expires_at":   dbLockout.ExpiresAt,
+		})
 	})
 	if err != nil {
 		return nil, err
@@ -60,6 +97,23 @@ func (r *accountLockoutRepository) GetLockoutByUserID(ctx context.Context, userI
 	return r.toDomain(dbLockout), nil
 }
 
+func (r *accountLockoutRepository) GetLockoutForLogin(ctx context.Context, userID int64, ipAddress string) (*domain.AccountLockout, error) {
+	// An address that does not parse matches no ip lockout
+	var ip *netip.Addr
+	if parsedIP, err := netip.ParseAddr(ipAddress); err == nil {
+		ip = &parsedIP
+	}
+	dbLockout, err := r.store.GetAccountLockoutForLogin(ctx, db.GetAccountLockoutForLoginParams{
+		UserID:    userID,
+		IpAddress: ip,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbLockout), nil
+}
+
 func (r *accountLockoutRepository) GetLockoutByIP(ctx context.Context, ipAddress string) (*domain.AccountLockout, error) {
 	parsedIP, err := netip.ParseAddr(ipAddress)
 	if err != nil {
@@ -89,21 +143,107 @@ func (r *accountLockoutRepository) GetLockoutByUserAndIP(ctx context.Context, us
 	return r.toDomain(dbLockout), nil
 }
 
-func (r *accountLockoutRepository) DeleteExpiredLockouts(ctx context.Context) error {
-	return r.store.DeleteExpiredLockouts(ctx)
+func (r *accountLockoutRepository) GetLockoutByUnlockTokenHash(ctx context.Context, tokenHash string) (*domain.AccountLockout, error) {
+	dbLockout, err := r.store.GetAccountLockoutByUnlockTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbLockout), nil
+}
+
+func (r *accountLockoutRepository) GetLockoutsByUserID(ctx context.Context, userID int64, limit int32) ([]domain.AccountLockout, error) {
+	dbLockouts, err := r.store.GetAccountLockoutsByUserID(ctx, db.GetAccountLockoutsByUserIDParams{
+		UserID: userID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lockouts := make([]domain.AccountLockout, len(dbLockouts))
+	for i, lockout := range dbLockouts {
+		lockouts[i] = *r.toDomain(lockout)
+	}
+
+	return lockouts, nil
+}
+
+func (r *accountLockoutRepository) CountRecentLockouts(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	return r.store.CountRecentAccountLockouts(ctx, db.CountRecentAccountLockoutsParams{
+		UserID: userID,
+		Since:  since,
+	})
+}
+
+func (r *accountLockoutRepository) CloseExpiredLockouts(ctx context.Context, userID int64) error {
+	return r.store.CloseExpiredAccountLockouts(ctx, userID)
+}
+
+func (r *accountLockoutRepository) UnlockAccount(ctx context.Context, req domain.UnlockAccountAction) ([]domain.AccountLockout, error) {
+	var unlockedBy pgtype.Int8
+	if req.UnlockedBy != nil {
+		unlockedBy = pgtype.Int8{Int64: *req.UnlockedBy, Valid: true}
+	}
+
+	var dbLockouts []db.AccountLockout
+	err := r.store.ExecTx(ctx, func(q db.Querier) error {
+		var err error
+		dbLockouts, err = q.UnlockAccountLockouts(ctx, db.UnlockAccountLockoutsParams{
+			UserID:       req.UserID,
+			UnlockedBy:   unlockedBy,
+			UnlockMethod: req.UnlockMethod,
+		})
+		if err != nil || len(dbLockouts) == 0 {
+			return err
+		}
+
+		lockoutIDs := make([]int64, len(dbLockouts))
+		for i, lockout := range dbLockouts {
+			lockoutIDs[i] = lockout.ID
+		}
+
+		return enqueueUserEvent(ctx, q, req.UserID, domain.OutboxEventAccountUnlocked, map[string]interface{}{
+			"user_id":       req.UserID,
+			"lockout_ids":   lockoutIDs,
+			"unlock_method": req.UnlockMethod,
+			"unlocked_by":   req.UnlockedBy,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lockouts := make([]domain.AccountLockout, len(dbLockouts))
+	for i, lockout := range dbLockouts {
+		lockouts[i] = *r.toDomain(lockout)
+	}
+
+	return lockouts, nil
 }
 
-func (r *accountLockoutRepository) DeleteLockoutByID(ctx context.Context, id int64) error {
-	return r.store.DeleteAccountLockoutByID(ctx, id)
+func (r *accountLockoutRepository) DeleteLockoutHistoryBefore(ctx context.Context, before time.Time) error {
+	return r.store.DeleteAccountLockoutHistoryBefore(ctx, before)
 }
 
 func (r *accountLockoutRepository) toDomain(dbLockout db.AccountLockout) *domain.AccountLockout {
+	var unlockedBy *int64
+	if dbLockout.UnlockedBy.Valid {
+		unlockedBy = &dbLockout.UnlockedBy.Int64
+	}
+
 	return &domain.AccountLockout{
-		ID:          dbLockout.ID,
-		UserID:      dbLockout.UserID,
-		IPAddress:   dbLockout.IpAddress.String(),
-		LockoutType: dbLockout.LockoutType,
-		ExpiresAt:   dbLockout.ExpiresAt,
-		CreatedAt:   dbLockout.CreatedAt,
+		ID:           dbLockout.ID,
+		UserID:       dbLockout.UserID,
+		IPAddress:    dbLockout.IpAddress.String(),
+		LockoutType:  dbLockout.LockoutType,
+		ExpiresAt:    dbLockout.ExpiresAt,
+		CreatedAt:    dbLockout.CreatedAt,
+		Level:        dbLockout.Level,
+		Permanent:    dbLockout.Permanent,
+		Reason:       dbLockout.Reason,
+		UnlockedAt:   dbLockout.UnlockedAt,
+		UnlockedBy:   unlockedBy,
+		UnlockMethod: dbLockout.UnlockMethod,
 	}
 }
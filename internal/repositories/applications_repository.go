@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+type ApplicationsRepository interface {
+	CreateApplication(ctx context.Context, req domain.CreateApplicationAction) (*domain.Application, error)
+	GetApplicationByID(ctx context.Context, id int64) (*domain.Application, error)
+	ListApplications(ctx context.Context) ([]domain.Application, error)
+	ListBackchannelLogoutApplications(ctx context.Context) ([]domain.Application, error)
+	UpdateApplication(ctx context.Context, req domain.UpdateApplicationAction) (*domain.Application, error)
+	DeleteApplication(ctx context.Context, id int64) error
+	CreateBackchannelLogoutDelivery(ctx context.Context, req domain.CreateBackchannelLogoutDeliveryAction) (*domain.BackchannelLogoutDelivery, error)
+	// ClaimDueBackchannelLogoutDeliveries leases up to limit due deliveries
+	// until leaseUntil, so no other instance picks them up meanwhile. Rows
+	// another instance is claiming are skipped rather than waited on.
+	ClaimDueBackchannelLogoutDeliveries(ctx context.Context, limit int32, leaseUntil time.Time) ([]domain.BackchannelLogoutDelivery, error)
+	GetBackchannelLogoutDeliveriesByApplicationID(ctx context.Context, applicationID int64, limit int32) ([]domain.BackchannelLogoutDelivery, error)
+	UpdateBackchannelLogoutDelivery(ctx context.Context, req domain.UpdateBackchannelLogoutDeliveryAction) (*domain.BackchannelLogoutDelivery, error)
+}
+
+type applicationsRepository struct {
+	store db.Store
+}
+
+func NewApplicationsRepository(store db.Store) ApplicationsRepository {
+	return &applicationsRepository{
+		store: store,
+	}
+}
+
+func (r *applicationsRepository) CreateApplication(ctx context.Context, req domain.CreateApplicationAction) (*domain.Application, error) {
+	dbApplication, err := r.store.CreateApplication(ctx, db.CreateApplicationParams{
+		Name:                 req.Name,
+		ClientID:             req.ClientID,
+		LogoutSecret:         req.LogoutSecret,
+		BackchannelLogoutUrl: req.BackchannelLogoutURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbApplication), nil
+}
+
+func (r *applicationsRepository) GetApplicationByID(ctx context.Context, id int64) (*domain.Application, error) {
+	dbApplication, err := r.store.GetApplicationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbApplication), nil
+}
+
+func (r *applicationsRepository) ListApplications(ctx context.Context) ([]domain.Application, error) {
+	dbApplications, err := r.store.ListApplications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applications := make([]domain.Application, len(dbApplications))
+	for i, application := range dbApplications {
+		applications[i] = *r.toDomain(application)
+	}
+
+	return applications, nil
+}
+
+func (r *applicationsRepository) ListBackchannelLogoutApplications(ctx context.Context) ([]domain.Application, error) {
+	dbApplications, err := r.store.ListBackchannelLogoutApplications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applications := make([]domain.Application, len(dbApplications))
+	for i, application := range dbApplications {
+		applications[i] = *r.toDomain(application)
+	}
+
+	return applications, nil
+}
+
+func (r *applicationsRepository) UpdateApplication(ctx context.Context, req domain.UpdateApplicationAction) (*domain.Application, error) {
+	dbApplication, err := r.store.UpdateApplication(ctx, db.UpdateApplicationParams{
+		ID:                   req.ID,
+		Name:                 req.Name,
+		BackchannelLogoutUrl: req.BackchannelLogoutURL,
+		Active:               req.Active,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbApplication), nil
+}
+
+func (r *applicationsRepository) DeleteApplication(ctx context.Context, id int64) error {
+	return r.store.DeleteApplication(ctx, id)
+}
+
+func (r *applicationsRepository) CreateBackchannelLogoutDelivery(ctx context.Context, req domain.CreateBackchannelLogoutDeliveryAction) (*domain.BackchannelLogoutDelivery, error) {
+	dbDelivery, err := r.store.CreateBackchannelLogoutDelivery(ctx, db.CreateBackchannelLogoutDeliveryParams{
+		ApplicationID: req.ApplicationID,
+		UserID:        req.UserID,
+		SessionID:     req.SessionID,
+		Jti:           req.JTI,
+		NextAttemptAt: req.NextAttemptAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.deliveryToDomain(dbDelivery), nil
+}
+
+func (r *applicationsRepository) ClaimDueBackchannelLogoutDeliveries(ctx context.Context, limit int32, leaseUntil time.Time) ([]domain.BackchannelLogoutDelivery, error) {
+	dbDeliveries, err := r.store.ClaimDueBackchannelLogoutDeliveries(ctx, db.ClaimDueBackchannelLogoutDeliveriesParams{
+		LeaseUntil: leaseUntil,
+		RowLimit:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]domain.BackchannelLogoutDelivery, len(dbDeliveries))
+	for i, delivery := range dbDeliveries {
+		deliveries[i] = *r.deliveryToDomain(delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (r *applicationsRepository) GetBackchannelLogoutDeliveriesByApplicationID(ctx context.Context, applicationID int64, limit int32) ([]domain.BackchannelLogoutDelivery, error) {
+	dbDeliveries, err := r.store.GetBackchannelLogoutDeliveriesByApplicationID(ctx, db.GetBackchannelLogoutDeliveriesByApplicationIDParams{
+		ApplicationID: applicationID,
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]domain.BackchannelLogoutDelivery, len(dbDeliveries))
+	for i, delivery := range dbDeliveries {
+		deliveries[i] = *r.deliveryToDomain(delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (r *applicationsRepository) UpdateBackchannelLogoutDelivery(ctx context.Context, req domain.UpdateBackchannelLogoutDeliveryAction) (*domain.BackchannelLogoutDelivery, error) {
+	dbDelivery, err := r.store.UpdateBackchannelLogoutDelivery(ctx, db.UpdateBackchannelLogoutDeliveryParams{
+		ID:             req.ID,
+		Status:         req.Status,
+		Attempts:       req.Attempts,
+		LastStatusCode: req.LastStatusCode,
+		LastError:      req.LastError,
+		NextAttemptAt:  req.NextAttemptAt,
+		DeliveredAt:    req.DeliveredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.deliveryToDomain(dbDelivery), nil
+}
+
+func (r *applicationsRepository) toDomain(dbApplication db.Application) *domain.Application {
+	return &domain.Application{
+		ID:                   dbApplication.ID,
+		Name:                 dbApplication.Name,
+		ClientID:             dbApplication.ClientID,
+		LogoutSecret:         dbApplication.LogoutSecret,
+		BackchannelLogoutURL: dbApplication.BackchannelLogoutUrl,
+		Active:               dbApplication.Active,
+		CreatedAt:            dbApplication.CreatedAt,
+		UpdatedAt:            dbApplication.UpdatedAt,
+	}
+}
+
+func (r *applicationsRepository) deliveryToDomain(dbDelivery db.BackchannelLogoutDelivery) *domain.BackchannelLogoutDelivery {
+	return &domain.BackchannelLogoutDelivery{
+		ID:             dbDelivery.ID,
+		ApplicationID:  dbDelivery.ApplicationID,
+		UserID:         dbDelivery.UserID,
+		SessionID:      dbDelivery.SessionID,
+		JTI:            dbDelivery.Jti,
+		Status:         dbDelivery.Status,
+		Attempts:       dbDelivery.Attempts,
+		LastStatusCode: dbDelivery.LastStatusCode,
+		LastError:      dbDelivery.LastError,
+		NextAttemptAt:  dbDelivery.NextAttemptAt,
+		CreatedAt:      dbDelivery.CreatedAt,
+		DeliveredAt:    dbDelivery.DeliveredAt,
+	}
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"encoding/hex"
+
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+type AuditChainRepository interface {
+	GetHead(ctx context.Context) (*domain.AuditChainHead, error)
+	// GetEntries returns up to limit entries after afterSeq and up to
+	// throughSeq, in chain order.
+	GetEntries(ctx context.Context, afterSeq, throughSeq int64, limit int32) ([]domain.AuditChainEntry, error)
+	CreateCheckpoint(ctx context.Context, chainSeq int64, entryHash, publicKey, signature []byte) (*domain.AuditCheckpoint, error)
+	GetLatestCheckpoint(ctx context.Context) (*domain.AuditCheckpoint, error)
+	GetCheckpointsAfter(ctx context.Context, chainSeq int64) ([]domain.AuditCheckpoint, error)
+}
+
+type auditChainRepository struct {
+	store db.Store
+}
+
+func NewAuditChainRepository(store db.Store) AuditChainRepository {
+	return &auditChainRepository{
+		store: store,
+	}
+}
+
+func (r *auditChainRepository) GetHead(ctx context.Context) (*domain.AuditChainHead, error) {
+	dbHead, err := r.store.GetAuditLogChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AuditChainHead{
+		LastSeq:    dbHead.LastSeq,
+		LastHash:   dbHead.LastHash,
+		PrunedSeq:  dbHead.PrunedSeq,
+		PrunedHash: dbHead.PrunedHash,
+	}, nil
+}
+
+func (r *auditChainRepository) GetEntries(ctx context.Context, afterSeq, throughSeq int64, limit int32) ([]domain.AuditChainEntry, error) {
+	rows, err := r.store.GetAuditLogChainEntries(ctx, db.GetAuditLogChainEntriesParams{
+		AfterSeq:   afterSeq,
+		ThroughSeq: throughSeq,
+		RowLimit:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]domain.AuditChainEntry, len(rows))
+	for i, row := range rows {
+		var userID *int64
+		if row.UserID.Valid {
+			userID = &row.UserID.Int64
+		}
+
+		var resourceType *string
+		if row.ResourceType.Valid {
+			resourceType = &row.ResourceType.String
+		}
+
+		var resourceID *int64
+		if row.ResourceID.Valid {
+			resourceID = &row.ResourceID.Int64
+		}
+
+		entries[i] = domain.AuditChainEntry{
+			ChainSeq:     row.ChainSeq,
+			ID:           row.ID,
+			PrevHash:     row.PrevHash,
+			EntryHash:    row.EntryHash,
+			UserID:       userID,
+			Action:       row.Action,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			IPAddress:    row.IpAddress,
+			UserAgent:    row.UserAgent,
+			Details:      row.Details,
+			CreatedAt:    row.CreatedAt,
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *auditChainRepository) CreateCheckpoint(ctx context.Context, chainSeq int64, entryHash, publicKey, signature []byte) (*domain.AuditCheckpoint, error) {
+	dbCheckpoint, err := r.store.CreateAuditLogCheckpoint(ctx, db.CreateAuditLogCheckpointParams{
+		ChainSeq:  chainSeq,
+		EntryHash: entryHash,
+		PublicKey: publicKey,
+		Signature: signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.checkpointToDomain(dbCheckpoint), nil
+}
+
+func (r *auditChainRepository) GetLatestCheckpoint(ctx context.Context) (*domain.AuditCheckpoint, error) {
+	dbCheckpoint, err := r.store.GetLatestAuditLogCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.checkpointToDomain(dbCheckpoint), nil
+}
+
+func (r *auditChainRepository) GetCheckpointsAfter(ctx context.Context, chainSeq int64) ([]domain.AuditCheckpoint, error) {
+	dbCheckpoints, err := r.store.GetAuditLogCheckpointsAfter(ctx, chainSeq)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints := make([]domain.AuditCheckpoint, len(dbCheckpoints))
+	for i, checkpoint := range dbCheckpoints {
+		checkpoints[i] = *r.checkpointToDomain(checkpoint)
+	}
+
+	return checkpoints, nil
+}
+
+func (r *auditChainRepository) checkpointToDomain(dbCheckpoint db.AuditLogCheckpoint) *domain.AuditCheckpoint {
+	return &domain.AuditCheckpoint{
+		ID:        dbCheckpoint.ID,
+		ChainSeq:  dbCheckpoint.ChainSeq,
+		EntryHash: hex.EncodeToString(dbCheckpoint.EntryHash),
+		PublicKey: hex.EncodeToString(dbCheckpoint.PublicKey),
+		Signature: hex.EncodeToString(dbCheckpoint.Signature),
+		CreatedAt: dbCheckpoint.CreatedAt,
+	}
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+// txStore runs everything in one transaction the test rolls back.
+type txStore struct {
+	*db.Queries
+}
+
+func (s txStore) ExecTx(ctx context.Context, fn func(db.Querier) error) error {
+	return fn(s.Queries)
+}
+
+// TestAuditEntryHashMatchesPostgres checks that security.AuditEntryHash
+// recomputes exactly what the audit_log_entry_hash trigger stored. It needs
+// a migrated database in TESTING_DB_SOURCE.
+func TestAuditEntryHashMatchesPostgres(t *testing.T) {
+	dbSource := os.Getenv("TESTING_DB_SOURCE")
+	if dbSource == "" {
+		t.Skip("TESTING_DB_SOURCE is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbSource)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(tx)
+
+	ipv4 := netip.MustParseAddr("203.0.113.9")
+	ipv6 := netip.MustParseAddr("2001:db8::1")
+	mapped := netip.MustParseAddr("::ffff:198.51.100.7")
+	userAgent := "Mozilla/5.0 (X11; Linux x86_64) zażółć\nsecond line"
+	emptyUserAgent := ""
+
+	rows := []db.CreateAuditLogParams{
+		{
+			Action: "user_login",
+		},
+		{
+			UserID:       pgtype.Int8{Int64: 7, Valid: true},
+			Action:       "password_change",
+			ResourceType: pgtype.Text{String: "user", Valid: true},
+			ResourceID:   pgtype.Int8{Int64: 7, Valid: true},
+			IpAddress:    &ipv4,
+			UserAgent:    &userAgent,
+			Details:      []byte(`{"success":true,"ip":"203.0.113.9","nested":{"list":[1,2.50,"ą"]}}`),
+		},
+		{
+			Action:       "ip_lockout",
+			ResourceType: pgtype.Text{String: "ip_rule", Valid: true},
+			IpAddress:    &ipv6,
+			UserAgent:    &emptyUserAgent,
+			Details:      []byte(`{}`),
+		},
+		{
+			Action:    "user_login",
+			IpAddress: &mapped,
+			Details:   []byte(`null`),
+		},
+	}
+	for _, row := range rows {
+		if _, err := queries.CreateAuditLog(ctx, row); err != nil {
+			t.Fatalf("failed to create audit log: %v", err)
+		}
+	}
+
+	// created_at with a whole second and with a single microsecond, in a
+	// session time zone that is not UTC
+	if _, err := tx.Exec(ctx, "SET LOCAL TIME ZONE 'Europe/Skopje'"); err != nil {
+		t.Fatalf("failed to set time zone: %v", err)
+	}
+	for _, createdAt := range []string{"2026-03-04 05:06:07+02", "2026-03-04 05:06:07.000001+02"} {
+		if _, err := tx.Exec(ctx, "INSERT INTO audit_logs (action, ip_address, created_at) VALUES ('user_logout', '10.0.0.1/32', $1)", createdAt); err != nil {
+			t.Fatalf("failed to create audit log: %v", err)
+		}
+	}
+
+	repo := NewAuditChainRepository(txStore{Queries: queries})
+
+	head, err := repo.GetHead(ctx)
+	if err != nil {
+		t.Fatalf("failed to get chain head: %v", err)
+	}
+	inserted := int64(len(rows) + 2)
+
+	entries, err := repo.GetEntries(ctx, head.LastSeq-inserted, head.LastSeq, int32(inserted))
+	if err != nil {
+		t.Fatalf("failed to get chain entries: %v", err)
+	}
+	if int64(len(entries)) != inserted {
+		t.Fatalf("got %d entries, want %d", len(entries), inserted)
+	}
+
+	for _, entry := range entries {
+		if got := security.AuditEntryHash(entry); !bytes.Equal(got, entry.EntryHash) {
+			t.Errorf("entry %d (ip %q, details %q, created_at %v): hash %x, Postgres stored %x",
+				entry.ChainSeq, entry.IPAddress, entry.Details, entry.CreatedAt, got, entry.EntryHash)
+		}
+	}
+}
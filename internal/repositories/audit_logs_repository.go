@@ -2,10 +2,13 @@ package repositories
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/netip"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
 	"github.com/m1thrandir225/whoami/internal/domain"
@@ -14,15 +17,16 @@ import (
 type AuditLogsRepository interface {
 	CreateAuditLog(ctx context.Context, req domain.CreateAuditLogAction) (*domain.AuditLog, error)
 	GetAuditLogsByUserID(ctx context.Context, userID int64, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByAction(ctx context.Context, action string, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByResourceType(ctx context.Context, resourceType string, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByResourceID(ctx context.Context, resourceType string, resourceID int64, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByIP(ctx context.Context, ipAddress string, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByDateRange(ctx context.Context, startDate, endDate time.Time, limit int32) ([]domain.AuditLog, error)
-	GetRecentAuditLogs(ctx context.Context, limit int32) ([]domain.AuditLog, error)
+	// SearchAuditLogs returns up to filter.Limit entries matching filter
+	// with an id below beforeID, when given, newest first.
+	SearchAuditLogs(ctx context.Context, filter domain.AuditLogFilter, beforeID *int64) ([]domain.AuditLog, error)
+	// DeleteOldAuditLogs removes entries older than the retention period from
+	// the start of the audit chain and records where the chain now begins.
 	DeleteOldAuditLogs(ctx context.Context) error
 }
 
+const auditLogRetention = 90 * 24 * time.Hour
+
 type auditLogsRepository struct {
 	store db.Store
 }
@@ -34,6 +38,22 @@ func NewAuditLogsRepository(store db.Store) AuditLogsRepository {
 }
 
 func (r *auditLogsRepository) CreateAuditLog(ctx context.Context, req domain.CreateAuditLogAction) (*domain.AuditLog, error) {
+	params, err := createAuditLogParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	dbLog, err := r.store.CreateAuditLog(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbLog), nil
+}
+
+// createAuditLogParams is shared with repositories that write an audit entry
+// in the same transaction as the change it records.
+func createAuditLogParams(req domain.CreateAuditLogAction) (db.CreateAuditLogParams, error) {
 	var pgUserID pgtype.Int8
 	if req.UserID != nil {
 		pgUserID = pgtype.Int8{Int64: *req.UserID, Valid: true}
@@ -53,7 +73,7 @@ func (r *auditLogsRepository) CreateAuditLog(ctx context.Context, req domain.Cre
 	if req.IPAddress != nil {
 		parsedIP, err := netip.ParseAddr(*req.IPAddress)
 		if err != nil {
-			return nil, err
+			return db.CreateAuditLogParams{}, err
 		}
 		ipAddress = parsedIP
 	}
@@ -63,7 +83,7 @@ func (r *auditLogsRepository) CreateAuditLog(ctx context.Context, req domain.Cre
 		details = req.Details
 	}
 
-	dbLog, err := r.store.CreateAuditLog(ctx, db.CreateAuditLogParams{
+	return db.CreateAuditLogParams{
 		UserID:       pgUserID,
 		Action:       req.Action,
 		ResourceType: pgResourceType,
@@ -71,12 +91,7 @@ func (r *auditLogsRepository) CreateAuditLog(ctx context.Context, req domain.Cre
 		IpAddress:    &ipAddress,
 		UserAgent:    req.UserAgent,
 		Details:      details,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return r.toDomain(dbLog), nil
+	}, nil
 }
 
 func (r *auditLogsRepository) GetAuditLogsByUserID(ctx context.Context, userID int64, limit int32) ([]domain.AuditLog, error) {
@@ -96,86 +111,29 @@ func (r *auditLogsRepository) GetAuditLogsByUserID(ctx context.Context, userID i
 	return logs, nil
 }
 
-func (r *auditLogsRepository) GetAuditLogsByAction(ctx context.Context, action string, limit int32) ([]domain.AuditLog, error) {
-	dbLogs, err := r.store.GetAuditLogsByAction(ctx, db.GetAuditLogsByActionParams{
-		Action: action,
-		Limit:  limit,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	logs := make([]domain.AuditLog, len(dbLogs))
-	for i, log := range dbLogs {
-		logs[i] = *r.toDomain(log)
-	}
-
-	return logs, nil
-}
-
-func (r *auditLogsRepository) GetAuditLogsByResourceType(ctx context.Context, resourceType string, limit int32) ([]domain.AuditLog, error) {
-	dbLogs, err := r.store.GetAuditLogsByResourceType(ctx, db.GetAuditLogsByResourceTypeParams{
-		ResourceType: pgtype.Text{String: resourceType, Valid: true},
-		Limit:        limit,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	logs := make([]domain.AuditLog, len(dbLogs))
-	for i, log := range dbLogs {
-		logs[i] = *r.toDomain(log)
-	}
-
-	return logs, nil
-}
-
-func (r *auditLogsRepository) GetAuditLogsByResourceID(ctx context.Context, resourceType string, resourceID int64, limit int32) ([]domain.AuditLog, error) {
-	dbLogs, err := r.store.GetAuditLogsByResourceID(ctx, db.GetAuditLogsByResourceIDParams{
-		ResourceType: pgtype.Text{String: resourceType, Valid: true},
-		ResourceID:   pgtype.Int8{Int64: resourceID, Valid: true},
-		Limit:        limit,
-	})
-	if err != nil {
-		return nil, err
+func (r *auditLogsRepository) SearchAuditLogs(ctx context.Context, filter domain.AuditLogFilter, beforeID *int64) ([]domain.AuditLog, error) {
+	params := db.SearchAuditLogsParams{
+		Actions:  filter.Actions,
+		Network:  filter.Network,
+		FromTime: filter.From,
+		ToTime:   filter.To,
+		Details:  filter.Details,
+		RowLimit: filter.Limit,
 	}
-
-	logs := make([]domain.AuditLog, len(dbLogs))
-	for i, log := range dbLogs {
-		logs[i] = *r.toDomain(log)
+	if filter.UserID != nil {
+		params.UserID = pgtype.Int8{Int64: *filter.UserID, Valid: true}
 	}
-
-	return logs, nil
-}
-
-func (r *auditLogsRepository) GetAuditLogsByIP(ctx context.Context, ipAddress string, limit int32) ([]domain.AuditLog, error) {
-	parsedIP, err := netip.ParseAddr(ipAddress)
-	if err != nil {
-		return nil, err
+	if filter.ResourceType != nil {
+		params.ResourceType = pgtype.Text{String: *filter.ResourceType, Valid: true}
 	}
-
-	dbLogs, err := r.store.GetAuditLogsByIP(ctx, db.GetAuditLogsByIPParams{
-		IpAddress: &parsedIP,
-		Limit:     limit,
-	})
-	if err != nil {
-		return nil, err
+	if filter.ResourceID != nil {
+		params.ResourceID = pgtype.Int8{Int64: *filter.ResourceID, Valid: true}
 	}
-
-	logs := make([]domain.AuditLog, len(dbLogs))
-	for i, log := range dbLogs {
-		logs[i] = *r.toDomain(log)
+	if beforeID != nil {
+		params.BeforeID = pgtype.Int8{Int64: *beforeID, Valid: true}
 	}
 
-	return logs, nil
-}
-
-func (r *auditLogsRepository) GetAuditLogsByDateRange(ctx context.Context, startDate, endDate time.Time, limit int32) ([]domain.AuditLog, error) {
-	dbLogs, err := r.store.GetAuditLogsByDateRange(ctx, db.GetAuditLogsByDateRangeParams{
-		CreatedAt:   &startDate,
-		CreatedAt_2: &endDate,
-		Limit:       limit,
-	})
+	dbLogs, err := r.store.SearchAuditLogs(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -188,22 +146,27 @@ func (r *auditLogsRepository) GetAuditLogsByDateRange(ctx context.Context, start
 	return logs, nil
 }
 
-func (r *auditLogsRepository) GetRecentAuditLogs(ctx context.Context, limit int32) ([]domain.AuditLog, error) {
-	dbLogs, err := r.store.GetRecentAuditLogs(ctx, limit)
-	if err != nil {
-		return nil, err
-	}
-
-	logs := make([]domain.AuditLog, len(dbLogs))
-	for i, log := range dbLogs {
-		logs[i] = *r.toDomain(log)
-	}
+func (r *auditLogsRepository) DeleteOldAuditLogs(ctx context.Context) error {
+	return r.store.ExecTx(ctx, func(q db.Querier) error {
+		// Only whole prefixes of the chain are removed, anything missing
+		// after the recorded boundary is reported by verification
+		boundary, err := q.GetAuditLogPruneBoundary(ctx, time.Now().Add(-auditLogRetention))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
 
-	return logs, nil
-}
+		if err := q.DeleteAuditLogsThrough(ctx, boundary.ChainSeq); err != nil {
+			return err
+		}
 
-func (r *auditLogsRepository) DeleteOldAuditLogs(ctx context.Context) error {
-	return r.store.DeleteOldAuditLogs(ctx)
+		return q.RecordAuditLogChainPrune(ctx, db.RecordAuditLogChainPruneParams{
+			PrunedSeq:  boundary.ChainSeq,
+			PrunedHash: boundary.EntryHash,
+		})
+	})
 }
 
 func (r *auditLogsRepository) toDomain(dbLog db.AuditLog) *domain.AuditLog {
@@ -242,5 +205,7 @@ func (r *auditLogsRepository) toDomain(dbLog db.AuditLog) *domain.AuditLog {
 		UserAgent:    dbLog.UserAgent,
 		Details:      details,
 		CreatedAt:    dbLog.CreatedAt,
+		ChainSeq:     dbLog.ChainSeq,
+		EntryHash:    hex.EncodeToString(dbLog.EntryHash),
 	}
 }
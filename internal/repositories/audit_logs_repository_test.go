@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// TestSearchAuditLogsFilters checks that the search filters combine with AND
+// and that paging by id moves past earlier pages. It needs a migrated
+// database in TESTING_DB_SOURCE.
+func TestSearchAuditLogsFilters(t *testing.T) {
+	dbSource := os.Getenv("TESTING_DB_SOURCE")
+	if dbSource == "" {
+		t.Skip("TESTING_DB_SOURCE is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbSource)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(tx)
+
+	// Actions only this test writes, so existing entries stay out of the
+	// results
+	const login, logout = "search_test_login", "search_test_logout"
+	ipv4 := netip.MustParseAddr("203.0.113.9")
+	otherIPv4 := netip.MustParseAddr("198.51.100.7")
+	ipv6 := netip.MustParseAddr("2001:db8::1")
+
+	rows := []db.CreateAuditLogParams{
+		{Action: login, ResourceType: pgtype.Text{String: "user", Valid: true}, ResourceID: pgtype.Int8{Int64: 7, Valid: true}, IpAddress: &ipv4, Details: []byte(`{"success":true,"method":"password"}`)},
+		{Action: login, ResourceType: pgtype.Text{String: "user", Valid: true}, ResourceID: pgtype.Int8{Int64: 7, Valid: true}, IpAddress: &ipv4, Details: []byte(`{"success":false,"method":"password"}`)},
+		{Action: login, ResourceType: pgtype.Text{String: "user", Valid: true}, ResourceID: pgtype.Int8{Int64: 8, Valid: true}, IpAddress: &otherIPv4, Details: []byte(`{"success":true,"method":"passkey"}`)},
+		{Action: logout, ResourceType: pgtype.Text{String: "user", Valid: true}, ResourceID: pgtype.Int8{Int64: 7, Valid: true}, IpAddress: &ipv6},
+		{Action: logout, ResourceType: pgtype.Text{String: "session", Valid: true}, ResourceID: pgtype.Int8{Int64: 7, Valid: true}},
+	}
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		log, err := queries.CreateAuditLog(ctx, row)
+		if err != nil {
+			t.Fatalf("failed to create audit log: %v", err)
+		}
+		ids[i] = log.ID
+	}
+
+	repo := NewAuditLogsRepository(txStore{Queries: queries})
+
+	both := []string{login, logout}
+	resourceType := func(s string) *string { return &s }
+	resourceID := func(id int64) *int64 { return &id }
+	network := func(s string) *netip.Prefix {
+		prefix := netip.MustParsePrefix(s)
+		return &prefix
+	}
+	inAnHour := time.Now().Add(time.Hour)
+	anHourAgo := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		filter   domain.AuditLogFilter
+		beforeID *int64
+		want     []int
+	}{
+		{name: "actions only", filter: domain.AuditLogFilter{Actions: both}, want: []int{4, 3, 2, 1, 0}},
+		{name: "one action", filter: domain.AuditLogFilter{Actions: []string{logout}}, want: []int{4, 3}},
+		{name: "resource", filter: domain.AuditLogFilter{Actions: both, ResourceType: resourceType("user"), ResourceID: resourceID(7)}, want: []int{3, 1, 0}},
+		{name: "action and resource", filter: domain.AuditLogFilter{Actions: []string{login}, ResourceID: resourceID(7)}, want: []int{1, 0}},
+		{name: "single address", filter: domain.AuditLogFilter{Actions: both, Network: network("203.0.113.9/32")}, want: []int{1, 0}},
+		{name: "network", filter: domain.AuditLogFilter{Actions: both, Network: network("198.51.100.0/24")}, want: []int{2}},
+		{name: "IPv6 network", filter: domain.AuditLogFilter{Actions: both, Network: network("2001:db8::/32")}, want: []int{3}},
+		{name: "details", filter: domain.AuditLogFilter{Actions: both, Details: json.RawMessage(`{"success":true}`)}, want: []int{2, 0}},
+		{name: "details and address", filter: domain.AuditLogFilter{Actions: both, Network: network("203.0.113.9/32"), Details: json.RawMessage(`{"method":"password","success":false}`)}, want: []int{1}},
+		{name: "time range", filter: domain.AuditLogFilter{Actions: both, From: &anHourAgo, To: &inAnHour}, want: []int{4, 3, 2, 1, 0}},
+		{name: "range ending before the entries", filter: domain.AuditLogFilter{Actions: both, To: &anHourAgo}, want: nil},
+		{name: "limit", filter: domain.AuditLogFilter{Actions: both, Limit: 2}, want: []int{4, 3}},
+		{name: "page after a cursor", filter: domain.AuditLogFilter{Actions: both, Limit: 2}, beforeID: &ids[3], want: []int{2, 1}},
+		{name: "cursor and filter", filter: domain.AuditLogFilter{Actions: both, ResourceID: resourceID(7)}, beforeID: &ids[3], want: []int{1, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.filter.Limit == 0 {
+				tt.filter.Limit = 100
+			}
+			logs, err := repo.SearchAuditLogs(ctx, tt.filter, tt.beforeID)
+			if err != nil {
+				t.Fatalf("SearchAuditLogs() error = %v", err)
+			}
+
+			var got, want []int64
+			for _, log := range logs {
+				got = append(got, log.ID)
+			}
+			for _, i := range tt.want {
+				want = append(want, ids[i])
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("SearchAuditLogs() = %v, want %v", got, want)
+			}
+		})
+	}
+}
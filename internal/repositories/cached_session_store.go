@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// cachedSessionStore writes through to a primary store and a cache. Lookups
+// hit the cache first and fall back to the primary, repopulating the cache on
+// a miss. Cache failures are logged and never fail the request.
+type cachedSessionStore struct {
+	primary SessionStore
+	cache   SessionStore
+}
+
+func NewCachedSessionStore(primary, cache SessionStore) SessionStore {
+	return &cachedSessionStore{
+		primary: primary,
+		cache:   cache,
+	}
+}
+
+func (s *cachedSessionStore) CreateSession(ctx context.Context, session *domain.Session) error {
+	if err := s.primary.CreateSession(ctx, session); err != nil {
+		return err
+	}
+
+	if err := s.cache.CreateSession(ctx, session); err != nil {
+		fmt.Printf("Warning: failed to cache session %s: %v\n", session.ID, err)
+	}
+
+	return nil
+}
+
+func (s *cachedSessionStore) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
+	if session, err := s.cache.GetSessionByID(ctx, sessionID); err == nil {
+		return session, nil
+	}
+
+	session, err := s.primary.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.populate(ctx, session)
+	return session, nil
+}
+
+func (s *cachedSessionStore) GetSessionByAccessTokenHash(ctx context.Context, accessTokenHash string) (*domain.Session, error) {
+	if session, err := s.cache.GetSessionByAccessTokenHash(ctx, accessTokenHash); err == nil {
+		return session, nil
+	}
+
+	session, err := s.primary.GetSessionByAccessTokenHash(ctx, accessTokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	s.populate(ctx, session)
+	return session, nil
+}
+
+func (s *cachedSessionStore) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*domain.Session, error) {
+	if session, err := s.cache.GetSessionByRefreshTokenHash(ctx, refreshTokenHash); err == nil {
+		return session, nil
+	}
+
+	session, err := s.primary.GetSessionByRefreshTokenHash(ctx, refreshTokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	s.populate(ctx, session)
+	return session, nil
+}
+
+// GetUserSessions always reads from the primary store since the cache only
+// holds sessions that have been looked up recently.
+func (s *cachedSessionStore) GetUserSessions(ctx context.Context, userID int64) ([]domain.Session, error) {
+	return s.primary.GetUserSessions(ctx, userID)
+}
+
+func (s *cachedSessionStore) UpdateSessionTokens(ctx context.Context, session *domain.Session, previousAccessTokenHash, previousRefreshTokenHash string) error {
+	if err := s.primary.UpdateSessionTokens(ctx, session, previousAccessTokenHash, previousRefreshTokenHash); err != nil {
+		return err
+	}
+
+	if err := s.cache.UpdateSessionTokens(ctx, session, previousAccessTokenHash, previousRefreshTokenHash); err != nil {
+		fmt.Printf("Warning: failed to update cached session %s: %v\n", session.ID, err)
+	}
+
+	return nil
+}
+
+func (s *cachedSessionStore) UpdateSessionActivity(ctx context.Context, session *domain.Session) error {
+	if err := s.primary.UpdateSessionActivity(ctx, session); err != nil {
+		return err
+	}
+
+	if err := s.cache.UpdateSessionActivity(ctx, session); err != nil {
+		fmt.Printf("Warning: failed to update cached session activity %s: %v\n", session.ID, err)
+	}
+
+	return nil
+}
+
+func (s *cachedSessionStore) DeleteSession(ctx context.Context, session *domain.Session) error {
+	// Evict from the cache first so a failed primary write never leaves a
+	// revoked session readable through the cache.
+	if err := s.cache.DeleteSession(ctx, session); err != nil {
+		fmt.Printf("Warning: failed to evict cached session %s: %v\n", session.ID, err)
+	}
+
+	return s.primary.DeleteSession(ctx, session)
+}
+
+func (s *cachedSessionStore) CleanupExpiredSessions(ctx context.Context) (int, error) {
+	if _, err := s.cache.CleanupExpiredSessions(ctx); err != nil {
+		fmt.Printf("Warning: failed to clean up session cache: %v\n", err)
+	}
+
+	return s.primary.CleanupExpiredSessions(ctx)
+}
+
+func (s *cachedSessionStore) populate(ctx context.Context, session *domain.Session) {
+	if err := s.cache.CreateSession(ctx, session); err != nil {
+		fmt.Printf("Warning: failed to cache session %s: %v\n", session.ID, err)
+	}
+}
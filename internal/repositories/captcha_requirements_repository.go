@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+type CaptchaRequirementsRepository interface {
+	// Require places the requirement, or extends the one already in place for
+	// the same scope and value.
+	Require(ctx context.Context, req domain.CreateCaptchaRequirementAction) (*domain.CaptchaRequirement, error)
+	// IsRequired reports whether an active requirement matches any field of
+	// subject.
+	IsRequired(ctx context.Context, subject domain.CaptchaSubject) (bool, error)
+	DeleteExpiredRequirements(ctx context.Context) error
+}
+
+type captchaRequirementsRepository struct {
+	store db.Store
+}
+
+func NewCaptchaRequirementsRepository(store db.Store) CaptchaRequirementsRepository {
+	return &captchaRequirementsRepository{
+		store: store,
+	}
+}
+
+func (r *captchaRequirementsRepository) Require(ctx context.Context, req domain.CreateCaptchaRequirementAction) (*domain.CaptchaRequirement, error) {
+	dbRequirement, err := r.store.UpsertCaptchaRequirement(ctx, db.UpsertCaptchaRequirementParams{
+		Scope:     req.Scope,
+		Value:     req.Value,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CaptchaRequirement{
+		ID:        dbRequirement.ID,
+		Scope:     dbRequirement.Scope,
+		Value:     dbRequirement.Value,
+		Reason:    dbRequirement.Reason,
+		ExpiresAt: dbRequirement.ExpiresAt,
+		CreatedAt: dbRequirement.CreatedAt,
+	}, nil
+}
+
+func (r *captchaRequirementsRepository) IsRequired(ctx context.Context, subject domain.CaptchaSubject) (bool, error) {
+	if subject.IPAddress == "" && subject.ASN == "" && subject.PasswordFingerprint == "" {
+		return false, nil
+	}
+
+	return r.store.HasActiveCaptchaRequirement(ctx, db.HasActiveCaptchaRequirementParams{
+		IpAddress:           subject.IPAddress,
+		Asn:                 subject.ASN,
+		PasswordFingerprint: subject.PasswordFingerprint,
+	})
+}
+
+func (r *captchaRequirementsRepository) DeleteExpiredRequirements(ctx context.Context) error {
+	return r.store.DeleteExpiredCaptchaRequirements(ctx)
+}
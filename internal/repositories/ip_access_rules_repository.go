@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+type IPAccessRulesRepository interface {
+	CreateRule(ctx context.Context, req domain.CreateIPAccessRuleAction) (*domain.IPAccessRule, error)
+	GetRuleByID(ctx context.Context, id int64) (*domain.IPAccessRule, error)
+	ListRules(ctx context.Context) ([]domain.IPAccessRule, error)
+	ListActiveRules(ctx context.Context) ([]domain.IPAccessRule, error)
+	DeleteRule(ctx context.Context, id int64) error
+	DeleteExpiredRules(ctx context.Context) error
+}
+
+type ipAccessRulesRepository struct {
+	store db.Store
+}
+
+func NewIPAccessRulesRepository(store db.Store) IPAccessRulesRepository {
+	return &ipAccessRulesRepository{
+		store: store,
+	}
+}
+
+func (r *ipAccessRulesRepository) CreateRule(ctx context.Context, req domain.CreateIPAccessRuleAction) (*domain.IPAccessRule, error) {
+	var createdBy pgtype.Int8
+	if req.CreatedBy != nil {
+		createdBy = pgtype.Int8{Int64: *req.CreatedBy, Valid: true}
+	}
+
+	dbRule, err := r.store.CreateIPAccessRule(ctx, db.CreateIPAccessRuleParams{
+		Network:   req.Network.Masked(),
+		RuleType:  string(req.RuleType),
+		Reason:    req.Reason,
+		CreatedBy: createdBy,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbRule), nil
+}
+
+func (r *ipAccessRulesRepository) GetRuleByID(ctx context.Context, id int64) (*domain.IPAccessRule, error) {
+	dbRule, err := r.store.GetIPAccessRuleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbRule), nil
+}
+
+func (r *ipAccessRulesRepository) ListRules(ctx context.Context) ([]domain.IPAccessRule, error) {
+	dbRules, err := r.store.ListIPAccessRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]domain.IPAccessRule, len(dbRules))
+	for i, rule := range dbRules {
+		rules[i] = *r.toDomain(rule)
+	}
+
+	return rules, nil
+}
+
+func (r *ipAccessRulesRepository) ListActiveRules(ctx context.Context) ([]domain.IPAccessRule, error) {
+	dbRules, err := r.store.ListActiveIPAccessRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]domain.IPAccessRule, len(dbRules))
+	for i, rule := range dbRules {
+		rules[i] = *r.toDomain(rule)
+	}
+
+	return rules, nil
+}
+
+func (r *ipAccessRulesRepository) DeleteRule(ctx context.Context, id int64) error {
+	return r.store.DeleteIPAccessRule(ctx, id)
+}
+
+func (r *ipAccessRulesRepository) DeleteExpiredRules(ctx context.Context) error {
+	return r.store.DeleteExpiredIPAccessRules(ctx)
+}
+
+func (r *ipAccessRulesRepository) toDomain(dbRule db.IpAccessRule) *domain.IPAccessRule {
+	var createdBy *int64
+	if dbRule.CreatedBy.Valid {
+		createdBy = &dbRule.CreatedBy.Int64
+	}
+
+	return &domain.IPAccessRule{
+		ID:        dbRule.ID,
+		Network:   dbRule.Network,
+		RuleType:  domain.IPAccessRuleType(dbRule.RuleType),
+		Reason:    dbRule.Reason,
+		CreatedBy: createdBy,
+		ExpiresAt: dbRule.ExpiresAt,
+		CreatedAt: dbRule.CreatedAt,
+	}
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"net/netip"
+
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+type LoginAlertsRepository interface {
+	CreateAlert(ctx context.Context, req domain.CreateLoginAlertAction) (*domain.LoginAlert, error)
+	GetAlertByTokenHash(ctx context.Context, tokenHash string) (*domain.LoginAlert, error)
+	// MarkAlertReported returns pgx.ErrNoRows when the alert was already
+	// reported, so each link works once.
+	MarkAlertReported(ctx context.Context, id int64) (*domain.LoginAlert, error)
+	// ReleaseAlertReport undoes MarkAlertReported so a report that failed
+	// part way can be retried with the same link.
+	ReleaseAlertReport(ctx context.Context, id int64) error
+	DeleteExpiredAlerts(ctx context.Context) error
+}
+
+type loginAlertsRepository struct {
+	store db.Store
+}
+
+func NewLoginAlertsRepository(store db.Store) LoginAlertsRepository {
+	return &loginAlertsRepository{
+		store: store,
+	}
+}
+
+func (r *loginAlertsRepository) CreateAlert(ctx context.Context, req domain.CreateLoginAlertAction) (*domain.LoginAlert, error) {
+	parsedIP, err := netip.ParseAddr(req.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	dbAlert, err := r.store.CreateLoginAlert(ctx, db.CreateLoginAlertParams{
+		UserID:      req.UserID,
+		SessionID:   req.SessionID,
+		DeviceName:  req.DeviceName,
+		IpAddress:   parsedIP,
+		Location:    req.Location,
+		NewDevice:   req.NewDevice,
+		NewLocation: req.NewLocation,
+		TokenHash:   req.TokenHash,
+		ExpiresAt:   req.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbAlert), nil
+}
+
+func (r *loginAlertsRepository) GetAlertByTokenHash(ctx context.Context, tokenHash string) (*domain.LoginAlert, error) {
+	dbAlert, err := r.store.GetLoginAlertByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbAlert), nil
+}
+
+func (r *loginAlertsRepository) MarkAlertReported(ctx context.Context, id int64) (*domain.LoginAlert, error) {
+	dbAlert, err := r.store.MarkLoginAlertReported(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbAlert), nil
+}
+
+func (r *loginAlertsRepository) ReleaseAlertReport(ctx context.Context, id int64) error {
+	return r.store.ReleaseLoginAlertReport(ctx, id)
+}
+
+func (r *loginAlertsRepository) DeleteExpiredAlerts(ctx context.Context) error {
+	return r.store.DeleteExpiredLoginAlerts(ctx)
+}
+
+func (r *loginAlertsRepository) toDomain(dbAlert db.LoginAlert) *domain.LoginAlert {
+	return &domain.LoginAlert{
+		ID:          dbAlert.ID,
+		UserID:      dbAlert.UserID,
+		SessionID:   dbAlert.SessionID,
+		DeviceName:  dbAlert.DeviceName,
+		IPAddress:   dbAlert.IpAddress.String(),
+		Location:    dbAlert.Location,
+		NewDevice:   dbAlert.NewDevice,
+		NewLocation: dbAlert.NewLocation,
+		ExpiresAt:   dbAlert.ExpiresAt,
+		ReportedAt:  dbAlert.ReportedAt,
+		CreatedAt:   dbAlert.CreatedAt,
+	}
+}
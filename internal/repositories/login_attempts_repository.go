@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"net/netip"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
@@ -20,6 +21,20 @@ type LoginAttemptsRepository interface {
 	GetRecentFailedAttemptsByUserID(ctx context.Context, userID int64) ([]domain.LoginAttempt, error)
 	GetRecentFailedAttemptsByEmail(ctx context.Context, email string) ([]domain.LoginAttempt, error)
 	GetRecentFailedAttemptsByIP(ctx context.Context, ipAddress string) ([]domain.LoginAttempt, error)
+	CountConsecutiveFailedLogins(ctx context.Context, email string, since time.Time) (int64, error)
+	// CountConsecutiveFailedLoginIPs counts the addresses the failures
+	// counted by CountConsecutiveFailedLogins came from.
+	CountConsecutiveFailedLoginIPs(ctx context.Context, email string, since time.Time) (int64, error)
+	GetFailedLoginVelocity(ctx context.Context, network netip.Prefix, since time.Time) (*domain.LoginVelocity, error)
+	GetLoginOutcomesByASN(ctx context.Context, asn int64, since time.Time) (*domain.LoginOutcomes, error)
+	GetPasswordSpread(ctx context.Context, passwordFingerprint string, since time.Time) (*domain.PasswordSpread, error)
+	// GetPasswordSprayAddresses returns the addresses that each tried the
+	// password against at least minEmails distinct emails, busiest first.
+	GetPasswordSprayAddresses(ctx context.Context, passwordFingerprint string, since time.Time, minEmails int64, limit int32) ([]string, error)
+	// GetLastLocatedLogin returns the user's latest successful login that
+	// could be placed on a map.
+	GetLastLocatedLogin(ctx context.Context, userID int64) (*domain.LoginAttempt, error)
+	GetLoginCountryHistory(ctx context.Context, userID int64, countryCode string) (*domain.LoginCountryHistory, error)
 	DeleteOldLoginAttempts(ctx context.Context) error
 }
 
@@ -49,14 +64,27 @@ func (r *loginAttemptsRepository) CreateLoginAttempt(ctx context.Context, req do
 		pgFailureReason = pgtype.Text{String: *req.FailureReason, Valid: true}
 	}
 
-	dbAttempt, err := r.store.CreateLoginAttempt(ctx, db.CreateLoginAttemptParams{
+	params := db.CreateLoginAttemptParams{
 		UserID:        pgUserID,
 		Email:         req.Email,
 		IpAddress:     parsedIP,
 		UserAgent:     req.UserAgent,
 		Success:       req.Success,
 		FailureReason: pgFailureReason,
-	})
+		PasswordFingerprint: pgtype.Text{
+			String: req.PasswordFingerprint,
+			Valid:  req.PasswordFingerprint != "",
+		},
+	}
+	if location := req.Location; location != nil {
+		params.CountryCode = pgtype.Text{String: location.CountryCode, Valid: location.CountryCode != ""}
+		params.City = pgtype.Text{String: location.City, Valid: location.City != ""}
+		params.Asn = pgtype.Int8{Int64: location.ASN, Valid: location.ASN != 0}
+		params.Latitude = pgtype.Float8{Float64: location.Latitude, Valid: location.HasCoordinates}
+		params.Longitude = pgtype.Float8{Float64: location.Longitude, Valid: location.HasCoordinates}
+	}
+
+	dbAttempt, err := r.store.CreateLoginAttempt(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -223,6 +251,109 @@ func (r *loginAttemptsRepository) GetRecentFailedAttemptsByIP(ctx context.Contex
 	return attempts, nil
 }
 
+// CountConsecutiveFailedLogins counts failures for email after both since and
+// its last successful login.
+func (r *loginAttemptsRepository) CountConsecutiveFailedLogins(ctx context.Context, email string, since time.Time) (int64, error) {
+	return r.store.CountConsecutiveFailedLoginsByEmail(ctx, db.CountConsecutiveFailedLoginsByEmailParams{
+		Email: email,
+		Since: since,
+	})
+}
+
+func (r *loginAttemptsRepository) CountConsecutiveFailedLoginIPs(ctx context.Context, email string, since time.Time) (int64, error) {
+	return r.store.CountConsecutiveFailedLoginIPsByEmail(ctx, db.CountConsecutiveFailedLoginIPsByEmailParams{
+		Email: email,
+		Since: since,
+	})
+}
+
+func (r *loginAttemptsRepository) GetFailedLoginVelocity(ctx context.Context, network netip.Prefix, since time.Time) (*domain.LoginVelocity, error) {
+	velocity, err := r.store.GetFailedLoginVelocityByNetwork(ctx, db.GetFailedLoginVelocityByNetworkParams{
+		Network: network.Masked(),
+		Since:   &since,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginVelocity{
+		FailedAttempts: velocity.FailedAttempts,
+		DistinctEmails: velocity.DistinctEmails,
+	}, nil
+}
+
+func (r *loginAttemptsRepository) GetLoginOutcomesByASN(ctx context.Context, asn int64, since time.Time) (*domain.LoginOutcomes, error) {
+	outcomes, err := r.store.GetLoginOutcomesByASN(ctx, db.GetLoginOutcomesByASNParams{
+		Asn:   pgtype.Int8{Int64: asn, Valid: true},
+		Since: &since,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginOutcomes{
+		Attempts:       outcomes.Attempts,
+		FailedAttempts: outcomes.FailedAttempts,
+	}, nil
+}
+
+func (r *loginAttemptsRepository) GetPasswordSpread(ctx context.Context, passwordFingerprint string, since time.Time) (*domain.PasswordSpread, error) {
+	spread, err := r.store.GetPasswordFingerprintSpread(ctx, db.GetPasswordFingerprintSpreadParams{
+		PasswordFingerprint: pgtype.Text{String: passwordFingerprint, Valid: true},
+		Since:               &since,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PasswordSpread{
+		DistinctEmails: spread.DistinctEmails,
+		DistinctIPs:    spread.DistinctIps,
+	}, nil
+}
+
+func (r *loginAttemptsRepository) GetPasswordSprayAddresses(ctx context.Context, passwordFingerprint string, since time.Time, minEmails int64, limit int32) ([]string, error) {
+	rows, err := r.store.GetPasswordFingerprintSprayers(ctx, db.GetPasswordFingerprintSprayersParams{
+		PasswordFingerprint: pgtype.Text{String: passwordFingerprint, Valid: true},
+		Since:               &since,
+		MinEmails:           minEmails,
+		RowLimit:            limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, len(rows))
+	for i, row := range rows {
+		addresses[i] = row.IpAddress.String()
+	}
+	return addresses, nil
+}
+
+func (r *loginAttemptsRepository) GetLastLocatedLogin(ctx context.Context, userID int64) (*domain.LoginAttempt, error) {
+	dbAttempt, err := r.store.GetLastSuccessfulLoginWithLocation(ctx, pgtype.Int8{Int64: userID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbAttempt), nil
+}
+
+func (r *loginAttemptsRepository) GetLoginCountryHistory(ctx context.Context, userID int64, countryCode string) (*domain.LoginCountryHistory, error) {
+	history, err := r.store.GetLoginCountryHistory(ctx, db.GetLoginCountryHistoryParams{
+		CountryCode: pgtype.Text{String: countryCode, Valid: true},
+		UserID:      pgtype.Int8{Int64: userID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginCountryHistory{
+		FromCountry: history.FromCountry,
+		Located:     history.Located,
+	}, nil
+}
+
 func (r *loginAttemptsRepository) DeleteOldLoginAttempts(ctx context.Context) error {
 	return r.store.DeleteOldLoginAttempts(ctx)
 }
@@ -238,6 +369,18 @@ func (r *loginAttemptsRepository) toDomain(dbAttempt db.LoginAttempt) *domain.Lo
 		failureReason = &dbAttempt.FailureReason.String
 	}
 
+	var location *domain.GeoLocation
+	if dbAttempt.CountryCode.Valid || dbAttempt.Asn.Valid || dbAttempt.Latitude.Valid {
+		location = &domain.GeoLocation{
+			CountryCode:    dbAttempt.CountryCode.String,
+			City:           dbAttempt.City.String,
+			Latitude:       dbAttempt.Latitude.Float64,
+			Longitude:      dbAttempt.Longitude.Float64,
+			HasCoordinates: dbAttempt.Latitude.Valid && dbAttempt.Longitude.Valid,
+			ASN:            dbAttempt.Asn.Int64,
+		}
+	}
+
 	return &domain.LoginAttempt{
 		ID:            dbAttempt.ID,
 		UserID:        userID,
@@ -246,6 +389,7 @@ func (r *loginAttemptsRepository) toDomain(dbAttempt db.LoginAttempt) *domain.Lo
 		UserAgent:     dbAttempt.UserAgent,
 		Success:       dbAttempt.Success,
 		FailureReason: failureReason,
+		Location:      location,
 		CreatedAt:     dbAttempt.CreatedAt,
 	}
 }
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// outboxRelayLockID is the advisory lock that keeps a single relay claiming
+// events at a time, so two relays never claim events of the same aggregate.
+const outboxRelayLockID int64 = 0x77686f616d69
+
+type OutboxRepository interface {
+	// ClaimPendingEvents leases up to limit due events until leaseUntil, so
+	// no other relay picks them up meanwhile, and returns them in the order
+	// they were written. An event waiting behind an earlier unpublished one
+	// for the same aggregate, leased or failed, is left out. It returns no
+	// events while another relay is claiming.
+	ClaimPendingEvents(ctx context.Context, limit int32, leaseUntil time.Time) ([]domain.OutboxEvent, error)
+	MarkEventsPublished(ctx context.Context, ids []int64) error
+	RecordEventFailure(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+	// ReleaseEvents ends the lease on claimed events that were not attempted.
+	ReleaseEvents(ctx context.Context, ids []int64) error
+	DeletePublishedEventsBefore(ctx context.Context, before time.Time) error
+}
+
+type outboxRepository struct {
+	store db.Store
+}
+
+func NewOutboxRepository(store db.Store) OutboxRepository {
+	return &outboxRepository{
+		store: store,
+	}
+}
+
+func (r *outboxRepository) ClaimPendingEvents(ctx context.Context, limit int32, leaseUntil time.Time) ([]domain.OutboxEvent, error) {
+	var dbEvents []db.OutboxEvent
+	err := r.store.ExecTx(ctx, func(q db.Querier) error {
+		locked, err := q.TryLockOutboxRelay(ctx, outboxRelayLockID)
+		if err != nil || !locked {
+			return err
+		}
+
+		dbEvents, err = q.ClaimPendingOutboxEvents(ctx, db.ClaimPendingOutboxEventsParams{
+			LeaseUntil: leaseUntil,
+			RowLimit:   limit,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ids follow the per-aggregate order, an event's id is drawn while its
+	// aggregate is locked by NextOutboxAggregateSeq
+	slices.SortFunc(dbEvents, func(a, b db.OutboxEvent) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	events := make([]domain.OutboxEvent, len(dbEvents))
+	for i, event := range dbEvents {
+		events[i] = domain.OutboxEvent{
+			ID:                event.EventID,
+			Sequence:          event.ID,
+			Type:              event.EventType,
+			AggregateType:     event.AggregateType,
+			AggregateID:       event.AggregateID,
+			AggregateSequence: event.AggregateSeq,
+			CreatedAt:         event.CreatedAt,
+			Data:              event.Payload,
+			Attempts:          event.Attempts,
+		}
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepository) MarkEventsPublished(ctx context.Context, ids []int64) error {
+	return r.store.MarkOutboxEventsPublished(ctx, ids)
+}
+
+func (r *outboxRepository) RecordEventFailure(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	return r.store.RecordOutboxEventFailure(ctx, db.RecordOutboxEventFailureParams{
+		ID:            id,
+		LastError:     lastError,
+		NextAttemptAt: nextAttemptAt,
+	})
+}
+
+func (r *outboxRepository) ReleaseEvents(ctx context.Context, ids []int64) error {
+	return r.store.ReleaseOutboxEvents(ctx, ids)
+}
+
+func (r *outboxRepository) DeletePublishedEventsBefore(ctx context.Context, before time.Time) error {
+	return r.store.DeletePublishedOutboxEventsBefore(ctx, before)
+}
+
+// enqueueOutboxEvent writes an event through q, which should be the
+// transaction that made the change the event describes. Numbering the event
+// locks its aggregate until that transaction ends, so another transaction's
+// event for the same aggregate is numbered, and written, after it commits.
+func enqueueOutboxEvent(ctx context.Context, q db.Querier, aggregateType, aggregateID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	aggregateSeq, err := q.NextOutboxAggregateSeq(ctx, db.NextOutboxAggregateSeqParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.CreateOutboxEvent(ctx, db.CreateOutboxEventParams{
+		EventID:       uuid.New(),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		AggregateSeq:  aggregateSeq,
+		EventType:     eventType,
+		Payload:       payload,
+	})
+}
+
+func enqueueUserEvent(ctx context.Context, q db.Querier, userID int64, eventType string, data interface{}) error {
+	return enqueueOutboxEvent(ctx, q, domain.OutboxAggregateUser, strconv.FormatInt(userID, 10), eventType, data)
+}
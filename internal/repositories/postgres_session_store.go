@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// postgresSessionStore keeps sessions in the sessions table. Deleted sessions
+// are only marked as revoked so they remain available for reporting until
+// CleanupExpiredSessions purges them.
+type postgresSessionStore struct {
+	store db.Store
+}
+
+func NewPostgresSessionStore(store db.Store) SessionStore {
+	return &postgresSessionStore{
+		store: store,
+	}
+}
+
+func (s *postgresSessionStore) CreateSession(ctx context.Context, session *domain.Session) error {
+	deviceInfo, err := json.Marshal(session.DeviceInfo)
+	if err != nil {
+		return fmt.Errorf("failed to marshal device info: %w", err)
+	}
+
+	var ipAddress *netip.Addr
+	if parsedIP, err := netip.ParseAddr(session.IPAddress); err == nil {
+		ipAddress = &parsedIP
+	}
+
+	_, err = s.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:                 session.ID,
+		UserID:             session.UserID,
+		AccessTokenHash:    session.TokenHash,
+		RefreshTokenHash:   session.RefreshTokenHash,
+		DeviceInfo:         deviceInfo,
+		IpAddress:          ipAddress,
+		UserAgent:          session.UserAgent,
+		ExpiresAt:          session.ExpiresAt,
+		AbsoluteExpiresAt:  session.AbsoluteExpiresAt,
+		IdleTimeoutSeconds: int32(session.IdleTimeout / time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *postgresSessionStore) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
+	dbSession, err := s.store.GetSessionByID(ctx, sessionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return s.toDomain(dbSession), nil
+}
+
+func (s *postgresSessionStore) GetSessionByAccessTokenHash(ctx context.Context, accessTokenHash string) (*domain.Session, error) {
+	dbSession, err := s.store.GetSessionByAccessTokenHash(ctx, accessTokenHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session for token: %w", err)
+	}
+
+	return s.toDomain(dbSession), nil
+}
+
+func (s *postgresSessionStore) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*domain.Session, error) {
+	dbSession, err := s.store.GetSessionByRefreshTokenHash(ctx, refreshTokenHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session for refresh token: %w", err)
+	}
+
+	return s.toDomain(dbSession), nil
+}
+
+func (s *postgresSessionStore) GetUserSessions(ctx context.Context, userID int64) ([]domain.Session, error) {
+	dbSessions, err := s.store.GetActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	sessions := make([]domain.Session, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		sessions[i] = *s.toDomain(dbSession)
+	}
+
+	return sessions, nil
+}
+
+func (s *postgresSessionStore) UpdateSessionTokens(ctx context.Context, session *domain.Session, previousAccessTokenHash, previousRefreshTokenHash string) error {
+	err := s.store.UpdateSessionTokens(ctx, db.UpdateSessionTokensParams{
+		ID:               session.ID,
+		AccessTokenHash:  session.TokenHash,
+		RefreshTokenHash: session.RefreshTokenHash,
+		ExpiresAt:        session.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *postgresSessionStore) UpdateSessionActivity(ctx context.Context, session *domain.Session) error {
+	err := s.store.UpdateSessionActivity(ctx, db.UpdateSessionActivityParams{
+		ID:           session.ID,
+		LastActiveAt: session.LastActive,
+		ExpiresAt:    session.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update session activity: %w", err)
+	}
+
+	return nil
+}
+
+func (s *postgresSessionStore) DeleteSession(ctx context.Context, session *domain.Session) error {
+	if err := s.store.RevokeSession(ctx, session.ID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *postgresSessionStore) CleanupExpiredSessions(ctx context.Context) (int, error) {
+	deleted, err := s.store.DeleteExpiredSessions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return int(deleted), nil
+}
+
+func (s *postgresSessionStore) toDomain(dbSession db.Session) *domain.Session {
+	deviceInfo := map[string]string{}
+	if len(dbSession.DeviceInfo) > 0 {
+		if err := json.Unmarshal(dbSession.DeviceInfo, &deviceInfo); err != nil {
+			fmt.Printf("Warning: failed to unmarshal device info for session %s: %v\n", dbSession.ID, err)
+		}
+	}
+
+	var ipAddress string
+	if dbSession.IpAddress != nil {
+		ipAddress = dbSession.IpAddress.String()
+	}
+
+	return &domain.Session{
+		ID:                dbSession.ID,
+		UserID:            dbSession.UserID,
+		TokenHash:         dbSession.AccessTokenHash,
+		RefreshTokenHash:  dbSession.RefreshTokenHash,
+		DeviceInfo:        deviceInfo,
+		IPAddress:         ipAddress,
+		UserAgent:         dbSession.UserAgent,
+		CreatedAt:         dbSession.CreatedAt,
+		LastActive:        dbSession.LastActiveAt,
+		ExpiresAt:         dbSession.ExpiresAt,
+		AbsoluteExpiresAt: dbSession.AbsoluteExpiresAt,
+		IdleTimeout:       time.Duration(dbSession.IdleTimeoutSeconds) * time.Second,
+		IsActive:          dbSession.IsActive,
+	}
+}
@@ -0,0 +1,264 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	redisutil "github.com/m1thrandir225/whoami/pkg/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisSessionStore struct {
+	redisClient *redis.Client
+}
+
+func NewRedisSessionStore(redisClient *redis.Client) SessionStore {
+	return &redisSessionStore{
+		redisClient: redisClient,
+	}
+}
+
+func sessionIDKey(sessionID string) string {
+	return fmt.Sprintf("session:id:%s", sessionID)
+}
+
+func sessionAccessTokenKey(tokenHash string) string {
+	return fmt.Sprintf("session:token:%s", tokenHash)
+}
+
+func sessionRefreshTokenKey(tokenHash string) string {
+	return fmt.Sprintf("session:refresh:%s", tokenHash)
+}
+
+func userSessionsKey(userID int64) string {
+	return fmt.Sprintf("user_sessions:%d", userID)
+}
+
+func (s *redisSessionStore) CreateSession(ctx context.Context, session *domain.Session) error {
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session data: %w", err)
+	}
+
+	expiration := time.Until(session.ExpiresAt)
+
+	// Store session by ID (primary key)
+	if err := s.redisClient.Set(ctx, sessionIDKey(session.ID), sessionData, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to store session by ID: %w", err)
+	}
+
+	// Store session by access token (for quick lookup)
+	if err := s.redisClient.Set(ctx, sessionAccessTokenKey(session.TokenHash), session.ID, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to store session by access token: %w", err)
+	}
+
+	// Store session by refresh token (for refresh operations)
+	if err := s.redisClient.Set(ctx, sessionRefreshTokenKey(session.RefreshTokenHash), session.ID, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to store session by refresh token: %w", err)
+	}
+
+	// Add to user's active sessions
+	userKey := userSessionsKey(session.UserID)
+	if err := s.redisClient.SAdd(ctx, userKey, session.ID).Err(); err != nil {
+		return fmt.Errorf("failed to add session to user sessions: %w", err)
+	}
+
+	// Keep the user sessions set alive for as long as any of its sessions could be
+	setExpiration := expiration
+	if !session.AbsoluteExpiresAt.IsZero() {
+		setExpiration = time.Until(session.AbsoluteExpiresAt)
+	}
+	if ttl, err := s.redisClient.TTL(ctx, userKey).Result(); err == nil && ttl < setExpiration {
+		s.redisClient.Expire(ctx, userKey, setExpiration)
+	}
+
+	return nil
+}
+
+func (s *redisSessionStore) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
+	sessionData, err := s.redisClient.Get(ctx, sessionIDKey(sessionID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	var session domain.Session
+	if err := json.Unmarshal([]byte(sessionData), &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
+	}
+
+	return &session, nil
+}
+
+func (s *redisSessionStore) GetSessionByAccessTokenHash(ctx context.Context, accessTokenHash string) (*domain.Session, error) {
+	sessionID, err := s.redisClient.Get(ctx, sessionAccessTokenKey(accessTokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session for token: %w", err)
+	}
+
+	return s.GetSessionByID(ctx, sessionID)
+}
+
+func (s *redisSessionStore) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*domain.Session, error) {
+	sessionID, err := s.redisClient.Get(ctx, sessionRefreshTokenKey(refreshTokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session for refresh token: %w", err)
+	}
+
+	return s.GetSessionByID(ctx, sessionID)
+}
+
+func (s *redisSessionStore) GetUserSessions(ctx context.Context, userID int64) ([]domain.Session, error) {
+	userKey := userSessionsKey(userID)
+	sessionIDs, err := s.redisClient.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user session IDs: %w", err)
+	}
+
+	sessions := make([]domain.Session, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		session, err := s.GetSessionByID(ctx, sessionID)
+		if err != nil {
+			// Skip invalid/expired sessions but log the issue
+			fmt.Printf("Warning: failed to get session %s, skipping: %v\n", sessionID, err)
+			// Remove invalid session ID from user sessions
+			s.redisClient.SRem(ctx, userKey, sessionID)
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, nil
+}
+
+func (s *redisSessionStore) UpdateSessionTokens(ctx context.Context, session *domain.Session, previousAccessTokenHash, previousRefreshTokenHash string) error {
+	// Remove old token mappings
+	s.redisClient.Del(ctx, sessionAccessTokenKey(previousAccessTokenHash), sessionRefreshTokenKey(previousRefreshTokenHash))
+
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal updated session: %w", err)
+	}
+
+	expiration := time.Until(session.ExpiresAt)
+
+	// Update session by ID
+	if err := s.redisClient.Set(ctx, sessionIDKey(session.ID), sessionData, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	// Create new token mappings
+	if err := s.redisClient.Set(ctx, sessionAccessTokenKey(session.TokenHash), session.ID, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to create new access token mapping: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, sessionRefreshTokenKey(session.RefreshTokenHash), session.ID, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to create new refresh token mapping: %w", err)
+	}
+
+	return nil
+}
+
+func (s *redisSessionStore) UpdateSessionActivity(ctx context.Context, session *domain.Session) error {
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session for activity update: %w", err)
+	}
+
+	expiration := time.Until(session.ExpiresAt)
+
+	if err := s.redisClient.Set(ctx, sessionIDKey(session.ID), sessionData, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to update session activity: %w", err)
+	}
+
+	s.redisClient.Expire(ctx, sessionAccessTokenKey(session.TokenHash), expiration)
+	s.redisClient.Expire(ctx, sessionRefreshTokenKey(session.RefreshTokenHash), expiration)
+
+	return nil
+}
+
+func (s *redisSessionStore) DeleteSession(ctx context.Context, session *domain.Session) error {
+	// Remove from user's active sessions
+	if err := s.redisClient.SRem(ctx, userSessionsKey(session.UserID), session.ID).Err(); err != nil {
+		fmt.Printf("Warning: failed to remove session from user sessions: %v\n", err)
+	}
+
+	// Delete all session keys
+	deletedCount, err := s.redisClient.Del(ctx,
+		sessionIDKey(session.ID),
+		sessionAccessTokenKey(session.TokenHash),
+		sessionRefreshTokenKey(session.RefreshTokenHash),
+	).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete session keys: %w", err)
+	}
+
+	fmt.Printf("Deleted %d session keys for session %s\n", deletedCount, session.ID)
+
+	return nil
+}
+
+func (s *redisSessionStore) CleanupExpiredSessions(ctx context.Context) (int, error) {
+	// This method cleans up orphaned session mappings and expired sessions
+	// Note: Redis should handle most expiration automatically, but this catches edge cases
+
+	cleanedCount := 0
+	err := redisutil.ScanKeys(ctx, s.redisClient, "user_sessions:*", func(userSessionKeys []string) error {
+		for _, userSessionKey := range userSessionKeys {
+			cleanedCount += s.cleanupUserSessionSet(ctx, userSessionKey)
+		}
+		return nil
+	})
+	if err != nil {
+		return cleanedCount, fmt.Errorf("failed to scan user session keys: %w", err)
+	}
+
+	return cleanedCount, nil
+}
+
+// cleanupUserSessionSet removes session IDs whose session has expired from a
+// single user's set, walking it with SSCAN.
+func (s *redisSessionStore) cleanupUserSessionSet(ctx context.Context, userSessionKey string) int {
+	cleanedCount := 0
+	var cursor uint64
+	for {
+		sessionIDs, next, err := s.redisClient.SScan(ctx, userSessionKey, cursor, "", 100).Result()
+		if err != nil {
+			fmt.Printf("Warning: failed to get session IDs for key %s: %v\n", userSessionKey, err)
+			return cleanedCount
+		}
+
+		// Check each session ID
+		for _, sessionID := range sessionIDs {
+			exists, err := s.redisClient.Exists(ctx, sessionIDKey(sessionID)).Result()
+			if err != nil {
+				fmt.Printf("Warning: failed to check session existence %s: %v\n", sessionID, err)
+				continue
+			}
+
+			// If session doesn't exist, remove it from user sessions
+			if exists == 0 {
+				s.redisClient.SRem(ctx, userSessionKey, sessionID)
+				cleanedCount++
+				fmt.Printf("Cleaned up orphaned session ID: %s\n", sessionID)
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return cleanedCount
+		}
+	}
+}
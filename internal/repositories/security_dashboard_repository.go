@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// SecurityDashboardRepository reads aggregates across login attempts,
+// lockouts, suspicious activities and users. Time series only contain
+// buckets that have rows.
+type SecurityDashboardRepository interface {
+	GetLoginAttemptSeries(ctx context.Context, r domain.DashboardRange) ([]domain.LoginAttemptBucket, error)
+	GetTopFailedLoginIPs(ctx context.Context, r domain.DashboardRange, limit int32) ([]domain.FailedLoginIP, error)
+	GetTopFailedLoginEmails(ctx context.Context, r domain.DashboardRange, limit int32) ([]domain.FailedLoginEmail, error)
+	GetLockoutSeries(ctx context.Context, r domain.DashboardRange) ([]domain.LockoutBucket, error)
+	GetActiveLockouts(ctx context.Context) (*domain.ActiveLockouts, error)
+	GetSuspiciousActivitySeries(ctx context.Context, r domain.DashboardRange) ([]domain.SuspiciousActivityBucket, error)
+	GetUnresolvedSuspiciousActivityCounts(ctx context.Context, r domain.DashboardRange) (*domain.SeverityCounts, error)
+	GetRegistrationSeries(ctx context.Context, r domain.DashboardRange) ([]domain.RegistrationBucket, error)
+	GetMFAAdoption(ctx context.Context) (*domain.MFAAdoption, error)
+}
+
+type securityDashboardRepository struct {
+	store db.Store
+}
+
+func NewSecurityDashboardRepository(store db.Store) SecurityDashboardRepository {
+	return &securityDashboardRepository{
+		store: store,
+	}
+}
+
+func (r *securityDashboardRepository) GetLoginAttemptSeries(ctx context.Context, dr domain.DashboardRange) ([]domain.LoginAttemptBucket, error) {
+	rows, err := r.store.GetLoginAttemptTimeSeries(ctx, db.GetLoginAttemptTimeSeriesParams{
+		BucketSize: dr.Bucket,
+		FromTime:   dr.From,
+		ToTime:     dr.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]domain.LoginAttemptBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = domain.LoginAttemptBucket{
+			Bucket:     row.Bucket.UTC(),
+			Successful: row.Successful,
+			Failed:     row.Failed,
+		}
+	}
+
+	return buckets, nil
+}
+
+func (r *securityDashboardRepository) GetTopFailedLoginIPs(ctx context.Context, dr domain.DashboardRange, limit int32) ([]domain.FailedLoginIP, error) {
+	rows, err := r.store.GetTopFailedLoginIPs(ctx, db.GetTopFailedLoginIPsParams{
+		FromTime: dr.From,
+		ToTime:   dr.To,
+		RowLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]domain.FailedLoginIP, len(rows))
+	for i, row := range rows {
+		ips[i] = domain.FailedLoginIP{
+			IPAddress:      row.IpAddress.String(),
+			FailedAttempts: row.FailedAttempts,
+			DistinctEmails: row.DistinctEmails,
+			LastAttemptAt:  row.LastAttemptAt,
+		}
+	}
+
+	return ips, nil
+}
+
+func (r *securityDashboardRepository) GetTopFailedLoginEmails(ctx context.Context, dr domain.DashboardRange, limit int32) ([]domain.FailedLoginEmail, error) {
+	rows, err := r.store.GetTopFailedLoginEmails(ctx, db.GetTopFailedLoginEmailsParams{
+		FromTime: dr.From,
+		ToTime:   dr.To,
+		RowLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]domain.FailedLoginEmail, len(rows))
+	for i, row := range rows {
+		emails[i] = domain.FailedLoginEmail{
+			Email:          row.Email,
+			FailedAttempts: row.FailedAttempts,
+			DistinctIPs:    row.DistinctIps,
+			LastAttemptAt:  row.LastAttemptAt,
+		}
+	}
+
+	return emails, nil
+}
+
+func (r *securityDashboardRepository) GetLockoutSeries(ctx context.Context, dr domain.DashboardRange) ([]domain.LockoutBucket, error) {
+	rows, err := r.store.GetLockoutTimeSeries(ctx, db.GetLockoutTimeSeriesParams{
+		BucketSize: dr.Bucket,
+		FromTime:   dr.From,
+		ToTime:     dr.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]domain.LockoutBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = domain.LockoutBucket{
+			Bucket:    row.Bucket.UTC(),
+			Lockouts:  row.Lockouts,
+			Permanent: row.Permanent,
+		}
+	}
+
+	return buckets, nil
+}
+
+func (r *securityDashboardRepository) GetActiveLockouts(ctx context.Context) (*domain.ActiveLockouts, error) {
+	accounts, err := r.store.CountActiveAccountLockouts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := r.store.CountActiveIPLockouts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ActiveLockouts{
+		Accounts:          accounts.Active,
+		PermanentAccounts: accounts.Permanent,
+		IPs:               ips,
+	}, nil
+}
+
+func (r *securityDashboardRepository) GetSuspiciousActivitySeries(ctx context.Context, dr domain.DashboardRange) ([]domain.SuspiciousActivityBucket, error) {
+	rows, err := r.store.GetSuspiciousActivityTimeSeries(ctx, db.GetSuspiciousActivityTimeSeriesParams{
+		BucketSize: dr.Bucket,
+		FromTime:   dr.From,
+		ToTime:     dr.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]domain.SuspiciousActivityBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = domain.SuspiciousActivityBucket{
+			Bucket:   row.Bucket.UTC(),
+			Low:      row.Low,
+			Medium:   row.Medium,
+			High:     row.High,
+			Critical: row.Critical,
+		}
+	}
+
+	return buckets, nil
+}
+
+func (r *securityDashboardRepository) GetUnresolvedSuspiciousActivityCounts(ctx context.Context, dr domain.DashboardRange) (*domain.SeverityCounts, error) {
+	rows, err := r.store.CountUnresolvedSuspiciousActivitiesBySeverity(ctx, db.CountUnresolvedSuspiciousActivitiesBySeverityParams{
+		FromTime: dr.From,
+		ToTime:   dr.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &domain.SeverityCounts{}
+	for _, row := range rows {
+		switch domain.Severity(row.Severity) {
+		case domain.LowActivity:
+			counts.Low = row.Count
+		case domain.MediumActivity:
+			counts.Medium = row.Count
+		case domain.HighActivity:
+			counts.High = row.Count
+		case domain.CriticalActivity:
+			counts.Critical = row.Count
+		}
+		counts.Total += row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *securityDashboardRepository) GetRegistrationSeries(ctx context.Context, dr domain.DashboardRange) ([]domain.RegistrationBucket, error) {
+	rows, err := r.store.GetRegistrationTimeSeries(ctx, db.GetRegistrationTimeSeriesParams{
+		BucketSize: dr.Bucket,
+		FromTime:   dr.From,
+		ToTime:     dr.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]domain.RegistrationBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = domain.RegistrationBucket{
+			Bucket:        row.Bucket.UTC(),
+			Registrations: row.Registrations,
+		}
+	}
+
+	return buckets, nil
+}
+
+func (r *securityDashboardRepository) GetMFAAdoption(ctx context.Context) (*domain.MFAAdoption, error) {
+	row, err := r.store.GetMFAAdoption(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	adoption := &domain.MFAAdoption{
+		ActiveUsers: row.ActiveUsers,
+		MFAEnabled:  row.MfaEnabled,
+	}
+	if row.ActiveUsers > 0 {
+		adoption.Rate = float64(row.MfaEnabled) / float64(row.ActiveUsers)
+	}
+
+	return adoption, nil
+}
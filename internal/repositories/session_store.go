@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSessionNotFound is returned by lookups when no live session matches.
+// Any other error means the store itself failed.
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore persists user sessions and indexes them by ID, access token
+// hash, refresh token hash and owning user. The expiry of a stored session is
+// taken from session.ExpiresAt.
+type SessionStore interface {
+	CreateSession(ctx context.Context, session *domain.Session) error
+	GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error)
+	GetSessionByAccessTokenHash(ctx context.Context, accessTokenHash string) (*domain.Session, error)
+	GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*domain.Session, error)
+	GetUserSessions(ctx context.Context, userID int64) ([]domain.Session, error)
+	// UpdateSessionTokens persists the new token hashes already set on
+	// session and drops the lookups for the previous pair.
+	UpdateSessionTokens(ctx context.Context, session *domain.Session, previousAccessTokenHash, previousRefreshTokenHash string) error
+	UpdateSessionActivity(ctx context.Context, session *domain.Session) error
+	DeleteSession(ctx context.Context, session *domain.Session) error
+	CleanupExpiredSessions(ctx context.Context) (int, error)
+}
+
+const (
+	SessionStoreRedis    = "redis"
+	SessionStorePostgres = "postgres"
+)
+
+// NewSessionStore returns the store configured by kind, Redis when empty. With
+// cacheEnabled the Postgres store is fronted by a Redis cache.
+func NewSessionStore(kind string, store db.Store, redisClient *redis.Client, cacheEnabled bool) (SessionStore, error) {
+	switch kind {
+	case SessionStorePostgres:
+		sessionStore := NewPostgresSessionStore(store)
+		if cacheEnabled {
+			sessionStore = NewCachedSessionStore(sessionStore, NewRedisSessionStore(redisClient))
+		}
+		return sessionStore, nil
+	case "", SessionStoreRedis:
+		return NewRedisSessionStore(redisClient), nil
+	default:
+		return nil, fmt.Errorf("unknown session store: %s", kind)
+	}
+}
@@ -0,0 +1,242 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	redisutil "github.com/m1thrandir225/whoami/pkg/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNewSessionStore(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	t.Cleanup(func() { redisClient.Close() })
+
+	tests := []struct {
+		name         string
+		kind         string
+		cacheEnabled bool
+		want         string
+		wantErr      bool
+	}{
+		{name: "defaults to redis", kind: "", want: "redis"},
+		{name: "redis", kind: SessionStoreRedis, want: "redis"},
+		{name: "redis ignores the cache", kind: SessionStoreRedis, cacheEnabled: true, want: "redis"},
+		{name: "postgres", kind: SessionStorePostgres, want: "postgres"},
+		{name: "postgres with a redis cache", kind: SessionStorePostgres, cacheEnabled: true, want: "cached"},
+		{name: "unknown store", kind: "memcached", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewSessionStore(tt.kind, nil, redisClient, tt.cacheEnabled)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewSessionStore() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSessionStore() error = %v", err)
+			}
+
+			var got string
+			switch s := store.(type) {
+			case *redisSessionStore:
+				got = "redis"
+			case *postgresSessionStore:
+				got = "postgres"
+			case *cachedSessionStore:
+				if _, ok := s.primary.(*postgresSessionStore); !ok {
+					t.Fatalf("cache primary is %T, want the postgres store", s.primary)
+				}
+				if _, ok := s.cache.(*redisSessionStore); !ok {
+					t.Fatalf("cache is %T, want the redis store", s.cache)
+				}
+				got = "cached"
+			default:
+				t.Fatalf("NewSessionStore() returned %T", store)
+			}
+			if got != tt.want {
+				t.Errorf("NewSessionStore() = %s store, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// memorySessionStore keeps sessions by ID, for testing the stores wrapping
+// another one.
+type memorySessionStore struct {
+	sessions map[string]domain.Session
+	err      error
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{sessions: make(map[string]domain.Session)}
+}
+
+func (s *memorySessionStore) CreateSession(ctx context.Context, session *domain.Session) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *memorySessionStore) find(match func(domain.Session) bool) (*domain.Session, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for _, session := range s.sessions {
+		if match(session) {
+			return &session, nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+func (s *memorySessionStore) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
+	return s.find(func(session domain.Session) bool { return session.ID == sessionID })
+}
+
+func (s *memorySessionStore) GetSessionByAccessTokenHash(ctx context.Context, accessTokenHash string) (*domain.Session, error) {
+	return s.find(func(session domain.Session) bool { return session.TokenHash == accessTokenHash })
+}
+
+func (s *memorySessionStore) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*domain.Session, error) {
+	return s.find(func(session domain.Session) bool { return session.RefreshTokenHash == refreshTokenHash })
+}
+
+func (s *memorySessionStore) GetUserSessions(ctx context.Context, userID int64) ([]domain.Session, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var sessions []domain.Session
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (s *memorySessionStore) UpdateSessionTokens(ctx context.Context, session *domain.Session, previousAccessTokenHash, previousRefreshTokenHash string) error {
+	return s.CreateSession(ctx, session)
+}
+
+func (s *memorySessionStore) UpdateSessionActivity(ctx context.Context, session *domain.Session) error {
+	return s.CreateSession(ctx, session)
+}
+
+func (s *memorySessionStore) DeleteSession(ctx context.Context, session *domain.Session) error {
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.sessions, session.ID)
+	return nil
+}
+
+func (s *memorySessionStore) CleanupExpiredSessions(ctx context.Context) (int, error) {
+	return 0, s.err
+}
+
+func TestCachedSessionStoreRepopulatesOnMiss(t *testing.T) {
+	ctx := context.Background()
+	primary := newMemorySessionStore()
+	cache := newMemorySessionStore()
+	store := NewCachedSessionStore(primary, cache)
+
+	session := &domain.Session{
+		ID:        "session-1",
+		UserID:    7,
+		TokenHash: "access-hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := primary.CreateSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetSessionByAccessTokenHash(ctx, "access-hash")
+	if err != nil {
+		t.Fatalf("GetSessionByAccessTokenHash() error = %v", err)
+	}
+	if got.ID != session.ID {
+		t.Fatalf("GetSessionByAccessTokenHash() = %s, want %s", got.ID, session.ID)
+	}
+	if _, ok := cache.sessions[session.ID]; !ok {
+		t.Error("session read from the primary was not cached")
+	}
+}
+
+func TestCachedSessionStoreSurvivesCacheFailures(t *testing.T) {
+	ctx := context.Background()
+	primary := newMemorySessionStore()
+	cache := newMemorySessionStore()
+	cache.err = errors.New("cache unavailable")
+	store := NewCachedSessionStore(primary, cache)
+
+	session := &domain.Session{ID: "session-1", UserID: 7, TokenHash: "access-hash"}
+	if err := store.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if _, err := store.GetSessionByID(ctx, session.ID); err != nil {
+		t.Fatalf("GetSessionByID() error = %v", err)
+	}
+	if err := store.DeleteSession(ctx, session); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	if _, err := store.GetSessionByID(ctx, session.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("GetSessionByID() after delete error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+// TestRedisSessionStoreCleanupExpiredSessions needs a Redis in
+// TESTING_REDIS_URL.
+func TestRedisSessionStoreCleanupExpiredSessions(t *testing.T) {
+	redisURL := os.Getenv("TESTING_REDIS_URL")
+	if redisURL == "" {
+		t.Skip("TESTING_REDIS_URL is not set")
+	}
+
+	redisClient, err := redisutil.NewRedisClient(redisURL)
+	if err != nil {
+		t.Fatalf("failed to create Redis client: %v", err)
+	}
+	t.Cleanup(func() { redisClient.Close() })
+
+	ctx := context.Background()
+	store := NewRedisSessionStore(redisClient)
+	userID := time.Now().UnixNano()
+	t.Cleanup(func() { redisClient.Unlink(context.Background(), userSessionsKey(userID)) })
+
+	live := &domain.Session{ID: fmt.Sprintf("live-%d", userID), UserID: userID, TokenHash: fmt.Sprintf("live-access-%d", userID), RefreshTokenHash: fmt.Sprintf("live-refresh-%d", userID), ExpiresAt: time.Now().Add(time.Hour)}
+	gone := &domain.Session{ID: fmt.Sprintf("gone-%d", userID), UserID: userID, TokenHash: fmt.Sprintf("gone-access-%d", userID), RefreshTokenHash: fmt.Sprintf("gone-refresh-%d", userID), ExpiresAt: time.Now().Add(time.Hour)}
+	for _, session := range []*domain.Session{live, gone} {
+		if err := store.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+		t.Cleanup(func() { store.DeleteSession(context.Background(), session) })
+	}
+
+	// Expired by Redis while its ID is still listed for the user
+	if err := redisClient.Unlink(ctx, sessionIDKey(gone.ID)).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.CleanupExpiredSessions(ctx); err != nil {
+		t.Fatalf("CleanupExpiredSessions() error = %v", err)
+	}
+
+	ids, err := redisClient.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != live.ID {
+		t.Errorf("user sessions after cleanup = %v, want only %s", ids, live.ID)
+	}
+}
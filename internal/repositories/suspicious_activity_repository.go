@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"net/netip"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
@@ -13,8 +15,15 @@ type SuspiciousActivityRepository interface {
 	CreateActivity(ctx context.Context, req domain.CreateSuspiciousActivityAction) (*domain.SuspiciousActivity, error)
 	GetActivitiesByUserID(ctx context.Context, userID int64, limit int32) ([]domain.SuspiciousActivity, error)
 	GetActivitiesByIP(ctx context.Context, ipAddress string, limit int32) ([]domain.SuspiciousActivity, error)
-	GetUnresolvedActivities(ctx context.Context, limit int32) ([]domain.SuspiciousActivity, error)
-	ResolveActivity(ctx context.Context, id int64) error
+	GetActivityByID(ctx context.Context, id int64) (*domain.SuspiciousActivity, error)
+	ListActivities(ctx context.Context, filter domain.SuspiciousActivityFilter) ([]domain.SuspiciousActivity, error)
+	// UpdateStatus moves an activity on only while it still has req.From, and
+	// returns pgx.ErrNoRows otherwise.
+	UpdateStatus(ctx context.Context, req domain.UpdateSuspiciousActivityStatusAction) (*domain.SuspiciousActivity, error)
+	// Assign hands the activity to assignee, or unassigns it when nil.
+	Assign(ctx context.Context, id int64, assignee *int64) (*domain.SuspiciousActivity, error)
+	AddNote(ctx context.Context, activityID int64, authorID *int64, note string) (*domain.SuspiciousActivityNote, error)
+	GetNotes(ctx context.Context, activityID int64) ([]domain.SuspiciousActivityNote, error)
 	GetActivityCountByUser(ctx context.Context, userID int64) (int64, error)
 	GetActivityCountByIP(ctx context.Context, ipAddress string) (int64, error)
 }
@@ -47,14 +56,39 @@ func (r *suspiciousActivityRepository) CreateActivity(ctx context.Context, req d
 		pgSeverity = pgtype.Text{String: severity, Valid: true}
 	}
 
-	dbActivity, err := r.store.CreateSuspiciousActivity(ctx, db.CreateSuspiciousActivityParams{
-		UserID:       pgtype.Int8{Int64: req.UserID, Valid: true},
-		ActivityType: req.ActivityType,
-		IpAddress:    parsedIP,
-		UserAgent:    &req.UserAgent,
-		Description:  pgtype.Text{String: req.Description, Valid: true},
-		Metadata:     req.Metadata,
-		Severity:     pgSeverity,
+	var dbActivity db.SuspiciousActivity
+	err = r.store.ExecTx(ctx, func(q db.Querier) error {
+		var err error
+		dbActivity, err = q.CreateSuspiciousActivity(ctx, db.CreateSuspiciousActivityParams{
+			UserID:       pgtype.Int8{Int64: req.UserID, Valid: req.UserID > 0},
+			ActivityType: req.ActivityType,
+			IpAddress:    parsedIP,
+			UserAgent:    &req.UserAgent,
+			Description:  pgtype.Text{String: req.Description, Valid: true},
+			Metadata:     req.Metadata,
+			Severity:     pgSeverity,
+		})
+		if err != nil {
+			return err
+		}
+
+		data := map[string]interface{}{
+			"activity_id":   dbActivity.ID,
+			"user_id":       req.UserID,
+			"activity_type": dbActivity.ActivityType,
+			"ip_address":    req.IPAddress,
+			"description":   req.Description,
+			"severity":      dbActivity.Severity.String,
+		}
+		if len(req.Metadata) > 0 {
+			data["metadata"] = json.RawMessage(req.Metadata)
+		}
+
+		// Activity that no account can be tied to is ordered by the IP instead
+		if req.UserID == 0 {
+			return enqueueOutboxEvent(ctx, q, domain.OutboxAggregateIP, parsedIP.String(), domain.OutboxEventSuspiciousActivity, data)
+		}
+		return enqueueUserEvent(ctx, q, req.UserID, domain.OutboxEventSuspiciousActivity, data)
 	})
 	if err != nil {
 		return nil, err
@@ -102,8 +136,26 @@ func (r *suspiciousActivityRepository) GetActivitiesByIP(ctx context.Context, ip
 	return activities, nil
 }
 
-func (r *suspiciousActivityRepository) GetUnresolvedActivities(ctx context.Context, limit int32) ([]domain.SuspiciousActivity, error) {
-	dbActivities, err := r.store.GetUnresolvedSuspiciousActivities(ctx, limit)
+func (r *suspiciousActivityRepository) GetActivityByID(ctx context.Context, id int64) (*domain.SuspiciousActivity, error) {
+	dbActivity, err := r.store.GetSuspiciousActivityByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbActivity), nil
+}
+
+func (r *suspiciousActivityRepository) ListActivities(ctx context.Context, filter domain.SuspiciousActivityFilter) ([]domain.SuspiciousActivity, error) {
+	statuses := make([]string, len(filter.Statuses))
+	for i, status := range filter.Statuses {
+		statuses[i] = string(status)
+	}
+
+	dbActivities, err := r.store.ListSuspiciousActivities(ctx, db.ListSuspiciousActivitiesParams{
+		Statuses:   statuses,
+		AssignedTo: toPgInt8(filter.AssignedTo),
+		RowLimit:   filter.Limit,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -116,8 +168,80 @@ func (r *suspiciousActivityRepository) GetUnresolvedActivities(ctx context.Conte
 	return activities, nil
 }
 
-func (r *suspiciousActivityRepository) ResolveActivity(ctx context.Context, id int64) error {
-	return r.store.ResolveSuspiciousActivity(ctx, id)
+func (r *suspiciousActivityRepository) UpdateStatus(ctx context.Context, req domain.UpdateSuspiciousActivityStatusAction) (*domain.SuspiciousActivity, error) {
+	var resolvedAt *time.Time
+	var resolvedBy pgtype.Int8
+	if req.To.Closed() {
+		now := time.Now()
+		resolvedAt = &now
+		resolvedBy = toPgInt8(req.ResolvedBy)
+	}
+
+	var dbActivity db.SuspiciousActivity
+	err := r.store.ExecTx(ctx, func(q db.Querier) error {
+		var err error
+		dbActivity, err = q.UpdateSuspiciousActivityStatus(ctx, db.UpdateSuspiciousActivityStatusParams{
+			NewStatus:     string(req.To),
+			ResolvedBy:    resolvedBy,
+			ResolvedAt:    resolvedAt,
+			ID:            req.ActivityID,
+			CurrentStatus: string(req.From),
+		})
+		if err != nil || req.Note == "" {
+			return err
+		}
+
+		_, err = q.CreateSuspiciousActivityNote(ctx, db.CreateSuspiciousActivityNoteParams{
+			ActivityID: req.ActivityID,
+			AuthorID:   toPgInt8(req.ResolvedBy),
+			Note:       req.Note,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbActivity), nil
+}
+
+func (r *suspiciousActivityRepository) Assign(ctx context.Context, id int64, assignee *int64) (*domain.SuspiciousActivity, error) {
+	dbActivity, err := r.store.AssignSuspiciousActivity(ctx, db.AssignSuspiciousActivityParams{
+		AssignedTo: toPgInt8(assignee),
+		ID:         id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbActivity), nil
+}
+
+func (r *suspiciousActivityRepository) AddNote(ctx context.Context, activityID int64, authorID *int64, note string) (*domain.SuspiciousActivityNote, error) {
+	dbNote, err := r.store.CreateSuspiciousActivityNote(ctx, db.CreateSuspiciousActivityNoteParams{
+		ActivityID: activityID,
+		AuthorID:   toPgInt8(authorID),
+		Note:       note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.noteToDomain(dbNote), nil
+}
+
+func (r *suspiciousActivityRepository) GetNotes(ctx context.Context, activityID int64) ([]domain.SuspiciousActivityNote, error) {
+	dbNotes, err := r.store.GetSuspiciousActivityNotes(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]domain.SuspiciousActivityNote, len(dbNotes))
+	for i, note := range dbNotes {
+		notes[i] = *r.noteToDomain(note)
+	}
+
+	return notes, nil
 }
 
 func (r *suspiciousActivityRepository) GetActivityCountByUser(ctx context.Context, userID int64) (int64, error) {
@@ -142,7 +266,35 @@ func (r *suspiciousActivityRepository) toDomain(dbActivity db.SuspiciousActivity
 		Description:  dbActivity.Description.String,
 		Metadata:     dbActivity.Metadata,
 		Severity:     dbActivity.Severity.String,
-		Resolved:     &dbActivity.Resolved.Bool,
 		CreatedAt:    dbActivity.CreatedAt,
+		Status:       domain.SuspiciousActivityStatus(dbActivity.Status),
+		AssignedTo:   fromPgInt8(dbActivity.AssignedTo),
+		ResolvedBy:   fromPgInt8(dbActivity.ResolvedBy),
+		ResolvedAt:   dbActivity.ResolvedAt,
+		UpdatedAt:    dbActivity.UpdatedAt,
+	}
+}
+
+func (r *suspiciousActivityRepository) noteToDomain(dbNote db.SuspiciousActivityNote) *domain.SuspiciousActivityNote {
+	return &domain.SuspiciousActivityNote{
+		ID:         dbNote.ID,
+		ActivityID: dbNote.ActivityID,
+		AuthorID:   fromPgInt8(dbNote.AuthorID),
+		Note:       dbNote.Note,
+		CreatedAt:  dbNote.CreatedAt,
+	}
+}
+
+func toPgInt8(v *int64) pgtype.Int8 {
+	if v == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *v, Valid: true}
+}
+
+func fromPgInt8(v pgtype.Int8) *int64 {
+	if !v.Valid {
+		return nil
 	}
+	return &v.Int64
 }
@@ -15,6 +15,8 @@ type UserDevicesRepository interface {
 	GetUserDevicesByUserID(ctx context.Context, userID int64) ([]domain.UserDevice, error)
 	GetUserDeviceByID(ctx context.Context, id, userID int64) (*domain.UserDevice, error)
 	UpdateUserDeviceLastUsed(ctx context.Context, id int64, lastUsedAt time.Time) (*domain.UserDevice, error)
+	// UpdateUserDeviceLocation records where the device was just used from.
+	UpdateUserDeviceLocation(ctx context.Context, id int64, ipAddress string, location *domain.GeoLocation) (*domain.UserDevice, error)
 	DeleteUserDevice(ctx context.Context, id, userID int64) error
 	DeleteAllUserDevices(ctx context.Context, userID int64) error
 	GetUserDeviceByDeviceID(ctx context.Context, userID int64, deviceID string) (*domain.UserDevice, error)
@@ -58,14 +60,18 @@ func (r *userDevicesRepository) CreateUserDevice(ctx context.Context, req domain
 		trusted = pgtype.Bool{Bool: false, Valid: true}
 	}
 
+	countryCode, city, asn := deviceLocationParams(req.Location)
 	dbDevice, err := r.store.CreateUserDevice(ctx, db.CreateUserDeviceParams{
-		UserID:     req.UserID,
-		DeviceID:   req.DeviceID,
-		DeviceName: deviceName,
-		DeviceType: deviceType,
-		UserAgent:  &req.UserAgent,
-		IpAddress:  &parsedIP,
-		Trusted:    trusted,
+		UserID:      req.UserID,
+		DeviceID:    req.DeviceID,
+		DeviceName:  deviceName,
+		DeviceType:  deviceType,
+		UserAgent:   &req.UserAgent,
+		IpAddress:   &parsedIP,
+		Trusted:     trusted,
+		CountryCode: countryCode,
+		City:        city,
+		Asn:         asn,
 	})
 	if err != nil {
 		return nil, err
@@ -184,7 +190,48 @@ func (r *userDevicesRepository) MarkDeviceAsTrusted(ctx context.Context, id, use
 	return r.toDomain(dbDevice), nil
 }
 
+func (r *userDevicesRepository) UpdateUserDeviceLocation(ctx context.Context, id int64, ipAddress string, location *domain.GeoLocation) (*domain.UserDevice, error) {
+	parsedIP, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	countryCode, city, asn := deviceLocationParams(location)
+	dbDevice, err := r.store.UpdateUserDeviceLocation(ctx, db.UpdateUserDeviceLocationParams{
+		ID:          id,
+		IpAddress:   &parsedIP,
+		CountryCode: countryCode,
+		City:        city,
+		Asn:         asn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toDomain(dbDevice), nil
+}
+
+func deviceLocationParams(location *domain.GeoLocation) (countryCode, city pgtype.Text, asn pgtype.Int8) {
+	if location == nil {
+		return
+	}
+
+	countryCode = pgtype.Text{String: location.CountryCode, Valid: location.CountryCode != ""}
+	city = pgtype.Text{String: location.City, Valid: location.City != ""}
+	asn = pgtype.Int8{Int64: location.ASN, Valid: location.ASN != 0}
+	return
+}
+
 func (r *userDevicesRepository) toDomain(dbDevice db.UserDevice) *domain.UserDevice {
+	var location *domain.GeoLocation
+	if dbDevice.CountryCode.Valid || dbDevice.Asn.Valid {
+		location = &domain.GeoLocation{
+			CountryCode: dbDevice.CountryCode.String,
+			City:        dbDevice.City.String,
+			ASN:         dbDevice.Asn.Int64,
+		}
+	}
+
 	return &domain.UserDevice{
 		ID:         dbDevice.ID,
 		UserID:     dbDevice.UserID,
@@ -194,6 +241,7 @@ func (r *userDevicesRepository) toDomain(dbDevice db.UserDevice) *domain.UserDev
 		UserAgent:  *dbDevice.UserAgent,
 		IPAddress:  dbDevice.IpAddress.String(),
 		Trusted:    dbDevice.Trusted.Bool,
+		Location:   location,
 		LastUsedAt: *dbDevice.LastUsedAt,
 		CreatedAt:  dbDevice.CreatedAt,
 	}
@@ -11,8 +11,12 @@ import (
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, req domain.CreateUserAction) (*domain.User, error)
+	// MigrateLegacyUser creates a user from the legacy identity store together
+	// with its password history, profile and audit entry, all or nothing.
+	MigrateLegacyUser(ctx context.Context, req domain.MigrateLegacyUserAction) (*domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
 	GetUserByID(ctx context.Context, id int64) (*domain.User, error)
+	GetUsersByIDs(ctx context.Context, ids []int64) ([]domain.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*domain.User, error)
 	IsUsernameAvailable(ctx context.Context, username string) (bool, error)
 	UpdateUser(ctx context.Context, user *domain.User) error
@@ -61,12 +65,115 @@ func (repo *userRepository) CreateUser(ctx context.Context, req domain.CreateUse
 		username = *req.Username
 	}
 
-	user, err := repo.store.CreateUser(ctx, db.CreateUserParams{
-		Email:           req.Email,
-		PasswordHash:    req.Password,
-		Username:        username,
-		Role:            string(domain.RoleUser),
-		PrivacySettings: privacySettingsJSON,
+	var user db.User
+	err = repo.store.ExecTx(ctx, func(q db.Querier) error {
+		var err error
+		user, err = q.CreateUser(ctx, db.CreateUserParams{
+			Email:           req.Email,
+			PasswordHash:    req.Password,
+			Username:        username,
+			Role:            string(domain.RoleUser),
+			PrivacySettings: privacySettingsJSON,
+		})
+		if err != nil {
+			return err
+		}
+
+		return enqueueUserEvent(ctx, q, user.ID, domain.OutboxEventUserCreated, map[string]interface{}{
+			"user_id":        user.ID,
+			"email":          user.Email,
+			"username":       user.Username,
+			"email_verified": user.EmailVerified,
+			"role":           user.Role,
+			"created_at":     user.CreatedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var privacySettings domain.PrivacySettings
+	err = json.Unmarshal(user.PrivacySettings, &privacySettings)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.toDomain(user, privacySettings), nil
+}
+
+func (repo *userRepository) MigrateLegacyUser(ctx context.Context, req domain.MigrateLegacyUserAction) (*domain.User, error) {
+	privacySettingsJSON, err := json.Marshal(req.User.PrivacySettings)
+	if err != nil {
+		return nil, err
+	}
+	username := ""
+	if req.User.Username != nil {
+		username = *req.User.Username
+	}
+
+	var user db.User
+	err = repo.store.ExecTx(ctx, func(q db.Querier) error {
+		var err error
+		user, err = q.CreateUser(ctx, db.CreateUserParams{
+			Email:           req.User.Email,
+			PasswordHash:    req.User.Password,
+			Username:        username,
+			Role:            string(domain.RoleUser),
+			PrivacySettings: privacySettingsJSON,
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.CreatePasswordHistory(ctx, db.CreatePasswordHistoryParams{
+			UserID:       user.ID,
+			PasswordHash: user.PasswordHash,
+			CreatedAt:    &user.CreatedAt,
+		}); err != nil {
+			return err
+		}
+
+		if req.Profile != nil {
+			if _, err := q.CreateUserProfile(ctx, db.CreateUserProfileParams{
+				UserID:    user.ID,
+				FirstName: req.Profile.FirstName,
+				LastName:  req.Profile.LastName,
+				Phone:     req.Profile.Phone,
+				AvatarUrl: req.Profile.AvatarURL,
+				Bio:       req.Profile.Bio,
+				Timezone:  req.Profile.Timezone,
+				Locale:    req.Profile.Locale,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if req.EmailVerified {
+			if err := q.MarkEmailVerified(ctx, user.ID); err != nil {
+				return err
+			}
+			user.EmailVerified = true
+		}
+
+		audit := req.Audit
+		audit.UserID = &user.ID
+		audit.ResourceID = &user.ID
+		auditParams, err := createAuditLogParams(audit)
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateAuditLog(ctx, auditParams); err != nil {
+			return err
+		}
+
+		return enqueueUserEvent(ctx, q, user.ID, domain.OutboxEventUserCreated, map[string]interface{}{
+			"user_id":        user.ID,
+			"email":          user.Email,
+			"username":       user.Username,
+			"email_verified": user.EmailVerified,
+			"role":           user.Role,
+			"created_at":     user.CreatedAt,
+		})
 	})
 	if err != nil {
 		return nil, err
@@ -111,6 +218,26 @@ func (repo *userRepository) GetUserByID(ctx context.Context, id int64) (*domain.
 	return repo.toDomain(user, privacySettings), nil
 }
 
+func (repo *userRepository) GetUsersByIDs(ctx context.Context, ids []int64) ([]domain.User, error) {
+	dbUsers, err := repo.store.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]domain.User, len(dbUsers))
+	for i, user := range dbUsers {
+		var privacySettings domain.PrivacySettings
+		err = json.Unmarshal(user.PrivacySettings, &privacySettings)
+		if err != nil {
+			return nil, err
+		}
+
+		users[i] = *repo.toDomain(user, privacySettings)
+	}
+
+	return users, nil
+}
+
 func (repo *userRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
 	user, err := repo.store.GetUserByUsername(ctx, username)
 	if err != nil {
@@ -135,10 +262,21 @@ func (repo *userRepository) IsUsernameAvailable(ctx context.Context, username st
 }
 
 func (repo *userRepository) UpdateUser(ctx context.Context, user *domain.User) error {
-	return repo.store.UpdateUser(ctx, db.UpdateUserParams{
-		ID:       user.ID,
-		Email:    user.Email,
-		Username: user.Username,
+	return repo.store.ExecTx(ctx, func(q db.Querier) error {
+		err := q.UpdateUser(ctx, db.UpdateUserParams{
+			ID:       user.ID,
+			Email:    user.Email,
+			Username: user.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		return enqueueUserEvent(ctx, q, user.ID, domain.OutboxEventUserUpdated, map[string]interface{}{
+			"user_id":  user.ID,
+			"email":    user.Email,
+			"username": user.Username,
+		})
 	})
 }
 
@@ -148,18 +286,42 @@ func (repo *userRepository) UpdateUserPrivacySettings(ctx context.Context, id in
 		return err
 	}
 
-	return repo.store.UpdateUserPrivacySettings(ctx, db.UpdateUserPrivacySettingsParams{
-		ID:              id,
-		PrivacySettings: privacySettingsJSON,
+	return repo.store.ExecTx(ctx, func(q db.Querier) error {
+		err := q.UpdateUserPrivacySettings(ctx, db.UpdateUserPrivacySettingsParams{
+			ID:              id,
+			PrivacySettings: privacySettingsJSON,
+		})
+		if err != nil {
+			return err
+		}
+
+		return enqueueUserEvent(ctx, q, id, domain.OutboxEventUserPrivacySettingsUpdated, map[string]interface{}{
+			"user_id":          id,
+			"privacy_settings": privacySettings,
+		})
 	})
 }
 
 func (repo *userRepository) DeactivateUser(ctx context.Context, id int64) error {
-	return repo.store.DeactivateUser(ctx, id)
+	return repo.store.ExecTx(ctx, func(q db.Querier) error {
+		if err := q.DeactivateUser(ctx, id); err != nil {
+			return err
+		}
+		return enqueueUserEvent(ctx, q, id, domain.OutboxEventUserDeactivated, map[string]interface{}{
+			"user_id": id,
+		})
+	})
 }
 
 func (repo *userRepository) ActivateUser(ctx context.Context, id int64) error {
-	return repo.store.ActivateUser(ctx, id)
+	return repo.store.ExecTx(ctx, func(q db.Querier) error {
+		if err := q.ActivateUser(ctx, id); err != nil {
+			return err
+		}
+		return enqueueUserEvent(ctx, q, id, domain.OutboxEventUserActivated, map[string]interface{}{
+			"user_id": id,
+		})
+	})
 }
 
 func (repo *userRepository) UpdateLastLogin(ctx context.Context, id int64) error {
@@ -167,12 +329,29 @@ func (repo *userRepository) UpdateLastLogin(ctx context.Context, id int64) error
 }
 
 func (repo *userRepository) MarkEmailVerified(ctx context.Context, id int64) error {
-	return repo.store.MarkEmailVerified(ctx, id)
+	return repo.store.ExecTx(ctx, func(q db.Querier) error {
+		if err := q.MarkEmailVerified(ctx, id); err != nil {
+			return err
+		}
+		return enqueueUserEvent(ctx, q, id, domain.OutboxEventUserEmailVerified, map[string]interface{}{
+			"user_id": id,
+		})
+	})
 }
 
 func (repo *userRepository) UpdateUserPassword(ctx context.Context, user *domain.User) error {
-	return repo.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
-		ID:           user.ID,
-		PasswordHash: user.Password,
+	return repo.store.ExecTx(ctx, func(q db.Querier) error {
+		err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:           user.ID,
+			PasswordHash: user.Password,
+		})
+		if err != nil {
+			return err
+		}
+
+		// The hash stays in the database, consumers only learn that it changed
+		return enqueueUserEvent(ctx, q, user.ID, domain.OutboxEventUserPasswordChanged, map[string]interface{}{
+			"user_id": user.ID,
+		})
 	})
 }
@@ -0,0 +1,242 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	db "github.com/m1thrandir225/whoami/internal/db/sqlc"
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+type WebhooksRepository interface {
+	CreateEndpoint(ctx context.Context, req domain.CreateWebhookEndpointAction) (*domain.WebhookEndpoint, error)
+	GetEndpointByID(ctx context.Context, id int64) (*domain.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context) ([]domain.WebhookEndpoint, error)
+	ListEndpointsForEvent(ctx context.Context, eventType string) ([]domain.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, req domain.UpdateWebhookEndpointAction) (*domain.WebhookEndpoint, error)
+	UpdateEndpointSecret(ctx context.Context, id int64, secret string) (*domain.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id int64) error
+	CreateDelivery(ctx context.Context, req domain.CreateWebhookDeliveryAction) (*domain.WebhookDelivery, error)
+	GetDeliveryByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error)
+	// ClaimDueDeliveries leases up to limit due deliveries until leaseUntil,
+	// so no other instance picks them up meanwhile. Rows another instance is
+	// claiming are skipped rather than waited on.
+	ClaimDueDeliveries(ctx context.Context, limit int32, leaseUntil time.Time) ([]domain.WebhookDelivery, error)
+	// GetDeliveriesByEndpointID lists the newest deliveries first. An empty
+	// status matches every status.
+	GetDeliveriesByEndpointID(ctx context.Context, endpointID int64, status string, limit int32) ([]domain.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, req domain.UpdateWebhookDeliveryAction) (*domain.WebhookDelivery, error)
+	// RequeueDelivery resets the delivery to pending with a fresh set of
+	// attempts, due at nextAttemptAt.
+	RequeueDelivery(ctx context.Context, id int64, nextAttemptAt time.Time) (*domain.WebhookDelivery, error)
+}
+
+type webhooksRepository struct {
+	store db.Store
+}
+
+func NewWebhooksRepository(store db.Store) WebhooksRepository {
+	return &webhooksRepository{
+		store: store,
+	}
+}
+
+func (r *webhooksRepository) CreateEndpoint(ctx context.Context, req domain.CreateWebhookEndpointAction) (*domain.WebhookEndpoint, error) {
+	dbEndpoint, err := r.store.CreateWebhookEndpoint(ctx, db.CreateWebhookEndpointParams{
+		Url:         req.URL,
+		Description: req.Description,
+		Secret:      req.Secret,
+		EventTypes:  req.EventTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.endpointToDomain(dbEndpoint), nil
+}
+
+func (r *webhooksRepository) GetEndpointByID(ctx context.Context, id int64) (*domain.WebhookEndpoint, error) {
+	dbEndpoint, err := r.store.GetWebhookEndpointByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.endpointToDomain(dbEndpoint), nil
+}
+
+func (r *webhooksRepository) ListEndpoints(ctx context.Context) ([]domain.WebhookEndpoint, error) {
+	dbEndpoints, err := r.store.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]domain.WebhookEndpoint, len(dbEndpoints))
+	for i, endpoint := range dbEndpoints {
+		endpoints[i] = *r.endpointToDomain(endpoint)
+	}
+
+	return endpoints, nil
+}
+
+func (r *webhooksRepository) ListEndpointsForEvent(ctx context.Context, eventType string) ([]domain.WebhookEndpoint, error) {
+	dbEndpoints, err := r.store.ListWebhookEndpointsForEvent(ctx, eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]domain.WebhookEndpoint, len(dbEndpoints))
+	for i, endpoint := range dbEndpoints {
+		endpoints[i] = *r.endpointToDomain(endpoint)
+	}
+
+	return endpoints, nil
+}
+
+func (r *webhooksRepository) UpdateEndpoint(ctx context.Context, req domain.UpdateWebhookEndpointAction) (*domain.WebhookEndpoint, error) {
+	dbEndpoint, err := r.store.UpdateWebhookEndpoint(ctx, db.UpdateWebhookEndpointParams{
+		ID:          req.ID,
+		Url:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		Active:      req.Active,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.endpointToDomain(dbEndpoint), nil
+}
+
+func (r *webhooksRepository) UpdateEndpointSecret(ctx context.Context, id int64, secret string) (*domain.WebhookEndpoint, error) {
+	dbEndpoint, err := r.store.UpdateWebhookEndpointSecret(ctx, db.UpdateWebhookEndpointSecretParams{
+		ID:     id,
+		Secret: secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.endpointToDomain(dbEndpoint), nil
+}
+
+func (r *webhooksRepository) DeleteEndpoint(ctx context.Context, id int64) error {
+	return r.store.DeleteWebhookEndpoint(ctx, id)
+}
+
+func (r *webhooksRepository) CreateDelivery(ctx context.Context, req domain.CreateWebhookDeliveryAction) (*domain.WebhookDelivery, error) {
+	dbDelivery, err := r.store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		EndpointID:    req.EndpointID,
+		EventID:       req.EventID,
+		EventType:     req.EventType,
+		Payload:       req.Payload,
+		NextAttemptAt: req.NextAttemptAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.deliveryToDomain(dbDelivery), nil
+}
+
+func (r *webhooksRepository) GetDeliveryByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	dbDelivery, err := r.store.GetWebhookDeliveryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.deliveryToDomain(dbDelivery), nil
+}
+
+func (r *webhooksRepository) ClaimDueDeliveries(ctx context.Context, limit int32, leaseUntil time.Time) ([]domain.WebhookDelivery, error) {
+	dbDeliveries, err := r.store.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: leaseUntil,
+		RowLimit:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]domain.WebhookDelivery, len(dbDeliveries))
+	for i, delivery := range dbDeliveries {
+		deliveries[i] = *r.deliveryToDomain(delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhooksRepository) GetDeliveriesByEndpointID(ctx context.Context, endpointID int64, status string, limit int32) ([]domain.WebhookDelivery, error) {
+	dbDeliveries, err := r.store.GetWebhookDeliveriesByEndpointID(ctx, db.GetWebhookDeliveriesByEndpointIDParams{
+		EndpointID: endpointID,
+		Status:     status,
+		RowLimit:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]domain.WebhookDelivery, len(dbDeliveries))
+	for i, delivery := range dbDeliveries {
+		deliveries[i] = *r.deliveryToDomain(delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhooksRepository) UpdateDelivery(ctx context.Context, req domain.UpdateWebhookDeliveryAction) (*domain.WebhookDelivery, error) {
+	dbDelivery, err := r.store.UpdateWebhookDelivery(ctx, db.UpdateWebhookDeliveryParams{
+		ID:             req.ID,
+		Status:         req.Status,
+		Attempts:       req.Attempts,
+		LastStatusCode: req.LastStatusCode,
+		LastError:      req.LastError,
+		NextAttemptAt:  req.NextAttemptAt,
+		DeliveredAt:    req.DeliveredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.deliveryToDomain(dbDelivery), nil
+}
+
+func (r *webhooksRepository) RequeueDelivery(ctx context.Context, id int64, nextAttemptAt time.Time) (*domain.WebhookDelivery, error) {
+	dbDelivery, err := r.store.RequeueWebhookDelivery(ctx, db.RequeueWebhookDeliveryParams{
+		ID:            id,
+		NextAttemptAt: nextAttemptAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.deliveryToDomain(dbDelivery), nil
+}
+
+func (r *webhooksRepository) endpointToDomain(dbEndpoint db.WebhookEndpoint) *domain.WebhookEndpoint {
+	return &domain.WebhookEndpoint{
+		ID:          dbEndpoint.ID,
+		URL:         dbEndpoint.Url,
+		Description: dbEndpoint.Description,
+		Secret:      dbEndpoint.Secret,
+		EventTypes:  dbEndpoint.EventTypes,
+		Active:      dbEndpoint.Active,
+		CreatedAt:   dbEndpoint.CreatedAt,
+		UpdatedAt:   dbEndpoint.UpdatedAt,
+	}
+}
+
+func (r *webhooksRepository) deliveryToDomain(dbDelivery db.WebhookDelivery) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             dbDelivery.ID,
+		EndpointID:     dbDelivery.EndpointID,
+		EventID:        dbDelivery.EventID,
+		EventType:      dbDelivery.EventType,
+		Payload:        dbDelivery.Payload,
+		Status:         dbDelivery.Status,
+		Attempts:       dbDelivery.Attempts,
+		LastStatusCode: dbDelivery.LastStatusCode,
+		LastError:      dbDelivery.LastError,
+		NextAttemptAt:  dbDelivery.NextAttemptAt,
+		CreatedAt:      dbDelivery.CreatedAt,
+		DeliveredAt:    dbDelivery.DeliveredAt,
+	}
+}
@@ -0,0 +1,106 @@
+package security
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// The expected hashes follow audit_log_entry_hash in migration 000027 by
+// hand: each field as Postgres casts it to text, length-prefixed and joined
+// by newlines. repositories.TestAuditEntryHashMatchesPostgres checks the same
+// against a database.
+func TestAuditEntryHash(t *testing.T) {
+	userID := int64(7)
+	resourceType := "user"
+	ipRule := "ip_rule"
+	userAgent := "Mozilla/5.0 (X11; Linux x86_64) zażółć"
+	createdAt := time.Date(2026, 3, 4, 5, 6, 7, 89_000, time.FixedZone("", 2*60*60))
+	roundCreatedAt := time.Date(2026, 3, 4, 3, 6, 7, 100_000_000, time.UTC)
+
+	prevHash := make([]byte, 32)
+	for i := range prevHash {
+		prevHash[i] = byte(i + 1)
+	}
+
+	tests := []struct {
+		name  string
+		entry domain.AuditChainEntry
+		want  string
+	}{
+		{
+			name: "absent fields are empty",
+			entry: domain.AuditChainEntry{
+				ChainSeq: 1,
+				ID:       10,
+				PrevHash: make([]byte, 32),
+				Action:   "user_login",
+			},
+			want: "22dea21d2a653589ad90193aa5acbd0e1cf2f91f0aa74ed58cf0d36100d45ad7",
+		},
+		{
+			name: "inet keeps its /32, utf-8 is counted in bytes, created_at is UTC",
+			entry: domain.AuditChainEntry{
+				ChainSeq:     42,
+				ID:           1042,
+				PrevHash:     prevHash,
+				UserID:       &userID,
+				Action:       "password_change",
+				ResourceType: &resourceType,
+				ResourceID:   &userID,
+				IPAddress:    "203.0.113.9/32",
+				UserAgent:    &userAgent,
+				Details:      `{"ip": "203.0.113.9", "success": true}`,
+				CreatedAt:    &createdAt,
+			},
+			want: "4e215c860a5bac97e5067e111c66d3e05b12e94e9d6a54b81f510e29fba67493",
+		},
+		{
+			name: "IPv6 and trailing zero microseconds",
+			entry: domain.AuditChainEntry{
+				ChainSeq:     43,
+				ID:           1043,
+				PrevHash:     bytes.Repeat([]byte{0xff}, 32),
+				Action:       "ip_lockout",
+				ResourceType: &ipRule,
+				IPAddress:    "2001:db8::1/128",
+				Details:      `{"nested": {"list": [1, 2.50]}}`,
+				CreatedAt:    &roundCreatedAt,
+			},
+			want: "acb4d7a353ab1701abe54d765e802766582565b8fa7c41075fa21831ebf78c02",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hex.EncodeToString(AuditEntryHash(tt.entry))
+			if got != tt.want {
+				t.Errorf("AuditEntryHash() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditCheckpointSignature(t *testing.T) {
+	signer := NewAuditCheckpointSigner("checkpoint secret")
+	other := NewAuditCheckpointSigner("another secret")
+	entryHash := bytes.Repeat([]byte{0xab}, 32)
+
+	signature := signer.Sign(5, entryHash)
+
+	if !VerifyAuditCheckpoint(signer.PublicKey(), 5, entryHash, signature) {
+		t.Error("signature does not verify with the signer's key")
+	}
+	if VerifyAuditCheckpoint(other.PublicKey(), 5, entryHash, signature) {
+		t.Error("signature verifies with another key")
+	}
+	if VerifyAuditCheckpoint(signer.PublicKey(), 6, entryHash, signature) {
+		t.Error("signature verifies for another chain_seq")
+	}
+	if VerifyAuditCheckpoint(nil, 5, entryHash, signature) {
+		t.Error("signature verifies without a key")
+	}
+}
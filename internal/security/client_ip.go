@@ -0,0 +1,215 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers a deployment can choose to take the client address from. Only one
+// is ever honoured, and only when the request arrived from a trusted proxy.
+const (
+	ClientIPHeaderNone           = ""
+	ClientIPHeaderXForwardedFor  = "X-Forwarded-For"
+	ClientIPHeaderXRealIP        = "X-Real-IP"
+	ClientIPHeaderCFConnectingIP = "CF-Connecting-IP"
+	ClientIPHeaderTrueClientIP   = "True-Client-IP"
+)
+
+type clientIPKey string
+
+const (
+	clientIPContextKey = clientIPKey("client_ip")
+	fallbackClientIP   = "127.0.0.1"
+)
+
+// ClientIPResolver works out the address of the client behind any trusted
+// reverse proxies.
+type ClientIPResolver struct {
+	trustedProxies []netip.Prefix
+	header         string
+}
+
+// NewClientIPResolver accepts CIDRs or bare addresses for trustedProxies and
+// one of the ClientIPHeader constants, matched case-insensitively.
+func NewClientIPResolver(trustedProxies []string, header string) (*ClientIPResolver, error) {
+	resolver := &ClientIPResolver{}
+
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		prefix, err := ParseNetwork(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		resolver.trustedProxies = append(resolver.trustedProxies, prefix)
+	}
+
+	switch canonical := http.CanonicalHeaderKey(strings.TrimSpace(header)); canonical {
+	case "", "None", "Remote", "Remoteaddr":
+		resolver.header = ClientIPHeaderNone
+	case http.CanonicalHeaderKey(ClientIPHeaderXForwardedFor),
+		http.CanonicalHeaderKey(ClientIPHeaderXRealIP),
+		http.CanonicalHeaderKey(ClientIPHeaderCFConnectingIP),
+		http.CanonicalHeaderKey(ClientIPHeaderTrueClientIP):
+		resolver.header = canonical
+	default:
+		return nil, fmt.Errorf("unsupported client IP header %q", header)
+	}
+
+	if resolver.header != ClientIPHeaderNone && len(resolver.trustedProxies) == 0 {
+		return nil, fmt.Errorf("client IP header %q requires at least one trusted proxy", resolver.header)
+	}
+
+	return resolver, nil
+}
+
+// ParseNetwork accepts a CIDR or a bare address, which becomes a single-host
+// prefix.
+func ParseNetwork(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// TrustedProxies returns the configured ranges in their string form, as gin's
+// SetTrustedProxies expects them.
+func (r *ClientIPResolver) TrustedProxies() []string {
+	proxies := make([]string, len(r.trustedProxies))
+	for i, prefix := range r.trustedProxies {
+		proxies[i] = prefix.String()
+	}
+	return proxies
+}
+
+// Header returns the header the resolver trusts, or "" for none.
+func (r *ClientIPResolver) Header() string {
+	return r.header
+}
+
+func (r *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the client address for req. Headers are ignored unless the
+// immediate peer is a trusted proxy. X-Forwarded-For is read right to left,
+// skipping trusted hops, so entries the client prepended itself are never
+// reached.
+func (r *ClientIPResolver) Resolve(req *http.Request) string {
+	remote, ok := parseHostAddr(req.RemoteAddr)
+	if !ok {
+		return fallbackClientIP
+	}
+
+	if r.header == ClientIPHeaderNone || !r.isTrusted(remote) {
+		return remote.String()
+	}
+
+	if r.header != ClientIPHeaderXForwardedFor {
+		if addr, ok := parseHostAddr(strings.TrimSpace(req.Header.Get(r.header))); ok {
+			return addr.String()
+		}
+		return remote.String()
+	}
+
+	var hops []string
+	for _, value := range req.Header.Values(ClientIPHeaderXForwardedFor) {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHostAddr(strings.TrimSpace(hops[i]))
+		if !ok {
+			// A malformed hop could have been written by anyone, so the
+			// last trusted proxy is the best answer we have
+			break
+		}
+
+		client = addr
+		if !r.isTrusted(addr) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+// parseHostAddr accepts a bare address or host:port, with or without IPv6
+// brackets.
+func parseHostAddr(value string) (netip.Addr, bool) {
+	if value == "" {
+		return netip.Addr{}, false
+	}
+
+	if addr, err := netip.ParseAddr(strings.Trim(value, "[]")); err == nil {
+		return addr.Unmap(), true
+	}
+
+	host, _, err := net.SplitHostPort(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// Middleware resolves the client address once and stores it on the request
+// context, where GetClientIP and ClientIPFromRequest pick it up.
+func (r *ClientIPResolver) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ip := r.Resolve(ctx.Request)
+		ctx.Request = ctx.Request.WithContext(WithClientIP(ctx.Request.Context(), ip))
+		ctx.Next()
+	}
+}
+
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPContextKey, ip)
+}
+
+// ClientIPFromRequest returns the address resolved by the middleware. Requests
+// that never passed through it, such as the synthetic ones built for gRPC
+// audit entries, fall back to their RemoteAddr.
+func ClientIPFromRequest(req *http.Request) string {
+	if ip, ok := req.Context().Value(clientIPContextKey).(string); ok && ip != "" {
+		return ip
+	}
+
+	if addr, ok := parseHostAddr(req.RemoteAddr); ok {
+		return addr.String()
+	}
+	return fallbackClientIP
+}
+
+func GetClientIP(ctx *gin.Context) string {
+	return ClientIPFromRequest(ctx.Request)
+}
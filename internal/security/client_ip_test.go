@@ -0,0 +1,256 @@
+package security
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClientIPResolverResolve(t *testing.T) {
+	trustedProxies := []string{"10.0.0.0/8", "fd00::/8", "192.0.2.10"}
+
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		values     []string
+		want       string
+	}{
+		{
+			name:       "no header configured ignores X-Forwarded-For",
+			header:     ClientIPHeaderNone,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"203.0.113.5"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "trusted peer without the header",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "trusted peer with an empty header",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{""},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "untrusted peer cannot set the client address",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "198.51.100.7:4711",
+			values:     []string{"203.0.113.5"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "bare address trusts only that host",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "192.0.2.11:4711",
+			values:     []string{"203.0.113.5"},
+			want:       "192.0.2.11",
+		},
+		{
+			name:       "rightmost entry appended by the proxy",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"203.0.113.5"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "spoofed leftmost entries are not reached",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"1.1.1.1, 10.0.0.99, 203.0.113.5"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "trusted hops are skipped",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"1.1.1.1, 203.0.113.5, 10.0.0.2,10.0.0.3"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "repeated headers are read as one list",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"1.1.1.1, 203.0.113.5", "10.0.0.2"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "only trusted hops gives the leftmost one",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"10.0.0.3, 10.0.0.2"},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "malformed hop stops at the last trusted proxy",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"203.0.113.5, not-an-ip"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "hops with ports",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"203.0.113.5:1234, 10.0.0.2:80"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "IPv6 peer and client",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "[fd00::1]:4711",
+			values:     []string{"2001:db8::1"},
+			want:       "2001:db8::1",
+		},
+		{
+			name:       "bracketed IPv6 hop with port",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "[fd00::1]:4711",
+			values:     []string{"[2001:db8::1]:443, [fd00::2]"},
+			want:       "2001:db8::1",
+		},
+		{
+			name:       "untrusted IPv6 peer",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "[2001:db8::99]:4711",
+			values:     []string{"203.0.113.5"},
+			want:       "2001:db8::99",
+		},
+		{
+			name:       "IPv4-mapped peer matches an IPv4 range",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "[::ffff:10.0.0.1]:4711",
+			values:     []string{"203.0.113.5"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "IPv4-mapped hops are unmapped",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"::ffff:203.0.113.5, ::ffff:10.0.0.2"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "single value header from a trusted peer",
+			header:     ClientIPHeaderXRealIP,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"203.0.113.5"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "single value header from an untrusted peer",
+			header:     ClientIPHeaderXRealIP,
+			remoteAddr: "198.51.100.7:4711",
+			values:     []string{"203.0.113.5"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "empty single value header",
+			header:     ClientIPHeaderCFConnectingIP,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{""},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "malformed single value header",
+			header:     ClientIPHeaderTrueClientIP,
+			remoteAddr: "10.0.0.1:4711",
+			values:     []string{"203.0.113.5, 1.1.1.1"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "unparseable peer",
+			header:     ClientIPHeaderXForwardedFor,
+			remoteAddr: "@",
+			values:     []string{"203.0.113.5"},
+			want:       fallbackClientIP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := NewClientIPResolver(trustedProxies, tt.header)
+			if err != nil {
+				t.Fatalf("NewClientIPResolver() error = %v", err)
+			}
+
+			req := &http.Request{RemoteAddr: tt.remoteAddr, Header: http.Header{}}
+			for _, value := range tt.values {
+				header := tt.header
+				if header == ClientIPHeaderNone {
+					header = ClientIPHeaderXForwardedFor
+				}
+				req.Header.Add(header, value)
+			}
+
+			if got := resolver.Resolve(req); got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientIPResolver(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		header         string
+		wantHeader     string
+		wantErr        bool
+	}{
+		{
+			name:       "no proxies and no header",
+			header:     "",
+			wantHeader: ClientIPHeaderNone,
+		},
+		{
+			name:       "remote addr alias",
+			header:     "RemoteAddr",
+			wantHeader: ClientIPHeaderNone,
+		},
+		{
+			name:           "header is matched case-insensitively",
+			trustedProxies: []string{"10.0.0.0/8"},
+			header:         "x-forwarded-for",
+			wantHeader:     ClientIPHeaderXForwardedFor,
+		},
+		{
+			name:    "header without trusted proxies",
+			header:  ClientIPHeaderXForwardedFor,
+			wantErr: true,
+		},
+		{
+			name:           "unsupported header",
+			trustedProxies: []string{"10.0.0.0/8"},
+			header:         "Forwarded",
+			wantErr:        true,
+		},
+		{
+			name:           "invalid trusted proxy",
+			trustedProxies: []string{"10.0.0.0/33"},
+			header:         ClientIPHeaderXForwardedFor,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := NewClientIPResolver(tt.trustedProxies, tt.header)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewClientIPResolver() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClientIPResolver() error = %v", err)
+			}
+			if got := resolver.Header(); got != tt.wantHeader {
+				t.Errorf("Header() = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
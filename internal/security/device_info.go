@@ -1,42 +1,108 @@
 package security
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/domain"
 )
 
+// DeviceIDCookieName holds the random device ID given to browsers that send
+// no X-Device-ID, so each browser keeps its own ID whatever its user agent.
+const DeviceIDCookieName = "whoami_device_id"
+
 type DeviceInfo struct {
 	DeviceID   string `json:"device_id"`
 	DeviceName string `json:"device_name"`
 	DeviceType string `json:"device_type"`
 	UserAgent  string `json:"user_agent"`
 	IPAddress  string `json:"ip_address"`
+	// NewDeviceID is set when the client sent neither an X-Device-ID nor
+	// the device cookie and DeviceID was generated for this request
+	NewDeviceID bool `json:"-"`
+	// LegacyDeviceID is the user agent and IP fingerprint devices were
+	// keyed by before the device cookie, set along with NewDeviceID
+	LegacyDeviceID string `json:"-"`
+	// Location is filled in by callers with a GeoIPResolver
+	Location *domain.GeoLocation `json:"location,omitempty"`
+}
+
+// SessionInfo flattens the device into the string map stored on sessions.
+func (d *DeviceInfo) SessionInfo() map[string]string {
+	info := map[string]string{
+		"device_id":   d.DeviceID,
+		"device_name": d.DeviceName,
+		"device_type": d.DeviceType,
+		"user_agent":  d.UserAgent,
+		"ip_address":  d.IPAddress,
+	}
+
+	if d.Location != nil {
+		if d.Location.CountryCode != "" {
+			info["country"] = d.Location.CountryCode
+		}
+		if d.Location.City != "" {
+			info["city"] = d.Location.City
+		}
+		if d.Location.ASN != 0 {
+			info["asn"] = strconv.FormatInt(d.Location.ASN, 10)
+		}
+	}
+
+	return info
 }
 
 func ExtractDeviceInfo(ctx *gin.Context) *DeviceInfo {
 	userAgent := ctx.GetHeader("User-Agent")
 	ipAddress := GetClientIP(ctx)
 
-	// Generate or extract device ID from headers
+	// Extract the device ID from headers or the device cookie, or hand out a new one
 	deviceID := ctx.GetHeader("X-Device-ID")
 	if deviceID == "" {
-		deviceID = generateDeviceIDFromFingerprint(userAgent, ipAddress)
+		if cookieID, err := ctx.Cookie(DeviceIDCookieName); err == nil && isDeviceID(cookieID) {
+			deviceID = cookieID
+		}
+	}
+
+	var legacyDeviceID string
+	newDeviceID := deviceID == ""
+	if newDeviceID {
+		deviceID = generateDeviceID()
+		legacyDeviceID = generateDeviceIDFromFingerprint(userAgent, ipAddress)
 	}
 
 	// Parse user agent for device info
 	deviceName, deviceType := parseUserAgent(userAgent)
 
 	return &DeviceInfo{
-		DeviceID:   deviceID,
-		DeviceName: deviceName,
-		DeviceType: deviceType,
-		UserAgent:  userAgent,
-		IPAddress:  ipAddress,
+		DeviceID:       deviceID,
+		DeviceName:     deviceName,
+		DeviceType:     deviceType,
+		UserAgent:      userAgent,
+		IPAddress:      ipAddress,
+		NewDeviceID:    newDeviceID,
+		LegacyDeviceID: legacyDeviceID,
 	}
 }
+
+func generateDeviceID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// isDeviceID accepts the IDs this package hands out, generated or
+// fingerprinted, both 16 bytes of hex.
+func isDeviceID(deviceID string) bool {
+	decoded, err := hex.DecodeString(deviceID)
+	return err == nil && len(decoded) == 16
+}
+
 func parseUserAgent(userAgent string) (deviceName, deviceType string) {
 	ua := strings.ToLower(userAgent)
 
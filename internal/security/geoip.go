@@ -0,0 +1,130 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// GeoIPResolver looks addresses up in offline MaxMind-format databases, one
+// with city data (GeoLite2-City, GeoIP2-City) and optionally one with ASN
+// data (GeoLite2-ASN). A nil resolver is valid and finds nothing.
+type GeoIPResolver struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+type mmdbCityRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+type mmdbASNRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// NewGeoIPResolver opens the databases at cityPath and asnPath, either of
+// which may be empty.
+func NewGeoIPResolver(cityPath, asnPath string) (*GeoIPResolver, error) {
+	resolver := &GeoIPResolver{}
+
+	if cityPath != "" {
+		city, err := maxminddb.Open(cityPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP city database: %w", err)
+		}
+		resolver.city = city
+	}
+
+	if asnPath != "" {
+		asn, err := maxminddb.Open(asnPath)
+		if err != nil {
+			resolver.Close()
+			return nil, fmt.Errorf("failed to open GeoIP ASN database: %w", err)
+		}
+		resolver.asn = asn
+	}
+
+	return resolver, nil
+}
+
+// Lookup returns the location of ip, or nil when it is unknown or private.
+func (r *GeoIPResolver) Lookup(ip string) *domain.GeoLocation {
+	if r == nil {
+		return nil
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	addr = addr.Unmap()
+
+	location := &domain.GeoLocation{}
+	found := false
+
+	if r.city != nil {
+		var record mmdbCityRecord
+		if result := r.city.Lookup(addr); result.Found() {
+			if err := result.Decode(&record); err != nil {
+				fmt.Printf("Warning: failed to decode GeoIP city record: %v\n", err)
+			} else {
+				found = true
+				location.CountryCode = record.Country.ISOCode
+				location.Country = record.Country.Names["en"]
+				location.City = record.City.Names["en"]
+				if record.Location.Latitude != nil && record.Location.Longitude != nil {
+					location.Latitude = *record.Location.Latitude
+					location.Longitude = *record.Location.Longitude
+					location.HasCoordinates = true
+				}
+			}
+		}
+	}
+
+	if r.asn != nil {
+		var record mmdbASNRecord
+		if result := r.asn.Lookup(addr); result.Found() {
+			if err := result.Decode(&record); err != nil {
+				fmt.Printf("Warning: failed to decode GeoIP ASN record: %v\n", err)
+			} else {
+				found = true
+				location.ASN = int64(record.AutonomousSystemNumber)
+				location.ASOrganization = record.AutonomousSystemOrganization
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return location
+}
+
+func (r *GeoIPResolver) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	if r.city != nil {
+		errs = append(errs, r.city.Close())
+	}
+	if r.asn != nil {
+		errs = append(errs, r.asn.Close())
+	}
+	return errors.Join(errs...)
+}
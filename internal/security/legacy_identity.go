@@ -0,0 +1,122 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var ErrLegacyCredentialsRejected = errors.New("legacy identity store rejected the credentials")
+
+type LegacyIdentityClient struct {
+	httpClient *http.Client
+	endpoint   string
+	secret     string
+	userAgent  string
+}
+
+type LegacyProfile struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+	AvatarURL string `json:"avatar_url"`
+	Bio       string `json:"bio"`
+	Timezone  string `json:"timezone"`
+	Locale    string `json:"locale"`
+}
+
+// LegacyIdentity is the user record returned by the legacy system once it
+// has confirmed a set of credentials.
+type LegacyIdentity struct {
+	Email         string         `json:"email"`
+	Username      *string        `json:"username"`
+	EmailVerified bool           `json:"email_verified"`
+	Profile       *LegacyProfile `json:"profile"`
+}
+
+type legacyVerifyRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type legacyVerifyResponse struct {
+	Valid bool            `json:"valid"`
+	User  *LegacyIdentity `json:"user"`
+}
+
+func NewLegacyIdentityClient(endpoint, secret string, timeout time.Duration) *LegacyIdentityClient {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &LegacyIdentityClient{
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		endpoint:  endpoint,
+		secret:    secret,
+		userAgent: "whoami-auth-service/1.0",
+	}
+}
+
+// VerifyCredentials asks the legacy system whether the email and password are
+// valid. The legacy endpoint is expected to answer 200 with {"valid": true, "user": {...}}
+// for good credentials and either {"valid": false} or a 401/403/404 otherwise.
+func (c *LegacyIdentityClient) VerifyCredentials(ctx context.Context, email, password string) (*LegacyIdentity, error) {
+	body, err := json.Marshal(legacyVerifyRequest{
+		Email:    email,
+		Password: password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal legacy request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+c.secret)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return nil, ErrLegacyCredentialsRejected
+	default:
+		return nil, fmt.Errorf("legacy identity store returned status code: %d", resp.StatusCode)
+	}
+
+	var result legacyVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode legacy response: %v", err)
+	}
+
+	if !result.Valid {
+		return nil, ErrLegacyCredentialsRejected
+	}
+
+	identity := result.User
+	if identity == nil {
+		identity = &LegacyIdentity{}
+	}
+	// The email the user typed is the one we looked up and will store, so
+	// never let the legacy system hand back a different account.
+	identity.Email = email
+
+	return identity, nil
+}
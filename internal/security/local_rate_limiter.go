@@ -0,0 +1,87 @@
+package security
+
+import (
+	"sync"
+	"time"
+)
+
+// localSweepInterval controls how often expired entries are dropped from the
+// in-process limiter.
+const localSweepInterval = time.Minute
+
+// localRateLimiter is the in-process GCRA used by the "local" failure mode
+// while Redis is unreachable. Its limits are per instance, so a fleet of N
+// instances admits up to N times the configured rate.
+type localRateLimiter struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+func newLocalRateLimiter() *localRateLimiter {
+	return &localRateLimiter{
+		tats:      make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// check mirrors gcraScript: the request counts against every window only if
+// all of them admit it.
+func (l *localRateLimiter) check(checks []rateLimitCheck) []*RateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	allowed := true
+	results := make([]*RateLimitResult, len(checks))
+	newTATs := make([]time.Time, len(checks))
+	for i, check := range checks {
+		emission := check.config.Window / time.Duration(check.config.Requests)
+
+		tat, ok := l.tats[check.key]
+		if !ok || tat.Before(now) {
+			tat = now
+		}
+
+		newTAT := tat.Add(emission)
+		allowAt := newTAT.Add(-check.config.Window)
+		if allowAt.After(now) {
+			allowed = false
+			results[i] = &RateLimitResult{
+				Allowed:    false,
+				ResetTime:  now.Unix() + microsecondsToSeconds(tat.Sub(now).Microseconds()),
+				RetryAfter: microsecondsToSeconds(allowAt.Sub(now).Microseconds()),
+			}
+		} else {
+			results[i] = &RateLimitResult{
+				Allowed:   true,
+				Remaining: int64(now.Sub(allowAt) / emission),
+				ResetTime: now.Unix() + microsecondsToSeconds(newTAT.Sub(now).Microseconds()),
+			}
+		}
+		newTATs[i] = newTAT
+	}
+
+	if allowed {
+		for i, check := range checks {
+			l.tats[check.key] = newTATs[i]
+		}
+	}
+
+	return results
+}
+
+func (l *localRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < localSweepInterval {
+		return
+	}
+
+	for key, tat := range l.tats {
+		if tat.Before(now) {
+			delete(l.tats, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,79 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// lockRetryInterval is how often Lock retries a lock that is held.
+const lockRetryInterval = 20 * time.Millisecond
+
+// Locker hands out short mutual exclusion locks shared by every instance.
+type Locker interface {
+	// Lock blocks until it holds key, wait passes or ctx is done. The lock
+	// is released by calling unlock, or by itself after ttl in case the
+	// holder dies.
+	Lock(ctx context.Context, key string, ttl, wait time.Duration) (unlock func(), err error)
+}
+
+type redisLocker struct {
+	redisClient *redis.Client
+}
+
+func NewRedisLocker(redisClient *redis.Client) Locker {
+	return &redisLocker{
+		redisClient: redisClient,
+	}
+}
+
+// unlockScript deletes the lock only while it still holds this holder's
+// token, so a holder that outlived its ttl cannot release someone else's.
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+func (l *redisLocker) Lock(ctx context.Context, key string, ttl, wait time.Duration) (func(), error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+	lockKey := fmt.Sprintf("lock:%s", key)
+
+	deadline := time.Now().Add(wait)
+	for {
+		acquired, err := l.redisClient.SetNX(ctx, lockKey, token, ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+
+	return func() {
+		if err := unlockScript.Run(context.WithoutCancel(ctx), l.redisClient, []string{lockKey}, token).Err(); err != nil {
+			fmt.Printf("Warning: failed to release lock %s: %v\n", key, err)
+		}
+	}, nil
+}
@@ -0,0 +1,96 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginThrottle spaces out login attempts for an email once its failures
+// call for a delay. Attempts that come too early are turned away before the
+// password is checked, so parallel requests and dropped connections do not
+// get around the wait.
+type LoginThrottle interface {
+	// Reserve claims the next attempt for email. It returns how long the
+	// caller has to wait, or zero when the attempt may go ahead.
+	Reserve(ctx context.Context, email string) (time.Duration, error)
+	// Delay makes every attempt for email wait delay from now on, until
+	// ttl passes without another failure.
+	Delay(ctx context.Context, email string, delay, ttl time.Duration) error
+	// Reset lifts the delay after a successful login.
+	Reset(ctx context.Context, email string) error
+}
+
+type redisLoginThrottle struct {
+	redisClient *redis.Client
+}
+
+func NewRedisLoginThrottle(redisClient *redis.Client) LoginThrottle {
+	return &redisLoginThrottle{
+		redisClient: redisClient,
+	}
+}
+
+// The delay key holds the current delay in milliseconds, the next key exists
+// while the email has to wait.
+func loginThrottleKeys(email string) []string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	return []string{
+		fmt.Sprintf("login_throttle:{%s}:delay", email),
+		fmt.Sprintf("login_throttle:{%s}:next", email),
+	}
+}
+
+// reserveScript lets one attempt through per delay: the attempt that finds
+// no wait pending starts the next one itself.
+//
+// KEYS: delay key, next key
+// Returns the wait in milliseconds, 0 when the attempt may go ahead
+var reserveScript = redis.NewScript(`
+local delay = tonumber(redis.call('GET', KEYS[1]) or '0')
+if delay <= 0 then
+	return 0
+end
+
+if redis.call('SET', KEYS[2], '1', 'NX', 'PX', delay) then
+	return 0
+end
+
+local wait = redis.call('PTTL', KEYS[2])
+if wait < 0 then
+	return 0
+end
+return wait
+`)
+
+func (t *redisLoginThrottle) Reserve(ctx context.Context, email string) (time.Duration, error) {
+	wait, err := reserveScript.Run(ctx, t.redisClient, loginThrottleKeys(email)).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve login attempt: %w", err)
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+func (t *redisLoginThrottle) Delay(ctx context.Context, email string, delay, ttl time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	keys := loginThrottleKeys(email)
+	_, err := t.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, keys[0], delay.Milliseconds(), max(ttl, delay))
+		pipe.Set(ctx, keys[1], 1, delay)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delay login attempts: %w", err)
+	}
+	return nil
+}
+
+func (t *redisLoginThrottle) Reset(ctx context.Context, email string) error {
+	return t.redisClient.Unlink(ctx, loginThrottleKeys(email)...).Err()
+}
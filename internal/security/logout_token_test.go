@@ -0,0 +1,95 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewLogoutTokenClaims(t *testing.T) {
+	jti := uuid.New()
+	before := time.Now().Unix()
+	claims := NewLogoutTokenClaims("https://auth.example.com", "app-42", 7, "session-1", jti, 2*time.Minute)
+
+	if claims.Issuer != "https://auth.example.com" || claims.Audience != "app-42" {
+		t.Errorf("iss/aud = %q/%q, want https://auth.example.com/app-42", claims.Issuer, claims.Audience)
+	}
+	if claims.Subject != "7" || claims.SessionID != "session-1" || claims.ID != jti.String() {
+		t.Errorf("sub/sid/jti = %q/%q/%q, want 7/session-1/%s", claims.Subject, claims.SessionID, claims.ID, jti)
+	}
+	if claims.IssuedAt < before || claims.ExpiresAt-claims.IssuedAt != 120 {
+		t.Errorf("iat %d, exp %d; want exp two minutes after an iat of now", claims.IssuedAt, claims.ExpiresAt)
+	}
+	if event, ok := claims.Events[BackchannelLogoutEvent]; !ok || len(event) != 0 {
+		t.Errorf("events = %v, want an empty %s member", claims.Events, BackchannelLogoutEvent)
+	}
+}
+
+func TestSignLogoutToken(t *testing.T) {
+	const secret = "logout-secret"
+	claims := NewLogoutTokenClaims("https://auth.example.com", "app-42", 7, "session-1", uuid.New(), 2*time.Minute)
+
+	token, err := SignLogoutToken(claims, secret)
+	if err != nil {
+		t.Fatalf("SignLogoutToken() error = %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token has %d parts, want 3", len(parts))
+	}
+
+	var header map[string]string
+	decodeSegment(t, parts[0], &header)
+	if header["alg"] != "HS256" || header["typ"] != "logout+jwt" {
+		t.Errorf("header = %v, want alg HS256 and typ logout+jwt", header)
+	}
+
+	var payload map[string]any
+	decodeSegment(t, parts[1], &payload)
+	for _, claim := range []string{"iss", "aud", "iat", "exp", "jti", "sub", "sid", "events"} {
+		if _, ok := payload[claim]; !ok {
+			t.Errorf("payload is missing %s", claim)
+		}
+	}
+	if _, ok := payload["nonce"]; ok {
+		t.Error("payload carries a nonce, logout tokens must not")
+	}
+	if events, _ := payload["events"].(map[string]any); events[BackchannelLogoutEvent] == nil {
+		t.Errorf("events = %v, want the back-channel logout event", payload["events"])
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("signature is not base64url: %v", err)
+	}
+	if !hmac.Equal(signature, logoutTokenMAC(parts[0]+"."+parts[1], secret)) {
+		t.Error("signature does not verify with the logout secret")
+	}
+	if hmac.Equal(signature, logoutTokenMAC(parts[0]+"."+parts[1], "other-secret")) {
+		t.Error("signature verifies with a different secret")
+	}
+}
+
+func decodeSegment(t *testing.T, segment string, v any) {
+	t.Helper()
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatalf("segment is not base64url: %v", err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatalf("segment is not JSON: %v", err)
+	}
+}
+
+func logoutTokenMAC(signingInput, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
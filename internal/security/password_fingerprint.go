@@ -0,0 +1,35 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// passwordFingerprintBytes keeps fingerprints to 64 bits, plenty to group
+// attempts by password without keeping the full HMAC around.
+const passwordFingerprintBytes = 8
+
+// PasswordFingerprinter derives a short keyed fingerprint of a password, so
+// failed logins that tried the same password can be grouped. Keying it means
+// a leaked login_attempts table cannot be checked against guesses offline.
+// A nil fingerprinter returns "" for every password.
+type PasswordFingerprinter struct {
+	key []byte
+}
+
+func NewPasswordFingerprinter(key string) *PasswordFingerprinter {
+	return &PasswordFingerprinter{key: []byte(key)}
+}
+
+// Fingerprint returns the hex prefix of the HMAC-SHA256 of password, or ""
+// for an empty password.
+func (f *PasswordFingerprinter) Fingerprint(password string) string {
+	if f == nil || password == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil)[:passwordFingerprintBytes])
+}
@@ -0,0 +1,456 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/metrics"
+	"gopkg.in/yaml.v3"
+)
+
+// RateLimitIdentity is the request attribute a policy counts requests by.
+type RateLimitIdentity string
+
+const (
+	RateLimitByIP       RateLimitIdentity = "ip"
+	RateLimitByUser     RateLimitIdentity = "user"
+	RateLimitByEmail    RateLimitIdentity = "email"
+	RateLimitByClientID RateLimitIdentity = "client_id"
+)
+
+// ClientIDHeaderKey identifies the calling application for client_id policies.
+const ClientIDHeaderKey = "X-Client-ID"
+
+// failClosedRetryAfter is the Retry-After, in seconds, sent while a
+// fail-closed policy is rejecting requests.
+const failClosedRetryAfter = 5
+
+// maxRateLimitBodySize caps how much of a request body is buffered to find the
+// email for email-keyed policies.
+const maxRateLimitBodySize = 1 << 20
+
+// RateLimitPolicy applies one or more stacked windows to the routes it lists.
+// Routes are Gin route patterns, optionally prefixed with a method
+// ("POST /api/v1/login") and optionally ending in "/*" to match a prefix.
+// PerRoute counts each matched route separately instead of sharing one budget
+// across all of them, and FailureMode overrides the limiter's default for
+// when Redis is unavailable.
+type RateLimitPolicy struct {
+	Name        string               `yaml:"name" json:"name"`
+	Routes      []string             `yaml:"routes" json:"routes"`
+	Identity    RateLimitIdentity    `yaml:"identity" json:"identity"`
+	PerRoute    bool                 `yaml:"per_route" json:"per_route"`
+	Limits      []RateLimitConfig    `yaml:"limits" json:"limits"`
+	FailureMode RateLimitFailureMode `yaml:"failure_mode" json:"failure_mode"`
+}
+
+type RateLimitPolicies struct {
+	Policies []RateLimitPolicy `yaml:"policies" json:"policies"`
+}
+
+// DefaultRateLimitPolicies is used when no policies file is configured.
+func DefaultRateLimitPolicies() *RateLimitPolicies {
+	return &RateLimitPolicies{
+		Policies: []RateLimitPolicy{
+			{
+				Name:     "register",
+				Routes:   []string{"POST /api/v1/register"},
+				Identity: RateLimitByIP,
+				Limits:   []RateLimitConfig{{Requests: 3, Window: time.Hour}},
+			},
+			{
+				Name:     "auth",
+				Routes:   []string{"POST /api/v1/login", "POST /api/v1/refresh"},
+				Identity: RateLimitByIP,
+				PerRoute: true,
+				Limits:   []RateLimitConfig{{Requests: 50, Window: 15 * time.Minute}},
+			},
+			{
+				Name:     "password-reset",
+				Routes:   []string{"/api/v1/password-reset/*"},
+				Identity: RateLimitByIP,
+				PerRoute: true,
+				Limits:   []RateLimitConfig{{Requests: 3, Window: time.Hour}},
+			},
+			{
+				Name:     "email",
+				Routes:   []string{"/api/v1/email/*", "POST /api/v1/account/unlock", "POST /api/v1/account/secure"},
+				Identity: RateLimitByIP,
+				PerRoute: true,
+				Limits:   []RateLimitConfig{{Requests: 5, Window: time.Hour}},
+			},
+			{
+				Name:     "authenticated",
+				Routes:   []string{"/api/v1/*"},
+				Identity: RateLimitByUser,
+				PerRoute: true,
+				Limits:   []RateLimitConfig{{Requests: 1000, Window: time.Hour}},
+				// Authenticated traffic already passed the brute-force limits
+				FailureMode: RateLimitFailOpen,
+			},
+		},
+	}
+}
+
+// LoadRateLimitPolicies reads policies from a YAML (or JSON) file.
+func LoadRateLimitPolicies(path string) (*RateLimitPolicies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit policies: %w", err)
+	}
+
+	var policies RateLimitPolicies
+	if err := yaml.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit policies: %w", err)
+	}
+
+	if err := policies.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &policies, nil
+}
+
+func (p *RateLimitPolicies) Validate() error {
+	names := make(map[string]bool, len(p.Policies))
+	for _, policy := range p.Policies {
+		if policy.Name == "" {
+			return fmt.Errorf("rate limit policy is missing a name")
+		}
+		if names[policy.Name] {
+			return fmt.Errorf("duplicate rate limit policy %q", policy.Name)
+		}
+		names[policy.Name] = true
+
+		switch policy.Identity {
+		case RateLimitByIP, RateLimitByUser, RateLimitByEmail, RateLimitByClientID:
+		default:
+			return fmt.Errorf("rate limit policy %q has unknown identity %q", policy.Name, policy.Identity)
+		}
+
+		switch policy.FailureMode {
+		case "", RateLimitFailOpen, RateLimitFailClosed, RateLimitFailLocal:
+		default:
+			return fmt.Errorf("rate limit policy %q has unknown failure mode %q", policy.Name, policy.FailureMode)
+		}
+
+		if len(policy.Routes) == 0 {
+			return fmt.Errorf("rate limit policy %q has no routes", policy.Name)
+		}
+		if len(policy.Limits) == 0 {
+			return fmt.Errorf("rate limit policy %q has no limits", policy.Name)
+		}
+		for _, limit := range policy.Limits {
+			if limit.Requests <= 0 || limit.Window.Microseconds() < int64(limit.Requests) {
+				return fmt.Errorf("rate limit policy %q needs positive requests and a window of at least one microsecond per request", policy.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchRoute reports whether the policy covers a request to fullPath, the
+// Gin route pattern the request was routed to.
+func (p *RateLimitPolicy) matchRoute(method, fullPath string) bool {
+	for _, route := range p.Routes {
+		routeMethod, pattern, hasMethod := strings.Cut(route, " ")
+		if !hasMethod {
+			routeMethod, pattern = "", route
+		}
+		pattern = strings.TrimSpace(pattern)
+
+		if routeMethod != "" && !strings.EqualFold(routeMethod, method) {
+			continue
+		}
+
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if fullPath == prefix || strings.HasPrefix(fullPath, prefix+"/") {
+				return true
+			}
+			continue
+		}
+
+		if fullPath == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPolicies swaps the active policies. Requests already being checked keep
+// using the previous set.
+func (rl *RateLimiter) SetPolicies(policies *RateLimitPolicies) {
+	rl.policies.Store(policies)
+}
+
+func (rl *RateLimiter) Policies() *RateLimitPolicies {
+	return rl.policies.Load()
+}
+
+// WatchPolicies reloads the policies file whenever its modification time
+// changes, so limits can be tuned without a redeploy. An invalid file is
+// logged and the previous policies stay in force.
+func (rl *RateLimiter) WatchPolicies(ctx context.Context, path string, interval time.Duration) {
+	var lastModified time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastModified = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				log.Printf("failed to stat rate limit policies: %v", err)
+				continue
+			}
+			if !info.ModTime().After(lastModified) {
+				continue
+			}
+			lastModified = info.ModTime()
+
+			policies, err := LoadRateLimitPolicies(path)
+			if err != nil {
+				log.Printf("keeping previous rate limit policies: %v", err)
+				continue
+			}
+
+			rl.SetPolicies(policies)
+			log.Printf("reloaded %d rate limit policies from %s", len(policies.Policies), path)
+		}
+	}
+}
+
+// PolicyMiddleware enforces every policy matching the route that can be keyed
+// before authentication (ip, email and client_id). It must be installed on a
+// group so that the route pattern is already resolved.
+func (rl *RateLimiter) PolicyMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		rl.enforcePolicies(ctx, func(identity RateLimitIdentity) bool {
+			return identity != RateLimitByUser
+		})
+	}
+}
+
+// UserPolicyMiddleware enforces user-keyed policies and must run after
+// authentication has stored the token payload.
+func (rl *RateLimiter) UserPolicyMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		rl.enforcePolicies(ctx, func(identity RateLimitIdentity) bool {
+			return identity == RateLimitByUser
+		})
+	}
+}
+
+func (rl *RateLimiter) enforcePolicies(ctx *gin.Context, include func(RateLimitIdentity) bool) {
+	policies := rl.Policies()
+	if policies == nil {
+		ctx.Next()
+		return
+	}
+
+	fullPath := ctx.FullPath()
+	var checks []rateLimitCheck
+	for _, policy := range policies.Policies {
+		if !include(policy.Identity) || !policy.matchRoute(ctx.Request.Method, fullPath) {
+			continue
+		}
+
+		identity, indexKeys := rl.resolveIdentity(ctx, policy.Identity)
+		if identity == "" {
+			continue
+		}
+
+		scope := policy.Name
+		if policy.PerRoute {
+			scope = policy.Name + ":" + ctx.Request.Method + ":" + fullPath
+		}
+
+		failureMode := policy.FailureMode
+		if failureMode == "" {
+			failureMode = rl.defaultFailureMode
+		}
+
+		for _, limit := range policy.Limits {
+			checks = append(checks, rateLimitCheck{
+				policy:      policy.Name,
+				key:         fmt.Sprintf("rate_limit:%s:%s:%s:%s", scope, limit.Window, policy.Identity, identity),
+				config:      limit,
+				indexKeys:   indexKeys,
+				failureMode: failureMode,
+			})
+		}
+	}
+
+	if len(checks) == 0 {
+		ctx.Next()
+		return
+	}
+
+	results, err := rl.checkRateLimits(ctx, checks)
+	if err != nil {
+		checks, results = rl.fallback(ctx, checks)
+		if checks == nil {
+			return
+		}
+	}
+
+	// Report the window closest to rejecting the request, or the one that
+	// will take longest to admit it again
+	tightest := 0
+	for i, result := range results {
+		current := results[tightest]
+		switch {
+		case !result.Allowed && current.Allowed:
+			tightest = i
+		case !result.Allowed && !current.Allowed && result.RetryAfter > current.RetryAfter:
+			tightest = i
+		case result.Allowed && current.Allowed && result.Remaining < current.Remaining:
+			tightest = i
+		}
+	}
+	result := results[tightest]
+
+	setRateLimitHeaders(ctx, checks, checks[tightest].config, result)
+
+	if !result.Allowed {
+		metrics.RateLimitRejections.WithLabelValues(checks[tightest].policy).Inc()
+		ctx.Header("Retry-After", strconv.FormatInt(result.RetryAfter, 10))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Rate limit exceeded",
+			"retry_after": result.RetryAfter,
+		})
+		ctx.Abort()
+		return
+	}
+
+	ctx.Next()
+}
+
+// fallback applies each policy's failure mode after Redis failed. Any
+// fail-closed policy rejects the request outright; fail-local policies are
+// checked against the in-process limiter and fail-open ones are skipped. It
+// returns nil checks when the request has already been answered.
+func (rl *RateLimiter) fallback(ctx *gin.Context, checks []rateLimitCheck) ([]rateLimitCheck, []*RateLimitResult) {
+	var localChecks []rateLimitCheck
+	for _, check := range checks {
+		switch check.failureMode {
+		case RateLimitFailClosed:
+			metrics.RateLimitFallbackDecisions.WithLabelValues(string(RateLimitFailClosed), "rejected").Inc()
+			ctx.Header("Retry-After", strconv.Itoa(failClosedRetryAfter))
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":       "Rate limiting is temporarily unavailable",
+				"retry_after": failClosedRetryAfter,
+			})
+			ctx.Abort()
+			return nil, nil
+		case RateLimitFailLocal:
+			localChecks = append(localChecks, check)
+		}
+	}
+
+	if len(localChecks) == 0 {
+		metrics.RateLimitFallbackDecisions.WithLabelValues(string(RateLimitFailOpen), "allowed").Inc()
+		ctx.Next()
+		return nil, nil
+	}
+
+	results := rl.local.check(localChecks)
+	outcome := "allowed"
+	for _, result := range results {
+		if !result.Allowed {
+			outcome = "rejected"
+		}
+	}
+	metrics.RateLimitFallbackDecisions.WithLabelValues(string(RateLimitFailLocal), outcome).Inc()
+
+	return localChecks, results
+}
+
+// setRateLimitHeaders writes the IETF RateLimit header fields
+// (draft-ietf-httpapi-ratelimit-headers): the limit, remaining quota and reset
+// delta of the tightest window, plus every window that applied in
+// RateLimit-Policy.
+func setRateLimitHeaders(ctx *gin.Context, checks []rateLimitCheck, limit RateLimitConfig, result *RateLimitResult) {
+	policies := make([]string, len(checks))
+	for i, check := range checks {
+		policies[i] = fmt.Sprintf("%d;w=%d", check.config.Requests, int64(check.config.Window/time.Second))
+	}
+
+	reset := result.ResetTime - time.Now().Unix()
+	if reset < 0 {
+		reset = 0
+	}
+
+	ctx.Header("RateLimit-Limit", strconv.Itoa(limit.Requests))
+	ctx.Header("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	ctx.Header("RateLimit-Reset", strconv.FormatInt(reset, 10))
+	ctx.Header("RateLimit-Policy", strings.Join(policies, ", "))
+}
+
+// resolveIdentity returns the value a policy counts by, or "" when the request
+// does not carry it, along with the index sets the key should be recorded in.
+func (rl *RateLimiter) resolveIdentity(ctx *gin.Context, identity RateLimitIdentity) (string, []string) {
+	switch identity {
+	case RateLimitByIP:
+		ip := GetClientIP(ctx)
+		return ip, []string{rateLimitIPIndexKey(ip)}
+	case RateLimitByUser:
+		payload, exists := ctx.Get("authorization_payload")
+		if !exists {
+			return "", nil
+		}
+		userPayload, ok := payload.(*Payload)
+		if !ok {
+			return "", nil
+		}
+		return strconv.FormatInt(userPayload.UserID, 10), []string{rateLimitUserIndexKey(userPayload.UserID)}
+	case RateLimitByEmail:
+		return requestEmail(ctx), nil
+	case RateLimitByClientID:
+		if clientID := ctx.GetHeader(ClientIDHeaderKey); clientID != "" {
+			return clientID, nil
+		}
+		return ctx.Query("client_id"), nil
+	}
+	return "", nil
+}
+
+// requestEmail peeks at the JSON body for an email field and restores the body
+// so the handler can still bind it.
+func requestEmail(ctx *gin.Context) string {
+	if ctx.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxRateLimitBodySize))
+	if err != nil {
+		return ""
+	}
+	ctx.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), ctx.Request.Body))
+
+	var fields struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(fields.Email))
+}
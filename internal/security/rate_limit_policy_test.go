@@ -0,0 +1,387 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// newOfflineRateLimiter returns a limiter whose Redis refuses connections, so
+// every check goes through the failure modes.
+func newOfflineRateLimiter(t *testing.T, defaultFailureMode RateLimitFailureMode, policies *RateLimitPolicies) *RateLimiter {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	t.Cleanup(func() { redisClient.Close() })
+
+	rl := &RateLimiter{
+		redisClient:        redisClient,
+		local:              newLocalRateLimiter(),
+		defaultFailureMode: defaultFailureMode,
+	}
+	rl.SetPolicies(policies)
+	return rl
+}
+
+func writePoliciesFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write policies file: %v", err)
+	}
+}
+
+func TestLoadRateLimitPolicies(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []RateLimitPolicy
+		wantErr string
+	}{
+		{
+			name: "stacked windows",
+			content: `
+policies:
+  - name: login-email
+    routes: ["POST /api/v1/login"]
+    identity: email
+    failure_mode: closed
+    limits:
+      - { requests: 5, window: 1m }
+      - { requests: 50, window: 24h }
+`,
+			want: []RateLimitPolicy{{
+				Name:        "login-email",
+				Routes:      []string{"POST /api/v1/login"},
+				Identity:    RateLimitByEmail,
+				FailureMode: RateLimitFailClosed,
+				Limits: []RateLimitConfig{
+					{Requests: 5, Window: time.Minute},
+					{Requests: 50, Window: 24 * time.Hour},
+				},
+			}},
+		},
+		{
+			name:    "JSON is accepted",
+			content: `{"policies": [{"name": "api", "routes": ["/api/v1/*"], "identity": "user", "per_route": true, "limits": [{"requests": 10, "window": "1h"}]}]}`,
+			want: []RateLimitPolicy{{
+				Name:     "api",
+				Routes:   []string{"/api/v1/*"},
+				Identity: RateLimitByUser,
+				PerRoute: true,
+				Limits:   []RateLimitConfig{{Requests: 10, Window: time.Hour}},
+			}},
+		},
+		{
+			name:    "malformed YAML",
+			content: "policies: [",
+			wantErr: "failed to parse",
+		},
+		{
+			name: "invalid policy",
+			content: `
+policies:
+  - name: broken
+    routes: ["/api/v1/*"]
+    identity: device
+    limits:
+      - { requests: 5, window: 1m }
+`,
+			wantErr: "unknown identity",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "policies"+string(rune('a'+i))+".yaml")
+			writePoliciesFile(t, path, tt.content)
+
+			policies, err := LoadRateLimitPolicies(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRateLimitPolicies() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadRateLimitPolicies() error = %v", err)
+			}
+
+			if len(policies.Policies) != len(tt.want) {
+				t.Fatalf("got %d policies, want %d", len(policies.Policies), len(tt.want))
+			}
+			for j, want := range tt.want {
+				got := policies.Policies[j]
+				if got.Name != want.Name || got.Identity != want.Identity || got.PerRoute != want.PerRoute || got.FailureMode != want.FailureMode {
+					t.Errorf("policy %d = %+v, want %+v", j, got, want)
+				}
+				if strings.Join(got.Routes, ",") != strings.Join(want.Routes, ",") {
+					t.Errorf("policy %d routes = %v, want %v", j, got.Routes, want.Routes)
+				}
+				if len(got.Limits) != len(want.Limits) {
+					t.Fatalf("policy %d has %d limits, want %d", j, len(got.Limits), len(want.Limits))
+				}
+				for k := range want.Limits {
+					if got.Limits[k] != want.Limits[k] {
+						t.Errorf("policy %d limit %d = %+v, want %+v", j, k, got.Limits[k], want.Limits[k])
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := LoadRateLimitPolicies(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("LoadRateLimitPolicies() succeeded for a missing file")
+	}
+}
+
+func TestExampleRateLimitPoliciesLoad(t *testing.T) {
+	if _, err := LoadRateLimitPolicies("../../deployment/rate_limits.example.yaml"); err != nil {
+		t.Fatalf("example policies do not load: %v", err)
+	}
+}
+
+func TestRateLimitPoliciesValidate(t *testing.T) {
+	valid := func() RateLimitPolicy {
+		return RateLimitPolicy{
+			Name:     "auth",
+			Routes:   []string{"POST /api/v1/login"},
+			Identity: RateLimitByIP,
+			Limits:   []RateLimitConfig{{Requests: 5, Window: time.Minute}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(policies *RateLimitPolicies)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			mutate: func(policies *RateLimitPolicies) {},
+		},
+		{
+			name:    "missing name",
+			mutate:  func(policies *RateLimitPolicies) { policies.Policies[0].Name = "" },
+			wantErr: "missing a name",
+		},
+		{
+			name:    "duplicate name",
+			mutate:  func(policies *RateLimitPolicies) { policies.Policies = append(policies.Policies, valid()) },
+			wantErr: "duplicate",
+		},
+		{
+			name:    "unknown identity",
+			mutate:  func(policies *RateLimitPolicies) { policies.Policies[0].Identity = "device" },
+			wantErr: "unknown identity",
+		},
+		{
+			name:    "unknown failure mode",
+			mutate:  func(policies *RateLimitPolicies) { policies.Policies[0].FailureMode = "retry" },
+			wantErr: "unknown failure mode",
+		},
+		{
+			name:    "no routes",
+			mutate:  func(policies *RateLimitPolicies) { policies.Policies[0].Routes = nil },
+			wantErr: "no routes",
+		},
+		{
+			name:    "no limits",
+			mutate:  func(policies *RateLimitPolicies) { policies.Policies[0].Limits = nil },
+			wantErr: "no limits",
+		},
+		{
+			name:    "zero requests",
+			mutate:  func(policies *RateLimitPolicies) { policies.Policies[0].Limits[0].Requests = 0 },
+			wantErr: "positive requests",
+		},
+		{
+			name: "window shorter than a microsecond per request",
+			mutate: func(policies *RateLimitPolicies) {
+				policies.Policies[0].Limits[0] = RateLimitConfig{Requests: 1000, Window: time.Microsecond}
+			},
+			wantErr: "positive requests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies := &RateLimitPolicies{Policies: []RateLimitPolicy{valid()}}
+			tt.mutate(policies)
+
+			err := policies.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := DefaultRateLimitPolicies().Validate(); err != nil {
+		t.Errorf("default policies do not validate: %v", err)
+	}
+}
+
+func TestRateLimitPolicyMatchRoute(t *testing.T) {
+	policy := RateLimitPolicy{
+		Routes: []string{
+			"POST /api/v1/login",
+			"/api/v1/password-reset/*",
+			"get /api/v1/sessions",
+		},
+	}
+
+	tests := []struct {
+		method   string
+		fullPath string
+		want     bool
+	}{
+		{http.MethodPost, "/api/v1/login", true},
+		{http.MethodGet, "/api/v1/login", false},
+		{http.MethodPost, "/api/v1/login/other", false},
+		{http.MethodPost, "/api/v1/password-reset", true},
+		{http.MethodPost, "/api/v1/password-reset/confirm", true},
+		{http.MethodGet, "/api/v1/password-reset/verify/:token", true},
+		{http.MethodPost, "/api/v1/password-resets", false},
+		{http.MethodGet, "/api/v1/sessions", true},
+		{http.MethodDelete, "/api/v1/sessions", false},
+		{http.MethodGet, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.matchRoute(tt.method, tt.fullPath); got != tt.want {
+			t.Errorf("matchRoute(%s, %q) = %v, want %v", tt.method, tt.fullPath, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyMiddlewareIdentities(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rl := newOfflineRateLimiter(t, RateLimitFailLocal, &RateLimitPolicies{
+		Policies: []RateLimitPolicy{
+			{
+				Name:     "login-email",
+				Routes:   []string{"POST /api/v1/login"},
+				Identity: RateLimitByEmail,
+				Limits:   []RateLimitConfig{{Requests: 2, Window: time.Minute}},
+			},
+			{
+				Name:     "client",
+				Routes:   []string{"/api/v1/clients/*"},
+				Identity: RateLimitByClientID,
+				Limits:   []RateLimitConfig{{Requests: 1, Window: time.Minute}},
+			},
+			{
+				Name:     "authenticated",
+				Routes:   []string{"/api/v1/*"},
+				Identity: RateLimitByUser,
+				Limits:   []RateLimitConfig{{Requests: 1, Window: time.Minute}},
+			},
+		},
+	})
+
+	router := gin.New()
+	api := router.Group("/api/v1", rl.PolicyMiddleware())
+	api.POST("/login", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	api.GET("/clients/token", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	send := func(req *http.Request) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	login := func(email string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/login", strings.NewReader(`{"email": "`+email+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return send(req)
+	}
+	client := func(clientID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/clients/token", nil)
+		req.Header.Set(ClientIDHeaderKey, clientID)
+		return send(req)
+	}
+
+	tests := []struct {
+		name string
+		send func() int
+		want int
+	}{
+		{"first login for an email", func() int { return login("a@example.com") }, http.StatusOK},
+		{"second login, case and spaces folded", func() int { return login(" A@Example.com") }, http.StatusOK},
+		{"third login for the same email", func() int { return login("a@example.com") }, http.StatusTooManyRequests},
+		{"another email has its own budget", func() int { return login("b@example.com") }, http.StatusOK},
+		{"no email is not counted", func() int { return login("") }, http.StatusOK},
+		{"no email is still not counted", func() int { return login("") }, http.StatusOK},
+		{"first request for a client", func() int { return client("app-1") }, http.StatusOK},
+		{"second request for the client", func() int { return client("app-1") }, http.StatusTooManyRequests},
+		{"another client", func() int { return client("app-2") }, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if got := tt.send(); got != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWatchPoliciesKeepsPreviousOnInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	writePoliciesFile(t, path, "policies: []\n")
+
+	rl := newOfflineRateLimiter(t, RateLimitFailLocal, DefaultRateLimitPolicies())
+	defaults := rl.Policies()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rl.WatchPolicies(ctx, path, 10*time.Millisecond)
+
+	touch := func(content string, at time.Time) {
+		writePoliciesFile(t, path, content)
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatalf("failed to touch policies file: %v", err)
+		}
+	}
+
+	touch("policies: [", time.Now().Add(time.Hour))
+	time.Sleep(100 * time.Millisecond)
+	if rl.Policies() != defaults {
+		t.Fatal("an invalid file replaced the default policies")
+	}
+
+	touch(`
+policies:
+  - name: auth
+    routes: ["POST /api/v1/login"]
+    identity: ip
+    limits:
+      - { requests: 1, window: 1m }
+`, time.Now().Add(2*time.Hour))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for rl.Policies() == defaults {
+		if time.Now().After(deadline) {
+			t.Fatal("a valid file was not loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if policies := rl.Policies().Policies; len(policies) != 1 || policies[0].Name != "auth" {
+		t.Errorf("loaded policies = %+v, want the auth policy", policies)
+	}
+}
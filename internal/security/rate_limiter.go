@@ -3,21 +3,50 @@ package security
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"strconv"
+	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gin-gonic/gin"
+	"github.com/m1thrandir225/whoami/internal/metrics"
+	redisutil "github.com/m1thrandir225/whoami/pkg/redis"
 	"github.com/redis/go-redis/v9"
 )
 
+// RateLimitFailureMode decides what a policy does when Redis is unreachable.
+type RateLimitFailureMode string
+
+const (
+	// RateLimitFailOpen admits every request
+	RateLimitFailOpen RateLimitFailureMode = "open"
+	// RateLimitFailClosed rejects every request with 503
+	RateLimitFailClosed RateLimitFailureMode = "closed"
+	// RateLimitFailLocal falls back to a per-instance in-memory limiter
+	RateLimitFailLocal RateLimitFailureMode = "local"
+)
+
 type RateLimiter struct {
-	redisClient *redis.Client
+	redisClient        *redis.Client
+	policies           atomic.Pointer[RateLimitPolicies]
+	local              *localRateLimiter
+	defaultFailureMode RateLimitFailureMode
+
+	statusMu      sync.RWMutex
+	degradedSince time.Time
+	lastError     string
+}
+
+// RateLimiterStatus reports whether the limiter is running on its failure
+// modes because Redis is unavailable.
+type RateLimiterStatus struct {
+	Degraded      bool       `json:"degraded"`
+	DegradedSince *time.Time `json:"degraded_since,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
 }
 
 type RateLimitConfig struct {
-	Requests int           `json:"requests"`
-	Window   time.Duration `json:"window"`
+	Requests int           `json:"requests" yaml:"requests"`
+	Window   time.Duration `json:"window" yaml:"window"`
 }
 
 type RateLimitResult struct {
@@ -27,29 +56,18 @@ type RateLimitResult struct {
 	RetryAfter int64 `json:"retry_after,omitempty"`
 }
 
-var (
-	DefaultRateLimit = RateLimitConfig{
-		Requests: 1000,
-		Window:   time.Hour * 1,
-	}
-
-	AuthRateLimit = RateLimitConfig{
-		Requests: 50,
-		Window:   15 * time.Minute,
-	}
-
-	RegistrationRateLimit = RateLimitConfig{
-		Requests: 3,
-		Window:   time.Hour * 1,
+func ParseRateLimitFailureMode(mode string) (RateLimitFailureMode, error) {
+	switch failureMode := RateLimitFailureMode(mode); failureMode {
+	case RateLimitFailOpen, RateLimitFailClosed, RateLimitFailLocal:
+		return failureMode, nil
+	case "":
+		return RateLimitFailLocal, nil
+	default:
+		return "", fmt.Errorf("unknown rate limit failure mode %q", mode)
 	}
+}
 
-	PasswordResetRateLimit = RateLimitConfig{
-		Requests: 3,
-		Window:   time.Hour * 1,
-	}
-)
-
-func NewRateLimiter(redisClient *redis.Client) (*RateLimiter, error) {
+func NewRateLimiter(redisClient *redis.Client, defaultFailureMode RateLimitFailureMode) (*RateLimiter, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -57,193 +75,223 @@ func NewRateLimiter(redisClient *redis.Client) (*RateLimiter, error) {
 		return nil, fmt.Errorf("failed to ping Redis: %w", err)
 	}
 
-	return &RateLimiter{
-		redisClient: redisClient,
-	}, nil
-}
+	rl := &RateLimiter{
+		redisClient:        redisClient,
+		local:              newLocalRateLimiter(),
+		defaultFailureMode: defaultFailureMode,
+	}
+	rl.SetPolicies(DefaultRateLimitPolicies())
 
-func (rl *RateLimiter) CheckRateLimit(ctx context.Context, key string, config RateLimitConfig) (*RateLimitResult, error) {
-	now := time.Now()
+	return rl, nil
+}
 
-	windowStart := now.Add(-config.Window)
+// Rate limit keys are also recorded in per-IP and per-user index sets so they
+// can be reset without scanning the keyspace.
+func rateLimitIPIndexKey(ip string) string {
+	return fmt.Sprintf("rate_limit_index:ip:%s", ip)
+}
 
-	pipe := rl.redisClient.Pipeline()
+func rateLimitUserIndexKey(userID int64) string {
+	return fmt.Sprintf("rate_limit_index:user:%d", userID)
+}
 
-	pipe.ZRemRangeByScore(ctx, key, "0", strconv.FormatInt(windowStart.Unix(), 10))
+// gcraScript implements the generic cell rate algorithm for one or more
+// stacked windows at once. Each key stores only its theoretical arrival time
+// (TAT) in microseconds. A request is admitted only if every window admits
+// it, and rejected requests leave all keys untouched so a client that keeps
+// retrying still recovers.
+//
+// KEYS: one key per window
+// ARGV: emission interval and burst tolerance, in microseconds, per key
+// Returns per key: {allowed, remaining, retry_after_us, reset_after_us}
+var gcraScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local results = {}
+local new_tats = {}
+local allowed = 1
+
+for i, key in ipairs(KEYS) do
+	local emission = tonumber(ARGV[i * 2 - 1])
+	local tolerance = tonumber(ARGV[i * 2])
+	local tat = tonumber(redis.call('GET', key) or now)
+	if tat < now then
+		tat = now
+	end
+
+	local new_tat = tat + emission
+	local allow_at = new_tat - tolerance
+	if allow_at > now then
+		allowed = 0
+		results[i] = {0, 0, allow_at - now, tat - now}
+	else
+		results[i] = {1, math.floor((now - allow_at) / emission), 0, new_tat - now}
+	end
+	new_tats[i] = new_tat
+end
+
+if allowed == 1 then
+	for i, key in ipairs(KEYS) do
+		redis.call('SET', key, string.format('%.0f', new_tats[i]), 'PX', math.ceil((new_tats[i] - now) / 1000))
+	end
+end
+
+return results
+`)
+
+type rateLimitCheck struct {
+	policy      string
+	key         string
+	config      RateLimitConfig
+	indexKeys   []string
+	failureMode RateLimitFailureMode
+}
 
-	countCmd := pipe.ZCard(ctx, key)
+func (rl *RateLimiter) CheckRateLimit(ctx context.Context, key string, config RateLimitConfig) (*RateLimitResult, error) {
+	return rl.checkRateLimit(ctx, key, config)
+}
 
-	pipe.ZAdd(ctx, key, redis.Z{
-		Score:  float64(now.Unix()),
-		Member: now.UnixNano(),
-	})
+func (rl *RateLimiter) checkRateLimit(ctx context.Context, key string, config RateLimitConfig, indexKeys ...string) (*RateLimitResult, error) {
+	results, err := rl.checkRateLimits(ctx, []rateLimitCheck{{key: key, config: config, indexKeys: indexKeys}})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
 
-	pipe.Expire(ctx, key, config.Window)
+// checkRateLimits evaluates several windows atomically. The request counts
+// against all of them only when none of them rejects it.
+func (rl *RateLimiter) checkRateLimits(ctx context.Context, checks []rateLimitCheck) ([]*RateLimitResult, error) {
+	keys := make([]string, len(checks))
+	args := make([]interface{}, 0, len(checks)*2)
+	for i, check := range checks {
+		keys[i] = check.key
+		emission := check.config.Window.Microseconds() / int64(check.config.Requests)
+		args = append(args, emission, check.config.Window.Microseconds())
+	}
 
-	_, err := pipe.Exec(ctx)
+	raw, err := gcraScript.Run(ctx, rl.redisClient, keys, args...).Slice()
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute Redis pipeline: %v", err)
+		rl.markDegraded(err)
+		return nil, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	rl.markHealthy()
+	if len(raw) != len(checks) {
+		return nil, fmt.Errorf("unexpected rate limit script reply: %v", raw)
 	}
 
-	count := countCmd.Val()
-	allowed := count < int64(config.Requests)
+	now := time.Now()
+	allowed := true
+	results := make([]*RateLimitResult, len(checks))
+	for i, entry := range raw {
+		values, ok := entry.([]interface{})
+		if !ok || len(values) != 4 {
+			return nil, fmt.Errorf("unexpected rate limit script reply: %v", entry)
+		}
 
-	result := &RateLimitResult{
-		Allowed:   allowed,
-		Remaining: int64(config.Requests) - count,
-		ResetTime: now.Add(config.Window).Unix(),
+		result := &RateLimitResult{
+			Allowed:    values[0].(int64) == 1,
+			Remaining:  values[1].(int64),
+			ResetTime:  now.Unix() + microsecondsToSeconds(values[3].(int64)),
+			RetryAfter: microsecondsToSeconds(values[2].(int64)),
+		}
+		allowed = allowed && result.Allowed
+		results[i] = result
 	}
 
-	if !allowed {
-		// Calculate retry after time
-		oldestRequest, err := rl.redisClient.ZRange(ctx, key, 0, 0).Result()
-		if err == nil && len(oldestRequest) > 0 {
-			if oldestTime, err := strconv.ParseInt(oldestRequest[0], 10, 64); err == nil {
-				retryAfter := time.Unix(oldestTime, 0).Add(config.Window).Unix() - now.Unix()
-				if retryAfter > 0 {
-					result.RetryAfter = retryAfter
-				}
+	if allowed {
+		pipe := rl.redisClient.Pipeline()
+		for _, check := range checks {
+			for _, indexKey := range check.indexKeys {
+				redisutil.AddToIndex(ctx, pipe, indexKey, check.key, check.config.Window)
+			}
+		}
+		if pipe.Len() > 0 {
+			if _, err := pipe.Exec(ctx); err != nil {
+				fmt.Printf("Warning: failed to index rate limit keys: %v\n", err)
 			}
 		}
 	}
 
-	return result, nil
-}
-
-func GetClientIP(ctx *gin.Context) string {
-	if ip := ctx.GetHeader("X-Forwarded-For"); ip != "" {
-		return ip
-	}
-	if ip := ctx.GetHeader("X-Real-IP"); ip != "" {
-		return ip
-	}
-	if ip := ctx.GetHeader("CF-Connecting-IP"); ip != "" {
-		return ip
-	}
-
-	return ctx.ClientIP()
+	return results, nil
 }
 
-func (rl *RateLimiter) RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		ip := GetClientIP(ctx)
-		key := fmt.Sprintf("rate_limit:%s:%s", ctx.Request.URL.Path, ip)
-		result, err := rl.CheckRateLimit(ctx, key, config)
-		if err != nil {
-			// If Redis is down, allow the request but log the error
-			ctx.Next()
-			return
-		}
+func (rl *RateLimiter) markDegraded(err error) {
+	metrics.RateLimitBackendErrors.Inc()
+	metrics.RateLimitDegraded.Set(1)
 
-		ctx.Header("X-RateLimit-Limit", strconv.Itoa(config.Requests))
-		ctx.Header("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
-		ctx.Header("X-RateLimit-Reset", strconv.FormatInt(result.ResetTime, 10))
-
-		if !result.Allowed {
-			ctx.Header("Retry-After", strconv.FormatInt(result.RetryAfter, 10))
-			ctx.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
-				"retry_after": result.RetryAfter,
-			})
-			ctx.Abort()
-			return
-		}
+	rl.statusMu.Lock()
+	defer rl.statusMu.Unlock()
 
-		ctx.Next()
+	if rl.degradedSince.IsZero() {
+		rl.degradedSince = time.Now()
+		log.Printf("rate limiting degraded, Redis unavailable: %v", err)
 	}
+	rl.lastError = err.Error()
 }
 
-func (rl *RateLimiter) UserRateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		payload, exists := ctx.Get("authorization_payload")
-		if !exists {
-			// If no user payload, fall back to IP-based rate limiting
-			clientIP := GetClientIP(ctx)
-			key := fmt.Sprintf("rate_limit:user:%s:%s", ctx.Request.URL.Path, clientIP)
-
-			result, err := rl.CheckRateLimit(ctx, key, config)
-			if err != nil {
-				ctx.Next()
-				return
-			}
+func (rl *RateLimiter) markHealthy() {
+	rl.statusMu.RLock()
+	degraded := !rl.degradedSince.IsZero()
+	rl.statusMu.RUnlock()
+	if !degraded {
+		return
+	}
 
-			if !result.Allowed {
-				ctx.JSON(http.StatusTooManyRequests, gin.H{
-					"error": "Rate limit exceeded",
-				})
-				ctx.Abort()
-				return
-			}
-		} else {
-			// Use user ID for rate limiting
-			userPayload, ok := payload.(*Payload)
-			if !ok {
-				ctx.Next()
-				return
-			}
+	metrics.RateLimitDegraded.Set(0)
 
-			key := fmt.Sprintf("rate_limit:user:%d:%s", userPayload.UserID, ctx.Request.URL.Path)
+	rl.statusMu.Lock()
+	defer rl.statusMu.Unlock()
 
-			result, err := rl.CheckRateLimit(ctx, key, config)
-			if err != nil {
-				ctx.Next()
-				return
-			}
+	if !rl.degradedSince.IsZero() {
+		log.Printf("rate limiting recovered after %s", time.Since(rl.degradedSince).Round(time.Second))
+	}
+	rl.degradedSince = time.Time{}
+	rl.lastError = ""
+}
 
-			if !result.Allowed {
-				ctx.JSON(http.StatusTooManyRequests, gin.H{
-					"error": "Rate limit exceeded",
-				})
-				ctx.Abort()
-				return
-			}
-		}
+func (rl *RateLimiter) Status() RateLimiterStatus {
+	rl.statusMu.RLock()
+	defer rl.statusMu.RUnlock()
 
-		ctx.Next()
+	if rl.degradedSince.IsZero() {
+		return RateLimiterStatus{}
 	}
+
+	since := rl.degradedSince
+	return RateLimiterStatus{
+		Degraded:      true,
+		DegradedSince: &since,
+		LastError:     rl.lastError,
+	}
+}
+
+// microsecondsToSeconds rounds up so clients never retry too early.
+func microsecondsToSeconds(us int64) int64 {
+	return (us + int64(time.Second/time.Microsecond) - 1) / int64(time.Second/time.Microsecond)
 }
 
 func (rl *RateLimiter) ResetRateLimit(ctx context.Context, key string) error {
 	return rl.redisClient.Del(ctx, key).Err()
 }
 
+// ResetAllRateLimits walks the keyspace with SCAN rather than KEYS so it is
+// safe to run against a large production Redis.
 func (rl *RateLimiter) ResetAllRateLimits(ctx context.Context) error {
-	pattern := "rate_limit:*"
-	keys, err := rl.redisClient.Keys(ctx, pattern).Result()
-	if err != nil {
+	if _, err := redisutil.DeleteMatching(ctx, rl.redisClient, "rate_limit:*"); err != nil {
 		return err
 	}
 
-	if len(keys) > 0 {
-		return rl.redisClient.Del(ctx, keys...).Err()
-	}
-	return nil
+	_, err := redisutil.DeleteMatching(ctx, rl.redisClient, "rate_limit_index:*")
+	return err
 }
 
 func (rl *RateLimiter) ResetRateLimitByIP(ctx context.Context, ip string) error {
-	pattern := fmt.Sprintf("rate_limit:*:%s", ip)
-	keys, err := rl.redisClient.Keys(ctx, pattern).Result()
-	if err != nil {
-		return err
-	}
-
-	if len(keys) > 0 {
-		return rl.redisClient.Del(ctx, keys...).Err()
-	}
-	return nil
+	return redisutil.DeleteIndexed(ctx, rl.redisClient, rateLimitIPIndexKey(ip))
 }
 
 func (rl *RateLimiter) ResetRateLimitByUser(ctx context.Context, userID int64) error {
-	pattern := fmt.Sprintf("rate_limit:user:%d:*", userID)
-	keys, err := rl.redisClient.Keys(ctx, pattern).Result()
-	if err != nil {
-		return err
-	}
-
-	if len(keys) > 0 {
-		return rl.redisClient.Del(ctx, keys...).Err()
-	}
-	return nil
+	return redisutil.DeleteIndexed(ctx, rl.redisClient, rateLimitUserIndexKey(userID))
 }
 
 func (rl *RateLimiter) Close() error {
@@ -0,0 +1,214 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	redisutil "github.com/m1thrandir225/whoami/pkg/redis"
+)
+
+// newTestRateLimiter connects to the Redis in TESTING_REDIS_URL and returns
+// a key prefix unique to the test.
+func newTestRateLimiter(t *testing.T) (*RateLimiter, string) {
+	redisURL := os.Getenv("TESTING_REDIS_URL")
+	if redisURL == "" {
+		t.Skip("TESTING_REDIS_URL is not set")
+	}
+
+	redisClient, err := redisutil.NewRedisClient(redisURL)
+	if err != nil {
+		t.Fatalf("failed to create Redis client: %v", err)
+	}
+	t.Cleanup(func() { redisClient.Close() })
+
+	rl, err := NewRateLimiter(redisClient, RateLimitFailClosed)
+	if err != nil {
+		t.Fatalf("failed to create rate limiter: %v", err)
+	}
+
+	prefix := fmt.Sprintf("test:gcra:%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		if _, err := redisutil.DeleteMatching(context.Background(), redisClient, prefix+":*"); err != nil {
+			t.Errorf("failed to clean up test keys: %v", err)
+		}
+	})
+
+	return rl, prefix
+}
+
+func TestGCRABurst(t *testing.T) {
+	rl, prefix := newTestRateLimiter(t)
+	ctx := context.Background()
+	config := RateLimitConfig{Requests: 3, Window: time.Minute}
+
+	for i, wantRemaining := range []int64{2, 1, 0} {
+		result, err := rl.CheckRateLimit(ctx, prefix+":burst", config)
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		if !result.Allowed || result.Remaining != wantRemaining {
+			t.Fatalf("request %d: allowed %v, remaining %d; want allowed, remaining %d", i+1, result.Allowed, result.Remaining, wantRemaining)
+		}
+	}
+
+	result, err := rl.CheckRateLimit(ctx, prefix+":burst", config)
+	if err != nil {
+		t.Fatalf("request 4: %v", err)
+	}
+	if result.Allowed {
+		t.Fatal("request 4 was allowed past the burst")
+	}
+	// One request is let through every 20s
+	if result.RetryAfter < 19 || result.RetryAfter > 20 {
+		t.Errorf("retry after %ds, want about 20s", result.RetryAfter)
+	}
+}
+
+func TestGCRARejectionLeavesWindowsUntouched(t *testing.T) {
+	rl, prefix := newTestRateLimiter(t)
+	ctx := context.Background()
+	wide := rateLimitCheck{key: prefix + ":wide", config: RateLimitConfig{Requests: 100, Window: time.Minute}}
+	narrow := rateLimitCheck{key: prefix + ":narrow", config: RateLimitConfig{Requests: 1, Window: time.Minute}}
+
+	results, err := rl.checkRateLimits(ctx, []rateLimitCheck{wide, narrow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Allowed || !results[1].Allowed {
+		t.Fatal("first request was rejected")
+	}
+
+	results, err = rl.checkRateLimits(ctx, []rateLimitCheck{wide, narrow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Allowed || results[1].Allowed {
+		t.Fatalf("second request: wide allowed %v, narrow allowed %v; want only wide", results[0].Allowed, results[1].Allowed)
+	}
+
+	// The rejected request must not have counted against the wide window
+	results, err = rl.checkRateLimits(ctx, []rateLimitCheck{wide})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Remaining != 98 {
+		t.Errorf("wide window has %d remaining, want 98", results[0].Remaining)
+	}
+}
+
+func TestParseRateLimitFailureMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    RateLimitFailureMode
+		wantErr bool
+	}{
+		{mode: "", want: RateLimitFailLocal},
+		{mode: "open", want: RateLimitFailOpen},
+		{mode: "closed", want: RateLimitFailClosed},
+		{mode: "local", want: RateLimitFailLocal},
+		{mode: "Closed", wantErr: true},
+		{mode: "ignore", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRateLimitFailureMode(tt.mode)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRateLimitFailureMode(%q) = %q, %v; want %q, error %v", tt.mode, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRateLimitFailureModes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy := func(name, route string, failureMode RateLimitFailureMode) RateLimitPolicy {
+		return RateLimitPolicy{
+			Name:        name,
+			Routes:      []string{route},
+			Identity:    RateLimitByIP,
+			Limits:      []RateLimitConfig{{Requests: 2, Window: time.Minute}},
+			FailureMode: failureMode,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		defaultMode RateLimitFailureMode
+		policies    []RateLimitPolicy
+		want        []int
+	}{
+		{
+			name:        "fail open admits everything",
+			defaultMode: RateLimitFailOpen,
+			policies:    []RateLimitPolicy{policy("api", "/api/v1/*", "")},
+			want:        []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:        "fail closed rejects everything",
+			defaultMode: RateLimitFailClosed,
+			policies:    []RateLimitPolicy{policy("api", "/api/v1/*", "")},
+			want:        []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		},
+		{
+			name:        "fail local keeps limiting in memory",
+			defaultMode: RateLimitFailLocal,
+			policies:    []RateLimitPolicy{policy("api", "/api/v1/*", "")},
+			want:        []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:        "policy overrides the default mode",
+			defaultMode: RateLimitFailClosed,
+			policies:    []RateLimitPolicy{policy("api", "/api/v1/*", RateLimitFailOpen)},
+			want:        []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:        "one fail closed policy rejects the request",
+			defaultMode: RateLimitFailOpen,
+			policies: []RateLimitPolicy{
+				policy("api", "/api/v1/*", ""),
+				policy("login", "POST /api/v1/login", RateLimitFailClosed),
+			},
+			want: []int{http.StatusServiceUnavailable},
+		},
+		{
+			name:        "fail open policies are skipped beside local ones",
+			defaultMode: RateLimitFailOpen,
+			policies: []RateLimitPolicy{
+				policy("api", "/api/v1/*", ""),
+				policy("login", "POST /api/v1/login", RateLimitFailLocal),
+			},
+			want: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := newOfflineRateLimiter(t, tt.defaultMode, &RateLimitPolicies{Policies: tt.policies})
+
+			router := gin.New()
+			router.POST("/api/v1/login", rl.PolicyMiddleware(), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+			for i, want := range tt.want {
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/login", nil))
+				if recorder.Code != want {
+					t.Fatalf("request %d: status %d, want %d", i+1, recorder.Code, want)
+				}
+				if want == http.StatusServiceUnavailable && recorder.Header().Get("Retry-After") != strconv.Itoa(failClosedRetryAfter) {
+					t.Errorf("request %d: Retry-After %q, want %d", i+1, recorder.Header().Get("Retry-After"), failClosedRetryAfter)
+				}
+			}
+
+			if status := rl.Status(); !status.Degraded || status.DegradedSince == nil || status.LastError == "" {
+				t.Errorf("Status() = %+v, want degraded with the Redis error", status)
+			}
+		})
+	}
+}
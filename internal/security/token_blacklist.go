@@ -2,15 +2,26 @@ package security
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	redisutil "github.com/m1thrandir225/whoami/pkg/redis"
 	"github.com/redis/go-redis/v9"
 )
 
+// BlacklistReasonSignedOutElsewhere marks tokens whose session was evicted to
+// make room for a newer login.
+const BlacklistReasonSignedOutElsewhere = "signed_out_elsewhere"
+
 type TokenBlacklist interface {
 	BlacklistToken(ctx context.Context, token string, expiration time.Duration) error
+	BlacklistTokenWithReason(ctx context.Context, token string, expiration time.Duration, reason string) error
+	// BlacklistTokenHash blacklists a token known only by its HashToken,
+	// as it is kept on a stored session.
+	BlacklistTokenHash(ctx context.Context, tokenHash string, expiration time.Duration, reason string) error
 	IsTokenBlacklisted(ctx context.Context, token string) (bool, error)
+	GetBlacklistReason(ctx context.Context, token string) (string, error)
 	RemoveFromBlacklist(ctx context.Context, token string) error
 	BlacklistUserTokens(ctx context.Context, userID int64, reason string) error
 	GetBlacklistedTokensForUser(ctx context.Context, userID int64) ([]string, error)
@@ -27,12 +38,36 @@ func NewTokenBlacklist(redisClient *redis.Client) TokenBlacklist {
 }
 
 func (tb *tokenBlacklist) BlacklistToken(ctx context.Context, token string, expiration time.Duration) error {
-	key := fmt.Sprintf("blacklist:token:%s", token)
-	return tb.redisClient.Set(ctx, key, "blacklisted", expiration).Err()
+	return tb.BlacklistTokenWithReason(ctx, token, expiration, "blacklisted")
+}
+
+func (tb *tokenBlacklist) BlacklistTokenWithReason(ctx context.Context, token string, expiration time.Duration, reason string) error {
+	return tb.BlacklistTokenHash(ctx, HashToken(token), expiration, reason)
+}
+
+func (tb *tokenBlacklist) BlacklistTokenHash(ctx context.Context, tokenHash string, expiration time.Duration, reason string) error {
+	return tb.redisClient.Set(ctx, blacklistTokenKey(tokenHash), reason, expiration).Err()
+}
+
+// blacklistTokenKey is keyed by the token hash so the blacklist holds no
+// usable tokens.
+func blacklistTokenKey(tokenHash string) string {
+	return fmt.Sprintf("blacklist:token:%s", tokenHash)
+}
+
+// GetBlacklistReason returns the reason a token was blacklisted, or an empty
+// string if it is not blacklisted.
+func (tb *tokenBlacklist) GetBlacklistReason(ctx context.Context, token string) (string, error) {
+	key := blacklistTokenKey(HashToken(token))
+	reason, err := tb.redisClient.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return reason, err
 }
 
 func (tb *tokenBlacklist) IsTokenBlacklisted(ctx context.Context, token string) (bool, error) {
-	key := fmt.Sprintf("blacklist:token:%s", token)
+	key := blacklistTokenKey(HashToken(token))
 	exists, err := tb.redisClient.Exists(ctx, key).Result()
 	if err != nil {
 		return false, err
@@ -41,50 +76,61 @@ func (tb *tokenBlacklist) IsTokenBlacklisted(ctx context.Context, token string)
 }
 
 func (tb *tokenBlacklist) RemoveFromBlacklist(ctx context.Context, token string) error {
-	key := fmt.Sprintf("blacklist:token:%s", token)
+	key := blacklistTokenKey(HashToken(token))
 	return tb.redisClient.Del(ctx, key).Err()
 }
 
+// blacklistUserIndexKey holds the blacklist keys written for a user so they
+// can be listed without scanning the keyspace.
+func blacklistUserIndexKey(userID int64) string {
+	return fmt.Sprintf("blacklist:index:user:%d", userID)
+}
+
 func (tb *tokenBlacklist) BlacklistUserTokens(ctx context.Context, userID int64, reason string) error {
-	// This would typically involve:
-	// 1. Getting all active tokens for the user
-	// 2. Blacklisting each token
-	// 3. Logging the security event
-
-	// For now, we'll use a pattern-based approach
-	pattern := fmt.Sprintf("blacklist:user:%d:*", userID)
-	_, err := tb.redisClient.Keys(ctx, pattern).Result()
-	if err != nil {
-		return err
-	}
+	indexKey := blacklistUserIndexKey(userID)
+	pipe := tb.redisClient.TxPipeline()
 
 	// Add a marker for the user's blacklisted status
 	userBlacklistKey := fmt.Sprintf("blacklist:user:%d:status", userID)
-	err = tb.redisClient.Set(ctx, userBlacklistKey, reason, 24*time.Hour).Err()
-	if err != nil {
-		return err
-	}
+	pipe.Set(ctx, userBlacklistKey, reason, 24*time.Hour)
+	redisutil.AddToIndex(ctx, pipe, indexKey, userBlacklistKey, 24*time.Hour)
 
 	// Log the security event
 	eventKey := fmt.Sprintf("blacklist:events:%d:%d", userID, time.Now().Unix())
-	err = tb.redisClient.Set(ctx, eventKey, reason, 7*24*time.Hour).Err()
+	pipe.Set(ctx, eventKey, reason, 7*24*time.Hour)
 
+	_, err := pipe.Exec(ctx)
 	return err
 }
 
 func (tb *tokenBlacklist) GetBlacklistedTokensForUser(ctx context.Context, userID int64) ([]string, error) {
-	pattern := fmt.Sprintf("blacklist:user:%d:*", userID)
-	keys, err := tb.redisClient.Keys(ctx, pattern).Result()
+	indexKey := blacklistUserIndexKey(userID)
+	keys, err := tb.redisClient.SMembers(ctx, indexKey).Result()
 	if err != nil {
 		return nil, err
 	}
 
+	if len(keys) == 0 {
+		return []string{}, nil
+	}
+
+	pipe := tb.redisClient.Pipeline()
+	existsCmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		existsCmds[i] = pipe.Exists(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
 	tokens := make([]string, 0, len(keys))
-	for _, key := range keys {
-		// Extract token from key
-		if len(key) > 0 {
-			tokens = append(tokens, key)
+	for i, key := range keys {
+		if existsCmds[i].Val() == 0 {
+			// Expired entry, drop it from the index
+			tb.redisClient.SRem(ctx, indexKey, key)
+			continue
 		}
+		tokens = append(tokens, key)
 	}
 
 	return tokens, nil
@@ -2,6 +2,8 @@
 package security
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 )
 
@@ -9,3 +11,10 @@ type TokenMaker interface {
 	CreateToken(userID int64, duration time.Duration) (string, *Payload, error)
 	VerifyToken(token string) (*Payload, error)
 }
+
+// HashToken returns the hex SHA-256 of token. Sessions and the blacklist keep
+// only this hash, so a copy of either cannot be replayed as a token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
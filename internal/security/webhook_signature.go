@@ -0,0 +1,28 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers sent with every webhook delivery.
+const (
+	WebhookIDHeader        = "Whoami-Webhook-Id"
+	WebhookEventHeader     = "Whoami-Webhook-Event"
+	WebhookTimestampHeader = "Whoami-Webhook-Timestamp"
+	WebhookSignatureHeader = "Whoami-Webhook-Signature"
+)
+
+// SignWebhook returns the signature header value for body sent at timestamp
+// (Unix seconds): "v1=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the endpoint secret. Signing the timestamp
+// lets receivers reject replays of old deliveries.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// verifyWebhook follows the steps receivers are told to take in the README.
+func verifyWebhook(secret, timestamp string, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	expected := "v1=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func TestSignWebhook(t *testing.T) {
+	const secret = "whsec_test"
+	const timestamp = int64(1700000000)
+	body := []byte(`{"id":"evt_1","type":"user.created"}`)
+
+	signature := SignWebhook(secret, timestamp, body)
+
+	// Computed independently over "1700000000.<body>"
+	want := "v1=25e9427a14f1b89708da76da758332a4c9d04e4bce30f9649ed20645dac8ffe5"
+	if signature != want {
+		t.Fatalf("SignWebhook() = %s, want %s", signature, want)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "untouched delivery", secret: secret, timestamp: "1700000000", body: body, signature: signature, want: true},
+		{name: "other secret", secret: "whsec_other", timestamp: "1700000000", body: body, signature: signature},
+		{name: "replayed with a new timestamp", secret: secret, timestamp: "1700000300", body: body, signature: signature},
+		{name: "altered body", secret: secret, timestamp: "1700000000", body: []byte(`{"id":"evt_1","type":"user.deleted"}`), signature: signature},
+		{name: "reformatted body", secret: secret, timestamp: "1700000000", body: []byte(`{"id": "evt_1", "type": "user.created"}`), signature: signature},
+		{name: "signature without version", secret: secret, timestamp: "1700000000", body: body, signature: strings.TrimPrefix(signature, "v1=")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyWebhook(tt.secret, tt.timestamp, tt.body, tt.signature); got != tt.want {
+				t.Errorf("signature verified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignWebhookCoversTimestamp(t *testing.T) {
+	body := []byte(`{}`)
+	seen := make(map[string]bool)
+	for _, timestamp := range []int64{0, 1, 10, 1700000000} {
+		signature := SignWebhook("secret", timestamp, body)
+		if seen[signature] {
+			t.Errorf("timestamp %d reused another timestamp's signature", timestamp)
+		}
+		seen[signature] = true
+
+		if !verifyWebhook("secret", strconv.FormatInt(timestamp, 10), body, signature) {
+			t.Errorf("signature for timestamp %d does not verify", timestamp)
+		}
+	}
+}
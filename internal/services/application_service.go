@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+const (
+	// logoutTokenLifetime bounds how long a relying application should accept
+	// a logout token after it was signed.
+	logoutTokenLifetime = 2 * time.Minute
+
+	logoutDeliveryTimeout = 10 * time.Second
+	// logoutDeliveryGrace keeps the retry worker away from a delivery while
+	// its first attempt is still in flight.
+	logoutDeliveryGrace      = logoutDeliveryTimeout + 30*time.Second
+	logoutDeliveryBaseDelay  = 30 * time.Second
+	logoutDeliveryMaxDelay   = time.Hour
+	logoutDeliveryMaxAttempt = 8
+	logoutDeliveryBatchSize  = 100
+	// Retries are claimed a few at a time, each claim leased for long enough
+	// to send all of them one after another.
+	logoutDeliveryClaimSize = 10
+	logoutDeliveryLease     = logoutDeliveryClaimSize*logoutDeliveryTimeout + logoutDeliveryGrace
+)
+
+// LogoutNotifier tells relying applications that a session has ended.
+type LogoutNotifier interface {
+	NotifyLogout(ctx context.Context, session *domain.Session)
+}
+
+type ApplicationService interface {
+	LogoutNotifier
+	// CreateApplication returns the logout secret the application uses to
+	// verify logout tokens. It is only shown once.
+	CreateApplication(ctx context.Context, name, backchannelLogoutURL string) (*domain.Application, string, error)
+	GetApplication(ctx context.Context, id int64) (*domain.Application, error)
+	ListApplications(ctx context.Context) ([]domain.Application, error)
+	UpdateApplication(ctx context.Context, req domain.UpdateApplicationAction) (*domain.Application, error)
+	DeleteApplication(ctx context.Context, id int64) error
+	GetLogoutDeliveries(ctx context.Context, applicationID int64, limit int32) ([]domain.BackchannelLogoutDelivery, error)
+	ProcessPendingLogoutDeliveries(ctx context.Context) error
+}
+
+type applicationService struct {
+	applicationsRepo repositories.ApplicationsRepository
+	httpClient       *http.Client
+	issuer           string
+}
+
+func NewApplicationService(applicationsRepo repositories.ApplicationsRepository, issuer string) ApplicationService {
+	return &applicationService{
+		applicationsRepo: applicationsRepo,
+		httpClient: &http.Client{
+			Timeout: logoutDeliveryTimeout,
+		},
+		issuer: issuer,
+	}
+}
+
+func validateBackchannelLogoutURL(rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return fmt.Errorf("invalid back-channel logout URL: %q", rawURL)
+	}
+
+	return nil
+}
+
+func generateRandomHex(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func (s *applicationService) CreateApplication(ctx context.Context, name, backchannelLogoutURL string) (*domain.Application, string, error) {
+	if err := validateBackchannelLogoutURL(backchannelLogoutURL); err != nil {
+		return nil, "", err
+	}
+
+	clientID, err := generateRandomHex(16)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate client ID: %w", err)
+	}
+
+	logoutSecret, err := generateRandomHex(32)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate logout secret: %w", err)
+	}
+
+	application, err := s.applicationsRepo.CreateApplication(ctx, domain.CreateApplicationAction{
+		Name:                 name,
+		ClientID:             clientID,
+		LogoutSecret:         logoutSecret,
+		BackchannelLogoutURL: backchannelLogoutURL,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return application, logoutSecret, nil
+}
+
+func (s *applicationService) GetApplication(ctx context.Context, id int64) (*domain.Application, error) {
+	return s.applicationsRepo.GetApplicationByID(ctx, id)
+}
+
+func (s *applicationService) ListApplications(ctx context.Context) ([]domain.Application, error) {
+	return s.applicationsRepo.ListApplications(ctx)
+}
+
+func (s *applicationService) UpdateApplication(ctx context.Context, req domain.UpdateApplicationAction) (*domain.Application, error) {
+	if err := validateBackchannelLogoutURL(req.BackchannelLogoutURL); err != nil {
+		return nil, err
+	}
+
+	return s.applicationsRepo.UpdateApplication(ctx, req)
+}
+
+func (s *applicationService) DeleteApplication(ctx context.Context, id int64) error {
+	return s.applicationsRepo.DeleteApplication(ctx, id)
+}
+
+func (s *applicationService) GetLogoutDeliveries(ctx context.Context, applicationID int64, limit int32) ([]domain.BackchannelLogoutDelivery, error) {
+	return s.applicationsRepo.GetBackchannelLogoutDeliveriesByApplicationID(ctx, applicationID, limit)
+}
+
+// NotifyLogout records a delivery for every application with a back-channel
+// logout URL and makes the first attempt in the background. Failed attempts
+// are retried by ProcessPendingLogoutDeliveries.
+func (s *applicationService) NotifyLogout(ctx context.Context, session *domain.Session) {
+	applications, err := s.applicationsRepo.ListBackchannelLogoutApplications(ctx)
+	if err != nil {
+		fmt.Printf("Warning: failed to list back-channel logout applications: %v\n", err)
+		return
+	}
+
+	for _, application := range applications {
+		jti, err := uuid.NewV7()
+		if err != nil {
+			fmt.Printf("Warning: failed to generate logout token ID: %v\n", err)
+			continue
+		}
+
+		delivery, err := s.applicationsRepo.CreateBackchannelLogoutDelivery(ctx, domain.CreateBackchannelLogoutDeliveryAction{
+			ApplicationID: application.ID,
+			UserID:        session.UserID,
+			SessionID:     session.ID,
+			JTI:           jti,
+			NextAttemptAt: time.Now().Add(logoutDeliveryGrace),
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to record logout delivery for application %d: %v\n", application.ID, err)
+			continue
+		}
+
+		go s.deliver(context.Background(), application, *delivery)
+	}
+}
+
+// ProcessPendingLogoutDeliveries retries due deliveries. Every instance runs
+// it, deliveries are leased to whichever claims them first.
+func (s *applicationService) ProcessPendingLogoutDeliveries(ctx context.Context) error {
+	applications := make(map[int64]*domain.Application)
+	for processed := 0; processed < logoutDeliveryBatchSize; {
+		deliveries, err := s.applicationsRepo.ClaimDueBackchannelLogoutDeliveries(ctx, logoutDeliveryClaimSize, time.Now().Add(logoutDeliveryLease))
+		if err != nil {
+			return fmt.Errorf("failed to claim pending logout deliveries: %w", err)
+		}
+
+		for _, delivery := range deliveries {
+			application, ok := applications[delivery.ApplicationID]
+			if !ok {
+				application, err = s.applicationsRepo.GetApplicationByID(ctx, delivery.ApplicationID)
+				if err != nil {
+					fmt.Printf("Warning: failed to get application %d: %v\n", delivery.ApplicationID, err)
+					continue
+				}
+				applications[delivery.ApplicationID] = application
+			}
+
+			s.deliver(ctx, *application, delivery)
+		}
+
+		processed += len(deliveries)
+		if len(deliveries) < logoutDeliveryClaimSize {
+			break
+		}
+	}
+
+	return nil
+}
+
+// deliver POSTs a freshly signed logout token and records the outcome. Tokens
+// are re-signed on every attempt so iat stays current, while jti stays fixed
+// so the application can discard duplicates.
+func (s *applicationService) deliver(ctx context.Context, application domain.Application, delivery domain.BackchannelLogoutDelivery) {
+	update := domain.UpdateBackchannelLogoutDeliveryAction{
+		ID:       delivery.ID,
+		Status:   domain.BackchannelLogoutStatusPending,
+		Attempts: delivery.Attempts + 1,
+	}
+
+	if !application.Active || application.BackchannelLogoutURL == "" {
+		update.Status = domain.BackchannelLogoutStatusFailed
+		update.LastError = "application no longer accepts back-channel logout"
+		update.NextAttemptAt = time.Now()
+	} else {
+		statusCode, err := s.send(ctx, application, delivery)
+		update.LastStatusCode = int32(statusCode)
+
+		now := time.Now()
+		switch {
+		case err == nil:
+			update.Status = domain.BackchannelLogoutStatusDelivered
+			update.NextAttemptAt = now
+			update.DeliveredAt = &now
+		case update.Attempts >= logoutDeliveryMaxAttempt:
+			update.Status = domain.BackchannelLogoutStatusFailed
+			update.LastError = err.Error()
+			update.NextAttemptAt = now
+		default:
+			update.LastError = err.Error()
+			update.NextAttemptAt = now.Add(logoutRetryDelay(update.Attempts))
+		}
+	}
+
+	if _, err := s.applicationsRepo.UpdateBackchannelLogoutDelivery(ctx, update); err != nil {
+		fmt.Printf("Warning: failed to update logout delivery %d: %v\n", delivery.ID, err)
+	}
+}
+
+func (s *applicationService) send(ctx context.Context, application domain.Application, delivery domain.BackchannelLogoutDelivery) (int, error) {
+	claims := security.NewLogoutTokenClaims(s.issuer, application.ClientID, delivery.UserID, delivery.SessionID, delivery.JTI, logoutTokenLifetime)
+	logoutToken, err := security.SignLogoutToken(claims, application.LogoutSecret)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sign logout token: %w", err)
+	}
+
+	form := url.Values{"logout_token": {logoutToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, application.BackchannelLogoutURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create logout request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "whoami-auth-service/1.0")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("logout request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("application responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// logoutRetryDelay doubles the wait after each failed attempt up to an hour.
+func logoutRetryDelay(attempts int32) time.Duration {
+	delay := logoutDeliveryBaseDelay
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= logoutDeliveryMaxDelay {
+			return logoutDeliveryMaxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+const (
+	auditChainBatchSize = 1000
+	auditChainMaxIssues = 1000
+)
+
+var ErrAuditCheckpointSignerMissing = errors.New("no audit checkpoint signing key is configured")
+
+// AuditChainService checks that the audit log is still the one that was
+// written. Postgres chains every entry to the one before it as it is
+// inserted, checkpoints sign where the chain stood so rehashing it all
+// afterwards is noticed too.
+type AuditChainService interface {
+	// Checkpoint signs the current end of the chain. When it has not moved
+	// since the latest checkpoint that one is returned instead, and nil when
+	// the chain is empty.
+	Checkpoint(ctx context.Context) (*domain.AuditCheckpoint, error)
+	// Verify recomputes every entry the chain still holds and checks each
+	// link and checkpoint along it.
+	Verify(ctx context.Context) (*domain.AuditChainReport, error)
+}
+
+type auditChainService struct {
+	chainRepo repositories.AuditChainRepository
+	signer    *security.AuditCheckpointSigner
+	publicKey ed25519.PublicKey
+}
+
+// NewAuditChainService verifies checkpoints against publicKey, whatever key
+// they claim to be signed with. signer may be nil where checkpoints are only
+// verified.
+func NewAuditChainService(
+	chainRepo repositories.AuditChainRepository,
+	signer *security.AuditCheckpointSigner,
+	publicKey ed25519.PublicKey,
+) AuditChainService {
+	return &auditChainService{
+		chainRepo: chainRepo,
+		signer:    signer,
+		publicKey: publicKey,
+	}
+}
+
+func (s *auditChainService) Checkpoint(ctx context.Context) (*domain.AuditCheckpoint, error) {
+	if s.signer == nil {
+		return nil, ErrAuditCheckpointSignerMissing
+	}
+
+	latest, err := s.chainRepo.GetLatestCheckpoint(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get latest checkpoint: %w", err)
+	}
+
+	head, err := s.chainRepo.GetHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain head: %w", err)
+	}
+	if head.LastSeq == 0 {
+		return nil, nil
+	}
+	if latest != nil && latest.ChainSeq >= head.LastSeq {
+		return latest, nil
+	}
+
+	signature := s.signer.Sign(head.LastSeq, head.LastHash)
+	return s.chainRepo.CreateCheckpoint(ctx, head.LastSeq, head.LastHash, s.signer.PublicKey(), signature)
+}
+
+func (s *auditChainService) Verify(ctx context.Context) (*domain.AuditChainReport, error) {
+	// Checkpoints are read before the head, so any past its end means the
+	// chain was cut back
+	latest, err := s.chainRepo.GetLatestCheckpoint(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get latest checkpoint: %w", err)
+	}
+
+	head, err := s.chainRepo.GetHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain head: %w", err)
+	}
+
+	// The checkpoint at the pruned boundary, if any, is checked against the
+	// hash retention recorded for it
+	checkpoints, err := s.chainRepo.GetCheckpointsAfter(ctx, head.PrunedSeq-1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoints: %w", err)
+	}
+
+	v := &auditChainVerification{
+		report: domain.AuditChainReport{
+			FromSeq:          head.PrunedSeq,
+			ThroughSeq:       head.LastSeq,
+			LatestCheckpoint: latest,
+			Issues:           []domain.AuditChainIssue{},
+		},
+		checkpoints: make(map[int64][]domain.AuditCheckpoint),
+	}
+
+	for _, checkpoint := range checkpoints {
+		v.report.CheckpointsChecked++
+		if !s.validSignature(checkpoint) {
+			v.issue(domain.AuditChainIssue{
+				Kind:         domain.AuditChainIssueInvalidSignature,
+				ChainSeq:     checkpoint.ChainSeq,
+				CheckpointID: &checkpoint.ID,
+				Detail:       "checkpoint is not signed by the trusted checkpoint key",
+			})
+			continue
+		}
+		if checkpoint.ChainSeq > head.LastSeq {
+			v.issue(domain.AuditChainIssue{
+				Kind:         domain.AuditChainIssueTruncated,
+				ChainSeq:     head.LastSeq + 1,
+				ThroughSeq:   checkpoint.ChainSeq,
+				CheckpointID: &checkpoint.ID,
+				Detail:       "a checkpoint covers entries past the end of the chain",
+			})
+			continue
+		}
+		v.checkpoints[checkpoint.ChainSeq] = append(v.checkpoints[checkpoint.ChainSeq], checkpoint)
+	}
+
+	v.checkCheckpoints(head.PrunedSeq, head.PrunedHash)
+
+	expectedSeq := head.PrunedSeq + 1
+	prevHash := head.PrunedHash
+	for afterSeq := head.PrunedSeq; afterSeq < head.LastSeq; {
+		entries, err := s.chainRepo.GetEntries(ctx, afterSeq, head.LastSeq, auditChainBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chain entries: %w", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		for _, entry := range entries {
+			// After a gap there is nothing to compare the link with
+			if entry.ChainSeq != expectedSeq {
+				v.issue(domain.AuditChainIssue{
+					Kind:       domain.AuditChainIssueGap,
+					ChainSeq:   expectedSeq,
+					ThroughSeq: entry.ChainSeq - 1,
+					Detail:     "entries are missing from the chain",
+				})
+			} else if !bytes.Equal(entry.PrevHash, prevHash) {
+				v.issue(domain.AuditChainIssue{
+					Kind:       domain.AuditChainIssueBrokenLink,
+					ChainSeq:   entry.ChainSeq,
+					AuditLogID: &entry.ID,
+					Detail:     "entry does not link to the one before it",
+				})
+			}
+
+			if !bytes.Equal(security.AuditEntryHash(entry), entry.EntryHash) {
+				v.issue(domain.AuditChainIssue{
+					Kind:       domain.AuditChainIssueModified,
+					ChainSeq:   entry.ChainSeq,
+					AuditLogID: &entry.ID,
+					Detail:     "entry content does not match its hash",
+				})
+			}
+
+			v.checkCheckpoints(entry.ChainSeq, entry.EntryHash)
+			v.report.EntriesChecked++
+
+			prevHash = entry.EntryHash
+			expectedSeq = entry.ChainSeq + 1
+			afterSeq = entry.ChainSeq
+		}
+	}
+
+	if expectedSeq <= head.LastSeq {
+		v.issue(domain.AuditChainIssue{
+			Kind:       domain.AuditChainIssueTruncated,
+			ChainSeq:   expectedSeq,
+			ThroughSeq: head.LastSeq,
+			Detail:     "entries are missing from the end of the chain",
+		})
+	} else if !bytes.Equal(prevHash, head.LastHash) {
+		v.issue(domain.AuditChainIssue{
+			Kind:     domain.AuditChainIssueBrokenLink,
+			ChainSeq: head.LastSeq,
+			Detail:   "the end of the chain does not match its last entry",
+		})
+	}
+
+	// Checkpoints left over are on entries that were never reached
+	for _, seq := range slices.Sorted(maps.Keys(v.checkpoints)) {
+		for _, checkpoint := range v.checkpoints[seq] {
+			v.issue(domain.AuditChainIssue{
+				Kind:         domain.AuditChainIssueCheckpointMismatch,
+				ChainSeq:     seq,
+				CheckpointID: &checkpoint.ID,
+				Detail:       "the checkpointed entry is missing from the chain",
+			})
+		}
+	}
+
+	v.report.Valid = len(v.report.Issues) == 0 && !v.report.IssuesTruncated
+	v.report.VerifiedAt = time.Now()
+
+	return &v.report, nil
+}
+
+func (s *auditChainService) validSignature(checkpoint domain.AuditCheckpoint) bool {
+	entryHash, err := hex.DecodeString(checkpoint.EntryHash)
+	if err != nil {
+		return false
+	}
+	signature, err := hex.DecodeString(checkpoint.Signature)
+	if err != nil {
+		return false
+	}
+	return security.VerifyAuditCheckpoint(s.publicKey, checkpoint.ChainSeq, entryHash, signature)
+}
+
+// auditChainVerification collects the issues of a single Verify run.
+type auditChainVerification struct {
+	report      domain.AuditChainReport
+	checkpoints map[int64][]domain.AuditCheckpoint
+}
+
+func (v *auditChainVerification) issue(issue domain.AuditChainIssue) {
+	if len(v.report.Issues) >= auditChainMaxIssues {
+		v.report.IssuesTruncated = true
+		return
+	}
+	v.report.Issues = append(v.report.Issues, issue)
+}
+
+// checkCheckpoints compares the checkpoints on chainSeq with the hash the
+// chain holds there.
+func (v *auditChainVerification) checkCheckpoints(chainSeq int64, entryHash []byte) {
+	for _, checkpoint := range v.checkpoints[chainSeq] {
+		if checkpoint.EntryHash != hex.EncodeToString(entryHash) {
+			v.issue(domain.AuditChainIssue{
+				Kind:         domain.AuditChainIssueCheckpointMismatch,
+				ChainSeq:     chainSeq,
+				CheckpointID: &checkpoint.ID,
+				Detail:       "the chain no longer has the checkpointed hash",
+			})
+		}
+	}
+	delete(v.checkpoints, chainSeq)
+}
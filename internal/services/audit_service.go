@@ -2,28 +2,32 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net"
 	"net/http"
-	"strings"
-	"time"
+	"strconv"
 
 	"github.com/m1thrandir225/whoami/internal/domain"
 	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
 )
 
+const (
+	auditLogDefaultLimit = 50
+	auditLogMaxLimit     = 200
+)
+
+var ErrInvalidAuditLogFilter = errors.New("invalid audit log filter")
+
 type AuditService interface {
 	LogUserAction(ctx context.Context, userID int64, action string, resourceType string, resourceID int64, r *http.Request, details map[string]interface{}) error
 	LogSystemAction(ctx context.Context, action string, resourceType string, resourceID int64, r *http.Request, details map[string]interface{}) error
 	LogAnonymousAction(ctx context.Context, action string, resourceType string, resourceID int64, r *http.Request, details map[string]interface{}) error
-	GetAuditLogsByUserID(ctx context.Context, userID int64, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByAction(ctx context.Context, action string, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByResourceType(ctx context.Context, resourceType string, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByResourceID(ctx context.Context, resourceType string, resourceID int64, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByIP(ctx context.Context, ipAddress string, limit int32) ([]domain.AuditLog, error)
-	GetAuditLogsByDateRange(ctx context.Context, startDate, endDate string, limit int32) ([]domain.AuditLog, error)
-	GetRecentAuditLogs(ctx context.Context, limit int32) ([]domain.AuditLog, error)
+	// SearchAuditLogs returns a page of entries matching filter, newest
+	// first.
+	SearchAuditLogs(ctx context.Context, filter domain.AuditLogFilter) (*domain.AuditLogPage, error)
 	CleanupOldAuditLogs(ctx context.Context) error
 }
 
@@ -110,90 +114,64 @@ func (s *auditService) LogAnonymousAction(ctx context.Context, action string, re
 	return nil
 }
 
-func (s *auditService) GetAuditLogsByUserID(ctx context.Context, userID int64, limit int32) ([]domain.AuditLog, error) {
-	return s.auditRepo.GetAuditLogsByUserID(ctx, userID, limit)
-}
-
-func (s *auditService) GetAuditLogsByAction(ctx context.Context, action string, limit int32) ([]domain.AuditLog, error) {
-	return s.auditRepo.GetAuditLogsByAction(ctx, action, limit)
-}
+func (s *auditService) SearchAuditLogs(ctx context.Context, filter domain.AuditLogFilter) (*domain.AuditLogPage, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAuditLogFilter)
+	}
 
-func (s *auditService) GetAuditLogsByResourceType(ctx context.Context, resourceType string, limit int32) ([]domain.AuditLog, error) {
-	return s.auditRepo.GetAuditLogsByResourceType(ctx, resourceType, limit)
-}
+	if len(filter.Details) > 0 {
+		var details map[string]json.RawMessage
+		if err := json.Unmarshal(filter.Details, &details); err != nil || details == nil {
+			return nil, fmt.Errorf("%w: details must be a JSON object", ErrInvalidAuditLogFilter)
+		}
+	}
 
-func (s *auditService) GetAuditLogsByResourceID(ctx context.Context, resourceType string, resourceID int64, limit int32) ([]domain.AuditLog, error) {
-	return s.auditRepo.GetAuditLogsByResourceID(ctx, resourceType, resourceID, limit)
-}
+	if filter.Network != nil {
+		network := filter.Network.Masked()
+		filter.Network = &network
+	}
 
-func (s *auditService) GetAuditLogsByIP(ctx context.Context, ipAddress string, limit int32) ([]domain.AuditLog, error) {
-	return s.auditRepo.GetAuditLogsByIP(ctx, ipAddress, limit)
-}
+	var beforeID *int64
+	if filter.Cursor != "" {
+		id, err := decodeAuditLogCursor(filter.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidAuditLogFilter)
+		}
+		beforeID = &id
+	}
 
-func (s *auditService) GetAuditLogsByDateRange(ctx context.Context, startDate, endDate string, limit int32) ([]domain.AuditLog, error) {
-	start, err := time.Parse(time.RFC3339, startDate)
-	if err != nil {
-		return nil, err
+	if filter.Limit <= 0 {
+		filter.Limit = auditLogDefaultLimit
 	}
+	limit := min(filter.Limit, auditLogMaxLimit)
 
-	end, err := time.Parse(time.RFC3339, endDate)
+	// One more than the page tells whether another page follows
+	filter.Limit = limit + 1
+	logs, err := s.auditRepo.SearchAuditLogs(ctx, filter, beforeID)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.auditRepo.GetAuditLogsByDateRange(ctx, start, end, limit)
-}
+	page := &domain.AuditLogPage{AuditLogs: logs}
+	if len(logs) > int(limit) {
+		page.AuditLogs = logs[:limit]
+		cursor := encodeAuditLogCursor(page.AuditLogs[limit-1].ID)
+		page.NextCursor = &cursor
+	}
 
-func (s *auditService) GetRecentAuditLogs(ctx context.Context, limit int32) ([]domain.AuditLog, error) {
-	return s.auditRepo.GetRecentAuditLogs(ctx, limit)
+	return page, nil
 }
 
 func (s *auditService) CleanupOldAuditLogs(ctx context.Context) error {
 	return s.auditRepo.DeleteOldAuditLogs(ctx)
 }
 
+// getClientIP defers to the address resolved by security.ClientIPResolver so
+// audit entries agree with rate limiting and device tracking on who the client
+// was.
 func (s *auditService) getClientIP(r *http.Request) *string {
-	// Try various headers for the real IP
-	headers := []string{
-		"CF-Connecting-IP",    // Cloudflare
-		"True-Client-IP",      // Cloudflare Enterprise
-		"X-Real-IP",           // Nginx
-		"X-Forwarded-For",     // Standard
-		"X-Client-IP",         // Apache
-		"X-Cluster-Client-IP", // Cluster
-	}
-
-	for _, header := range headers {
-		ip := r.Header.Get(header)
-		if ip != "" {
-			// For X-Forwarded-For, take the first IP
-			if header == "X-Forwarded-For" {
-				ips := strings.Split(ip, ",")
-				ip = strings.TrimSpace(ips[0])
-			}
-
-			// Validate the IP address
-			if net.ParseIP(ip) != nil {
-				return &ip
-			}
-		}
-	}
-
-	// Fallback to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		// If SplitHostPort fails, use RemoteAddr as is
-		ip = r.RemoteAddr
-	}
-
-	// Validate the IP
-	if net.ParseIP(ip) != nil {
-		return &ip
-	}
-
-	// If all else fails, return localhost
-	localhost := "127.0.0.1"
-	return &localhost
+	ip := security.ClientIPFromRequest(r)
+	return &ip
 }
 
 func (s *auditService) getUserAgent(r *http.Request) *string {
@@ -203,3 +181,16 @@ func (s *auditService) getUserAgent(r *http.Request) *string {
 	}
 	return &userAgent
 }
+
+// Cursors are opaque to clients, they hold the id of the last entry of a page.
+func encodeAuditLogCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeAuditLogCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(raw), 10, 64)
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+func TestAuditLogCursor(t *testing.T) {
+	for _, id := range []int64{1, 42, 1 << 53} {
+		cursor := encodeAuditLogCursor(id)
+		got, err := decodeAuditLogCursor(cursor)
+		if err != nil {
+			t.Fatalf("decodeAuditLogCursor(%q) error = %v", cursor, err)
+		}
+		if got != id {
+			t.Errorf("decodeAuditLogCursor(encodeAuditLogCursor(%d)) = %d", id, got)
+		}
+	}
+
+	for _, cursor := range []string{"!!!", "NDI=", "YWJj", ""} {
+		if id, err := decodeAuditLogCursor(cursor); err == nil {
+			t.Errorf("decodeAuditLogCursor(%q) = %d, want an error", cursor, id)
+		}
+	}
+}
+
+// fakeAuditLogSearch returns entries count down from its newest id and
+// records what it was searched with.
+type fakeAuditLogSearch struct {
+	repositories.AuditLogsRepository
+	newest   int64
+	filter   domain.AuditLogFilter
+	beforeID *int64
+}
+
+func (r *fakeAuditLogSearch) SearchAuditLogs(ctx context.Context, filter domain.AuditLogFilter, beforeID *int64) ([]domain.AuditLog, error) {
+	r.filter = filter
+	r.beforeID = beforeID
+
+	id := r.newest
+	if beforeID != nil {
+		id = *beforeID - 1
+	}
+	var logs []domain.AuditLog
+	for ; id > 0 && len(logs) < int(filter.Limit); id-- {
+		logs = append(logs, domain.AuditLog{ID: id})
+	}
+	return logs, nil
+}
+
+func TestSearchAuditLogsPages(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeAuditLogSearch{newest: 5}
+	service := NewAuditService(repo)
+
+	var pages [][]int64
+	filter := domain.AuditLogFilter{Limit: 2}
+	for {
+		page, err := service.SearchAuditLogs(ctx, filter)
+		if err != nil {
+			t.Fatalf("SearchAuditLogs() error = %v", err)
+		}
+		var ids []int64
+		for _, log := range page.AuditLogs {
+			ids = append(ids, log.ID)
+		}
+		pages = append(pages, ids)
+
+		if page.NextCursor == nil {
+			break
+		}
+		if len(pages) > 5 {
+			t.Fatal("SearchAuditLogs() keeps returning a next cursor")
+		}
+		filter.Cursor = *page.NextCursor
+	}
+
+	want := [][]int64{{5, 4}, {3, 2}, {1}}
+	if !slices.EqualFunc(pages, want, slices.Equal) {
+		t.Errorf("got pages %v, want %v", pages, want)
+	}
+
+	// A page that exactly fills the limit is still the last one
+	repo = &fakeAuditLogSearch{newest: 2}
+	page, err := NewAuditService(repo).SearchAuditLogs(ctx, domain.AuditLogFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("SearchAuditLogs() error = %v", err)
+	}
+	if len(page.AuditLogs) != 2 || page.NextCursor != nil {
+		t.Errorf("SearchAuditLogs() = %d entries, next cursor %v; want 2 and none", len(page.AuditLogs), page.NextCursor)
+	}
+}
+
+func TestSearchAuditLogsFilter(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	unmasked := netip.MustParsePrefix("203.0.113.9/24")
+
+	tests := []struct {
+		name         string
+		filter       domain.AuditLogFilter
+		wantErr      bool
+		wantLimit    int32
+		wantNetwork  string
+		wantBeforeID int64
+	}{
+		{name: "default limit", filter: domain.AuditLogFilter{}, wantLimit: auditLogDefaultLimit + 1},
+		{name: "limit capped", filter: domain.AuditLogFilter{Limit: 10000}, wantLimit: auditLogMaxLimit + 1},
+		{name: "network masked", filter: domain.AuditLogFilter{Network: &unmasked}, wantLimit: auditLogDefaultLimit + 1, wantNetwork: "203.0.113.0/24"},
+		{name: "cursor", filter: domain.AuditLogFilter{Cursor: encodeAuditLogCursor(42)}, wantLimit: auditLogDefaultLimit + 1, wantBeforeID: 42},
+		{name: "time range", filter: domain.AuditLogFilter{From: &from, To: &to}, wantLimit: auditLogDefaultLimit + 1},
+		{name: "details object", filter: domain.AuditLogFilter{Details: json.RawMessage(`{"success":true}`)}, wantLimit: auditLogDefaultLimit + 1},
+		{name: "from after to", filter: domain.AuditLogFilter{From: &to, To: &from}, wantErr: true},
+		{name: "empty time range", filter: domain.AuditLogFilter{From: &from, To: &from}, wantErr: true},
+		{name: "details array", filter: domain.AuditLogFilter{Details: json.RawMessage(`[1]`)}, wantErr: true},
+		{name: "details null", filter: domain.AuditLogFilter{Details: json.RawMessage(`null`)}, wantErr: true},
+		{name: "details not JSON", filter: domain.AuditLogFilter{Details: json.RawMessage(`success=true`)}, wantErr: true},
+		{name: "invalid cursor", filter: domain.AuditLogFilter{Cursor: "not a cursor"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAuditLogSearch{}
+			_, err := NewAuditService(repo).SearchAuditLogs(context.Background(), tt.filter)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAuditLogFilter) {
+					t.Fatalf("SearchAuditLogs() error = %v, want ErrInvalidAuditLogFilter", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SearchAuditLogs() error = %v", err)
+			}
+
+			if repo.filter.Limit != tt.wantLimit {
+				t.Errorf("searched with limit %d, want %d", repo.filter.Limit, tt.wantLimit)
+			}
+			if tt.wantNetwork != "" && (repo.filter.Network == nil || repo.filter.Network.String() != tt.wantNetwork) {
+				t.Errorf("searched network %v, want %s", repo.filter.Network, tt.wantNetwork)
+			}
+			switch {
+			case tt.wantBeforeID == 0 && repo.beforeID != nil:
+				t.Errorf("searched before %d, want no cursor", *repo.beforeID)
+			case tt.wantBeforeID != 0 && (repo.beforeID == nil || *repo.beforeID != tt.wantBeforeID):
+				t.Errorf("searched before %v, want %d", repo.beforeID, tt.wantBeforeID)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+// CaptchaPolicy decides when a challenge is required. A zero EmailFailures or
+// IPFailures disables that check.
+type CaptchaPolicy struct {
+	Window time.Duration
+	// EmailFailures is the consecutive failed logins for an email within
+	// Window after which requests for it need a challenge.
+	EmailFailures int64
+	// IPFailures does the same for failed logins from one address, across
+	// every email.
+	IPFailures int64
+}
+
+// CaptchaService asks for a solved challenge once login_attempts shows an
+// email or address is being guessed at, instead of locking it out straight
+// away, and wherever a credential stuffing detection required one.
+type CaptchaService interface {
+	// Required reports whether a request for email from ip must carry a
+	// solved challenge. email may be empty when the request is not tied to
+	// an existing account, and passwordFingerprint when it carries no
+	// password.
+	Required(ctx context.Context, email, ip, passwordFingerprint string) (bool, error)
+	Verify(ctx context.Context, token, ip string) error
+	Provider() string
+	SiteKey() string
+}
+
+type captchaService struct {
+	verifier                security.CaptchaVerifier
+	loginAttemptsRepo       repositories.LoginAttemptsRepository
+	captchaRequirementsRepo repositories.CaptchaRequirementsRepository
+	geoIP                   *security.GeoIPResolver
+	siteKey                 string
+	policy                  CaptchaPolicy
+}
+
+func NewCaptchaService(
+	verifier security.CaptchaVerifier,
+	loginAttemptsRepo repositories.LoginAttemptsRepository,
+	captchaRequirementsRepo repositories.CaptchaRequirementsRepository,
+	geoIP *security.GeoIPResolver,
+	siteKey string,
+	policy CaptchaPolicy,
+) CaptchaService {
+	if policy.Window <= 0 {
+		policy.Window = 15 * time.Minute
+	}
+
+	return &captchaService{
+		verifier:                verifier,
+		loginAttemptsRepo:       loginAttemptsRepo,
+		captchaRequirementsRepo: captchaRequirementsRepo,
+		geoIP:                   geoIP,
+		siteKey:                 siteKey,
+		policy:                  policy,
+	}
+}
+
+func (s *captchaService) Required(ctx context.Context, email, ip, passwordFingerprint string) (bool, error) {
+	subject := domain.CaptchaSubject{
+		IPAddress:           ip,
+		PasswordFingerprint: passwordFingerprint,
+	}
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		subject.IPAddress = addr.Unmap().String()
+	}
+	if location := s.geoIP.Lookup(ip); location != nil && location.ASN != 0 {
+		subject.ASN = strconv.FormatInt(location.ASN, 10)
+	}
+
+	required, err := s.captchaRequirementsRepo.IsRequired(ctx, subject)
+	if err != nil {
+		return false, fmt.Errorf("failed to check captcha requirements: %w", err)
+	}
+	if required {
+		return true, nil
+	}
+
+	since := time.Now().Add(-s.policy.Window)
+
+	if email != "" && s.policy.EmailFailures > 0 {
+		failures, err := s.loginAttemptsRepo.CountConsecutiveFailedLogins(ctx, email, since)
+		if err != nil {
+			return false, fmt.Errorf("failed to count failed logins for email: %w", err)
+		}
+		if failures >= s.policy.EmailFailures {
+			return true, nil
+		}
+	}
+
+	if s.policy.IPFailures > 0 {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return false, nil
+		}
+		addr = addr.Unmap()
+
+		velocity, err := s.loginAttemptsRepo.GetFailedLoginVelocity(ctx, netip.PrefixFrom(addr, addr.BitLen()), since)
+		if err != nil {
+			return false, fmt.Errorf("failed to count failed logins for address: %w", err)
+		}
+		if velocity.FailedAttempts >= s.policy.IPFailures {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *captchaService) Verify(ctx context.Context, token, ip string) error {
+	return s.verifier.Verify(ctx, token, ip)
+}
+
+func (s *captchaService) Provider() string {
+	return s.verifier.Provider()
+}
+
+func (s *captchaService) SiteKey() string {
+	return s.siteKey
+}
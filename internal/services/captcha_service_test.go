@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+// fakeFailedLogins answers the failed login counts the CAPTCHA policy reads.
+type fakeFailedLogins struct {
+	repositories.LoginAttemptsRepository
+	emailFailures map[string]int64
+	ipFailures    map[netip.Prefix]int64
+}
+
+func (r *fakeFailedLogins) CountConsecutiveFailedLogins(ctx context.Context, email string, since time.Time) (int64, error) {
+	return r.emailFailures[email], nil
+}
+
+func (r *fakeFailedLogins) GetFailedLoginVelocity(ctx context.Context, network netip.Prefix, since time.Time) (*domain.LoginVelocity, error) {
+	return &domain.LoginVelocity{FailedAttempts: r.ipFailures[network]}, nil
+}
+
+// fakeCaptchaRequirements records the subject it was asked about.
+type fakeCaptchaRequirements struct {
+	repositories.CaptchaRequirementsRepository
+	required bool
+	subject  domain.CaptchaSubject
+}
+
+func (r *fakeCaptchaRequirements) IsRequired(ctx context.Context, subject domain.CaptchaSubject) (bool, error) {
+	r.subject = subject
+	return r.required, nil
+}
+
+func TestCaptchaRequired(t *testing.T) {
+	policy := CaptchaPolicy{Window: 15 * time.Minute, EmailFailures: 3, IPFailures: 10}
+	address := netip.MustParsePrefix("203.0.113.5/32")
+
+	tests := []struct {
+		name          string
+		policy        CaptchaPolicy
+		email         string
+		ip            string
+		emailFailures int64
+		ipFailures    int64
+		requirement   bool
+		want          bool
+	}{
+		{
+			name:          "below both thresholds",
+			policy:        policy,
+			email:         "a@example.com",
+			ip:            "203.0.113.5",
+			emailFailures: 2,
+			ipFailures:    9,
+		},
+		{
+			name:          "email threshold reached",
+			policy:        policy,
+			email:         "a@example.com",
+			ip:            "203.0.113.5",
+			emailFailures: 3,
+			want:          true,
+		},
+		{
+			name:       "address threshold reached",
+			policy:     policy,
+			email:      "a@example.com",
+			ip:         "203.0.113.5",
+			ipFailures: 10,
+			want:       true,
+		},
+		{
+			name:       "mapped address counts as IPv4",
+			policy:     policy,
+			ip:         "::ffff:203.0.113.5",
+			ipFailures: 10,
+			want:       true,
+		},
+		{
+			name:          "email check skipped without an email",
+			policy:        policy,
+			ip:            "203.0.113.5",
+			emailFailures: 100,
+		},
+		{
+			name:          "email check disabled",
+			policy:        CaptchaPolicy{IPFailures: 10},
+			email:         "a@example.com",
+			ip:            "203.0.113.5",
+			emailFailures: 100,
+		},
+		{
+			name:       "address check disabled",
+			policy:     CaptchaPolicy{EmailFailures: 3},
+			email:      "a@example.com",
+			ip:         "203.0.113.5",
+			ipFailures: 100,
+		},
+		{
+			name:        "credential stuffing requirement",
+			policy:      policy,
+			email:       "a@example.com",
+			ip:          "203.0.113.5",
+			requirement: true,
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loginAttempts := &fakeFailedLogins{
+				emailFailures: map[string]int64{tt.email: tt.emailFailures},
+				ipFailures:    map[netip.Prefix]int64{address: tt.ipFailures},
+			}
+			requirements := &fakeCaptchaRequirements{required: tt.requirement}
+			service := NewCaptchaService(nil, loginAttempts, requirements, nil, "", tt.policy)
+
+			got, err := service.Required(context.Background(), tt.email, tt.ip, "fingerprint")
+			if err != nil {
+				t.Fatalf("Required() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Required() = %v, want %v", got, tt.want)
+			}
+			if requirements.subject.IPAddress != "203.0.113.5" || requirements.subject.PasswordFingerprint != "fingerprint" {
+				t.Errorf("requirements checked for %+v, want the unmapped address and fingerprint", requirements.subject)
+			}
+		})
+	}
+}
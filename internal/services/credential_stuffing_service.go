@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/metrics"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+const (
+	// passwordSprayMinEmailsPerAddress is the distinct emails an address must
+	// have tried a sprayed password against itself before it is locked out.
+	passwordSprayMinEmailsPerAddress = 3
+	// passwordSprayMaxLockouts caps the addresses one detection locks out.
+	passwordSprayMaxLockouts = 100
+)
+
+// CredentialStuffingPolicy sets when the credential stuffing detectors fire.
+// A zero threshold disables its detector.
+type CredentialStuffingPolicy struct {
+	Window time.Duration
+	// CaptchaDuration is how long a detection requires a challenge for.
+	CaptchaDuration time.Duration
+	// IPMaxEmails is the distinct emails one address may fail logins for
+	// within Window.
+	IPMaxEmails int64
+	// ASNMinAttempts is the logins from one ASN within Window before its
+	// failure ratio is judged, and ASNMaxFailureRatio the share of them that
+	// may fail.
+	ASNMinAttempts     int64
+	ASNMaxFailureRatio float64
+	// PasswordMaxEmails is the distinct emails one password may be tried
+	// against within Window.
+	PasswordMaxEmails int64
+}
+
+func (p CredentialStuffingPolicy) withDefaults() CredentialStuffingPolicy {
+	if p.Window <= 0 {
+		p.Window = time.Hour
+	}
+	if p.CaptchaDuration <= 0 {
+		p.CaptchaDuration = 24 * time.Hour
+	}
+	return p
+}
+
+// asnFailing returns the share of outcomes that failed, and whether it is over
+// ASNMaxFailureRatio once there are ASNMinAttempts to judge.
+func (p CredentialStuffingPolicy) asnFailing(outcomes domain.LoginOutcomes) (float64, bool) {
+	if outcomes.Attempts == 0 || outcomes.Attempts < p.ASNMinAttempts {
+		return 0, false
+	}
+	failureRatio := float64(outcomes.FailedAttempts) / float64(outcomes.Attempts)
+	return failureRatio, failureRatio > p.ASNMaxFailureRatio
+}
+
+// CredentialStuffingService looks across accounts for the failed logins of a
+// credential stuffing or password spraying run, which stay under the per
+// account thresholds. Every detection requires a challenge for what it
+// matched and records a critical suspicious activity. The address detector
+// locks the address out as well, the password detector every address that
+// tried the password against several accounts itself.
+type CredentialStuffingService interface {
+	// Fingerprint returns what failed logins store of password, see
+	// security.PasswordFingerprinter. It is "" when the service has no
+	// fingerprinter, the password detector then stays quiet.
+	Fingerprint(password string) string
+	// Evaluate runs the detectors after a failed login from ip that tried
+	// the password with passwordFingerprint, and returns those that fired. A
+	// detector stays quiet while the challenge its last detection required
+	// is in force, so an attack is recorded once rather than per attempt.
+	Evaluate(ctx context.Context, ip, userAgent, passwordFingerprint string) ([]domain.CredentialStuffingDetection, error)
+	CleanupExpiredRequirements(ctx context.Context) error
+}
+
+type credentialStuffingService struct {
+	loginAttemptsRepo       repositories.LoginAttemptsRepository
+	captchaRequirementsRepo repositories.CaptchaRequirementsRepository
+	suspiciousRepo          repositories.SuspiciousActivityRepository
+	ipAccessService         IPAccessService
+	geoIP                   *security.GeoIPResolver
+	fingerprinter           *security.PasswordFingerprinter
+	policy                  CredentialStuffingPolicy
+}
+
+func NewCredentialStuffingService(
+	loginAttemptsRepo repositories.LoginAttemptsRepository,
+	captchaRequirementsRepo repositories.CaptchaRequirementsRepository,
+	suspiciousRepo repositories.SuspiciousActivityRepository,
+	ipAccessService IPAccessService,
+	geoIP *security.GeoIPResolver,
+	fingerprinter *security.PasswordFingerprinter,
+	policy CredentialStuffingPolicy,
+) CredentialStuffingService {
+	return &credentialStuffingService{
+		loginAttemptsRepo:       loginAttemptsRepo,
+		captchaRequirementsRepo: captchaRequirementsRepo,
+		suspiciousRepo:          suspiciousRepo,
+		ipAccessService:         ipAccessService,
+		geoIP:                   geoIP,
+		fingerprinter:           fingerprinter,
+		policy:                  policy.withDefaults(),
+	}
+}
+
+func (s *credentialStuffingService) Fingerprint(password string) string {
+	return s.fingerprinter.Fingerprint(password)
+}
+
+func (s *credentialStuffingService) Evaluate(ctx context.Context, ip, userAgent, passwordFingerprint string) ([]domain.CredentialStuffingDetection, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, nil
+	}
+	ip = addr.Unmap().String()
+	since := time.Now().Add(-s.policy.Window)
+
+	detectors := []func() (*domain.CredentialStuffingDetection, error){
+		func() (*domain.CredentialStuffingDetection, error) {
+			return s.detectAddress(ctx, ip, userAgent, since)
+		},
+		func() (*domain.CredentialStuffingDetection, error) {
+			return s.detectASN(ctx, ip, userAgent, since)
+		},
+		func() (*domain.CredentialStuffingDetection, error) {
+			return s.detectPassword(ctx, ip, userAgent, passwordFingerprint, since)
+		},
+	}
+
+	// A failing detector should not keep the others from running
+	var detections []domain.CredentialStuffingDetection
+	var errs []error
+	for _, detect := range detectors {
+		detection, err := detect()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if detection != nil {
+			detections = append(detections, *detection)
+		}
+	}
+
+	return detections, errors.Join(errs...)
+}
+
+// detectAddress fires when ip failed logins for too many distinct emails.
+func (s *credentialStuffingService) detectAddress(ctx context.Context, ip, userAgent string, since time.Time) (*domain.CredentialStuffingDetection, error) {
+	if s.policy.IPMaxEmails <= 0 {
+		return nil, nil
+	}
+
+	if required, err := s.captchaRequirementsRepo.IsRequired(ctx, domain.CaptchaSubject{IPAddress: ip}); err != nil || required {
+		return nil, err
+	}
+
+	addr := netip.MustParseAddr(ip)
+	velocity, err := s.loginAttemptsRepo.GetFailedLoginVelocity(ctx, netip.PrefixFrom(addr, addr.BitLen()), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count failed logins for address: %w", err)
+	}
+	if velocity.DistinctEmails < s.policy.IPMaxEmails {
+		return nil, nil
+	}
+
+	detection := &domain.CredentialStuffingDetection{
+		Detector: domain.CredentialStuffingIP,
+		Reason:   fmt.Sprintf("Failed logins for %d accounts from one address within %s", velocity.DistinctEmails, s.policy.Window),
+	}
+
+	lockout, err := s.ipAccessService.LockAddress(ctx, ip, detection.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if lockout != nil {
+		detection.Lockouts = append(detection.Lockouts, *lockout)
+	}
+
+	return detection, s.respond(ctx, detection, domain.CaptchaScopeIP, ip, ip, userAgent, map[string]interface{}{
+		"distinct_emails": velocity.DistinctEmails,
+		"failed_attempts": velocity.FailedAttempts,
+	})
+}
+
+// detectASN fires when most logins from ip's network operator fail. Lookups
+// need the GeoIP ASN database, without it the detector never fires.
+func (s *credentialStuffingService) detectASN(ctx context.Context, ip, userAgent string, since time.Time) (*domain.CredentialStuffingDetection, error) {
+	if s.policy.ASNMinAttempts <= 0 || s.policy.ASNMaxFailureRatio <= 0 {
+		return nil, nil
+	}
+
+	location := s.geoIP.Lookup(ip)
+	if location == nil || location.ASN == 0 {
+		return nil, nil
+	}
+	asn := strconv.FormatInt(location.ASN, 10)
+
+	if required, err := s.captchaRequirementsRepo.IsRequired(ctx, domain.CaptchaSubject{ASN: asn}); err != nil || required {
+		return nil, err
+	}
+
+	outcomes, err := s.loginAttemptsRepo.GetLoginOutcomesByASN(ctx, location.ASN, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count logins for ASN: %w", err)
+	}
+	failureRatio, failing := s.policy.asnFailing(*outcomes)
+	if !failing {
+		return nil, nil
+	}
+
+	detection := &domain.CredentialStuffingDetection{
+		Detector: domain.CredentialStuffingASN,
+		Reason:   fmt.Sprintf("%d of %d logins from AS%s failed within %s", outcomes.FailedAttempts, outcomes.Attempts, asn, s.policy.Window),
+	}
+
+	return detection, s.respond(ctx, detection, domain.CaptchaScopeASN, asn, ip, userAgent, map[string]interface{}{
+		"asn":             location.ASN,
+		"as_organization": location.ASOrganization,
+		"attempts":        outcomes.Attempts,
+		"failed_attempts": outcomes.FailedAttempts,
+		"failure_ratio":   failureRatio,
+	})
+}
+
+// detectPassword fires when the password was tried against too many distinct
+// emails, from any number of addresses. Every login with it then needs a
+// CAPTCHA.
+func (s *credentialStuffingService) detectPassword(ctx context.Context, ip, userAgent, passwordFingerprint string, since time.Time) (*domain.CredentialStuffingDetection, error) {
+	if s.policy.PasswordMaxEmails <= 0 || passwordFingerprint == "" {
+		return nil, nil
+	}
+
+	if required, err := s.captchaRequirementsRepo.IsRequired(ctx, domain.CaptchaSubject{PasswordFingerprint: passwordFingerprint}); err != nil || required {
+		return nil, err
+	}
+
+	spread, err := s.loginAttemptsRepo.GetPasswordSpread(ctx, passwordFingerprint, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count accounts for password: %w", err)
+	}
+	if spread.DistinctEmails < s.policy.PasswordMaxEmails {
+		return nil, nil
+	}
+
+	detection := &domain.CredentialStuffingDetection{
+		Detector: domain.CredentialStuffingPassword,
+		Reason:   fmt.Sprintf("Same password tried against %d accounts from %d addresses within %s", spread.DistinctEmails, spread.DistinctIPs, s.policy.Window),
+	}
+
+	// The address that crossed the threshold may be the password's rightful
+	// owner, only those spraying it across several accounts are locked out
+	sprayers, err := s.loginAttemptsRepo.GetPasswordSprayAddresses(ctx, passwordFingerprint, since, passwordSprayMinEmailsPerAddress, passwordSprayMaxLockouts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses spraying password: %w", err)
+	}
+	lockedAddresses := []string{}
+	for _, address := range sprayers {
+		lockout, err := s.ipAccessService.LockAddress(ctx, address, detection.Reason)
+		if err != nil {
+			return nil, err
+		}
+		if lockout != nil {
+			detection.Lockouts = append(detection.Lockouts, *lockout)
+			lockedAddresses = append(lockedAddresses, address)
+		}
+	}
+
+	return detection, s.respond(ctx, detection, domain.CaptchaScopePassword, passwordFingerprint, ip, userAgent, map[string]interface{}{
+		"password_fingerprint": passwordFingerprint,
+		"distinct_emails":      spread.DistinctEmails,
+		"distinct_ips":         spread.DistinctIPs,
+		"locked_addresses":     lockedAddresses,
+	})
+}
+
+// respond requires a challenge for scope and value and records the detection
+// as a critical suspicious activity not tied to any account.
+func (s *credentialStuffingService) respond(ctx context.Context, detection *domain.CredentialStuffingDetection, scope, value, ip, userAgent string, details map[string]interface{}) error {
+	metrics.CredentialStuffingDetections.WithLabelValues(detection.Detector).Inc()
+
+	var err error
+	detection.Captcha, err = s.captchaRequirementsRepo.Require(ctx, domain.CreateCaptchaRequirementAction{
+		Scope:     scope,
+		Value:     value,
+		Reason:    detection.Reason,
+		ExpiresAt: time.Now().Add(s.policy.CaptchaDuration),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to require captcha for %s %s: %w", scope, value, err)
+	}
+
+	details["action"] = detection.Detector
+	details["window"] = s.policy.Window.String()
+	details["timestamp"] = time.Now().Unix()
+	metadata, _ := json.Marshal(details)
+
+	criticalSeverity := domain.CriticalActivity
+	detection.Activity, err = s.suspiciousRepo.CreateActivity(ctx, domain.CreateSuspiciousActivityAction{
+		ActivityType: detection.Detector,
+		IPAddress:    ip,
+		UserAgent:    userAgent,
+		Description:  detection.Reason,
+		Metadata:     metadata,
+		Severity:     &criticalSeverity,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record suspicious activity: %w", err)
+	}
+
+	return nil
+}
+
+func (s *credentialStuffingService) CleanupExpiredRequirements(ctx context.Context) error {
+	return s.captchaRequirementsRepo.DeleteExpiredRequirements(ctx)
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+// fakeStuffingCounts answers the cross-account counts the detectors read.
+type fakeStuffingCounts struct {
+	repositories.LoginAttemptsRepository
+	addressEmails int64
+	spread        domain.PasswordSpread
+	sprayers      []string
+}
+
+func (r *fakeStuffingCounts) GetFailedLoginVelocity(ctx context.Context, network netip.Prefix, since time.Time) (*domain.LoginVelocity, error) {
+	return &domain.LoginVelocity{FailedAttempts: r.addressEmails, DistinctEmails: r.addressEmails}, nil
+}
+
+func (r *fakeStuffingCounts) GetPasswordSpread(ctx context.Context, passwordFingerprint string, since time.Time) (*domain.PasswordSpread, error) {
+	return &r.spread, nil
+}
+
+func (r *fakeStuffingCounts) GetPasswordSprayAddresses(ctx context.Context, passwordFingerprint string, since time.Time, minEmails int64, limit int32) ([]string, error) {
+	return r.sprayers, nil
+}
+
+// fakeCaptchaRequirementStore keeps requirements in memory.
+type fakeCaptchaRequirementStore struct {
+	repositories.CaptchaRequirementsRepository
+	requirements []domain.CaptchaRequirement
+}
+
+func (r *fakeCaptchaRequirementStore) Require(ctx context.Context, req domain.CreateCaptchaRequirementAction) (*domain.CaptchaRequirement, error) {
+	requirement := domain.CaptchaRequirement{
+		ID:        int64(len(r.requirements) + 1),
+		Scope:     req.Scope,
+		Value:     req.Value,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	}
+	r.requirements = append(r.requirements, requirement)
+	return &requirement, nil
+}
+
+func (r *fakeCaptchaRequirementStore) IsRequired(ctx context.Context, subject domain.CaptchaSubject) (bool, error) {
+	for _, requirement := range r.requirements {
+		switch {
+		case requirement.Scope == domain.CaptchaScopeIP && requirement.Value == subject.IPAddress,
+			requirement.Scope == domain.CaptchaScopeASN && requirement.Value == subject.ASN,
+			requirement.Scope == domain.CaptchaScopePassword && requirement.Value == subject.PasswordFingerprint:
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestCredentialStuffingEvaluate(t *testing.T) {
+	policy := CredentialStuffingPolicy{IPMaxEmails: 20, PasswordMaxEmails: 10}
+
+	tests := []struct {
+		name         string
+		policy       CredentialStuffingPolicy
+		ip           string
+		fingerprint  string
+		counts       fakeStuffingCounts
+		want         []string
+		wantLocked   []string
+		wantRequired []string
+	}{
+		{
+			name:        "below both thresholds",
+			policy:      policy,
+			ip:          "203.0.113.5",
+			fingerprint: "fp",
+			counts:      fakeStuffingCounts{addressEmails: 19, spread: domain.PasswordSpread{DistinctEmails: 9}},
+		},
+		{
+			name:         "address threshold reached",
+			policy:       policy,
+			ip:           "203.0.113.5",
+			fingerprint:  "fp",
+			counts:       fakeStuffingCounts{addressEmails: 20},
+			want:         []string{domain.CredentialStuffingIP},
+			wantLocked:   []string{"203.0.113.5/32"},
+			wantRequired: []string{"ip:203.0.113.5"},
+		},
+		{
+			name:         "mapped address is judged as IPv4",
+			policy:       policy,
+			ip:           "::ffff:203.0.113.5",
+			counts:       fakeStuffingCounts{addressEmails: 20},
+			want:         []string{domain.CredentialStuffingIP},
+			wantLocked:   []string{"203.0.113.5/32"},
+			wantRequired: []string{"ip:203.0.113.5"},
+		},
+		{
+			name:        "password threshold locks only the sprayers",
+			policy:      policy,
+			ip:          "203.0.113.5",
+			fingerprint: "fp",
+			counts: fakeStuffingCounts{
+				spread:   domain.PasswordSpread{DistinctEmails: 10, DistinctIPs: 4},
+				sprayers: []string{"198.51.100.1", "198.51.100.2"},
+			},
+			want:         []string{domain.CredentialStuffingPassword},
+			wantLocked:   []string{"198.51.100.1/32", "198.51.100.2/32"},
+			wantRequired: []string{"password:fp"},
+		},
+		{
+			name:   "password detector needs a fingerprint",
+			policy: policy,
+			ip:     "203.0.113.5",
+			counts: fakeStuffingCounts{spread: domain.PasswordSpread{DistinctEmails: 100}},
+		},
+		{
+			name:        "both detectors fire",
+			policy:      policy,
+			ip:          "203.0.113.5",
+			fingerprint: "fp",
+			counts: fakeStuffingCounts{
+				addressEmails: 20,
+				spread:        domain.PasswordSpread{DistinctEmails: 10, DistinctIPs: 1},
+			},
+			want:         []string{domain.CredentialStuffingIP, domain.CredentialStuffingPassword},
+			wantLocked:   []string{"203.0.113.5/32"},
+			wantRequired: []string{"ip:203.0.113.5", "password:fp"},
+		},
+		{
+			name:        "zero thresholds disable the detectors",
+			ip:          "203.0.113.5",
+			fingerprint: "fp",
+			counts: fakeStuffingCounts{
+				addressEmails: 100,
+				spread:        domain.PasswordSpread{DistinctEmails: 100},
+			},
+		},
+		{
+			name:   "unparsable address",
+			policy: policy,
+			ip:     "not-an-ip",
+			counts: fakeStuffingCounts{addressEmails: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			rulesRepo := &fakeIPAccessRulesRepository{}
+			requirements := &fakeCaptchaRequirementStore{}
+			suspicious := &recordingSuspiciousActivity{}
+			ipAccess := NewIPAccessService(rulesRepo, &fakeLoginVelocity{}, IPLockoutPolicy{Duration: time.Hour})
+			service := NewCredentialStuffingService(&tt.counts, requirements, suspicious, ipAccess, nil, nil, tt.policy)
+
+			detections, err := service.Evaluate(ctx, tt.ip, "curl/8.0", tt.fingerprint)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+
+			var detectors []string
+			for _, detection := range detections {
+				detectors = append(detectors, detection.Detector)
+				if detection.Captcha == nil || detection.Activity == nil {
+					t.Errorf("%s detection has no CAPTCHA requirement or activity", detection.Detector)
+				}
+			}
+			if !slices.Equal(detectors, tt.want) {
+				t.Errorf("Evaluate() fired %v, want %v", detectors, tt.want)
+			}
+			if !slices.Equal(suspicious.types, tt.want) {
+				t.Errorf("recorded activities %v, want %v", suspicious.types, tt.want)
+			}
+
+			var locked []string
+			for _, rule := range rulesRepo.rules {
+				locked = append(locked, rule.Network.String())
+			}
+			if !slices.Equal(locked, tt.wantLocked) {
+				t.Errorf("locked %v, want %v", locked, tt.wantLocked)
+			}
+
+			var required []string
+			for _, requirement := range requirements.requirements {
+				required = append(required, requirement.Scope+":"+requirement.Value)
+			}
+			if !slices.Equal(required, tt.wantRequired) {
+				t.Errorf("required CAPTCHA for %v, want %v", required, tt.wantRequired)
+			}
+
+			// A detection in force keeps its detector quiet
+			again, err := service.Evaluate(ctx, tt.ip, "curl/8.0", tt.fingerprint)
+			if err != nil {
+				t.Fatalf("Evaluate() again error = %v", err)
+			}
+			if len(again) != 0 {
+				t.Errorf("Evaluate() again fired %d detectors, want none", len(again))
+			}
+		})
+	}
+}
+
+func TestCredentialStuffingASNFailing(t *testing.T) {
+	policy := CredentialStuffingPolicy{ASNMinAttempts: 100, ASNMaxFailureRatio: 0.8}
+
+	tests := []struct {
+		name     string
+		outcomes domain.LoginOutcomes
+		want     bool
+	}{
+		{name: "no logins", outcomes: domain.LoginOutcomes{}},
+		{name: "too few logins to judge", outcomes: domain.LoginOutcomes{Attempts: 99, FailedAttempts: 99}},
+		{name: "ratio at the threshold", outcomes: domain.LoginOutcomes{Attempts: 100, FailedAttempts: 80}},
+		{name: "ratio over the threshold", outcomes: domain.LoginOutcomes{Attempts: 100, FailedAttempts: 81}, want: true},
+		{name: "every login failing", outcomes: domain.LoginOutcomes{Attempts: 500, FailedAttempts: 500}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := policy.asnFailing(tt.outcomes); got != tt.want {
+				t.Errorf("asnFailing(%+v) = %v, want %v", tt.outcomes, got, tt.want)
+			}
+		})
+	}
+}
@@ -14,7 +14,8 @@ import (
 
 type EmailService interface {
 	SendVerificationEmail(ctx context.Context, userID int64, email string) error
-	VerifyEmailToken(ctx context.Context, token string) error
+	// VerifyEmailToken returns the user whose email the token verified.
+	VerifyEmailToken(ctx context.Context, token string) (*domain.User, error)
 	ResendVerificationEmail(ctx context.Context, userID int64, email string) error
 }
 
@@ -56,41 +57,41 @@ func (s *emailService) SendVerificationEmail(ctx context.Context, userID int64,
 	return s.sendEmail(email, "Email Verification", s.buildVerificationEmailContent(token))
 }
 
-func (s *emailService) VerifyEmailToken(ctx context.Context, token string) error {
+func (s *emailService) VerifyEmailToken(ctx context.Context, token string) (*domain.User, error) {
 	// Get verification record
 	verification, err := s.emailVerificationRepo.GetEmailVerificationByToken(ctx, token)
 	if err != nil {
-		return fmt.Errorf("invalid or expired verification token")
+		return nil, fmt.Errorf("invalid or expired verification token")
 	}
 
 	// Check if token is expired
 	if time.Now().After(verification.ExpiresAt) {
-		return fmt.Errorf("verification token has expired")
+		return nil, fmt.Errorf("verification token has expired")
 	}
 
 	// Check if already verified
 	if verification.UsedAt != nil {
-		return fmt.Errorf("email is already verified")
+		return nil, fmt.Errorf("email is already verified")
 	}
 
 	// Mark as verified
 	err = s.emailVerificationRepo.MarkEmailVerified(ctx, verification.ID)
 	if err != nil {
-		return fmt.Errorf("failed to mark email as verified: %v", err)
+		return nil, fmt.Errorf("failed to mark email as verified: %v", err)
 	}
 
 	// Update user email verification status
 	user, err := s.userRepo.GetUserByID(ctx, verification.UserID)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %v", err)
+		return nil, fmt.Errorf("failed to get user: %v", err)
 	}
 
 	err = s.userRepo.MarkEmailVerified(ctx, user.ID)
 	if err != nil {
-		return fmt.Errorf("failed to update user verification status: %v", err)
+		return nil, fmt.Errorf("failed to update user verification status: %v", err)
 	}
 
-	return nil
+	return user, nil
 }
 
 func (s *emailService) ResendVerificationEmail(ctx context.Context, userID int64, email string) error {
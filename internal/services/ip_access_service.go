@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync/atomic"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+const (
+	// Subnet sizes failed logins are aggregated over, roughly one customer
+	// allocation for each family
+	ipv4LockoutSubnetBits = 24
+	ipv6LockoutSubnetBits = 64
+)
+
+var ErrInvalidIPAccessRule = errors.New("invalid IP access rule")
+
+// IPLockoutPolicy controls automatic lockouts from failed-login velocity. A
+// zero MaxFailures or SubnetMaxFailures disables that check.
+type IPLockoutPolicy struct {
+	Window   time.Duration
+	Duration time.Duration
+	// MaxFailures is the failed logins from a single address within Window,
+	// across at least MinAccounts distinct emails, that lock the address.
+	MaxFailures int64
+	MinAccounts int64
+	// SubnetMaxFailures does the same for the surrounding /24 or /64.
+	SubnetMaxFailures int64
+}
+
+type IPAccessService interface {
+	// CheckIP returns the rule that blocks ip, or nil when it may proceed.
+	CheckIP(ip string) *domain.IPAccessRule
+	// EvaluateFailedLogins locks ip, or its subnet, once failed logins from it
+	// cross the lockout policy. It returns the lockouts it created.
+	EvaluateFailedLogins(ctx context.Context, ip string) ([]domain.IPAccessRule, error)
+	// LockAddress locks ip out for the lockout policy's duration. It returns
+	// nil without error when ip is allowlisted or already blocked.
+	LockAddress(ctx context.Context, ip, reason string) (*domain.IPAccessRule, error)
+	CreateRule(ctx context.Context, req domain.CreateIPAccessRuleAction) (*domain.IPAccessRule, error)
+	GetRule(ctx context.Context, id int64) (*domain.IPAccessRule, error)
+	ListRules(ctx context.Context) ([]domain.IPAccessRule, error)
+	DeleteRule(ctx context.Context, id int64) (*domain.IPAccessRule, error)
+	CleanupExpiredRules(ctx context.Context) error
+	// Refresh reloads the active rules CheckIP matches against.
+	Refresh(ctx context.Context) error
+	// WatchRules refreshes the active rules every interval, so rules written
+	// by other instances take effect here too, until ctx is done.
+	WatchRules(ctx context.Context, interval time.Duration)
+}
+
+type ipAccessService struct {
+	ipAccessRulesRepo repositories.IPAccessRulesRepository
+	loginAttemptsRepo repositories.LoginAttemptsRepository
+	policy            IPLockoutPolicy
+	rules             atomic.Pointer[[]domain.IPAccessRule]
+}
+
+func NewIPAccessService(
+	ipAccessRulesRepo repositories.IPAccessRulesRepository,
+	loginAttemptsRepo repositories.LoginAttemptsRepository,
+	policy IPLockoutPolicy,
+) IPAccessService {
+	service := &ipAccessService{
+		ipAccessRulesRepo: ipAccessRulesRepo,
+		loginAttemptsRepo: loginAttemptsRepo,
+		policy:            policy,
+	}
+	service.rules.Store(&[]domain.IPAccessRule{})
+	return service
+}
+
+func (s *ipAccessService) CheckIP(ip string) *domain.IPAccessRule {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+
+	return matchIPAccessRules(*s.rules.Load(), addr.Unmap(), time.Now())
+}
+
+// matchIPAccessRules lets allow rules win over everything else and deny rules
+// win over lockouts, so an admin decision is never masked by an automatic one.
+func matchIPAccessRules(rules []domain.IPAccessRule, addr netip.Addr, now time.Time) *domain.IPAccessRule {
+	var blocking *domain.IPAccessRule
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Active(now) || !rule.Network.Contains(addr) {
+			continue
+		}
+
+		switch rule.RuleType {
+		case domain.IPAccessRuleAllow:
+			return nil
+		case domain.IPAccessRuleDeny:
+			if blocking == nil || blocking.RuleType != domain.IPAccessRuleDeny {
+				blocking = rule
+			}
+		case domain.IPAccessRuleLockout:
+			if blocking == nil {
+				blocking = rule
+			}
+		}
+	}
+
+	return blocking
+}
+
+func (s *ipAccessService) isAllowed(addr netip.Addr, now time.Time) bool {
+	for _, rule := range *s.rules.Load() {
+		if rule.RuleType == domain.IPAccessRuleAllow && rule.Active(now) && rule.Network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ipAccessService) EvaluateFailedLogins(ctx context.Context, ip string) ([]domain.IPAccessRule, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, nil
+	}
+	addr = addr.Unmap()
+
+	now := time.Now()
+	if s.isAllowed(addr, now) || matchIPAccessRules(*s.rules.Load(), addr, now) != nil {
+		return nil, nil
+	}
+
+	since := now.Add(-s.policy.Window)
+	subnetBits := ipv4LockoutSubnetBits
+	if addr.Is6() {
+		subnetBits = ipv6LockoutSubnetBits
+	}
+
+	candidates := []struct {
+		network     netip.Prefix
+		maxFailures int64
+	}{
+		{netip.PrefixFrom(addr, addr.BitLen()), s.policy.MaxFailures},
+		{netip.PrefixFrom(addr, subnetBits).Masked(), s.policy.SubnetMaxFailures},
+	}
+
+	var lockouts []domain.IPAccessRule
+	for _, candidate := range candidates {
+		if candidate.maxFailures <= 0 {
+			continue
+		}
+
+		velocity, err := s.loginAttemptsRepo.GetFailedLoginVelocity(ctx, candidate.network, since)
+		if err != nil {
+			return lockouts, fmt.Errorf("failed to count failed logins for %s: %w", candidate.network, err)
+		}
+		if velocity.FailedAttempts < candidate.maxFailures || velocity.DistinctEmails < s.policy.MinAccounts {
+			continue
+		}
+
+		expiresAt := now.Add(s.policy.Duration)
+		lockout, err := s.ipAccessRulesRepo.CreateRule(ctx, domain.CreateIPAccessRuleAction{
+			Network:   candidate.network,
+			RuleType:  domain.IPAccessRuleLockout,
+			Reason:    fmt.Sprintf("%d failed logins for %d accounts within %s", velocity.FailedAttempts, velocity.DistinctEmails, s.policy.Window),
+			ExpiresAt: &expiresAt,
+		})
+		if err != nil {
+			return lockouts, fmt.Errorf("failed to lock %s: %w", candidate.network, err)
+		}
+		lockouts = append(lockouts, *lockout)
+
+		// The subnet check is only worth running if the address itself was
+		// not locked
+		break
+	}
+
+	if len(lockouts) > 0 {
+		if err := s.Refresh(ctx); err != nil {
+			fmt.Printf("Warning: failed to refresh IP access rules: %v\n", err)
+		}
+	}
+
+	return lockouts, nil
+}
+
+func (s *ipAccessService) LockAddress(ctx context.Context, ip, reason string) (*domain.IPAccessRule, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, nil
+	}
+	addr = addr.Unmap()
+
+	now := time.Now()
+	if s.isAllowed(addr, now) || matchIPAccessRules(*s.rules.Load(), addr, now) != nil {
+		return nil, nil
+	}
+
+	expiresAt := now.Add(s.policy.Duration)
+	lockout, err := s.ipAccessRulesRepo.CreateRule(ctx, domain.CreateIPAccessRuleAction{
+		Network:   netip.PrefixFrom(addr, addr.BitLen()),
+		RuleType:  domain.IPAccessRuleLockout,
+		Reason:    reason,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", addr, err)
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		fmt.Printf("Warning: failed to refresh IP access rules: %v\n", err)
+	}
+
+	return lockout, nil
+}
+
+func (s *ipAccessService) CreateRule(ctx context.Context, req domain.CreateIPAccessRuleAction) (*domain.IPAccessRule, error) {
+	if !req.Network.IsValid() {
+		return nil, fmt.Errorf("%w: network is required", ErrInvalidIPAccessRule)
+	}
+	if req.RuleType != domain.IPAccessRuleAllow && req.RuleType != domain.IPAccessRuleDeny {
+		return nil, fmt.Errorf("%w: rule type must be allow or deny", ErrInvalidIPAccessRule)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidIPAccessRule)
+	}
+
+	rule, err := s.ipAccessRulesRepo.CreateRule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		fmt.Printf("Warning: failed to refresh IP access rules: %v\n", err)
+	}
+
+	return rule, nil
+}
+
+func (s *ipAccessService) GetRule(ctx context.Context, id int64) (*domain.IPAccessRule, error) {
+	return s.ipAccessRulesRepo.GetRuleByID(ctx, id)
+}
+
+func (s *ipAccessService) ListRules(ctx context.Context) ([]domain.IPAccessRule, error) {
+	return s.ipAccessRulesRepo.ListRules(ctx)
+}
+
+// DeleteRule also lifts automatic lockouts, and returns the removed rule so
+// callers can audit it.
+func (s *ipAccessService) DeleteRule(ctx context.Context, id int64) (*domain.IPAccessRule, error) {
+	rule, err := s.ipAccessRulesRepo.GetRuleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ipAccessRulesRepo.DeleteRule(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		fmt.Printf("Warning: failed to refresh IP access rules: %v\n", err)
+	}
+
+	return rule, nil
+}
+
+func (s *ipAccessService) CleanupExpiredRules(ctx context.Context) error {
+	return s.ipAccessRulesRepo.DeleteExpiredRules(ctx)
+}
+
+func (s *ipAccessService) Refresh(ctx context.Context) error {
+	rules, err := s.ipAccessRulesRepo.ListActiveRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.rules.Store(&rules)
+	return nil
+}
+
+func (s *ipAccessService) WatchRules(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				fmt.Printf("Warning: failed to refresh IP access rules: %v\n", err)
+			}
+		}
+	}
+}
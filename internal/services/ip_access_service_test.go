@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+func TestMatchIPAccessRules(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	rule := func(id int64, network string, ruleType domain.IPAccessRuleType, expiresAt *time.Time) domain.IPAccessRule {
+		return domain.IPAccessRule{ID: id, Network: netip.MustParsePrefix(network), RuleType: ruleType, ExpiresAt: expiresAt}
+	}
+
+	tests := []struct {
+		name   string
+		rules  []domain.IPAccessRule
+		addr   string
+		wantID int64
+	}{
+		{
+			name:  "no rules",
+			addr:  "203.0.113.5",
+			rules: nil,
+		},
+		{
+			name:   "denied network",
+			rules:  []domain.IPAccessRule{rule(1, "203.0.113.0/24", domain.IPAccessRuleDeny, nil)},
+			addr:   "203.0.113.5",
+			wantID: 1,
+		},
+		{
+			name:  "address outside the denied network",
+			rules: []domain.IPAccessRule{rule(1, "203.0.113.0/24", domain.IPAccessRuleDeny, nil)},
+			addr:  "203.0.114.5",
+		},
+		{
+			name:   "single address rule",
+			rules:  []domain.IPAccessRule{rule(1, "203.0.113.5/32", domain.IPAccessRuleLockout, &future)},
+			addr:   "203.0.113.5",
+			wantID: 1,
+		},
+		{
+			name:  "single address rule does not cover its neighbour",
+			rules: []domain.IPAccessRule{rule(1, "203.0.113.5/32", domain.IPAccessRuleLockout, &future)},
+			addr:  "203.0.113.6",
+		},
+		{
+			name:  "expired rule",
+			rules: []domain.IPAccessRule{rule(1, "203.0.113.0/24", domain.IPAccessRuleDeny, &past)},
+			addr:  "203.0.113.5",
+		},
+		{
+			name: "allow beats deny",
+			rules: []domain.IPAccessRule{
+				rule(1, "203.0.113.0/24", domain.IPAccessRuleDeny, nil),
+				rule(2, "203.0.113.5/32", domain.IPAccessRuleAllow, nil),
+			},
+			addr: "203.0.113.5",
+		},
+		{
+			name: "allow beats a lockout",
+			rules: []domain.IPAccessRule{
+				rule(1, "203.0.113.5/32", domain.IPAccessRuleLockout, &future),
+				rule(2, "203.0.113.0/24", domain.IPAccessRuleAllow, nil),
+			},
+			addr: "203.0.113.5",
+		},
+		{
+			name: "expired allow does not exempt",
+			rules: []domain.IPAccessRule{
+				rule(1, "203.0.113.0/24", domain.IPAccessRuleDeny, nil),
+				rule(2, "203.0.113.5/32", domain.IPAccessRuleAllow, &past),
+			},
+			addr:   "203.0.113.5",
+			wantID: 1,
+		},
+		{
+			name: "deny is reported over a lockout",
+			rules: []domain.IPAccessRule{
+				rule(1, "203.0.113.5/32", domain.IPAccessRuleLockout, &future),
+				rule(2, "203.0.113.0/24", domain.IPAccessRuleDeny, nil),
+			},
+			addr:   "203.0.113.5",
+			wantID: 2,
+		},
+		{
+			name:   "IPv6 network",
+			rules:  []domain.IPAccessRule{rule(1, "2001:db8::/32", domain.IPAccessRuleDeny, nil)},
+			addr:   "2001:db8:1::1",
+			wantID: 1,
+		},
+		{
+			name:  "IPv4 rule does not match IPv6",
+			rules: []domain.IPAccessRule{rule(1, "0.0.0.0/0", domain.IPAccessRuleDeny, nil)},
+			addr:  "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchIPAccessRules(tt.rules, netip.MustParseAddr(tt.addr), now)
+			switch {
+			case tt.wantID == 0 && got != nil:
+				t.Errorf("matchIPAccessRules() = rule %d, want none", got.ID)
+			case tt.wantID != 0 && (got == nil || got.ID != tt.wantID):
+				t.Errorf("matchIPAccessRules() = %v, want rule %d", got, tt.wantID)
+			}
+		})
+	}
+}
+
+// fakeIPAccessRulesRepository keeps rules in memory.
+type fakeIPAccessRulesRepository struct {
+	repositories.IPAccessRulesRepository
+	rules []domain.IPAccessRule
+}
+
+func (r *fakeIPAccessRulesRepository) CreateRule(ctx context.Context, req domain.CreateIPAccessRuleAction) (*domain.IPAccessRule, error) {
+	rule := domain.IPAccessRule{
+		ID:        int64(len(r.rules) + 1),
+		Network:   req.Network,
+		RuleType:  req.RuleType,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	}
+	r.rules = append(r.rules, rule)
+	return &rule, nil
+}
+
+func (r *fakeIPAccessRulesRepository) ListActiveRules(ctx context.Context) ([]domain.IPAccessRule, error) {
+	return append([]domain.IPAccessRule(nil), r.rules...), nil
+}
+
+// fakeLoginVelocity answers GetFailedLoginVelocity per network.
+type fakeLoginVelocity struct {
+	repositories.LoginAttemptsRepository
+	velocities map[netip.Prefix]domain.LoginVelocity
+}
+
+func (r *fakeLoginVelocity) GetFailedLoginVelocity(ctx context.Context, network netip.Prefix, since time.Time) (*domain.LoginVelocity, error) {
+	velocity := r.velocities[network]
+	return &velocity, nil
+}
+
+func TestCheckIPUnmapsAddresses(t *testing.T) {
+	rulesRepo := &fakeIPAccessRulesRepository{rules: []domain.IPAccessRule{
+		{ID: 1, Network: netip.MustParsePrefix("203.0.113.0/24"), RuleType: domain.IPAccessRuleDeny},
+	}}
+	service := NewIPAccessService(rulesRepo, &fakeLoginVelocity{}, IPLockoutPolicy{})
+	if err := service.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ip := range []string{"203.0.113.5", "::ffff:203.0.113.5"} {
+		if rule := service.CheckIP(ip); rule == nil {
+			t.Errorf("CheckIP(%s) = nil, want the deny rule", ip)
+		}
+	}
+	for _, ip := range []string{"198.51.100.1", "not-an-ip", ""} {
+		if rule := service.CheckIP(ip); rule != nil {
+			t.Errorf("CheckIP(%q) = rule %d, want nil", ip, rule.ID)
+		}
+	}
+}
+
+func TestEvaluateFailedLogins(t *testing.T) {
+	policy := IPLockoutPolicy{
+		Window:            15 * time.Minute,
+		Duration:          time.Hour,
+		MaxFailures:       10,
+		MinAccounts:       3,
+		SubnetMaxFailures: 50,
+	}
+	address := netip.MustParsePrefix("203.0.113.5/32")
+	subnet := netip.MustParsePrefix("203.0.113.0/24")
+
+	tests := []struct {
+		name        string
+		ip          string
+		velocities  map[netip.Prefix]domain.LoginVelocity
+		rules       []domain.IPAccessRule
+		wantNetwork netip.Prefix
+	}{
+		{
+			name:       "below the address threshold",
+			ip:         "203.0.113.5",
+			velocities: map[netip.Prefix]domain.LoginVelocity{address: {FailedAttempts: 9, DistinctEmails: 9}},
+		},
+		{
+			name:       "too few accounts",
+			ip:         "203.0.113.5",
+			velocities: map[netip.Prefix]domain.LoginVelocity{address: {FailedAttempts: 40, DistinctEmails: 2}},
+		},
+		{
+			name:        "address crosses the threshold",
+			ip:          "203.0.113.5",
+			velocities:  map[netip.Prefix]domain.LoginVelocity{address: {FailedAttempts: 10, DistinctEmails: 3}},
+			wantNetwork: address,
+		},
+		{
+			name: "subnet crosses the threshold",
+			ip:   "203.0.113.5",
+			velocities: map[netip.Prefix]domain.LoginVelocity{
+				address: {FailedAttempts: 2, DistinctEmails: 2},
+				subnet:  {FailedAttempts: 50, DistinctEmails: 20},
+			},
+			wantNetwork: subnet,
+		},
+		{
+			name:        "IPv6 subnet is a /64",
+			ip:          "2001:db8:1:2::5",
+			velocities:  map[netip.Prefix]domain.LoginVelocity{netip.MustParsePrefix("2001:db8:1:2::/64"): {FailedAttempts: 50, DistinctEmails: 20}},
+			wantNetwork: netip.MustParsePrefix("2001:db8:1:2::/64"),
+		},
+		{
+			name:       "allowlisted address is never locked",
+			ip:         "203.0.113.5",
+			velocities: map[netip.Prefix]domain.LoginVelocity{address: {FailedAttempts: 100, DistinctEmails: 100}},
+			rules:      []domain.IPAccessRule{{ID: 99, Network: subnet, RuleType: domain.IPAccessRuleAllow}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			rulesRepo := &fakeIPAccessRulesRepository{rules: tt.rules}
+			service := NewIPAccessService(rulesRepo, &fakeLoginVelocity{velocities: tt.velocities}, policy)
+			if err := service.Refresh(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			lockouts, err := service.EvaluateFailedLogins(ctx, tt.ip)
+			if err != nil {
+				t.Fatalf("EvaluateFailedLogins() error = %v", err)
+			}
+			if !tt.wantNetwork.IsValid() {
+				if len(lockouts) != 0 {
+					t.Fatalf("EvaluateFailedLogins() locked %v, want nothing", lockouts[0].Network)
+				}
+				return
+			}
+			if len(lockouts) != 1 || lockouts[0].Network != tt.wantNetwork || lockouts[0].RuleType != domain.IPAccessRuleLockout {
+				t.Fatalf("EvaluateFailedLogins() = %v, want one lockout of %s", lockouts, tt.wantNetwork)
+			}
+
+			// The new lockout applies straight away
+			if rule := service.CheckIP(tt.ip); rule == nil || rule.RuleType != domain.IPAccessRuleLockout {
+				t.Errorf("CheckIP(%s) = %v after the lockout, want the lockout", tt.ip, rule)
+			}
+		})
+	}
+}
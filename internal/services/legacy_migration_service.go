@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
+	"github.com/m1thrandir225/whoami/internal/util"
+)
+
+// LegacyMigrationService lazily moves users over from a legacy identity store
+// the first time they log in with credentials the legacy system accepts.
+type LegacyMigrationService interface {
+	MigrateUser(ctx context.Context, email, password string, r *http.Request) (*domain.User, error)
+}
+
+type legacyMigrationService struct {
+	legacyClient *security.LegacyIdentityClient
+	userRepo     repositories.UserRepository
+}
+
+func NewLegacyMigrationService(
+	legacyClient *security.LegacyIdentityClient,
+	userRepo repositories.UserRepository,
+) LegacyMigrationService {
+	return &legacyMigrationService{
+		legacyClient: legacyClient,
+		userRepo:     userRepo,
+	}
+}
+
+func (s *legacyMigrationService) MigrateUser(ctx context.Context, email, password string, r *http.Request) (*domain.User, error) {
+	identity, err := s.legacyClient.VerifyCredentials(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := util.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	baseName := strings.Split(identity.Email, "@")[0]
+	if identity.Username != nil && *identity.Username != "" {
+		baseName = *identity.Username
+	}
+
+	username := util.GenerateUniqueUsername(baseName, func(username string) bool {
+		available, err := s.userRepo.IsUsernameAvailable(ctx, username)
+		return err == nil && available
+	})
+
+	var profile *domain.CreateUserProfileAction
+	if identity.Profile != nil {
+		locale := identity.Profile.Locale
+		if locale == "" {
+			locale = "en-US"
+		}
+
+		profile = &domain.CreateUserProfileAction{
+			FirstName: identity.Profile.FirstName,
+			LastName:  identity.Profile.LastName,
+			Phone:     identity.Profile.Phone,
+			AvatarURL: identity.Profile.AvatarURL,
+			Bio:       identity.Profile.Bio,
+			Timezone:  identity.Profile.Timezone,
+			Locale:    locale,
+		}
+	}
+
+	details, err := json.Marshal(map[string]interface{}{
+		"email":   identity.Email,
+		"source":  "legacy_identity_store",
+		"success": true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	resourceType := domain.AuditResourceTypeUser
+	ipAddress := security.ClientIPFromRequest(r)
+	var userAgent *string
+	if ua := r.Header.Get("User-Agent"); ua != "" {
+		userAgent = &ua
+	}
+
+	// A half migrated user would have to be cleaned up by hand, so the user,
+	// its profile and the audit entry are written together
+	user, err := s.userRepo.MigrateLegacyUser(ctx, domain.MigrateLegacyUserAction{
+		User: domain.CreateUserAction{
+			Email:           identity.Email,
+			Username:        &username,
+			PrivacySettings: &domain.PrivacySettings{},
+			Password:        passwordHash,
+		},
+		EmailVerified: identity.EmailVerified,
+		Profile:       profile,
+		Audit: domain.CreateAuditLogAction{
+			Action:       domain.AuditActionUserMigrate,
+			ResourceType: &resourceType,
+			IPAddress:    &ipAddress,
+			UserAgent:    userAgent,
+			Details:      details,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrated user: %w", err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/mail"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+var ErrInvalidLoginAlertToken = errors.New("invalid, expired or already used sign-in report link")
+
+// LoginAlertService emails users about sign-ins from devices and locations
+// they have not used before, and acts on the "this wasn't me" link in them.
+type LoginAlertService interface {
+	// NotifySignIn sends the alert for a sign-in that started sessionID. It
+	// does nothing unless newDevice or newLocation is set.
+	NotifySignIn(ctx context.Context, user *domain.User, sessionID string, deviceInfo *security.DeviceInfo, newDevice, newLocation bool) error
+	// ReportSignIn revokes the reported session, locks the account and emails
+	// a password reset link. Each token can be reported once. The lockout is
+	// nil when the account was already locked.
+	ReportSignIn(ctx context.Context, token, ipAddress, userAgent string) (*domain.LoginAlert, *domain.AccountLockout, error)
+	CleanupExpiredAlerts(ctx context.Context) error
+}
+
+type loginAlertService struct {
+	loginAlertsRepo      repositories.LoginAlertsRepository
+	userDevicesRepo      repositories.UserDevicesRepository
+	userRepo             repositories.UserRepository
+	sessionService       SessionService
+	securityService      SecurityService
+	passwordResetService PasswordResetService
+	mailService          mail.MailService
+	frontendURL          string
+	tokenTTL             time.Duration
+}
+
+func NewLoginAlertService(
+	loginAlertsRepo repositories.LoginAlertsRepository,
+	userDevicesRepo repositories.UserDevicesRepository,
+	userRepo repositories.UserRepository,
+	sessionService SessionService,
+	securityService SecurityService,
+	passwordResetService PasswordResetService,
+	mailService mail.MailService,
+	frontendURL string,
+	tokenTTL time.Duration,
+) LoginAlertService {
+	if tokenTTL <= 0 {
+		tokenTTL = 7 * 24 * time.Hour
+	}
+
+	return &loginAlertService{
+		loginAlertsRepo:      loginAlertsRepo,
+		userDevicesRepo:      userDevicesRepo,
+		userRepo:             userRepo,
+		sessionService:       sessionService,
+		securityService:      securityService,
+		passwordResetService: passwordResetService,
+		mailService:          mailService,
+		frontendURL:          frontendURL,
+		tokenTTL:             tokenTTL,
+	}
+}
+
+func (s *loginAlertService) NotifySignIn(ctx context.Context, user *domain.User, sessionID string, deviceInfo *security.DeviceInfo, newDevice, newLocation bool) error {
+	if newDevice {
+		devices, err := s.userDevicesRepo.GetUserDevicesByUserID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get user devices: %w", err)
+		}
+		// The device an account first signs in from is not news to its owner
+		if len(devices) <= 1 && !newLocation {
+			return nil
+		}
+	}
+	if !newDevice && !newLocation {
+		return nil
+	}
+
+	token, err := generateRandomHex(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate report token: %w", err)
+	}
+
+	alert, err := s.loginAlertsRepo.CreateAlert(ctx, domain.CreateLoginAlertAction{
+		UserID:      user.ID,
+		SessionID:   sessionID,
+		DeviceName:  deviceInfo.DeviceName,
+		IPAddress:   deviceInfo.IPAddress,
+		Location:    deviceInfo.Location.String(),
+		NewDevice:   newDevice,
+		NewLocation: newLocation,
+		TokenHash:   hashUnlockToken(token),
+		ExpiresAt:   time.Now().Add(s.tokenTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create login alert: %w", err)
+	}
+
+	return s.mailService.SendMail("whoami@sebastijanzindl.me", user.Email, "New sign-in to your account", s.buildAlertEmailContent(alert, token))
+}
+
+func (s *loginAlertService) ReportSignIn(ctx context.Context, token, ipAddress, userAgent string) (*domain.LoginAlert, *domain.AccountLockout, error) {
+	if token == "" {
+		return nil, nil, ErrInvalidLoginAlertToken
+	}
+
+	alert, err := s.loginAlertsRepo.GetAlertByTokenHash(ctx, hashUnlockToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrInvalidLoginAlertToken
+		}
+		return nil, nil, err
+	}
+	if time.Now().After(alert.ExpiresAt) {
+		return nil, nil, ErrInvalidLoginAlertToken
+	}
+
+	// Claiming the alert first keeps a double click from locking and
+	// resetting twice
+	alert, err = s.loginAlertsRepo.MarkAlertReported(ctx, alert.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrInvalidLoginAlertToken
+		}
+		return nil, nil, err
+	}
+
+	// A report that fails part way gives the claim back so the link can be
+	// used again. Locking an already locked account is a no-op.
+	reported := false
+	defer func() {
+		if reported {
+			return
+		}
+		if err := s.loginAlertsRepo.ReleaseAlertReport(context.WithoutCancel(ctx), alert.ID); err != nil {
+			fmt.Printf("Warning: failed to release login alert %d: %v\n", alert.ID, err)
+		}
+	}()
+
+	user, err := s.userRepo.GetUserByID(ctx, alert.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// 1. End the reported session, it may have expired on its own already
+	if alert.SessionID != "" {
+		if err := s.sessionService.RevokeSession(ctx, alert.SessionID); err != nil {
+			fmt.Printf("Warning: failed to revoke reported session %s: %v\n", alert.SessionID, err)
+		}
+	}
+
+	// 2. Keep whoever signed in from signing in again
+	lockout, err := s.securityService.LockAccount(ctx, user.ID, alert.IPAddress, userAgent, "sign-in reported as not made by the account owner")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"action":       "sign_in_reported",
+		"alert_id":     alert.ID,
+		"session_id":   alert.SessionID,
+		"device_name":  alert.DeviceName,
+		"location":     alert.Location,
+		"new_device":   alert.NewDevice,
+		"new_location": alert.NewLocation,
+		"reported_ip":  ipAddress,
+	})
+	criticalSeverity := domain.CriticalActivity
+	if err := s.securityService.RecordSuspiciousActivity(ctx, domain.CreateSuspiciousActivityAction{
+		UserID:       user.ID,
+		ActivityType: "sign_in_reported",
+		IPAddress:    alert.IPAddress,
+		UserAgent:    userAgent,
+		Description:  fmt.Sprintf("User reported the sign-in from %s at %s as not theirs", alert.DeviceName, alert.IPAddress),
+		Metadata:     metadata,
+		Severity:     &criticalSeverity,
+	}); err != nil {
+		fmt.Printf("Warning: failed to record suspicious activity: %v\n", err)
+	}
+
+	// 3. Completing the reset lifts the lockout
+	if err := s.passwordResetService.RequestPasswordReset(ctx, user.Email); err != nil {
+		return nil, nil, fmt.Errorf("failed to start password reset: %w", err)
+	}
+
+	reported = true
+	return alert, lockout, nil
+}
+
+func (s *loginAlertService) CleanupExpiredAlerts(ctx context.Context) error {
+	return s.loginAlertsRepo.DeleteExpiredAlerts(ctx)
+}
+
+func (s *loginAlertService) buildAlertEmailContent(alert *domain.LoginAlert, token string) string {
+	reportURL := fmt.Sprintf("%s/secure-account?token=%s", s.frontendURL, token)
+
+	reason := "a device you have not used before"
+	switch {
+	case alert.NewDevice && alert.NewLocation:
+		reason = "a device and location you have not used before"
+	case alert.NewLocation:
+		reason = "a location you have not signed in from before"
+	}
+
+	location := alert.Location
+	if location == "" {
+		location = "Unknown"
+	}
+
+	return fmt.Sprintf(`
+New sign-in to your account
+
+Your account was just signed in to from %s:
+
+Device:   %s
+IP:       %s
+Location: %s (approximate)
+Time:     %s
+
+If this was you, there is nothing to do.
+
+If it wasn't you, use the link below. We will sign that device out, lock
+your account and email you a link to reset your password:
+
+%s
+
+This link will expire on %s.
+
+Best regards,
+The whoami Team
+`, reason, alert.DeviceName, alert.IPAddress, location, alert.CreatedAt.UTC().Format("2 Jan 2006 15:04 MST"), reportURL, alert.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST"))
+}
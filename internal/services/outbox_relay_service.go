@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/events"
+	"github.com/m1thrandir225/whoami/internal/metrics"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+const (
+	outboxRelayBatchSize = 20
+	outboxRetryBaseDelay = time.Second
+	outboxRetryMaxDelay  = 5 * time.Minute
+	// outboxLeaseMargin is added to the time a batch takes to publish at
+	// worst, the lease on a claimed batch must outlast it.
+	outboxLeaseMargin = 30 * time.Second
+)
+
+// OutboxRelayService moves events from the outbox to the event publisher.
+// Delivery is at least once: an event is marked published only after the
+// publisher accepted it, so a crash in between publishes it again. Events
+// for the same aggregate go out in the order they committed, a failed event
+// holds back the ones behind it until it is published.
+type OutboxRelayService interface {
+	// ProcessPendingEvents claims one batch of due events, publishes it and
+	// returns how many were published. Events are published outside any
+	// transaction, instances only take turns claiming.
+	ProcessPendingEvents(ctx context.Context) (int, error)
+	CleanupPublishedEvents(ctx context.Context) error
+}
+
+type outboxRelayService struct {
+	outboxRepo     repositories.OutboxRepository
+	publisher      events.EventPublisher
+	publishTimeout time.Duration
+	retention      time.Duration
+}
+
+func NewOutboxRelayService(
+	outboxRepo repositories.OutboxRepository,
+	publisher events.EventPublisher,
+	publishTimeout time.Duration,
+	retention time.Duration,
+) OutboxRelayService {
+	if publishTimeout <= 0 {
+		publishTimeout = 5 * time.Second
+	}
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+
+	return &outboxRelayService{
+		outboxRepo:     outboxRepo,
+		publisher:      publisher,
+		publishTimeout: publishTimeout,
+		retention:      retention,
+	}
+}
+
+func (s *outboxRelayService) ProcessPendingEvents(ctx context.Context) (int, error) {
+	lease := outboxRelayBatchSize*s.publishTimeout + outboxLeaseMargin
+	pending, err := s.outboxRepo.ClaimPendingEvents(ctx, outboxRelayBatchSize, time.Now().Add(lease))
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim pending outbox events: %w", err)
+	}
+
+	var publishedIDs, skippedIDs []int64
+	blocked := make(map[string]bool)
+	for _, event := range pending {
+		if blocked[event.Key()] {
+			skippedIDs = append(skippedIDs, event.Sequence)
+			continue
+		}
+
+		if err := s.publish(ctx, event); err != nil {
+			// Later events for the aggregate wait until this one is out
+			blocked[event.Key()] = true
+			metrics.OutboxPublishAttempts.WithLabelValues(event.Type, "retry").Inc()
+
+			nextAttemptAt := time.Now().Add(outboxRetryDelay(event.Attempts + 1))
+			if err := s.outboxRepo.RecordEventFailure(ctx, event.Sequence, err.Error(), nextAttemptAt); err != nil {
+				fmt.Printf("Warning: failed to record outbox event failure: %v\n", err)
+			}
+			continue
+		}
+
+		metrics.OutboxPublishAttempts.WithLabelValues(event.Type, "published").Inc()
+		publishedIDs = append(publishedIDs, event.Sequence)
+	}
+
+	// The failed event ahead of them keeps the skipped ones back, they need
+	// not wait out the lease as well
+	if len(skippedIDs) > 0 {
+		if err := s.outboxRepo.ReleaseEvents(ctx, skippedIDs); err != nil {
+			fmt.Printf("Warning: failed to release outbox events: %v\n", err)
+		}
+	}
+
+	if len(publishedIDs) == 0 {
+		return 0, nil
+	}
+	if err := s.outboxRepo.MarkEventsPublished(ctx, publishedIDs); err != nil {
+		return 0, fmt.Errorf("failed to mark outbox events published: %w", err)
+	}
+
+	return len(publishedIDs), nil
+}
+
+func (s *outboxRelayService) publish(ctx context.Context, event domain.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, s.publishTimeout)
+	defer cancel()
+
+	return s.publisher.Publish(ctx, event)
+}
+
+func (s *outboxRelayService) CleanupPublishedEvents(ctx context.Context) error {
+	return s.outboxRepo.DeletePublishedEventsBefore(ctx, time.Now().Add(-s.retention))
+}
+
+// outboxRetryDelay doubles the wait after each failed attempt up to five
+// minutes. Events are never given up on, dropping one would break the order
+// of the events behind it.
+func outboxRetryDelay(attempts int32) time.Duration {
+	delay := outboxRetryBaseDelay
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= outboxRetryMaxDelay {
+			return outboxRetryMaxDelay
+		}
+	}
+	return delay
+}
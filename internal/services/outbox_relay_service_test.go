@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+)
+
+// fakeOutboxRepository hands out one claimed batch and records what the
+// relay did with each event.
+type fakeOutboxRepository struct {
+	pending   []domain.OutboxEvent
+	published []int64
+	failed    []int64
+	released  []int64
+}
+
+func (r *fakeOutboxRepository) ClaimPendingEvents(ctx context.Context, limit int32, leaseUntil time.Time) ([]domain.OutboxEvent, error) {
+	return r.pending, nil
+}
+
+func (r *fakeOutboxRepository) MarkEventsPublished(ctx context.Context, ids []int64) error {
+	r.published = append(r.published, ids...)
+	return nil
+}
+
+func (r *fakeOutboxRepository) RecordEventFailure(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	r.failed = append(r.failed, id)
+	return nil
+}
+
+func (r *fakeOutboxRepository) ReleaseEvents(ctx context.Context, ids []int64) error {
+	r.released = append(r.released, ids...)
+	return nil
+}
+
+func (r *fakeOutboxRepository) DeletePublishedEventsBefore(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+// fakePublisher records the events it was handed, failing the given ones.
+type fakePublisher struct {
+	fail []int64
+	sent []int64
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	p.sent = append(p.sent, event.Sequence)
+	if slices.Contains(p.fail, event.Sequence) {
+		return errors.New("broker unavailable")
+	}
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	return nil
+}
+
+func TestProcessPendingEventsKeepsAggregateOrder(t *testing.T) {
+	event := func(sequence int64, aggregateID string, aggregateSequence int64) domain.OutboxEvent {
+		return domain.OutboxEvent{
+			Sequence:          sequence,
+			Type:              domain.OutboxEventUserCreated,
+			AggregateType:     "user",
+			AggregateID:       aggregateID,
+			AggregateSequence: aggregateSequence,
+		}
+	}
+	// Two users' events interleaved in commit order
+	pending := []domain.OutboxEvent{
+		event(1, "7", 1),
+		event(2, "8", 1),
+		event(3, "7", 2),
+		event(4, "8", 2),
+		event(5, "7", 3),
+	}
+
+	tests := []struct {
+		name          string
+		fail          []int64
+		wantSent      []int64
+		wantPublished []int64
+		wantFailed    []int64
+		wantReleased  []int64
+	}{
+		{
+			name:          "all published in commit order",
+			wantSent:      []int64{1, 2, 3, 4, 5},
+			wantPublished: []int64{1, 2, 3, 4, 5},
+		},
+		{
+			name:          "failure holds back the rest of its aggregate",
+			fail:          []int64{1},
+			wantSent:      []int64{1, 2, 4},
+			wantPublished: []int64{2, 4},
+			wantFailed:    []int64{1},
+			wantReleased:  []int64{3, 5},
+		},
+		{
+			name:          "events before the failure stay published",
+			fail:          []int64{3},
+			wantSent:      []int64{1, 2, 3, 4},
+			wantPublished: []int64{1, 2, 4},
+			wantFailed:    []int64{3},
+			wantReleased:  []int64{5},
+		},
+		{
+			name:         "every aggregate failing",
+			fail:         []int64{1, 2},
+			wantSent:     []int64{1, 2},
+			wantFailed:   []int64{1, 2},
+			wantReleased: []int64{3, 4, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOutboxRepository{pending: pending}
+			publisher := &fakePublisher{fail: tt.fail}
+			service := NewOutboxRelayService(repo, publisher, time.Second, 0)
+
+			count, err := service.ProcessPendingEvents(context.Background())
+			if err != nil {
+				t.Fatalf("ProcessPendingEvents() error = %v", err)
+			}
+			if count != len(tt.wantPublished) {
+				t.Errorf("ProcessPendingEvents() = %d, want %d", count, len(tt.wantPublished))
+			}
+
+			for _, check := range []struct {
+				what      string
+				got, want []int64
+			}{
+				{"sent", publisher.sent, tt.wantSent},
+				{"published", repo.published, tt.wantPublished},
+				{"failed", repo.failed, tt.wantFailed},
+				{"released", repo.released, tt.wantReleased},
+			} {
+				if fmt.Sprint(check.got) != fmt.Sprint(check.want) {
+					t.Errorf("%s %v, want %v", check.what, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 9, want: 256 * time.Second},
+		{attempts: 10, want: 5 * time.Minute},
+		{attempts: 1000, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := outboxRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("outboxRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
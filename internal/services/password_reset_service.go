@@ -17,12 +17,13 @@ type PasswordResetService interface {
 	RequestPasswordReset(ctx context.Context, email string) error
 	VerifyResetToken(ctx context.Context, token string) (*domain.PasswordReset, error)
 	VerifyResetOTP(ctx context.Context, token, otp string) error
-	ResetPassword(ctx context.Context, token string, newPassword string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) (*domain.PasswordReset, error)
 }
 
 type passwordResetService struct {
 	passwordResetRepo       repositories.PasswordResetRepository
 	userRepo                repositories.UserRepository
+	lockoutRepo             repositories.AccountLockoutRepository
 	passwordSecurityService PasswordSecurityService
 	mailService             mail.MailService
 	frontendURL             string
@@ -31,6 +32,7 @@ type passwordResetService struct {
 func NewPasswordResetService(
 	passwordResetRepo repositories.PasswordResetRepository,
 	userRepo repositories.UserRepository,
+	lockoutRepo repositories.AccountLockoutRepository,
 	passwordSecurityService PasswordSecurityService,
 	mailService mail.MailService,
 	frontendURL string,
@@ -38,6 +40,7 @@ func NewPasswordResetService(
 	return &passwordResetService{
 		passwordResetRepo:       passwordResetRepo,
 		userRepo:                userRepo,
+		lockoutRepo:             lockoutRepo,
 		passwordSecurityService: passwordSecurityService,
 		mailService:             mailService,
 		frontendURL:             frontendURL,
@@ -151,29 +154,38 @@ func (s *passwordResetService) VerifyResetOTP(ctx context.Context, token, otp st
 	return nil
 }
 
-func (s *passwordResetService) ResetPassword(ctx context.Context, token string, newPassword string) error {
+func (s *passwordResetService) ResetPassword(ctx context.Context, token string, newPassword string) (*domain.PasswordReset, error) {
 	// Verify the token
 	reset, err := s.VerifyResetToken(ctx, token)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Validate the new password
 	if err := s.passwordSecurityService.ValidateNewUserPassword(ctx, newPassword); err != nil {
-		return fmt.Errorf("invalid password: %v", err)
+		return nil, fmt.Errorf("invalid password: %v", err)
 	}
 
 	// Update the user's password
 	if err := s.passwordSecurityService.UpdatePassword(ctx, reset.UserID, newPassword); err != nil {
-		return fmt.Errorf("failed to update password: %v", err)
+		return nil, fmt.Errorf("failed to update password: %v", err)
 	}
 
 	// Mark the reset token as used
 	if err := s.passwordResetRepo.MarkPasswordResetAsUsed(ctx, reset.ID); err != nil {
-		return fmt.Errorf("failed to mark reset as used: %v", err)
+		return nil, fmt.Errorf("failed to mark reset as used: %v", err)
 	}
 
-	return nil
+	// Completing a reset proves control of the mailbox, which is all the
+	// emailed unlock link asks for, so it lifts lockouts too
+	if _, err := s.lockoutRepo.UnlockAccount(ctx, domain.UnlockAccountAction{
+		UserID:       reset.UserID,
+		UnlockMethod: domain.UnlockMethodEmail,
+	}); err != nil {
+		fmt.Printf("Warning: failed to unlock account %d after password reset: %v\n", reset.UserID, err)
+	}
+
+	return reset, nil
 }
 
 func (s *passwordResetService) generateResetToken() (string, error) {
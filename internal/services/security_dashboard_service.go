@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+const (
+	dashboardDefaultRange = 7 * 24 * time.Hour
+	// dashboardMaxBuckets keeps a series small enough to chart
+	dashboardMaxBuckets = 1000
+)
+
+var ErrInvalidDashboardRange = errors.New("invalid dashboard range")
+
+// SecurityDashboardService serves the aggregates behind the admin security
+// dashboard. Time series cover every bucket in the range, empty ones
+// included, so they can be charted as they are.
+type SecurityDashboardService interface {
+	// ParseRange builds a range from RFC 3339 timestamps or YYYY-MM-DD dates.
+	// It defaults to the last 7 days, and picks the bucket size from the
+	// length of the range unless one is given.
+	ParseRange(from, to, bucket string) (domain.DashboardRange, error)
+	GetSummary(ctx context.Context, r domain.DashboardRange) (*domain.SecurityDashboardSummary, error)
+	GetLoginSeries(ctx context.Context, r domain.DashboardRange) ([]domain.LoginAttemptBucket, *domain.LoginTotals, error)
+	GetTopOffenders(ctx context.Context, r domain.DashboardRange, limit int32) ([]domain.FailedLoginIP, []domain.FailedLoginEmail, error)
+	GetLockoutSeries(ctx context.Context, r domain.DashboardRange) ([]domain.LockoutBucket, *domain.ActiveLockouts, error)
+	GetSuspiciousActivitySeries(ctx context.Context, r domain.DashboardRange) ([]domain.SuspiciousActivityBucket, *domain.SeverityCounts, error)
+	GetRegistrationSeries(ctx context.Context, r domain.DashboardRange) ([]domain.RegistrationBucket, int64, error)
+}
+
+type securityDashboardService struct {
+	dashboardRepo repositories.SecurityDashboardRepository
+}
+
+func NewSecurityDashboardService(dashboardRepo repositories.SecurityDashboardRepository) SecurityDashboardService {
+	return &securityDashboardService{
+		dashboardRepo: dashboardRepo,
+	}
+}
+
+func (s *securityDashboardService) ParseRange(from, to, bucket string) (domain.DashboardRange, error) {
+	r := domain.DashboardRange{
+		To:     time.Now().UTC(),
+		Bucket: bucket,
+	}
+
+	if to != "" {
+		t, err := parseDashboardTime(to)
+		if err != nil {
+			return r, fmt.Errorf("%w: to: %v", ErrInvalidDashboardRange, err)
+		}
+		r.To = t
+	}
+
+	r.From = r.To.Add(-dashboardDefaultRange)
+	if from != "" {
+		t, err := parseDashboardTime(from)
+		if err != nil {
+			return r, fmt.Errorf("%w: from: %v", ErrInvalidDashboardRange, err)
+		}
+		r.From = t
+	}
+
+	if !r.From.Before(r.To) {
+		return r, fmt.Errorf("%w: from must be before to", ErrInvalidDashboardRange)
+	}
+
+	switch r.Bucket {
+	case "":
+		switch length := r.To.Sub(r.From); {
+		case length <= 2*24*time.Hour:
+			r.Bucket = domain.DashboardBucketHour
+		case length <= 90*24*time.Hour:
+			r.Bucket = domain.DashboardBucketDay
+		default:
+			r.Bucket = domain.DashboardBucketWeek
+		}
+	case domain.DashboardBucketHour, domain.DashboardBucketDay, domain.DashboardBucketWeek:
+	default:
+		return r, fmt.Errorf("%w: bucket must be hour, day or week", ErrInvalidDashboardRange)
+	}
+
+	if len(dashboardBuckets(r)) > dashboardMaxBuckets {
+		return r, fmt.Errorf("%w: more than %d %s buckets, use a larger bucket or a shorter range", ErrInvalidDashboardRange, dashboardMaxBuckets, r.Bucket)
+	}
+
+	return r, nil
+}
+
+func (s *securityDashboardService) GetSummary(ctx context.Context, r domain.DashboardRange) (*domain.SecurityDashboardSummary, error) {
+	_, logins, err := s.GetLoginSeries(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	_, registrations, err := s.GetRegistrationSeries(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	activeLockouts, err := s.dashboardRepo.GetActiveLockouts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active lockouts: %w", err)
+	}
+
+	unresolved, err := s.dashboardRepo.GetUnresolvedSuspiciousActivityCounts(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unresolved suspicious activities: %w", err)
+	}
+
+	mfaAdoption, err := s.dashboardRepo.GetMFAAdoption(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MFA adoption: %w", err)
+	}
+
+	return &domain.SecurityDashboardSummary{
+		Range:                          r,
+		Logins:                         *logins,
+		Registrations:                  registrations,
+		ActiveLockouts:                 *activeLockouts,
+		UnresolvedSuspiciousActivities: *unresolved,
+		MFAAdoption:                    *mfaAdoption,
+	}, nil
+}
+
+func (s *securityDashboardService) GetLoginSeries(ctx context.Context, r domain.DashboardRange) ([]domain.LoginAttemptBucket, *domain.LoginTotals, error) {
+	rows, err := s.dashboardRepo.GetLoginAttemptSeries(ctx, r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+
+	totals := &domain.LoginTotals{}
+	for _, row := range rows {
+		totals.Successful += row.Successful
+		totals.Failed += row.Failed
+	}
+	if attempts := totals.Successful + totals.Failed; attempts > 0 {
+		totals.FailureRate = float64(totals.Failed) / float64(attempts)
+	}
+
+	series := fillDashboardBuckets(r, rows,
+		func(b domain.LoginAttemptBucket) time.Time { return b.Bucket },
+		func(t time.Time) domain.LoginAttemptBucket { return domain.LoginAttemptBucket{Bucket: t} },
+	)
+	return series, totals, nil
+}
+
+func (s *securityDashboardService) GetTopOffenders(ctx context.Context, r domain.DashboardRange, limit int32) ([]domain.FailedLoginIP, []domain.FailedLoginEmail, error) {
+	ips, err := s.dashboardRepo.GetTopFailedLoginIPs(ctx, r, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get top failed login IPs: %w", err)
+	}
+
+	emails, err := s.dashboardRepo.GetTopFailedLoginEmails(ctx, r, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get top failed login emails: %w", err)
+	}
+
+	return ips, emails, nil
+}
+
+func (s *securityDashboardService) GetLockoutSeries(ctx context.Context, r domain.DashboardRange) ([]domain.LockoutBucket, *domain.ActiveLockouts, error) {
+	rows, err := s.dashboardRepo.GetLockoutSeries(ctx, r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get lockouts: %w", err)
+	}
+
+	active, err := s.dashboardRepo.GetActiveLockouts(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count active lockouts: %w", err)
+	}
+
+	series := fillDashboardBuckets(r, rows,
+		func(b domain.LockoutBucket) time.Time { return b.Bucket },
+		func(t time.Time) domain.LockoutBucket { return domain.LockoutBucket{Bucket: t} },
+	)
+	return series, active, nil
+}
+
+func (s *securityDashboardService) GetSuspiciousActivitySeries(ctx context.Context, r domain.DashboardRange) ([]domain.SuspiciousActivityBucket, *domain.SeverityCounts, error) {
+	rows, err := s.dashboardRepo.GetSuspiciousActivitySeries(ctx, r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get suspicious activities: %w", err)
+	}
+
+	unresolved, err := s.dashboardRepo.GetUnresolvedSuspiciousActivityCounts(ctx, r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count unresolved suspicious activities: %w", err)
+	}
+
+	series := fillDashboardBuckets(r, rows,
+		func(b domain.SuspiciousActivityBucket) time.Time { return b.Bucket },
+		func(t time.Time) domain.SuspiciousActivityBucket { return domain.SuspiciousActivityBucket{Bucket: t} },
+	)
+	return series, unresolved, nil
+}
+
+func (s *securityDashboardService) GetRegistrationSeries(ctx context.Context, r domain.DashboardRange) ([]domain.RegistrationBucket, int64, error) {
+	rows, err := s.dashboardRepo.GetRegistrationSeries(ctx, r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get registrations: %w", err)
+	}
+
+	var total int64
+	for _, row := range rows {
+		total += row.Registrations
+	}
+
+	series := fillDashboardBuckets(r, rows,
+		func(b domain.RegistrationBucket) time.Time { return b.Bucket },
+		func(t time.Time) domain.RegistrationBucket { return domain.RegistrationBucket{Bucket: t} },
+	)
+	return series, total, nil
+}
+
+func parseDashboardTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// truncateDashboardBucket matches Postgres' date_trunc on a UTC timestamp.
+func truncateDashboardBucket(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	switch bucket {
+	case domain.DashboardBucketHour:
+		return t.Truncate(time.Hour)
+	case domain.DashboardBucketWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// dashboardBuckets lists the start of every bucket that overlaps the range.
+func dashboardBuckets(r domain.DashboardRange) []time.Time {
+	var buckets []time.Time
+	for t := truncateDashboardBucket(r.From, r.Bucket); t.Before(r.To); {
+		buckets = append(buckets, t)
+		if len(buckets) > dashboardMaxBuckets {
+			break
+		}
+
+		switch r.Bucket {
+		case domain.DashboardBucketHour:
+			t = t.Add(time.Hour)
+		case domain.DashboardBucketWeek:
+			t = t.AddDate(0, 0, 7)
+		default:
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+	return buckets
+}
+
+// fillDashboardBuckets returns one entry per bucket in the range, taking the
+// matching row where there is one and an empty entry otherwise.
+func fillDashboardBuckets[T any](r domain.DashboardRange, rows []T, bucketOf func(T) time.Time, empty func(time.Time) T) []T {
+	byBucket := make(map[int64]T, len(rows))
+	for _, row := range rows {
+		byBucket[bucketOf(row).Unix()] = row
+	}
+
+	buckets := dashboardBuckets(r)
+	series := make([]T, len(buckets))
+	for i, bucket := range buckets {
+		if row, ok := byBucket[bucket.Unix()]; ok {
+			series[i] = row
+		} else {
+			series[i] = empty(bucket)
+		}
+	}
+	return series
+}
@@ -2,21 +2,149 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/mail"
 	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
 )
 
+var (
+	ErrAccountLocked      = errors.New("account is locked")
+	ErrInvalidUnlockToken = errors.New("invalid or already used unlock token")
+)
+
+// LockoutPolicy controls how failed logins escalate into account lockouts.
+type LockoutPolicy struct {
+	// MaxFailures consecutive failures within FailureWindow lock the account.
+	MaxFailures   int64
+	FailureWindow time.Duration
+	// Every lockout within EscalationWindow doubles the duration of the next,
+	// from BaseDuration up to MaxDuration.
+	BaseDuration     time.Duration
+	MaxDuration      time.Duration
+	EscalationWindow time.Duration
+	// The PermanentAfter-th lockout within EscalationWindow holds until an
+	// admin or the emailed unlock link lifts it. Zero disables permanent locks.
+	PermanentAfter int64
+	// Failures beyond DelayAfter are answered after BaseDelay, doubling with
+	// each further failure up to MaxDelay. A zero BaseDelay disables delays.
+	DelayAfter int64
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// HistoryRetention prunes closed lockouts older than this. Zero keeps
+	// them forever.
+	HistoryRetention time.Duration
+}
+
+// TravelPolicy flags a login as impossible travel when reaching it from the
+// previous located login would take more than MaxSpeedKmh. Moves shorter
+// than MinDistanceKm are ignored, GeoIP is rarely more precise than that.
+type TravelPolicy struct {
+	MaxSpeedKmh   float64
+	MinDistanceKm float64
+}
+
+func (p TravelPolicy) withDefaults() TravelPolicy {
+	if p.MaxSpeedKmh <= 0 {
+		p.MaxSpeedKmh = 1000
+	}
+	if p.MinDistanceKm <= 0 {
+		p.MinDistanceKm = 500
+	}
+	return p
+}
+
+func (p LockoutPolicy) withDefaults() LockoutPolicy {
+	if p.MaxFailures <= 0 {
+		p.MaxFailures = 5
+	}
+	if p.FailureWindow <= 0 {
+		p.FailureWindow = 24 * time.Hour
+	}
+	if p.BaseDuration <= 0 {
+		p.BaseDuration = 30 * time.Minute
+	}
+	if p.MaxDuration < p.BaseDuration {
+		p.MaxDuration = p.BaseDuration
+	}
+	if p.EscalationWindow <= 0 {
+		p.EscalationWindow = 7 * 24 * time.Hour
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	return p
+}
+
+// lockoutDuration doubles BaseDuration for every earlier lockout in the
+// escalation window, up to MaxDuration.
+func (p LockoutPolicy) lockoutDuration(level int64) time.Duration {
+	duration := p.BaseDuration
+	for i := int64(1); i < level; i++ {
+		duration *= 2
+		if duration >= p.MaxDuration {
+			return p.MaxDuration
+		}
+	}
+	return duration
+}
+
+func (p LockoutPolicy) failureDelay(failures int64) time.Duration {
+	if p.BaseDelay <= 0 || failures <= p.DelayAfter {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.DelayAfter + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return delay
+}
+
 type SecurityService interface {
-	RecordFailedLogin(ctx context.Context, userID int64, email, ipAddress, userAgent string) error
-	RecordSuccessfulLogin(ctx context.Context, userID int64, email, ipAddress, userAgent string) error
+	// RecordFailedLogin records the attempt and locks the account once the
+	// lockout policy says so. The result carries the delay the next attempt
+	// for the email has to wait, which is also set for unknown emails.
+	// passwordFingerprint is kept with the attempt for the credential
+	// stuffing detectors.
+	RecordFailedLogin(ctx context.Context, userID int64, email, passwordFingerprint, ipAddress, userAgent string) (*domain.FailedLoginResult, error)
+	// RecordSuccessfulLogin records the attempt and flags location anomalies.
+	// The result says where the login came from.
+	RecordSuccessfulLogin(ctx context.Context, userID int64, email, ipAddress, userAgent string) (*domain.SuccessfulLoginResult, error)
+	// ReserveLoginAttempt returns how long a login for email has to wait
+	// because of earlier failures, or zero when it may go ahead. It has to
+	// be called before the password is checked.
+	ReserveLoginAttempt(ctx context.Context, email string) (time.Duration, error)
+	// CheckAccountLockout returns ErrAccountLocked while the account has an
+	// open lockout that applies to logins from ipAddress.
 	CheckAccountLockout(ctx context.Context, userID int64, ipAddress string) error
+	// UnlockAccount lifts every open lockout on the user, permanent ones
+	// included, and returns those it lifted.
+	UnlockAccount(ctx context.Context, userID, adminID int64) ([]domain.AccountLockout, error)
+	// UnlockAccountWithToken lifts the lockout whose emailed unlock link
+	// carried token.
+	UnlockAccountWithToken(ctx context.Context, token string) (*domain.AccountLockout, error)
+	// LockAccount places a permanent lockout on the user for reason, tied to
+	// the address the account was misused from. It holds until an admin
+	// unlocks the account or the user resets their password, and returns nil
+	// without error when such a lockout is already open.
+	LockAccount(ctx context.Context, userID int64, ipAddress, userAgent, reason string) (*domain.AccountLockout, error)
+	GetLockoutHistory(ctx context.Context, userID int64) ([]domain.AccountLockout, error)
 	RecordSuspiciousActivity(ctx context.Context, req domain.CreateSuspiciousActivityAction) error
 	GetSuspiciousActivities(ctx context.Context, userID int64) ([]domain.SuspiciousActivity, error)
-	ResolveSuspiciousActivity(ctx context.Context, activityID int64) error
 	CleanupExpiredLockouts(ctx context.Context) error
 }
 
@@ -25,6 +153,12 @@ type securityService struct {
 	suspiciousRepo    repositories.SuspiciousActivityRepository
 	lockoutRepo       repositories.AccountLockoutRepository
 	userRepo          repositories.UserRepository
+	mailService       mail.MailService
+	frontendURL       string
+	policy            LockoutPolicy
+	geoIP             *security.GeoIPResolver
+	travelPolicy      TravelPolicy
+	loginThrottle     security.LoginThrottle
 }
 
 func NewSecurityService(
@@ -32,47 +166,90 @@ func NewSecurityService(
 	suspiciousRepo repositories.SuspiciousActivityRepository,
 	lockoutRepo repositories.AccountLockoutRepository,
 	userRepo repositories.UserRepository,
+	mailService mail.MailService,
+	frontendURL string,
+	policy LockoutPolicy,
+	geoIP *security.GeoIPResolver,
+	travelPolicy TravelPolicy,
+	loginThrottle security.LoginThrottle,
 ) SecurityService {
 	return &securityService{
 		loginAttemptsRepo: loginAttemptsRepo,
 		suspiciousRepo:    suspiciousRepo,
 		lockoutRepo:       lockoutRepo,
 		userRepo:          userRepo,
+		mailService:       mailService,
+		frontendURL:       frontendURL,
+		policy:            policy.withDefaults(),
+		geoIP:             geoIP,
+		travelPolicy:      travelPolicy.withDefaults(),
+		loginThrottle:     loginThrottle,
 	}
 }
 
 // RecordFailedLogin records a failed login attempt and checks if the account should be locked
-func (s *securityService) RecordFailedLogin(ctx context.Context, userID int64, email, ipAddress, userAgent string) error {
-	// 1. Record in login_attempts table (for detailed tracking)
+func (s *securityService) RecordFailedLogin(ctx context.Context, userID int64, email, passwordFingerprint, ipAddress, userAgent string) (*domain.FailedLoginResult, error) {
+	// 1. Record in login_attempts table (for detailed tracking). Unknown
+	// emails are kept too, they count towards IP lockout velocity
 	failureReason := "invalid_credentials"
+	var attemptUserID *int64
+	if userID > 0 {
+		attemptUserID = &userID
+	}
 	_, err := s.loginAttemptsRepo.CreateLoginAttempt(ctx, domain.CreateLoginAttemptAction{
-		UserID:        &userID,
-		Email:         email,
-		IPAddress:     ipAddress,
-		UserAgent:     &userAgent,
-		Success:       false,
-		FailureReason: &failureReason,
+		UserID:              attemptUserID,
+		Email:               email,
+		IPAddress:           ipAddress,
+		UserAgent:           &userAgent,
+		Success:             false,
+		FailureReason:       &failureReason,
+		Location:            s.geoIP.Lookup(ipAddress),
+		PasswordFingerprint: passwordFingerprint,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 2. Check if we should create suspicious activity
-	var recentAttempts []domain.LoginAttempt
+	// 2. Count failures since the last successful login, the last lockout
+	// and the start of the failure window, whichever is latest
+	since := time.Now().Add(-s.policy.FailureWindow)
 	if userID > 0 {
-		recentAttempts, err = s.loginAttemptsRepo.GetRecentFailedAttemptsByUserID(ctx, userID)
-	} else {
-		recentAttempts, err = s.loginAttemptsRepo.GetRecentFailedAttemptsByEmail(ctx, email)
+		lockouts, err := s.lockoutRepo.GetLockoutsByUserID(ctx, userID, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(lockouts) > 0 && lockouts[0].CreatedAt != nil && lockouts[0].CreatedAt.After(since) {
+			since = *lockouts[0].CreatedAt
+		}
 	}
+
+	failures, err := s.loginAttemptsRepo.CountConsecutiveFailedLogins(ctx, email, since)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	result := &domain.FailedLoginResult{
+		FailedAttempts: failures,
+		Delay:          s.policy.failureDelay(failures),
+	}
+
+	// The wait is stored even when the client has already hung up
+	if result.Delay > 0 {
+		if err := s.loginThrottle.Delay(context.WithoutCancel(ctx), email, result.Delay, s.policy.FailureWindow); err != nil {
+			log.Printf("Warning: failed to delay login attempts: %v", err)
+		}
+	}
+
+	// Unknown emails have no account to flag or lock
+	if userID <= 0 {
+		return result, nil
 	}
 
 	// 3. Record suspicious activity if multiple failed attempts
-	if len(recentAttempts) >= 3 {
+	if failures >= 3 {
 		metadata, _ := json.Marshal(map[string]interface{}{
 			"action":          "multiple_failed_logins",
-			"failed_attempts": len(recentAttempts),
+			"failed_attempts": failures,
 			"timestamp":       time.Now().Unix(),
 		})
 
@@ -82,73 +259,170 @@ func (s *securityService) RecordFailedLogin(ctx context.Context, userID int64, e
 			ActivityType: "multiple_failed_logins",
 			IPAddress:    ipAddress,
 			UserAgent:    userAgent,
-			Description:  fmt.Sprintf("Multiple failed login attempts (%d)", len(recentAttempts)),
+			Description:  fmt.Sprintf("Multiple failed login attempts (%d)", failures),
 			Metadata:     metadata,
 			Severity:     &mediumSeverity,
 		})
+		if err != nil {
+			log.Printf("Warning: failed to record suspicious activity: %v", err)
+		}
 	}
 
-	// 4. Lock account if too many failed attempts
-	if len(recentAttempts) >= 5 {
-		lockoutDuration := 30 * time.Minute
-		expiresAt := time.Now().Add(lockoutDuration)
+	// 4. Lock account if too many failed attempts. Failures that all came
+	// from one address only lock the account for that address
+	if failures >= s.policy.MaxFailures {
+		addresses, err := s.loginAttemptsRepo.CountConsecutiveFailedLoginIPs(ctx, email, since)
+		if err != nil {
+			return result, err
+		}
 
-		_, err = s.lockoutRepo.CreateLockout(ctx, domain.CreateAccountLockoutAction{
-			UserID:      userID,
-			IPAddress:   ipAddress,
-			LockoutType: domain.LockoutTypeAccount,
-			ExpiresAt:   expiresAt.Format(time.RFC3339),
-		})
+		lockoutType := domain.LockoutTypeAccount
+		if addresses == 1 {
+			lockoutType = domain.LockoutTypeIP
+		}
+
+		result.Lockout, err = s.lockAccount(ctx, userID, ipAddress, userAgent, failures, lockoutType)
 		if err != nil {
-			return err
+			return result, err
 		}
+	}
 
-		// Record lockout as suspicious activity
-		lockoutMetadata, _ := json.Marshal(map[string]interface{}{
-			"action":           "account_locked",
-			"reason":           "too_many_failed_logins",
-			"lockout_duration": lockoutDuration.String(),
-		})
+	return result, nil
+}
 
-		highSeverity := domain.HighActivity
-		_, err = s.suspiciousRepo.CreateActivity(ctx, domain.CreateSuspiciousActivityAction{
-			UserID:       userID,
-			ActivityType: "account_locked",
-			IPAddress:    ipAddress,
-			UserAgent:    userAgent,
-			Description:  "Account locked due to multiple failed login attempts",
-			Metadata:     lockoutMetadata,
-			Severity:     &highSeverity,
-		})
+// lockAccount escalates with every lockout in the escalation window. It
+// returns nil without error when a concurrent failure locked the account first.
+func (s *securityService) lockAccount(ctx context.Context, userID int64, ipAddress, userAgent string, failures int64, lockoutType domain.LockoutType) (*domain.AccountLockout, error) {
+	if err := s.lockoutRepo.CloseExpiredLockouts(ctx, userID); err != nil {
+		return nil, err
 	}
 
-	return err
+	now := time.Now()
+	previous, err := s.lockoutRepo.CountRecentLockouts(ctx, userID, now.Add(-s.policy.EscalationWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	level := previous + 1
+	permanent := s.policy.PermanentAfter > 0 && level >= s.policy.PermanentAfter
+	lockoutDuration := s.policy.lockoutDuration(level)
+	// Permanent lockouts ignore expires_at, it only records when they began
+	expiresAt := now.Add(lockoutDuration)
+	reason := fmt.Sprintf("%d consecutive failed logins", failures)
+
+	var unlockToken, unlockTokenHash string
+	if permanent {
+		expiresAt = now
+		unlockToken, err = generateRandomHex(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate unlock token: %w", err)
+		}
+		unlockTokenHash = hashUnlockToken(unlockToken)
+	}
+
+	lockout, err := s.lockoutRepo.CreateLockout(ctx, domain.CreateAccountLockoutAction{
+		UserID:          userID,
+		IPAddress:       ipAddress,
+		LockoutType:     lockoutType,
+		ExpiresAt:       expiresAt.Format(time.RFC3339),
+		Level:           int32(level),
+		Permanent:       permanent,
+		Reason:          reason,
+		UnlockTokenHash: unlockTokenHash,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Record lockout as suspicious activity
+	lockoutMetadata := map[string]interface{}{
+		"action":       "account_locked",
+		"reason":       "too_many_failed_logins",
+		"level":        level,
+		"permanent":    permanent,
+		"lockout_type": lockoutType,
+	}
+	severity := domain.HighActivity
+	description := fmt.Sprintf("Account locked due to multiple failed login attempts (lockout %d)", level)
+	if permanent {
+		severity = domain.CriticalActivity
+		description = "Account locked until unlocked by an administrator or by email"
+	} else {
+		lockoutMetadata["lockout_duration"] = lockoutDuration.String()
+	}
+	metadata, _ := json.Marshal(lockoutMetadata)
+
+	_, err = s.suspiciousRepo.CreateActivity(ctx, domain.CreateSuspiciousActivityAction{
+		UserID:       userID,
+		ActivityType: "account_locked",
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Description:  description,
+		Metadata:     metadata,
+		Severity:     &severity,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record suspicious activity: %v", err)
+	}
+
+	if permanent {
+		if err := s.sendUnlockEmail(ctx, userID, unlockToken); err != nil {
+			log.Printf("Warning: failed to send unlock email to user %d: %v", userID, err)
+		}
+	}
+
+	return lockout, nil
 }
 
-func (s *securityService) RecordSuccessfulLogin(ctx context.Context, userID int64, email, ipAddress, userAgent string) error {
-	// 1. Record the successful login attempt
+func (s *securityService) ReserveLoginAttempt(ctx context.Context, email string) (time.Duration, error) {
+	return s.loginThrottle.Reserve(ctx, email)
+}
+
+func (s *securityService) RecordSuccessfulLogin(ctx context.Context, userID int64, email, ipAddress, userAgent string) (*domain.SuccessfulLoginResult, error) {
+	location := s.geoIP.Lookup(ipAddress)
+
+	// 1. Look at earlier logins before this one joins them
+	previous, countries := s.loginHistory(ctx, userID, location)
+
+	// 2. Record the successful login attempt
 	_, err := s.loginAttemptsRepo.CreateLoginAttempt(ctx, domain.CreateLoginAttemptAction{
 		UserID:    &userID,
 		Email:     email,
 		IPAddress: ipAddress,
 		UserAgent: &userAgent,
 		Success:   true,
+		Location:  location,
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := s.loginThrottle.Reset(ctx, email); err != nil {
+		log.Printf("Warning: failed to reset login delay: %v", err)
+	}
+
+	// 3. Flag impossible travel and never-seen countries
+	s.detectLocationAnomalies(ctx, userID, ipAddress, userAgent, location, previous, countries)
+	result := &domain.SuccessfulLoginResult{
+		Location:    location,
+		NewLocation: countries != nil && countries.Located > 0 && countries.FromCountry == 0,
 	}
 
-	// 2. Get user to check if this is a new device/location
+	// 4. Get user to check if this is a new device/location
 	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 3. Record successful login as suspicious activity (for monitoring)
+	// 5. Record successful login as suspicious activity (for monitoring)
 	metadata, _ := json.Marshal(map[string]interface{}{
 		"action":     "successful_login",
 		"timestamp":  time.Now().Unix(),
 		"last_login": user.LastLoginAt,
+		"location":   location,
 	})
 
 	lowSeverity := domain.LowActivity
@@ -161,30 +435,250 @@ func (s *securityService) RecordSuccessfulLogin(ctx context.Context, userID int6
 		Metadata:     metadata,
 		Severity:     &lowSeverity,
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	return result, nil
+}
+
+// loginHistory returns the user's last located login and their country
+// history, whichever location makes comparable. Lookup failures only cost
+// the detection, never the login.
+func (s *securityService) loginHistory(ctx context.Context, userID int64, location *domain.GeoLocation) (*domain.LoginAttempt, *domain.LoginCountryHistory) {
+	if location == nil {
+		return nil, nil
+	}
+
+	var previous *domain.LoginAttempt
+	if location.HasCoordinates {
+		attempt, err := s.loginAttemptsRepo.GetLastLocatedLogin(ctx, userID)
+		if err == nil {
+			previous = attempt
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Warning: failed to load last located login: %v", err)
+		}
+	}
+
+	var countries *domain.LoginCountryHistory
+	if location.CountryCode != "" {
+		history, err := s.loginAttemptsRepo.GetLoginCountryHistory(ctx, userID, location.CountryCode)
+		if err != nil {
+			log.Printf("Warning: failed to load login country history: %v", err)
+		} else {
+			countries = history
+		}
+	}
+
+	return previous, countries
+}
+
+func (s *securityService) detectLocationAnomalies(
+	ctx context.Context,
+	userID int64,
+	ipAddress, userAgent string,
+	location *domain.GeoLocation,
+	previous *domain.LoginAttempt,
+	countries *domain.LoginCountryHistory,
+) {
+	highSeverity := domain.HighActivity
+	now := time.Now()
+
+	if previous != nil && previous.Location != nil && previous.Location.HasCoordinates && previous.CreatedAt != nil {
+		distance := previous.Location.DistanceKm(location)
+		// A floor of one minute keeps back-to-back logins from dividing by zero
+		elapsed := math.Max(now.Sub(*previous.CreatedAt).Hours(), 1.0/60)
+		speed := distance / elapsed
+
+		if distance >= s.travelPolicy.MinDistanceKm && speed > s.travelPolicy.MaxSpeedKmh {
+			metadata, _ := json.Marshal(map[string]interface{}{
+				"action":        "impossible_travel",
+				"from":          previous.Location,
+				"from_ip":       previous.IPAddress,
+				"from_time":     previous.CreatedAt,
+				"to":            location,
+				"distance_km":   math.Round(distance),
+				"elapsed":       now.Sub(*previous.CreatedAt).Round(time.Second).String(),
+				"speed_kmh":     math.Round(speed),
+				"max_speed_kmh": s.travelPolicy.MaxSpeedKmh,
+			})
+
+			_, err := s.suspiciousRepo.CreateActivity(ctx, domain.CreateSuspiciousActivityAction{
+				UserID:       userID,
+				ActivityType: "impossible_travel",
+				IPAddress:    ipAddress,
+				UserAgent:    userAgent,
+				Description:  fmt.Sprintf("Login from %s, %.0f km from the previous login in %s", location, distance, previous.Location),
+				Metadata:     metadata,
+				Severity:     &highSeverity,
+			})
+			if err != nil {
+				log.Printf("Warning: failed to record suspicious activity: %v", err)
+			}
+		}
+	}
+
+	// A user with no located logins yet has no countries to compare against
+	if countries != nil && countries.Located > 0 && countries.FromCountry == 0 {
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"action":   "new_country",
+			"location": location,
+		})
+
+		_, err := s.suspiciousRepo.CreateActivity(ctx, domain.CreateSuspiciousActivityAction{
+			UserID:       userID,
+			ActivityType: "new_country",
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+			Description:  fmt.Sprintf("Login from a country not seen before: %s", location),
+			Metadata:     metadata,
+			Severity:     &highSeverity,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to record suspicious activity: %v", err)
+		}
+	}
 }
 
 func (s *securityService) CheckAccountLockout(ctx context.Context, userID int64, ipAddress string) error {
-	// Check if user is locked out
-	lockout, err := s.lockoutRepo.GetLockoutByUserID(ctx, userID)
+	// Only open, unexpired lockouts that cover ipAddress are returned
+	lockout, err := s.lockoutRepo.GetLockoutForLogin(ctx, userID, ipAddress)
 	if err != nil {
 		// No lockout found, user can proceed
 		return nil
 	}
 
-	// Check if lockout has expired
-	if time.Now().After(lockout.ExpiresAt) {
-		// Lockout expired, remove it
-		err = s.lockoutRepo.DeleteLockoutByID(ctx, lockout.ID)
-		if err != nil {
-			return err
-		}
-		return nil
+	if lockout.Permanent {
+		return fmt.Errorf("%w: use the unlock link sent by email or contact an administrator", ErrAccountLocked)
 	}
 
 	// User is still locked out
-	return fmt.Errorf("account is locked until %s", lockout.ExpiresAt.Format(time.RFC3339))
+	return fmt.Errorf("%w until %s", ErrAccountLocked, lockout.ExpiresAt.Format(time.RFC3339))
+}
+
+func (s *securityService) UnlockAccount(ctx context.Context, userID, adminID int64) ([]domain.AccountLockout, error) {
+	if err := s.lockoutRepo.CloseExpiredLockouts(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.lockoutRepo.UnlockAccount(ctx, domain.UnlockAccountAction{
+		UserID:       userID,
+		UnlockedBy:   &adminID,
+		UnlockMethod: domain.UnlockMethodAdmin,
+	})
+}
+
+func (s *securityService) UnlockAccountWithToken(ctx context.Context, token string) (*domain.AccountLockout, error) {
+	if token == "" {
+		return nil, ErrInvalidUnlockToken
+	}
+
+	lockout, err := s.lockoutRepo.GetLockoutByUnlockTokenHash(ctx, hashUnlockToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidUnlockToken
+		}
+		return nil, err
+	}
+
+	if err := s.lockoutRepo.CloseExpiredLockouts(ctx, lockout.UserID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.lockoutRepo.UnlockAccount(ctx, domain.UnlockAccountAction{
+		UserID:       lockout.UserID,
+		UnlockMethod: domain.UnlockMethodEmail,
+	}); err != nil {
+		return nil, err
+	}
+
+	return lockout, nil
+}
+
+func (s *securityService) LockAccount(ctx context.Context, userID int64, ipAddress, userAgent, reason string) (*domain.AccountLockout, error) {
+	if err := s.lockoutRepo.CloseExpiredLockouts(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	previous, err := s.lockoutRepo.CountRecentLockouts(ctx, userID, now.Add(-s.policy.EscalationWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	// No unlock token: the way back in is a password reset, not a link that
+	// may have gone to whoever holds the account
+	lockout, err := s.lockoutRepo.CreateLockout(ctx, domain.CreateAccountLockoutAction{
+		UserID:      userID,
+		IPAddress:   ipAddress,
+		LockoutType: domain.LockoutTypeBoth,
+		ExpiresAt:   now.Format(time.RFC3339),
+		Level:       int32(previous + 1),
+		Permanent:   true,
+		Reason:      reason,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"action":    "account_locked",
+		"reason":    reason,
+		"level":     lockout.Level,
+		"permanent": true,
+	})
+	criticalSeverity := domain.CriticalActivity
+	_, err = s.suspiciousRepo.CreateActivity(ctx, domain.CreateSuspiciousActivityAction{
+		UserID:       userID,
+		ActivityType: "account_locked",
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		Description:  fmt.Sprintf("Account locked: %s", reason),
+		Metadata:     metadata,
+		Severity:     &criticalSeverity,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record suspicious activity: %v", err)
+	}
+
+	return lockout, nil
+}
+
+func (s *securityService) GetLockoutHistory(ctx context.Context, userID int64) ([]domain.AccountLockout, error) {
+	return s.lockoutRepo.GetLockoutsByUserID(ctx, userID, 50)
+}
+
+func hashUnlockToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *securityService) sendUnlockEmail(ctx context.Context, userID int64, token string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	unlockURL := fmt.Sprintf("%s/unlock-account?token=%s", s.frontendURL, token)
+	content := fmt.Sprintf(`
+Your account has been locked
+
+We locked your account after repeated failed sign-in attempts. It will stay
+locked until you unlock it with the link below or an administrator unlocks it:
+
+%s
+
+If these attempts were not you, consider resetting your password once your
+account is unlocked.
+
+Best regards,
+The whoami Team
+`, unlockURL)
+
+	return s.mailService.SendMail("whoami@sebastijanzindl.me", user.Email, "Your account has been locked", content)
 }
 
 func (s *securityService) RecordSuspiciousActivity(ctx context.Context, req domain.CreateSuspiciousActivityAction) error {
@@ -196,10 +690,11 @@ func (s *securityService) GetSuspiciousActivities(ctx context.Context, userID in
 	return s.suspiciousRepo.GetActivitiesByUserID(ctx, userID, 50)
 }
 
-func (s *securityService) ResolveSuspiciousActivity(ctx context.Context, activityID int64) error {
-	return s.suspiciousRepo.ResolveActivity(ctx, activityID)
-}
-
+// CleanupExpiredLockouts prunes lockout history past its retention. Open
+// lockouts are never removed.
 func (s *securityService) CleanupExpiredLockouts(ctx context.Context) error {
-	return s.lockoutRepo.DeleteExpiredLockouts(ctx)
+	if s.policy.HistoryRetention <= 0 {
+		return nil
+	}
+	return s.lockoutRepo.DeleteLockoutHistoryBefore(ctx, time.Now().Add(-s.policy.HistoryRetention))
 }
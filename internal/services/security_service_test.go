@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+func TestLockoutPolicyLockoutDuration(t *testing.T) {
+	policy := LockoutPolicy{
+		BaseDuration: 30 * time.Minute,
+		MaxDuration:  4 * time.Hour,
+	}.withDefaults()
+
+	tests := []struct {
+		level int64
+		want  time.Duration
+	}{
+		{level: 1, want: 30 * time.Minute},
+		{level: 2, want: time.Hour},
+		{level: 3, want: 2 * time.Hour},
+		{level: 4, want: 4 * time.Hour},
+		{level: 5, want: 4 * time.Hour},
+		{level: 60, want: 4 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := policy.lockoutDuration(tt.level); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestLockoutPolicyMaxDurationBelowBase(t *testing.T) {
+	policy := LockoutPolicy{
+		BaseDuration: time.Hour,
+		MaxDuration:  time.Minute,
+	}.withDefaults()
+
+	for _, level := range []int64{1, 2, 10} {
+		if got := policy.lockoutDuration(level); got != time.Hour {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", level, got, time.Hour)
+		}
+	}
+}
+
+func TestLockoutPolicyFailureDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   LockoutPolicy
+		failures int64
+		want     time.Duration
+	}{
+		{
+			name:     "delays disabled",
+			policy:   LockoutPolicy{DelayAfter: 1},
+			failures: 10,
+			want:     0,
+		},
+		{
+			name:     "at the threshold",
+			policy:   LockoutPolicy{DelayAfter: 3, BaseDelay: time.Second, MaxDelay: 8 * time.Second},
+			failures: 3,
+			want:     0,
+		},
+		{
+			name:     "first delayed failure",
+			policy:   LockoutPolicy{DelayAfter: 3, BaseDelay: time.Second, MaxDelay: 8 * time.Second},
+			failures: 4,
+			want:     time.Second,
+		},
+		{
+			name:     "doubles per failure",
+			policy:   LockoutPolicy{DelayAfter: 3, BaseDelay: time.Second, MaxDelay: 8 * time.Second},
+			failures: 6,
+			want:     4 * time.Second,
+		},
+		{
+			name:     "capped at MaxDelay",
+			policy:   LockoutPolicy{DelayAfter: 3, BaseDelay: time.Second, MaxDelay: 8 * time.Second},
+			failures: 50,
+			want:     8 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.withDefaults().failureDelay(tt.failures); got != tt.want {
+				t.Errorf("failureDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingSuspiciousActivity keeps the activity types it was asked to create.
+type recordingSuspiciousActivity struct {
+	repositories.SuspiciousActivityRepository
+	types []string
+}
+
+func (r *recordingSuspiciousActivity) CreateActivity(ctx context.Context, req domain.CreateSuspiciousActivityAction) (*domain.SuspiciousActivity, error) {
+	r.types = append(r.types, req.ActivityType)
+	return &domain.SuspiciousActivity{}, nil
+}
+
+func TestDetectLocationAnomalies(t *testing.T) {
+	london := &domain.GeoLocation{CountryCode: "GB", Latitude: 51.5074, Longitude: -0.1278, HasCoordinates: true}
+	paris := &domain.GeoLocation{CountryCode: "FR", Latitude: 48.8566, Longitude: 2.3522, HasCoordinates: true}
+	newYork := &domain.GeoLocation{CountryCode: "US", Latitude: 40.7128, Longitude: -74.0060, HasCoordinates: true}
+	unlocated := &domain.GeoLocation{CountryCode: "US"}
+
+	// London to New York is about 5570 km
+	tests := []struct {
+		name      string
+		from      *domain.GeoLocation
+		ago       time.Duration
+		to        *domain.GeoLocation
+		countries *domain.LoginCountryHistory
+		want      []string
+	}{
+		{
+			name: "faster than a plane",
+			from: london, ago: 2 * time.Hour, to: newYork,
+			want: []string{"impossible_travel"},
+		},
+		{
+			name: "slow enough to have flown",
+			from: london, ago: 7 * time.Hour, to: newYork,
+		},
+		{
+			name: "short hop ignored however fast",
+			from: london, ago: time.Minute, to: paris,
+		},
+		{
+			name: "back to back logins do not divide by zero",
+			from: london, ago: 0, to: newYork,
+			want: []string{"impossible_travel"},
+		},
+		{
+			name: "previous login without coordinates",
+			from: unlocated, ago: time.Minute, to: london,
+		},
+		{
+			name: "never seen country",
+			from: london, ago: 24 * time.Hour, to: newYork,
+			countries: &domain.LoginCountryHistory{Located: 5},
+			want:      []string{"new_country"},
+		},
+		{
+			name: "known country",
+			from: london, ago: 24 * time.Hour, to: newYork,
+			countries: &domain.LoginCountryHistory{Located: 5, FromCountry: 1},
+		},
+		{
+			name: "first located login",
+			from: london, ago: 24 * time.Hour, to: newYork,
+			countries: &domain.LoginCountryHistory{},
+		},
+		{
+			name: "impossible travel to a new country",
+			from: london, ago: time.Hour, to: newYork,
+			countries: &domain.LoginCountryHistory{Located: 5},
+			want:      []string{"impossible_travel", "new_country"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suspicious := &recordingSuspiciousActivity{}
+			service := &securityService{
+				suspiciousRepo: suspicious,
+				travelPolicy:   TravelPolicy{}.withDefaults(),
+			}
+
+			createdAt := time.Now().Add(-tt.ago)
+			previous := &domain.LoginAttempt{IPAddress: "203.0.113.5", Location: tt.from, CreatedAt: &createdAt}
+			service.detectLocationAnomalies(context.Background(), 1, "198.51.100.7", "test", tt.to, previous, tt.countries)
+
+			if len(suspicious.types) != len(tt.want) {
+				t.Fatalf("recorded %v, want %v", suspicious.types, tt.want)
+			}
+			for i := range tt.want {
+				if suspicious.types[i] != tt.want[i] {
+					t.Errorf("recorded %v, want %v", suspicious.types, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTravelPolicyThresholds(t *testing.T) {
+	london := &domain.GeoLocation{Latitude: 51.5074, Longitude: -0.1278, HasCoordinates: true}
+	paris := &domain.GeoLocation{Latitude: 48.8566, Longitude: 2.3522, HasCoordinates: true}
+
+	// London to Paris is about 344 km, covered here in an hour
+	tests := []struct {
+		name   string
+		policy TravelPolicy
+		want   bool
+	}{
+		{name: "default minimum distance ignores it", policy: TravelPolicy{}, want: false},
+		{name: "lower minimum distance", policy: TravelPolicy{MinDistanceKm: 100}, want: false},
+		{name: "lower speed limit", policy: TravelPolicy{MinDistanceKm: 100, MaxSpeedKmh: 300}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suspicious := &recordingSuspiciousActivity{}
+			service := &securityService{suspiciousRepo: suspicious, travelPolicy: tt.policy.withDefaults()}
+
+			createdAt := time.Now().Add(-time.Hour)
+			previous := &domain.LoginAttempt{Location: london, CreatedAt: &createdAt}
+			service.detectLocationAnomalies(context.Background(), 1, "198.51.100.7", "test", paris, previous, nil)
+
+			if got := len(suspicious.types) > 0; got != tt.want {
+				t.Errorf("impossible travel flagged = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
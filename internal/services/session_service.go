@@ -4,18 +4,23 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
 	"github.com/m1thrandir225/whoami/internal/security"
-	"github.com/redis/go-redis/v9"
 )
 
 // SessionService is responsible for managing the user active sessions
 type SessionService interface {
-	CreateSession(ctx context.Context, userID int64, accessToken, refreshToken string, deviceInfo map[string]string) error
+	// CreateSession returns any sessions evicted to stay within the user's
+	// max active sessions limit.
+	CreateSession(ctx context.Context, userID int64, role string, accessToken, refreshToken string, deviceInfo map[string]string) ([]domain.Session, error)
 	GetSession(ctx context.Context, token string) (*domain.Session, error)
 	GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, error)
 	GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error)
@@ -28,112 +33,314 @@ type SessionService interface {
 	CleanupExpiredSessions(ctx context.Context) error
 }
 
+var (
+	ErrSessionExpired      = errors.New("session has expired")
+	ErrSessionLimitReached = errors.New("maximum number of active sessions reached")
+	// ErrSessionNotFound means the session was revoked or has expired
+	ErrSessionNotFound = repositories.ErrSessionNotFound
+)
+
+// activityRefreshInterval throttles how often UpdateSessionActivity writes to
+// the session store, so authenticated requests usually cost a single read.
+const activityRefreshInterval = time.Minute
+
+// Logins of one user are serialized while the session limit is enforced, so
+// two concurrent logins cannot both see room for one more session.
+const (
+	sessionLimitLockTTL  = 10 * time.Second
+	sessionLimitLockWait = 5 * time.Second
+)
+
+// SessionTimeouts bounds a session's lifetime. Idle is the longest allowed gap
+// between requests (zero disables it); Absolute is the hard limit from login.
+type SessionTimeouts struct {
+	Idle     time.Duration
+	Absolute time.Duration
+}
+
+type SessionLimitPolicy string
+
+const (
+	// SessionLimitReject refuses new logins once the limit is reached
+	SessionLimitReject SessionLimitPolicy = "reject"
+	// SessionLimitEvictOldest signs out the least recently active session
+	SessionLimitEvictOldest SessionLimitPolicy = "evict_oldest"
+)
+
+// SessionLimits caps concurrent sessions per user. A MaxActive of zero means
+// unlimited; RoleMaxActive overrides it for specific roles.
+type SessionLimits struct {
+	MaxActive     int
+	RoleMaxActive map[string]int
+	Policy        SessionLimitPolicy
+}
+
 type sessionService struct {
-	redisClient    *redis.Client
+	sessionStore   repositories.SessionStore
 	tokenBlacklist security.TokenBlacklist
+	timeouts       SessionTimeouts
+	roleTimeouts   map[string]SessionTimeouts
+	limits         SessionLimits
+	locker         security.Locker
+	logoutNotifier LogoutNotifier
 }
 
-func NewSessionService(redisClient *redis.Client, tokenBlacklist security.TokenBlacklist) SessionService {
+func NewSessionService(
+	sessionStore repositories.SessionStore,
+	tokenBlacklist security.TokenBlacklist,
+	timeouts SessionTimeouts,
+	roleTimeouts map[string]SessionTimeouts,
+	limits SessionLimits,
+	locker security.Locker,
+	logoutNotifier LogoutNotifier,
+) SessionService {
 	return &sessionService{
-		redisClient:    redisClient,
+		sessionStore:   sessionStore,
 		tokenBlacklist: tokenBlacklist,
+		timeouts:       timeouts,
+		roleTimeouts:   roleTimeouts,
+		limits:         limits,
+		locker:         locker,
+		logoutNotifier: logoutNotifier,
 	}
 }
 
-func generateSessionID() string {
-	bytes := make([]byte, 16)
-	rand.Read(bytes)
-	return hex.EncodeToString(bytes)
+// ParseRoleSessionTimeouts parses per-role overrides in the form
+// "admin=15m/8h,moderator=30m/24h" where each value is idle/absolute.
+func ParseRoleSessionTimeouts(raw string) (map[string]SessionTimeouts, error) {
+	roleTimeouts := make(map[string]SessionTimeouts)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		role, value, found := strings.Cut(entry, "=")
+		idle, absolute, hasAbsolute := strings.Cut(value, "/")
+		if !found || !hasAbsolute {
+			return nil, fmt.Errorf("invalid session timeout override %q, expected role=idle/absolute", entry)
+		}
+
+		idleTimeout, err := time.ParseDuration(strings.TrimSpace(idle))
+		if err != nil {
+			return nil, fmt.Errorf("invalid idle timeout for role %s: %w", role, err)
+		}
+
+		absoluteTimeout, err := time.ParseDuration(strings.TrimSpace(absolute))
+		if err != nil {
+			return nil, fmt.Errorf("invalid absolute timeout for role %s: %w", role, err)
+		}
+
+		roleTimeouts[strings.TrimSpace(role)] = SessionTimeouts{
+			Idle:     idleTimeout,
+			Absolute: absoluteTimeout,
+		}
+	}
+
+	return roleTimeouts, nil
 }
 
-func (s *sessionService) CreateSession(ctx context.Context, userID int64, accessToken, refreshToken string, deviceInfo map[string]string) error {
-	sessionID := generateSessionID()
+// ParseRoleSessionLimits parses per-role max active sessions in the form
+// "admin=1,user=3".
+func ParseRoleSessionLimits(raw string) (map[string]int, error) {
+	roleLimits := make(map[string]int)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
 
-	session := &domain.Session{
-		ID:           sessionID,
-		UserID:       userID,
-		Token:        accessToken,
-		RefreshToken: refreshToken,
-		DeviceInfo:   deviceInfo,
-		IPAddress:    deviceInfo["ip_address"],
-		UserAgent:    deviceInfo["user_agent"],
-		CreatedAt:    time.Now(),
-		LastActive:   time.Now(),
-		IsActive:     true,
-	}
-
-	sessionData, err := json.Marshal(session)
+		role, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid session limit override %q, expected role=max", entry)
+		}
+
+		maxActive, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || maxActive < 0 {
+			return nil, fmt.Errorf("invalid max active sessions for role %s: %q", role, value)
+		}
+
+		roleLimits[strings.TrimSpace(role)] = maxActive
+	}
+
+	return roleLimits, nil
+}
+
+func (s *sessionService) maxActiveForRole(role string) int {
+	if maxActive, ok := s.limits.RoleMaxActive[role]; ok {
+		return maxActive
+	}
+	return s.limits.MaxActive
+}
+
+// enforceSessionLimit makes room for one more session, either by rejecting the
+// login or by evicting the least recently active sessions. Callers hold the
+// user's session limit lock until the new session is stored.
+func (s *sessionService) enforceSessionLimit(ctx context.Context, userID int64, role string) ([]domain.Session, error) {
+	maxActive := s.maxActiveForRole(role)
+	if maxActive <= 0 {
+		return nil, nil
+	}
+
+	sessions, err := s.GetUserSessions(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal session data: %w", err)
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
 	}
 
-	expiration := 7 * 24 * time.Hour // 7 days
+	now := time.Now()
+	active := make([]domain.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.ExpiresAt.IsZero() || now.Before(session.ExpiresAt) {
+			active = append(active, session)
+		}
+	}
 
-	// Store session by ID (primary key)
-	sessionIDKey := fmt.Sprintf("session:id:%s", sessionID)
-	if err := s.redisClient.Set(ctx, sessionIDKey, sessionData, expiration).Err(); err != nil {
-		return fmt.Errorf("failed to store session by ID: %w", err)
+	if len(active) < maxActive {
+		return nil, nil
 	}
 
-	// Store session by access token (for quick lookup)
-	accessTokenKey := fmt.Sprintf("session:token:%s", accessToken)
-	if err := s.redisClient.Set(ctx, accessTokenKey, sessionID, expiration).Err(); err != nil {
-		return fmt.Errorf("failed to store session by access token: %w", err)
+	if s.limits.Policy != SessionLimitEvictOldest {
+		return nil, ErrSessionLimitReached
 	}
 
-	// Store session by refresh token (for refresh operations)
-	refreshTokenKey := fmt.Sprintf("session:refresh:%s", refreshToken)
-	if err := s.redisClient.Set(ctx, refreshTokenKey, sessionID, expiration).Err(); err != nil {
-		return fmt.Errorf("failed to store session by refresh token: %w", err)
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].LastActive.Before(active[j].LastActive)
+	})
+
+	var evicted []domain.Session
+	for _, session := range active[:len(active)-maxActive+1] {
+		if err := s.sessionStore.DeleteSession(ctx, &session); err != nil {
+			fmt.Printf("Warning: failed to evict session %s: %v\n", session.ID, err)
+			continue
+		}
+
+		if err := s.tokenBlacklist.BlacklistTokenHash(ctx, session.TokenHash, 24*time.Hour, security.BlacklistReasonSignedOutElsewhere); err != nil {
+			fmt.Printf("Warning: failed to blacklist evicted access token: %v\n", err)
+		}
+		if err := s.tokenBlacklist.BlacklistTokenHash(ctx, session.RefreshTokenHash, 24*time.Hour, security.BlacklistReasonSignedOutElsewhere); err != nil {
+			fmt.Printf("Warning: failed to blacklist evicted refresh token: %v\n", err)
+		}
+		s.notifyLogout(ctx, &session)
+
+		evicted = append(evicted, session)
 	}
 
-	// Add to user's active sessions
-	userSessionsKey := fmt.Sprintf("user_sessions:%d", userID)
-	if err := s.redisClient.SAdd(ctx, userSessionsKey, sessionID).Err(); err != nil {
-		return fmt.Errorf("failed to add session to user sessions: %w", err)
+	return evicted, nil
+}
+
+func (s *sessionService) timeoutsForRole(role string) SessionTimeouts {
+	if timeouts, ok := s.roleTimeouts[role]; ok {
+		return timeouts
 	}
+	return s.timeouts
+}
 
-	// Set expiration for user sessions set
-	s.redisClient.Expire(ctx, userSessionsKey, expiration)
+// nextExpiry slides the idle deadline forward from now without ever passing
+// the session's absolute deadline.
+func nextExpiry(session *domain.Session, now time.Time) time.Time {
+	if session.AbsoluteExpiresAt.IsZero() {
+		return session.ExpiresAt
+	}
+	if session.IdleTimeout <= 0 {
+		return session.AbsoluteExpiresAt
+	}
 
-	return nil
+	idleExpiry := now.Add(session.IdleTimeout)
+	if idleExpiry.After(session.AbsoluteExpiresAt) {
+		return session.AbsoluteExpiresAt
+	}
+	return idleExpiry
 }
 
-func (s *sessionService) GetSession(ctx context.Context, token string) (*domain.Session, error) {
-	// First try to get session ID from access token
-	accessTokenKey := fmt.Sprintf("session:token:%s", token)
-	sessionID, err := s.redisClient.Get(ctx, accessTokenKey).Result()
+// checkExpired revokes a session the store still returned but whose deadline
+// has passed, e.g. a Redis entry that has not been evicted yet.
+func (s *sessionService) checkExpired(ctx context.Context, session *domain.Session) error {
+	if session.ExpiresAt.IsZero() || time.Now().Before(session.ExpiresAt) {
+		return nil
+	}
+
+	// The session is revoked from the copy in hand, the stores no longer
+	// return it by ID once it has expired
+	if err := s.revokeSession(ctx, session); err != nil {
+		fmt.Printf("Warning: failed to revoke expired session %s: %v\n", session.ID, err)
+	}
+	return ErrSessionExpired
+}
+
+func generateSessionID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+func (s *sessionService) CreateSession(ctx context.Context, userID int64, role string, accessToken, refreshToken string, deviceInfo map[string]string) ([]domain.Session, error) {
+	if s.maxActiveForRole(role) > 0 {
+		unlock, err := s.locker.Lock(ctx, fmt.Sprintf("session_limit:%d", userID), sessionLimitLockTTL, sessionLimitLockWait)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock user sessions: %w", err)
+		}
+		defer unlock()
+	}
+
+	evicted, err := s.enforceSessionLimit(ctx, userID, role)
 	if err != nil {
-		return nil, fmt.Errorf("session not found for token: %w", err)
+		return nil, err
 	}
 
-	return s.GetSessionByID(ctx, sessionID)
+	now := time.Now()
+	timeouts := s.timeoutsForRole(role)
+
+	session := &domain.Session{
+		ID:                generateSessionID(),
+		UserID:            userID,
+		TokenHash:         security.HashToken(accessToken),
+		RefreshTokenHash:  security.HashToken(refreshToken),
+		DeviceInfo:        deviceInfo,
+		IPAddress:         deviceInfo["ip_address"],
+		UserAgent:         deviceInfo["user_agent"],
+		CreatedAt:         now,
+		LastActive:        now,
+		AbsoluteExpiresAt: now.Add(timeouts.Absolute),
+		IdleTimeout:       timeouts.Idle,
+		IsActive:          true,
+	}
+	session.ExpiresAt = nextExpiry(session, now)
+
+	if err := s.sessionStore.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return evicted, nil
 }
 
-func (s *sessionService) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, error) {
-	// Get session ID from refresh token
-	refreshTokenKey := fmt.Sprintf("session:refresh:%s", refreshToken)
-	sessionID, err := s.redisClient.Get(ctx, refreshTokenKey).Result()
+func (s *sessionService) GetSession(ctx context.Context, token string) (*domain.Session, error) {
+	session, err := s.sessionStore.GetSessionByAccessTokenHash(ctx, security.HashToken(token))
 	if err != nil {
-		return nil, fmt.Errorf("session not found for refresh token: %w", err)
+		return nil, err
+	}
+
+	if err := s.checkExpired(ctx, session); err != nil {
+		return nil, err
 	}
 
-	return s.GetSessionByID(ctx, sessionID)
+	return session, nil
 }
 
-func (s *sessionService) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
-	sessionIDKey := fmt.Sprintf("session:id:%s", sessionID)
-	sessionData, err := s.redisClient.Get(ctx, sessionIDKey).Result()
+func (s *sessionService) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*domain.Session, error) {
+	session, err := s.sessionStore.GetSessionByRefreshTokenHash(ctx, security.HashToken(refreshToken))
 	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+		return nil, err
 	}
 
-	var session domain.Session
-	if err := json.Unmarshal([]byte(sessionData), &session); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
+	if err := s.checkExpired(ctx, session); err != nil {
+		return nil, err
 	}
 
-	return &session, nil
+	return session, nil
+}
+
+func (s *sessionService) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
+	return s.sessionStore.GetSessionByID(ctx, sessionID)
 }
 
 func (s *sessionService) UpdateSessionTokens(ctx context.Context, sessionID, newAccessToken, newRefreshToken string) error {
@@ -143,50 +350,29 @@ func (s *sessionService) UpdateSessionTokens(ctx context.Context, sessionID, new
 		return fmt.Errorf("failed to get session: %w", err)
 	}
 
+	if err := s.checkExpired(ctx, session); err != nil {
+		return err
+	}
+
 	// Blacklist old tokens
-	if err := s.tokenBlacklist.BlacklistToken(ctx, session.Token, 24*time.Hour); err != nil {
+	if err := s.tokenBlacklist.BlacklistTokenHash(ctx, session.TokenHash, 24*time.Hour, "blacklisted"); err != nil {
 		fmt.Printf("Warning: failed to blacklist old access token: %v\n", err)
 	}
-	if err := s.tokenBlacklist.BlacklistToken(ctx, session.RefreshToken, 24*time.Hour); err != nil {
+	if err := s.tokenBlacklist.BlacklistTokenHash(ctx, session.RefreshTokenHash, 24*time.Hour, "blacklisted"); err != nil {
 		fmt.Printf("Warning: failed to blacklist old refresh token: %v\n", err)
 	}
 
-	// Remove old token mappings
-	oldAccessTokenKey := fmt.Sprintf("session:token:%s", session.Token)
-	oldRefreshTokenKey := fmt.Sprintf("session:refresh:%s", session.RefreshToken)
-	s.redisClient.Del(ctx, oldAccessTokenKey, oldRefreshTokenKey)
+	previousAccessTokenHash := session.TokenHash
+	previousRefreshTokenHash := session.RefreshTokenHash
 
 	// Update session with new tokens
-	session.Token = newAccessToken
-	session.RefreshToken = newRefreshToken
-	session.LastActive = time.Now()
-
-	sessionData, err := json.Marshal(session)
-	if err != nil {
-		return fmt.Errorf("failed to marshal updated session: %w", err)
-	}
-
-	expiration := 7 * 24 * time.Hour
-
-	// Update session by ID
-	sessionIDKey := fmt.Sprintf("session:id:%s", sessionID)
-	if err := s.redisClient.Set(ctx, sessionIDKey, sessionData, expiration).Err(); err != nil {
-		return fmt.Errorf("failed to update session: %w", err)
-	}
-
-	// Create new token mappings
-	newAccessTokenKey := fmt.Sprintf("session:token:%s", newAccessToken)
-	newRefreshTokenKey := fmt.Sprintf("session:refresh:%s", newRefreshToken)
-
-	if err := s.redisClient.Set(ctx, newAccessTokenKey, sessionID, expiration).Err(); err != nil {
-		return fmt.Errorf("failed to create new access token mapping: %w", err)
-	}
+	now := time.Now()
+	session.TokenHash = security.HashToken(newAccessToken)
+	session.RefreshTokenHash = security.HashToken(newRefreshToken)
+	session.LastActive = now
+	session.ExpiresAt = nextExpiry(session, now)
 
-	if err := s.redisClient.Set(ctx, newRefreshTokenKey, sessionID, expiration).Err(); err != nil {
-		return fmt.Errorf("failed to create new refresh token mapping: %w", err)
-	}
-
-	return nil
+	return s.sessionStore.UpdateSessionTokens(ctx, session, previousAccessTokenHash, previousRefreshTokenHash)
 }
 
 func (s *sessionService) RevokeSession(ctx context.Context, sessionID string) error {
@@ -196,35 +382,36 @@ func (s *sessionService) RevokeSession(ctx context.Context, sessionID string) er
 		return fmt.Errorf("failed to get session for revocation: %w", err)
 	}
 
-	// Remove from user's active sessions
-	userSessionsKey := fmt.Sprintf("user_sessions:%d", session.UserID)
-	if err := s.redisClient.SRem(ctx, userSessionsKey, sessionID).Err(); err != nil {
-		fmt.Printf("Warning: failed to remove session from user sessions: %v\n", err)
-	}
-
-	// Delete all session keys
-	sessionIDKey := fmt.Sprintf("session:id:%s", sessionID)
-	accessTokenKey := fmt.Sprintf("session:token:%s", session.Token)
-	refreshTokenKey := fmt.Sprintf("session:refresh:%s", session.RefreshToken)
+	return s.revokeSession(ctx, session)
+}
 
-	deletedCount, err := s.redisClient.Del(ctx, sessionIDKey, accessTokenKey, refreshTokenKey).Result()
-	if err != nil {
-		return fmt.Errorf("failed to delete session keys: %w", err)
+// revokeSession deletes session, blacklists its tokens and tells relying
+// applications about the logout.
+func (s *sessionService) revokeSession(ctx context.Context, session *domain.Session) error {
+	if err := s.sessionStore.DeleteSession(ctx, session); err != nil {
+		return err
 	}
 
-	fmt.Printf("Deleted %d session keys for session %s\n", deletedCount, sessionID)
-
 	// Blacklist the tokens to prevent any remaining usage
-	if err := s.tokenBlacklist.BlacklistToken(ctx, session.Token, 24*time.Hour); err != nil {
+	if err := s.tokenBlacklist.BlacklistTokenHash(ctx, session.TokenHash, 24*time.Hour, "blacklisted"); err != nil {
 		fmt.Printf("Warning: failed to blacklist access token: %v\n", err)
 	}
-	if err := s.tokenBlacklist.BlacklistToken(ctx, session.RefreshToken, 24*time.Hour); err != nil {
+	if err := s.tokenBlacklist.BlacklistTokenHash(ctx, session.RefreshTokenHash, 24*time.Hour, "blacklisted"); err != nil {
 		fmt.Printf("Warning: failed to blacklist refresh token: %v\n", err)
 	}
 
+	s.notifyLogout(ctx, session)
+
 	return nil
 }
 
+// notifyLogout lets relying applications end their own copy of the session.
+func (s *sessionService) notifyLogout(ctx context.Context, session *domain.Session) {
+	if s.logoutNotifier != nil {
+		s.logoutNotifier.NotifyLogout(ctx, session)
+	}
+}
+
 func (s *sessionService) RevokeAllUserSessions(ctx context.Context, userID int64, reason string) error {
 	// Get all user sessions
 	sessions, err := s.GetUserSessions(ctx, userID)
@@ -241,12 +428,6 @@ func (s *sessionService) RevokeAllUserSessions(ctx context.Context, userID int64
 		}
 	}
 
-	// Clear the user sessions set
-	userSessionsKey := fmt.Sprintf("user_sessions:%d", userID)
-	if err := s.redisClient.Del(ctx, userSessionsKey).Err(); err != nil {
-		fmt.Printf("Warning: failed to clear user sessions set: %v\n", err)
-	}
-
 	// Blacklist all user tokens (additional security measure)
 	if err := s.tokenBlacklist.BlacklistUserTokens(ctx, userID, reason); err != nil {
 		fmt.Printf("Warning: failed to blacklist user tokens: %v\n", err)
@@ -261,86 +442,36 @@ func (s *sessionService) RevokeAllUserSessions(ctx context.Context, userID int64
 }
 
 func (s *sessionService) GetUserSessions(ctx context.Context, userID int64) ([]domain.Session, error) {
-	userSessionsKey := fmt.Sprintf("user_sessions:%d", userID)
-	sessionIDs, err := s.redisClient.SMembers(ctx, userSessionsKey).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user session IDs: %w", err)
-	}
-
-	sessions := make([]domain.Session, 0, len(sessionIDs))
-	for _, sessionID := range sessionIDs {
-		session, err := s.GetSessionByID(ctx, sessionID)
-		if err != nil {
-			// Skip invalid/expired sessions but log the issue
-			fmt.Printf("Warning: failed to get session %s, skipping: %v\n", sessionID, err)
-			// Remove invalid session ID from user sessions
-			s.redisClient.SRem(ctx, userSessionsKey, sessionID)
-			continue
-		}
-		sessions = append(sessions, *session)
-	}
-
-	return sessions, nil
+	return s.sessionStore.GetUserSessions(ctx, userID)
 }
 
+// UpdateSessionActivity marks the session behind token as active and slides its
+// idle deadline. It fails if the session is gone or expired, which callers use
+// to reject tokens that are still cryptographically valid.
 func (s *sessionService) UpdateSessionActivity(ctx context.Context, token string) error {
 	session, err := s.GetSession(ctx, token)
 	if err != nil {
 		return fmt.Errorf("failed to get session for activity update: %w", err)
 	}
 
-	session.LastActive = time.Now()
-	sessionData, err := json.Marshal(session)
-	if err != nil {
-		return fmt.Errorf("failed to marshal session for activity update: %w", err)
+	now := time.Now()
+	if now.Sub(session.LastActive) < activityRefreshInterval {
+		return nil
 	}
 
-	sessionIDKey := fmt.Sprintf("session:id:%s", session.ID)
-	if err := s.redisClient.Set(ctx, sessionIDKey, sessionData, 7*24*time.Hour).Err(); err != nil {
-		return fmt.Errorf("failed to update session activity: %w", err)
-	}
+	session.LastActive = now
+	session.ExpiresAt = nextExpiry(session, now)
 
-	return nil
+	return s.sessionStore.UpdateSessionActivity(ctx, session)
 }
 
 func (s *sessionService) CleanupExpiredSessions(ctx context.Context) error {
-	// This method cleans up orphaned session mappings and expired sessions
-	// Note: Redis should handle most expiration automatically, but this catches edge cases
-
-	// Get all user session keys
-	userSessionKeys, err := s.redisClient.Keys(ctx, "user_sessions:*").Result()
+	cleanedCount, err := s.sessionStore.CleanupExpiredSessions(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get user session keys: %w", err)
-	}
-
-	cleanedCount := 0
-	for _, userSessionKey := range userSessionKeys {
-		// Get all session IDs for this user
-		sessionIDs, err := s.redisClient.SMembers(ctx, userSessionKey).Result()
-		if err != nil {
-			fmt.Printf("Warning: failed to get session IDs for key %s: %v\n", userSessionKey, err)
-			continue
-		}
-
-		// Check each session ID
-		for _, sessionID := range sessionIDs {
-			sessionIDKey := fmt.Sprintf("session:id:%s", sessionID)
-			exists, err := s.redisClient.Exists(ctx, sessionIDKey).Result()
-			if err != nil {
-				fmt.Printf("Warning: failed to check session existence %s: %v\n", sessionID, err)
-				continue
-			}
-
-			// If session doesn't exist, remove it from user sessions
-			if exists == 0 {
-				s.redisClient.SRem(ctx, userSessionKey, sessionID)
-				cleanedCount++
-				fmt.Printf("Cleaned up orphaned session ID: %s\n", sessionID)
-			}
-		}
+		return err
 	}
 
-	fmt.Printf("Cleaned up %d orphaned session references\n", cleanedCount)
+	fmt.Printf("Cleaned up %d expired session references\n", cleanedCount)
 	return nil
 }
 
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+// memorySessionStore keeps sessions by ID.
+type memorySessionStore struct {
+	sessions map[string]domain.Session
+}
+
+func newMemorySessionStore(sessions ...domain.Session) *memorySessionStore {
+	store := &memorySessionStore{sessions: make(map[string]domain.Session)}
+	for _, session := range sessions {
+		store.sessions[session.ID] = session
+	}
+	return store
+}
+
+func (s *memorySessionStore) CreateSession(ctx context.Context, session *domain.Session) error {
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *memorySessionStore) find(match func(domain.Session) bool) (*domain.Session, error) {
+	for _, session := range s.sessions {
+		if match(session) {
+			return &session, nil
+		}
+	}
+	return nil, repositories.ErrSessionNotFound
+}
+
+func (s *memorySessionStore) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
+	return s.find(func(session domain.Session) bool { return session.ID == sessionID })
+}
+
+func (s *memorySessionStore) GetSessionByAccessTokenHash(ctx context.Context, accessTokenHash string) (*domain.Session, error) {
+	return s.find(func(session domain.Session) bool { return session.TokenHash == accessTokenHash })
+}
+
+func (s *memorySessionStore) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*domain.Session, error) {
+	return s.find(func(session domain.Session) bool { return session.RefreshTokenHash == refreshTokenHash })
+}
+
+func (s *memorySessionStore) GetUserSessions(ctx context.Context, userID int64) ([]domain.Session, error) {
+	var sessions []domain.Session
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (s *memorySessionStore) UpdateSessionTokens(ctx context.Context, session *domain.Session, previousAccessTokenHash, previousRefreshTokenHash string) error {
+	return s.CreateSession(ctx, session)
+}
+
+func (s *memorySessionStore) UpdateSessionActivity(ctx context.Context, session *domain.Session) error {
+	return s.CreateSession(ctx, session)
+}
+
+func (s *memorySessionStore) DeleteSession(ctx context.Context, session *domain.Session) error {
+	delete(s.sessions, session.ID)
+	return nil
+}
+
+func (s *memorySessionStore) CleanupExpiredSessions(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// recordingBlacklist records the token hashes blacklisted by the session
+// service. Other methods are not used by it.
+type recordingBlacklist struct {
+	security.TokenBlacklist
+	hashes map[string]string
+}
+
+func newRecordingBlacklist() *recordingBlacklist {
+	return &recordingBlacklist{hashes: make(map[string]string)}
+}
+
+func (b *recordingBlacklist) BlacklistTokenHash(ctx context.Context, tokenHash string, expiration time.Duration, reason string) error {
+	b.hashes[tokenHash] = reason
+	return nil
+}
+
+type noopLocker struct{}
+
+func (noopLocker) Lock(ctx context.Context, key string, ttl, wait time.Duration) (func(), error) {
+	return func() {}, nil
+}
+
+func TestNextExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		session domain.Session
+		want    time.Time
+	}{
+		{
+			name:    "slides the idle deadline",
+			session: domain.Session{IdleTimeout: 30 * time.Minute, AbsoluteExpiresAt: now.Add(8 * time.Hour)},
+			want:    now.Add(30 * time.Minute),
+		},
+		{
+			name:    "capped at the absolute deadline",
+			session: domain.Session{IdleTimeout: 30 * time.Minute, AbsoluteExpiresAt: now.Add(10 * time.Minute)},
+			want:    now.Add(10 * time.Minute),
+		},
+		{
+			name:    "idle timeout disabled",
+			session: domain.Session{AbsoluteExpiresAt: now.Add(8 * time.Hour)},
+			want:    now.Add(8 * time.Hour),
+		},
+		{
+			name:    "session without an absolute deadline keeps its expiry",
+			session: domain.Session{IdleTimeout: 30 * time.Minute, ExpiresAt: now.Add(time.Hour)},
+			want:    now.Add(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextExpiry(&tt.session, now); !got.Equal(tt.want) {
+				t.Errorf("nextExpiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateSessionUsesRoleTimeouts(t *testing.T) {
+	ctx := context.Background()
+	store := newMemorySessionStore()
+	service := NewSessionService(
+		store,
+		newRecordingBlacklist(),
+		SessionTimeouts{Idle: 30 * time.Minute, Absolute: 24 * time.Hour},
+		map[string]SessionTimeouts{"admin": {Idle: 5 * time.Minute, Absolute: time.Hour}},
+		SessionLimits{},
+		noopLocker{},
+		nil,
+	)
+
+	tests := []struct {
+		role         string
+		wantIdle     time.Duration
+		wantAbsolute time.Duration
+	}{
+		{role: "user", wantIdle: 30 * time.Minute, wantAbsolute: 24 * time.Hour},
+		{role: "admin", wantIdle: 5 * time.Minute, wantAbsolute: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			accessToken := "access-" + tt.role
+			if _, err := service.CreateSession(ctx, 1, tt.role, accessToken, "refresh-"+tt.role, map[string]string{}); err != nil {
+				t.Fatalf("CreateSession() error = %v", err)
+			}
+
+			session, err := store.GetSessionByAccessTokenHash(ctx, security.HashToken(accessToken))
+			if err != nil {
+				t.Fatalf("session was not stored under its token hash: %v", err)
+			}
+			if got := session.ExpiresAt.Sub(session.CreatedAt); got != tt.wantIdle {
+				t.Errorf("idle deadline %v after login, want %v", got, tt.wantIdle)
+			}
+			if got := session.AbsoluteExpiresAt.Sub(session.CreatedAt); got != tt.wantAbsolute {
+				t.Errorf("absolute deadline %v after login, want %v", got, tt.wantAbsolute)
+			}
+		})
+	}
+}
+
+func TestGetSessionRevokesExpiredSession(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	expired := domain.Session{
+		ID:                "expired",
+		UserID:            1,
+		TokenHash:         security.HashToken("access"),
+		RefreshTokenHash:  security.HashToken("refresh"),
+		LastActive:        now.Add(-time.Hour),
+		ExpiresAt:         now.Add(-time.Minute),
+		AbsoluteExpiresAt: now.Add(time.Hour),
+		IdleTimeout:       30 * time.Minute,
+	}
+	store := newMemorySessionStore(expired)
+	blacklist := newRecordingBlacklist()
+	service := NewSessionService(store, blacklist, SessionTimeouts{}, nil, SessionLimits{}, noopLocker{}, nil)
+
+	if _, err := service.GetSession(ctx, "access"); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("GetSession() error = %v, want ErrSessionExpired", err)
+	}
+	if _, ok := store.sessions[expired.ID]; ok {
+		t.Error("expired session was left in the store")
+	}
+	for _, hash := range []string{expired.TokenHash, expired.RefreshTokenHash} {
+		if _, ok := blacklist.hashes[hash]; !ok {
+			t.Errorf("token hash %s of the expired session was not blacklisted", hash)
+		}
+	}
+}
+
+func TestParseRoleSessionTimeouts(t *testing.T) {
+	got, err := ParseRoleSessionTimeouts(" admin=15m/8h, moderator=0s/24h ,")
+	if err != nil {
+		t.Fatalf("ParseRoleSessionTimeouts() error = %v", err)
+	}
+	want := map[string]SessionTimeouts{
+		"admin":     {Idle: 15 * time.Minute, Absolute: 8 * time.Hour},
+		"moderator": {Idle: 0, Absolute: 24 * time.Hour},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseRoleSessionTimeouts() = %v, want %v", got, want)
+	}
+	for role, timeouts := range want {
+		if got[role] != timeouts {
+			t.Errorf("timeouts for %s = %+v, want %+v", role, got[role], timeouts)
+		}
+	}
+
+	for _, raw := range []string{"admin=15m", "admin", "admin=soon/8h", "admin=15m/later"} {
+		if _, err := ParseRoleSessionTimeouts(raw); err == nil {
+			t.Errorf("ParseRoleSessionTimeouts(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestCreateSessionAtLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	existing := []domain.Session{
+		{ID: "oldest", UserID: 1, TokenHash: "oldest-access", RefreshTokenHash: "oldest-refresh", LastActive: now.Add(-3 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{ID: "middle", UserID: 1, TokenHash: "middle-access", RefreshTokenHash: "middle-refresh", LastActive: now.Add(-2 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{ID: "newest", UserID: 1, TokenHash: "newest-access", RefreshTokenHash: "newest-refresh", LastActive: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+		// Expired sessions and other users' sessions do not count
+		{ID: "expired", UserID: 1, LastActive: now.Add(-5 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+		{ID: "other-user", UserID: 2, LastActive: now.Add(-5 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name        string
+		limits      SessionLimits
+		role        string
+		wantErr     error
+		wantEvicted []string
+	}{
+		{
+			name:   "unlimited",
+			limits: SessionLimits{Policy: SessionLimitReject},
+		},
+		{
+			name:   "below the limit",
+			limits: SessionLimits{MaxActive: 4, Policy: SessionLimitReject},
+		},
+		{
+			name:    "reject at the limit",
+			limits:  SessionLimits{MaxActive: 3, Policy: SessionLimitReject},
+			wantErr: ErrSessionLimitReached,
+		},
+		{
+			name:    "unknown policy rejects",
+			limits:  SessionLimits{MaxActive: 3},
+			wantErr: ErrSessionLimitReached,
+		},
+		{
+			name:        "evict the least recently active",
+			limits:      SessionLimits{MaxActive: 3, Policy: SessionLimitEvictOldest},
+			wantEvicted: []string{"oldest"},
+		},
+		{
+			name:        "evict down to a lowered limit",
+			limits:      SessionLimits{MaxActive: 2, Policy: SessionLimitEvictOldest},
+			wantEvicted: []string{"oldest", "middle"},
+		},
+		{
+			name:        "role limit overrides the default",
+			limits:      SessionLimits{MaxActive: 10, RoleMaxActive: map[string]int{"admin": 1}, Policy: SessionLimitEvictOldest},
+			role:        "admin",
+			wantEvicted: []string{"oldest", "middle", "newest"},
+		},
+		{
+			name:   "role without a limit",
+			limits: SessionLimits{MaxActive: 1, RoleMaxActive: map[string]int{"admin": 0}, Policy: SessionLimitReject},
+			role:   "admin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemorySessionStore(existing...)
+			blacklist := newRecordingBlacklist()
+			service := NewSessionService(store, blacklist, SessionTimeouts{Absolute: time.Hour}, nil, tt.limits, noopLocker{}, nil)
+
+			role := tt.role
+			if role == "" {
+				role = "user"
+			}
+			evicted, err := service.CreateSession(ctx, 1, role, "access", "refresh", map[string]string{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateSession() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if _, err := store.GetSessionByAccessTokenHash(ctx, security.HashToken("access")); (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("new session stored = %v, want %v", err == nil, tt.wantErr == nil)
+			}
+
+			if len(evicted) != len(tt.wantEvicted) {
+				t.Fatalf("evicted %d sessions, want %v", len(evicted), tt.wantEvicted)
+			}
+			for i, session := range evicted {
+				if session.ID != tt.wantEvicted[i] {
+					t.Errorf("evicted[%d] = %s, want %s", i, session.ID, tt.wantEvicted[i])
+				}
+				if _, ok := store.sessions[session.ID]; ok {
+					t.Errorf("evicted session %s is still stored", session.ID)
+				}
+				for _, hash := range []string{session.TokenHash, session.RefreshTokenHash} {
+					if reason := blacklist.hashes[hash]; reason != security.BlacklistReasonSignedOutElsewhere {
+						t.Errorf("token hash %s blacklisted with reason %q, want %q", hash, reason, security.BlacklistReasonSignedOutElsewhere)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseRoleSessionLimits(t *testing.T) {
+	got, err := ParseRoleSessionLimits("admin=1, user = 3,")
+	if err != nil {
+		t.Fatalf("ParseRoleSessionLimits() error = %v", err)
+	}
+	if len(got) != 2 || got["admin"] != 1 || got["user"] != 3 {
+		t.Errorf("ParseRoleSessionLimits() = %v, want admin=1 user=3", got)
+	}
+
+	for _, raw := range []string{"admin", "admin=-1", "admin=many"} {
+		if _, err := ParseRoleSessionLimits(raw); err == nil {
+			t.Errorf("ParseRoleSessionLimits(%q) succeeded, want an error", raw)
+		}
+	}
+}
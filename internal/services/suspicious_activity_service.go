@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+const (
+	suspiciousActivityDefaultLimit = 50
+	suspiciousActivityMaxLimit     = 200
+)
+
+var (
+	ErrInvalidActivityStatus   = errors.New("invalid suspicious activity status")
+	ErrActivityStatusConflict  = errors.New("suspicious activity was changed in the meantime, reload it and try again")
+	ErrInvalidActivityAssignee = errors.New("activities can only be assigned to active users who manage security")
+	ErrInvalidActivityResponse = errors.New("sessions can only be revoked and password resets forced when confirming an activity on an account as malicious")
+	ErrEmptyActivityNote       = errors.New("note must not be empty")
+)
+
+// SuspiciousActivityService runs the review of suspicious activities as cases.
+// Users can acknowledge what was recorded on their own account, the security
+// team assigns, annotates and decides on any activity.
+type SuspiciousActivityService interface {
+	// Acknowledge marks an open activity on the user's account as seen. Other
+	// users' activities are reported as not found.
+	Acknowledge(ctx context.Context, userID, activityID int64) (*domain.SuspiciousActivity, error)
+	// ListActivities returns the newest activities matching filter, those
+	// still under review unless it names statuses.
+	ListActivities(ctx context.Context, filter domain.SuspiciousActivityFilter) ([]domain.SuspiciousActivity, error)
+	GetCase(ctx context.Context, activityID int64) (*domain.SuspiciousActivityCase, error)
+	// Assign hands the activity to assignee, or unassigns it when nil.
+	Assign(ctx context.Context, activityID int64, assignee *int64) (*domain.SuspiciousActivity, error)
+	AddNote(ctx context.Context, activityID, authorID int64, note string) (*domain.SuspiciousActivityNote, error)
+	// Review moves the activity to req.Status and carries out the responses
+	// it asks for. The review is returned whenever the status changed, along
+	// with the error of any response that then failed.
+	Review(ctx context.Context, req domain.ReviewSuspiciousActivityAction) (*domain.SuspiciousActivityReview, error)
+}
+
+type suspiciousActivityService struct {
+	suspiciousRepo       repositories.SuspiciousActivityRepository
+	userRepo             repositories.UserRepository
+	sessionService       SessionService
+	securityService      SecurityService
+	passwordResetService PasswordResetService
+}
+
+func NewSuspiciousActivityService(
+	suspiciousRepo repositories.SuspiciousActivityRepository,
+	userRepo repositories.UserRepository,
+	sessionService SessionService,
+	securityService SecurityService,
+	passwordResetService PasswordResetService,
+) SuspiciousActivityService {
+	return &suspiciousActivityService{
+		suspiciousRepo:       suspiciousRepo,
+		userRepo:             userRepo,
+		sessionService:       sessionService,
+		securityService:      securityService,
+		passwordResetService: passwordResetService,
+	}
+}
+
+func (s *suspiciousActivityService) Acknowledge(ctx context.Context, userID, activityID int64) (*domain.SuspiciousActivity, error) {
+	activity, err := s.suspiciousRepo.GetActivityByID(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+	if activity.UserID != userID {
+		return nil, pgx.ErrNoRows
+	}
+	if activity.Status != domain.ActivityStatusOpen {
+		return nil, fmt.Errorf("%w: only open activities can be acknowledged", ErrInvalidActivityStatus)
+	}
+
+	return s.updateStatus(ctx, domain.UpdateSuspiciousActivityStatusAction{
+		ActivityID: activity.ID,
+		From:       activity.Status,
+		To:         domain.ActivityStatusAcknowledged,
+	})
+}
+
+func (s *suspiciousActivityService) ListActivities(ctx context.Context, filter domain.SuspiciousActivityFilter) ([]domain.SuspiciousActivity, error) {
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []domain.SuspiciousActivityStatus{domain.ActivityStatusOpen, domain.ActivityStatusAcknowledged}
+	}
+	for _, status := range filter.Statuses {
+		if !status.Valid() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidActivityStatus, status)
+		}
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = suspiciousActivityDefaultLimit
+	}
+	filter.Limit = min(filter.Limit, suspiciousActivityMaxLimit)
+
+	return s.suspiciousRepo.ListActivities(ctx, filter)
+}
+
+func (s *suspiciousActivityService) GetCase(ctx context.Context, activityID int64) (*domain.SuspiciousActivityCase, error) {
+	activity, err := s.suspiciousRepo.GetActivityByID(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.suspiciousRepo.GetNotes(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+
+	return &domain.SuspiciousActivityCase{
+		Activity: *activity,
+		Notes:    notes,
+	}, nil
+}
+
+func (s *suspiciousActivityService) Assign(ctx context.Context, activityID int64, assignee *int64) (*domain.SuspiciousActivity, error) {
+	if assignee != nil {
+		user, err := s.userRepo.GetUserByID(ctx, *assignee)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrInvalidActivityAssignee
+			}
+			return nil, fmt.Errorf("failed to get assignee: %w", err)
+		}
+		if !user.Active || !domain.HasPermission(user.Role, domain.PermissionSecurityManage) {
+			return nil, ErrInvalidActivityAssignee
+		}
+	}
+
+	return s.suspiciousRepo.Assign(ctx, activityID, assignee)
+}
+
+func (s *suspiciousActivityService) AddNote(ctx context.Context, activityID, authorID int64, note string) (*domain.SuspiciousActivityNote, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, ErrEmptyActivityNote
+	}
+
+	// Looked up first so a missing activity is not found rather than a
+	// foreign key violation
+	if _, err := s.suspiciousRepo.GetActivityByID(ctx, activityID); err != nil {
+		return nil, err
+	}
+
+	return s.suspiciousRepo.AddNote(ctx, activityID, &authorID, note)
+}
+
+func (s *suspiciousActivityService) Review(ctx context.Context, req domain.ReviewSuspiciousActivityAction) (*domain.SuspiciousActivityReview, error) {
+	respond := req.RevokeSessions || req.ForcePasswordReset
+	if respond && req.Status != domain.ActivityStatusConfirmedMalicious {
+		return nil, ErrInvalidActivityResponse
+	}
+
+	activity, err := s.suspiciousRepo.GetActivityByID(ctx, req.ActivityID)
+	if err != nil {
+		return nil, err
+	}
+	if respond && activity.UserID == 0 {
+		return nil, ErrInvalidActivityResponse
+	}
+	if !activity.Status.CanTransitionTo(req.Status) {
+		return nil, fmt.Errorf("%w: cannot move from %s to %q", ErrInvalidActivityStatus, activity.Status, req.Status)
+	}
+
+	activity, err = s.updateStatus(ctx, domain.UpdateSuspiciousActivityStatusAction{
+		ActivityID: activity.ID,
+		From:       activity.Status,
+		To:         req.Status,
+		ResolvedBy: &req.ReviewerID,
+		Note:       strings.TrimSpace(req.Note),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	review := &domain.SuspiciousActivityReview{Activity: activity}
+
+	// 1. End every session the attacker may hold
+	if req.RevokeSessions {
+		if err := s.sessionService.RevokeAllUserSessions(ctx, activity.UserID, "suspicious activity confirmed as malicious"); err != nil {
+			return review, fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+		review.SessionsRevoked = true
+	}
+
+	// 2. Keep the account locked until its owner picks a new password,
+	// completing the reset lifts the lockout
+	if req.ForcePasswordReset {
+		user, err := s.userRepo.GetUserByID(ctx, activity.UserID)
+		if err != nil {
+			return review, fmt.Errorf("failed to get user: %w", err)
+		}
+
+		review.Lockout, err = s.securityService.LockAccount(ctx, user.ID, activity.IPAddress, activity.UserAgent, "suspicious activity confirmed as malicious")
+		if err != nil {
+			return review, fmt.Errorf("failed to lock account: %w", err)
+		}
+
+		if err := s.passwordResetService.RequestPasswordReset(ctx, user.Email); err != nil {
+			return review, fmt.Errorf("failed to start password reset: %w", err)
+		}
+		review.PasswordResetSent = true
+	}
+
+	return review, nil
+}
+
+// updateStatus reports a change that lost the race against another as a
+// conflict.
+func (s *suspiciousActivityService) updateStatus(ctx context.Context, req domain.UpdateSuspiciousActivityStatusAction) (*domain.SuspiciousActivity, error) {
+	activity, err := s.suspiciousRepo.UpdateStatus(ctx, req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrActivityStatusConflict
+		}
+		return nil, fmt.Errorf("failed to update activity status: %w", err)
+	}
+	return activity, nil
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+)
+
+// fakeSuspiciousActivities keeps activities by ID. UpdateStatus only applies
+// when the activity still has the status the change started from.
+type fakeSuspiciousActivities struct {
+	repositories.SuspiciousActivityRepository
+	activities map[int64]domain.SuspiciousActivity
+}
+
+func newFakeSuspiciousActivities(activities ...domain.SuspiciousActivity) *fakeSuspiciousActivities {
+	r := &fakeSuspiciousActivities{activities: make(map[int64]domain.SuspiciousActivity)}
+	for _, activity := range activities {
+		r.activities[activity.ID] = activity
+	}
+	return r
+}
+
+func (r *fakeSuspiciousActivities) GetActivityByID(ctx context.Context, id int64) (*domain.SuspiciousActivity, error) {
+	activity, ok := r.activities[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &activity, nil
+}
+
+func (r *fakeSuspiciousActivities) UpdateStatus(ctx context.Context, req domain.UpdateSuspiciousActivityStatusAction) (*domain.SuspiciousActivity, error) {
+	activity, ok := r.activities[req.ActivityID]
+	if !ok || activity.Status != req.From {
+		return nil, pgx.ErrNoRows
+	}
+	activity.Status = req.To
+	if req.To.Closed() {
+		activity.ResolvedBy = req.ResolvedBy
+	}
+	r.activities[activity.ID] = activity
+	return &activity, nil
+}
+
+func (r *fakeSuspiciousActivities) Assign(ctx context.Context, id int64, assignee *int64) (*domain.SuspiciousActivity, error) {
+	activity, ok := r.activities[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	activity.AssignedTo = assignee
+	r.activities[id] = activity
+	return &activity, nil
+}
+
+// fakeUsers answers GetUserByID from a map.
+type fakeUsers struct {
+	repositories.UserRepository
+	users map[int64]domain.User
+}
+
+func (r *fakeUsers) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &user, nil
+}
+
+// recordingSessionRevoker records whose sessions were revoked.
+type recordingSessionRevoker struct {
+	SessionService
+	revoked []int64
+}
+
+func (s *recordingSessionRevoker) RevokeAllUserSessions(ctx context.Context, userID int64, reason string) error {
+	s.revoked = append(s.revoked, userID)
+	return nil
+}
+
+func TestAcknowledgeSuspiciousActivity(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int64
+		status  domain.SuspiciousActivityStatus
+		wantErr error
+	}{
+		{name: "own open activity", userID: 7, status: domain.ActivityStatusOpen},
+		{name: "another user's activity", userID: 8, status: domain.ActivityStatusOpen, wantErr: pgx.ErrNoRows},
+		{name: "already acknowledged", userID: 7, status: domain.ActivityStatusAcknowledged, wantErr: ErrInvalidActivityStatus},
+		{name: "closed activity", userID: 7, status: domain.ActivityStatusConfirmedBenign, wantErr: ErrInvalidActivityStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activities := newFakeSuspiciousActivities(domain.SuspiciousActivity{ID: 1, UserID: 7, Status: tt.status})
+			service := NewSuspiciousActivityService(activities, nil, nil, nil, nil)
+
+			activity, err := service.Acknowledge(context.Background(), tt.userID, 1)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Acknowledge() error = %v, want %v", err, tt.wantErr)
+				}
+				if got := activities.activities[1].Status; got != tt.status {
+					t.Errorf("status changed to %s on a rejected acknowledgement", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Acknowledge() error = %v", err)
+			}
+			if activity.Status != domain.ActivityStatusAcknowledged {
+				t.Errorf("Acknowledge() status = %s, want acknowledged", activity.Status)
+			}
+		})
+	}
+
+	t.Run("unknown activity", func(t *testing.T) {
+		service := NewSuspiciousActivityService(newFakeSuspiciousActivities(), nil, nil, nil, nil)
+		if _, err := service.Acknowledge(context.Background(), 7, 1); !errors.Is(err, pgx.ErrNoRows) {
+			t.Errorf("Acknowledge() error = %v, want pgx.ErrNoRows", err)
+		}
+	})
+}
+
+func TestAssignSuspiciousActivity(t *testing.T) {
+	users := &fakeUsers{users: map[int64]domain.User{
+		1: {ID: 1, Role: string(domain.RoleAdmin), Active: true},
+		2: {ID: 2, Role: string(domain.RoleAdmin)},
+		3: {ID: 3, Role: string(domain.RoleMod), Active: true},
+		4: {ID: 4, Role: string(domain.RoleUser), Active: true},
+	}}
+	assignee := func(id int64) *int64 { return &id }
+
+	tests := []struct {
+		name     string
+		assignee *int64
+		wantErr  error
+	}{
+		{name: "active security manager", assignee: assignee(1)},
+		{name: "unassign", assignee: nil},
+		{name: "inactive admin", assignee: assignee(2), wantErr: ErrInvalidActivityAssignee},
+		{name: "moderator without security permission", assignee: assignee(3), wantErr: ErrInvalidActivityAssignee},
+		{name: "regular user", assignee: assignee(4), wantErr: ErrInvalidActivityAssignee},
+		{name: "unknown user", assignee: assignee(99), wantErr: ErrInvalidActivityAssignee},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activities := newFakeSuspiciousActivities(domain.SuspiciousActivity{ID: 1, UserID: 7, Status: domain.ActivityStatusOpen, AssignedTo: assignee(1)})
+			service := NewSuspiciousActivityService(activities, users, nil, nil, nil)
+
+			activity, err := service.Assign(context.Background(), 1, tt.assignee)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Assign() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Assign() error = %v", err)
+			}
+			if (activity.AssignedTo == nil) != (tt.assignee == nil) || (tt.assignee != nil && *activity.AssignedTo != *tt.assignee) {
+				t.Errorf("Assign() assigned to %v, want %v", activity.AssignedTo, tt.assignee)
+			}
+		})
+	}
+}
+
+func TestReviewSuspiciousActivity(t *testing.T) {
+	tests := []struct {
+		name        string
+		userID      int64
+		status      domain.SuspiciousActivityStatus
+		req         domain.ReviewSuspiciousActivityAction
+		wantErr     error
+		wantRevoked bool
+	}{
+		{
+			name:   "confirm benign",
+			userID: 7,
+			status: domain.ActivityStatusOpen,
+			req:    domain.ReviewSuspiciousActivityAction{Status: domain.ActivityStatusConfirmedBenign},
+		},
+		{
+			name:        "confirm malicious and revoke sessions",
+			userID:      7,
+			status:      domain.ActivityStatusAcknowledged,
+			req:         domain.ReviewSuspiciousActivityAction{Status: domain.ActivityStatusConfirmedMalicious, RevokeSessions: true},
+			wantRevoked: true,
+		},
+		{
+			name:    "revoke sessions on a benign activity",
+			userID:  7,
+			status:  domain.ActivityStatusOpen,
+			req:     domain.ReviewSuspiciousActivityAction{Status: domain.ActivityStatusConfirmedBenign, RevokeSessions: true},
+			wantErr: ErrInvalidActivityResponse,
+		},
+		{
+			name:    "force a reset on an activity without an account",
+			status:  domain.ActivityStatusOpen,
+			req:     domain.ReviewSuspiciousActivityAction{Status: domain.ActivityStatusConfirmedMalicious, ForcePasswordReset: true},
+			wantErr: ErrInvalidActivityResponse,
+		},
+		{
+			name:    "acknowledged activity cannot be reopened",
+			userID:  7,
+			status:  domain.ActivityStatusAcknowledged,
+			req:     domain.ReviewSuspiciousActivityAction{Status: domain.ActivityStatusOpen},
+			wantErr: ErrInvalidActivityStatus,
+		},
+		{
+			name:    "unknown status",
+			userID:  7,
+			status:  domain.ActivityStatusOpen,
+			req:     domain.ReviewSuspiciousActivityAction{Status: "escalated"},
+			wantErr: ErrInvalidActivityStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activities := newFakeSuspiciousActivities(domain.SuspiciousActivity{ID: 1, UserID: tt.userID, Status: tt.status})
+			sessions := &recordingSessionRevoker{}
+			service := NewSuspiciousActivityService(activities, nil, sessions, nil, nil)
+
+			tt.req.ActivityID = 1
+			tt.req.ReviewerID = 2
+			review, err := service.Review(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Review() error = %v, want %v", err, tt.wantErr)
+				}
+				if got := activities.activities[1].Status; got != tt.status {
+					t.Errorf("status changed to %s on a rejected review", got)
+				}
+				if len(sessions.revoked) != 0 {
+					t.Errorf("sessions of %v revoked on a rejected review", sessions.revoked)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Review() error = %v", err)
+			}
+
+			if review.Activity.Status != tt.req.Status {
+				t.Errorf("Review() status = %s, want %s", review.Activity.Status, tt.req.Status)
+			}
+			if review.Activity.ResolvedBy == nil || *review.Activity.ResolvedBy != tt.req.ReviewerID {
+				t.Errorf("Review() resolved by %v, want the reviewer", review.Activity.ResolvedBy)
+			}
+			if review.SessionsRevoked != tt.wantRevoked || (tt.wantRevoked && (len(sessions.revoked) != 1 || sessions.revoked[0] != tt.userID)) {
+				t.Errorf("sessions revoked for %v, want the activity's user: %v", sessions.revoked, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusReportsConflicts(t *testing.T) {
+	activities := newFakeSuspiciousActivities(domain.SuspiciousActivity{ID: 1, UserID: 7, Status: domain.ActivityStatusAcknowledged})
+	service := &suspiciousActivityService{suspiciousRepo: activities}
+
+	// Changed by someone else since it was read as open
+	_, err := service.updateStatus(context.Background(), domain.UpdateSuspiciousActivityStatusAction{
+		ActivityID: 1,
+		From:       domain.ActivityStatusOpen,
+		To:         domain.ActivityStatusAcknowledged,
+	})
+	if !errors.Is(err, ErrActivityStatusConflict) {
+		t.Errorf("updateStatus() error = %v, want ErrActivityStatusConflict", err)
+	}
+}
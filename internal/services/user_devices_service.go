@@ -18,7 +18,9 @@ type UserDevicesService interface {
 	UpdateDevice(ctx context.Context, req domain.UpdateUserDeviceAction) (*domain.UserDevice, error)
 	DeleteDevice(ctx context.Context, id, userID int64) error
 	DeleteAllDevices(ctx context.Context, userID int64) error
-	GetOrCreateDevice(ctx context.Context, userID int64, deviceInfo *security.DeviceInfo) (*domain.UserDevice, error)
+	// GetOrCreateDevice reports whether the device was registered by this
+	// call, i.e. the user had never signed in from it before.
+	GetOrCreateDevice(ctx context.Context, userID int64, deviceInfo *security.DeviceInfo) (*domain.UserDevice, bool, error)
 	MarkDeviceAsTrusted(ctx context.Context, id, userID int64, trusted bool) (*domain.UserDevice, error)
 }
 
@@ -41,6 +43,7 @@ func (s *userDevicesService) RegisterDevice(ctx context.Context, userID int64, d
 		UserAgent:  deviceInfo.UserAgent,
 		IPAddress:  deviceInfo.IPAddress,
 		Trusted:    false, // Default to untrusted
+		Location:   deviceInfo.Location,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to register device: %w", err)
@@ -73,16 +76,29 @@ func (s *userDevicesService) DeleteAllDevices(ctx context.Context, userID int64)
 	return s.userDevicesRepo.DeleteAllUserDevices(ctx, userID)
 }
 
-func (s *userDevicesService) GetOrCreateDevice(ctx context.Context, userID int64, deviceInfo *security.DeviceInfo) (*domain.UserDevice, error) {
+func (s *userDevicesService) GetOrCreateDevice(ctx context.Context, userID int64, deviceInfo *security.DeviceInfo) (*domain.UserDevice, bool, error) {
 	// Try to find existing device
 	existingDevice, err := s.userDevicesRepo.GetUserDeviceByDeviceID(ctx, userID, deviceInfo.DeviceID)
+	if err != nil && deviceInfo.LegacyDeviceID != "" {
+		// Devices used to be keyed by a user agent and IP fingerprint, a
+		// browser seen again under it keeps that ID from now on
+		if legacyDevice, legacyErr := s.userDevicesRepo.GetUserDeviceByDeviceID(ctx, userID, deviceInfo.LegacyDeviceID); legacyErr == nil {
+			deviceInfo.DeviceID = legacyDevice.DeviceID
+			existingDevice, err = legacyDevice, nil
+		}
+	}
 	if err == nil {
-		// Device exists, update last used
-		return s.UpdateDeviceLastUsed(ctx, existingDevice.ID)
+		// Device exists, update last used and where from
+		device, err := s.userDevicesRepo.UpdateUserDeviceLocation(ctx, existingDevice.ID, deviceInfo.IPAddress, deviceInfo.Location)
+		return device, false, err
 	}
 
 	// Device doesn't exist, create new one
-	return s.RegisterDevice(ctx, userID, deviceInfo)
+	device, err := s.RegisterDevice(ctx, userID, deviceInfo)
+	if err != nil {
+		return nil, false, err
+	}
+	return device, true, nil
 }
 
 func (s *userDevicesService) MarkDeviceAsTrusted(ctx context.Context, id, userID int64, trusted bool) (*domain.UserDevice, error) {
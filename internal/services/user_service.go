@@ -12,6 +12,7 @@ type UserService interface {
 	CreateUser(ctx context.Context, req domain.CreateUserAction) (*domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
 	GetUserByID(ctx context.Context, id int64) (*domain.User, error)
+	GetUsersByIDs(ctx context.Context, ids []int64) ([]domain.User, error)
 	ActivateUser(ctx context.Context, id int64) error
 	DeactivateUser(ctx context.Context, id int64) error
 	UpdateUser(ctx context.Context, user domain.User) error
@@ -42,6 +43,10 @@ func (s *userService) GetUserByID(ctx context.Context, id int64) (*domain.User,
 	return s.repository.GetUserByID(ctx, id)
 }
 
+func (s *userService) GetUsersByIDs(ctx context.Context, ids []int64) ([]domain.User, error) {
+	return s.repository.GetUsersByIDs(ctx, ids)
+}
+
 func (s *userService) ActivateUser(ctx context.Context, id int64) error {
 	return s.repository.ActivateUser(ctx, id)
 }
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/metrics"
+	"github.com/m1thrandir225/whoami/internal/repositories"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+const (
+	// webhookDeliveryGraceMargin is added to the request timeout to keep the
+	// retry worker away from a delivery while an attempt is still in flight.
+	webhookDeliveryGraceMargin = 30 * time.Second
+	// Retries are claimed a few at a time, each claim leased for long enough
+	// to send all of them one after another.
+	webhookDeliveryClaimSize  = 10
+	webhookDeliveryBaseDelay  = 30 * time.Second
+	webhookDeliveryMaxDelay   = 6 * time.Hour
+	webhookDeliveryMaxAttempt = 12
+	webhookDeliveryBatchSize  = 100
+)
+
+var ErrInvalidWebhookEventType = errors.New("unknown webhook event type")
+
+// WebhookService sends signed user lifecycle and security events to the
+// endpoints subscribed to them.
+type WebhookService interface {
+	// CreateEndpoint returns the secret the endpoint uses to verify
+	// signatures. It is only shown once.
+	CreateEndpoint(ctx context.Context, rawURL, description string, eventTypes []string) (*domain.WebhookEndpoint, string, error)
+	GetEndpoint(ctx context.Context, id int64) (*domain.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context) ([]domain.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, req domain.UpdateWebhookEndpointAction) (*domain.WebhookEndpoint, error)
+	// RotateEndpointSecret replaces the signing secret. Deliveries still
+	// pending are signed with the new one.
+	RotateEndpointSecret(ctx context.Context, id int64) (*domain.WebhookEndpoint, string, error)
+	DeleteEndpoint(ctx context.Context, id int64) error
+	// Publish records a delivery of the event for every endpoint subscribed
+	// to eventType and makes the first attempts in the background. It never
+	// fails the caller, which has already done the work the event reports.
+	Publish(ctx context.Context, eventType string, data map[string]interface{})
+	GetDeliveries(ctx context.Context, endpointID int64, status string, limit int32) ([]domain.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error)
+	// Redeliver sends a delivery again whatever its status, with a fresh set
+	// of attempts.
+	Redeliver(ctx context.Context, id int64) (*domain.WebhookDelivery, error)
+	ProcessPendingDeliveries(ctx context.Context) error
+}
+
+type webhookService struct {
+	webhooksRepo repositories.WebhooksRepository
+	httpClient   *http.Client
+	// grace delays the retry worker past a first attempt, lease past a
+	// claimed batch of retries
+	grace time.Duration
+	lease time.Duration
+}
+
+func NewWebhookService(webhooksRepo repositories.WebhooksRepository, timeout time.Duration) WebhookService {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	grace := timeout + webhookDeliveryGraceMargin
+
+	return &webhookService{
+		webhooksRepo: webhooksRepo,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		grace: grace,
+		lease: webhookDeliveryClaimSize*timeout + grace,
+	}
+}
+
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return fmt.Errorf("invalid webhook URL: %q", rawURL)
+	}
+
+	return nil
+}
+
+// normalizeWebhookEventTypes rejects unknown event types and drops duplicates.
+func normalizeWebhookEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		return nil, errors.New("at least one event type is required")
+	}
+
+	normalized := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !domain.IsWebhookEventType(eventType) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidWebhookEventType, eventType)
+		}
+		if !slices.Contains(normalized, eventType) {
+			normalized = append(normalized, eventType)
+		}
+	}
+
+	return normalized, nil
+}
+
+func (s *webhookService) CreateEndpoint(ctx context.Context, rawURL, description string, eventTypes []string) (*domain.WebhookEndpoint, string, error) {
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, "", err
+	}
+	eventTypes, err := normalizeWebhookEventTypes(eventTypes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := generateRandomHex(32)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	endpoint, err := s.webhooksRepo.CreateEndpoint(ctx, domain.CreateWebhookEndpointAction{
+		URL:         rawURL,
+		Description: description,
+		Secret:      secret,
+		EventTypes:  eventTypes,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return endpoint, secret, nil
+}
+
+func (s *webhookService) GetEndpoint(ctx context.Context, id int64) (*domain.WebhookEndpoint, error) {
+	return s.webhooksRepo.GetEndpointByID(ctx, id)
+}
+
+func (s *webhookService) ListEndpoints(ctx context.Context) ([]domain.WebhookEndpoint, error) {
+	return s.webhooksRepo.ListEndpoints(ctx)
+}
+
+func (s *webhookService) UpdateEndpoint(ctx context.Context, req domain.UpdateWebhookEndpointAction) (*domain.WebhookEndpoint, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := normalizeWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	req.EventTypes = eventTypes
+
+	return s.webhooksRepo.UpdateEndpoint(ctx, req)
+}
+
+func (s *webhookService) RotateEndpointSecret(ctx context.Context, id int64) (*domain.WebhookEndpoint, string, error) {
+	secret, err := generateRandomHex(32)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	endpoint, err := s.webhooksRepo.UpdateEndpointSecret(ctx, id, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return endpoint, secret, nil
+}
+
+func (s *webhookService) DeleteEndpoint(ctx context.Context, id int64) error {
+	return s.webhooksRepo.DeleteEndpoint(ctx, id)
+}
+
+func (s *webhookService) Publish(ctx context.Context, eventType string, data map[string]interface{}) {
+	endpoints, err := s.webhooksRepo.ListEndpointsForEvent(ctx, eventType)
+	if err != nil {
+		fmt.Printf("Warning: failed to list webhook endpoints for %s: %v\n", eventType, err)
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	eventID, err := uuid.NewV7()
+	if err != nil {
+		fmt.Printf("Warning: failed to generate webhook event ID: %v\n", err)
+		return
+	}
+
+	payload, err := json.Marshal(domain.WebhookEvent{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to encode webhook event %s: %v\n", eventType, err)
+		return
+	}
+
+	for _, endpoint := range endpoints {
+		delivery, err := s.webhooksRepo.CreateDelivery(ctx, domain.CreateWebhookDeliveryAction{
+			EndpointID:    endpoint.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       payload,
+			NextAttemptAt: time.Now().Add(s.grace),
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to record webhook delivery for endpoint %d: %v\n", endpoint.ID, err)
+			continue
+		}
+
+		go s.deliver(context.Background(), endpoint, *delivery)
+	}
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, endpointID int64, status string, limit int32) ([]domain.WebhookDelivery, error) {
+	return s.webhooksRepo.GetDeliveriesByEndpointID(ctx, endpointID, status, limit)
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	return s.webhooksRepo.GetDeliveryByID(ctx, id)
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	delivery, err := s.webhooksRepo.RequeueDelivery(ctx, id, time.Now().Add(s.grace))
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.webhooksRepo.GetEndpointByID(ctx, delivery.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.deliver(context.Background(), *endpoint, *delivery)
+
+	return delivery, nil
+}
+
+// ProcessPendingDeliveries retries due deliveries. Every instance runs it,
+// deliveries are leased to whichever claims them first.
+func (s *webhookService) ProcessPendingDeliveries(ctx context.Context) error {
+	endpoints := make(map[int64]*domain.WebhookEndpoint)
+	for processed := 0; processed < webhookDeliveryBatchSize; {
+		deliveries, err := s.webhooksRepo.ClaimDueDeliveries(ctx, webhookDeliveryClaimSize, time.Now().Add(s.lease))
+		if err != nil {
+			return fmt.Errorf("failed to claim pending webhook deliveries: %w", err)
+		}
+
+		for _, delivery := range deliveries {
+			endpoint, ok := endpoints[delivery.EndpointID]
+			if !ok {
+				endpoint, err = s.webhooksRepo.GetEndpointByID(ctx, delivery.EndpointID)
+				if err != nil {
+					fmt.Printf("Warning: failed to get webhook endpoint %d: %v\n", delivery.EndpointID, err)
+					continue
+				}
+				endpoints[delivery.EndpointID] = endpoint
+			}
+
+			s.deliver(ctx, *endpoint, delivery)
+		}
+
+		processed += len(deliveries)
+		if len(deliveries) < webhookDeliveryClaimSize {
+			break
+		}
+	}
+
+	return nil
+}
+
+// deliver POSTs the stored payload and records the outcome. Each attempt is
+// signed with a fresh timestamp, while the event ID stays fixed so the
+// endpoint can discard duplicates.
+func (s *webhookService) deliver(ctx context.Context, endpoint domain.WebhookEndpoint, delivery domain.WebhookDelivery) {
+	update := domain.UpdateWebhookDeliveryAction{
+		ID:       delivery.ID,
+		Status:   domain.WebhookDeliveryStatusPending,
+		Attempts: delivery.Attempts + 1,
+	}
+
+	now := time.Now()
+	if !endpoint.Active {
+		update.Status = domain.WebhookDeliveryStatusDead
+		update.LastError = "webhook endpoint is disabled"
+		update.NextAttemptAt = now
+	} else {
+		statusCode, err := s.send(ctx, endpoint, delivery)
+		update.LastStatusCode = int32(statusCode)
+
+		now = time.Now()
+		switch {
+		case err == nil:
+			update.Status = domain.WebhookDeliveryStatusDelivered
+			update.NextAttemptAt = now
+			update.DeliveredAt = &now
+		case update.Attempts >= webhookDeliveryMaxAttempt:
+			update.Status = domain.WebhookDeliveryStatusDead
+			update.LastError = err.Error()
+			update.NextAttemptAt = now
+		default:
+			update.LastError = err.Error()
+			update.NextAttemptAt = now.Add(webhookRetryDelay(update.Attempts))
+		}
+	}
+
+	result := "retry"
+	switch update.Status {
+	case domain.WebhookDeliveryStatusDelivered:
+		result = "delivered"
+	case domain.WebhookDeliveryStatusDead:
+		result = "dead"
+	}
+	metrics.WebhookDeliveryAttempts.WithLabelValues(delivery.EventType, result).Inc()
+
+	if _, err := s.webhooksRepo.UpdateDelivery(ctx, update); err != nil {
+		fmt.Printf("Warning: failed to update webhook delivery %d: %v\n", delivery.ID, err)
+	}
+}
+
+func (s *webhookService) send(ctx context.Context, endpoint domain.WebhookEndpoint, delivery domain.WebhookDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "whoami-auth-service/1.0")
+	req.Header.Set(security.WebhookIDHeader, delivery.EventID.String())
+	req.Header.Set(security.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(security.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(security.WebhookSignatureHeader, security.SignWebhook(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// webhookRetryDelay doubles the wait after each failed attempt up to six hours.
+func webhookRetryDelay(attempts int32) time.Duration {
+	delay := webhookDeliveryBaseDelay
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookDeliveryMaxDelay {
+			return webhookDeliveryMaxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/m1thrandir225/whoami/internal/domain"
+	"github.com/m1thrandir225/whoami/internal/security"
+)
+
+func TestWebhookSendSignsDelivery(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"user.created","data":{"user_id":7}}`)
+	delivery := domain.WebhookDelivery{EventID: uuid.New(), EventType: "user.created", Payload: payload}
+
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	service := NewWebhookService(nil, time.Second).(*webhookService)
+	status, err := service.send(context.Background(), domain.WebhookEndpoint{URL: server.URL, Secret: secret}, delivery)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("send() = %d, %v; want %d", status, err, http.StatusNoContent)
+	}
+
+	if got := received.Header.Get(security.WebhookIDHeader); got != delivery.EventID.String() {
+		t.Errorf("%s = %q, want %q", security.WebhookIDHeader, got, delivery.EventID)
+	}
+	if got := received.Header.Get(security.WebhookEventHeader); got != "user.created" {
+		t.Errorf("%s = %q, want user.created", security.WebhookEventHeader, got)
+	}
+
+	timestamp, err := strconv.ParseInt(received.Header.Get(security.WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header is not Unix seconds: %v", err)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age < 0 || age > time.Minute {
+		t.Errorf("timestamp is %v old, want the time of sending", age)
+	}
+
+	want := security.SignWebhook(secret, timestamp, receivedBody)
+	if got := received.Header.Get(security.WebhookSignatureHeader); got != want {
+		t.Errorf("%s = %q, want the signature of the raw body %q", security.WebhookSignatureHeader, got, want)
+	}
+}
+
+func TestWebhookSendReportsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown signature", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	service := NewWebhookService(nil, time.Second).(*webhookService)
+	status, err := service.send(context.Background(), domain.WebhookEndpoint{URL: server.URL, Secret: "secret"}, domain.WebhookDelivery{Payload: []byte(`{}`)})
+	if status != http.StatusUnauthorized || err == nil {
+		t.Errorf("send() = %d, %v; want %d with an error", status, err, http.StatusUnauthorized)
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 10, want: 256 * time.Minute},
+		{attempts: 11, want: 6 * time.Hour},
+		{attempts: 40, want: 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("webhookRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
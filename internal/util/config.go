@@ -41,6 +41,125 @@ type Config struct {
 	TLSKeyFile     string   `mapstructure:"TLS_KEY_FILE"`
 
 	FrontendURL string `mapstructure:"FRONTEND_URL"`
+
+	// Legacy identity store (lazy migration)
+	LegacyAuthURL     string        `mapstructure:"LEGACY_AUTH_URL"`
+	LegacyAuthSecret  string        `mapstructure:"LEGACY_AUTH_SECRET"`
+	LegacyAuthTimeout time.Duration `mapstructure:"LEGACY_AUTH_TIMEOUT"`
+
+	// Offline GeoIP databases and location anomaly detection
+	GeoIPCityDBPath               string  `mapstructure:"GEOIP_CITY_DB_PATH"`
+	GeoIPASNDBPath                string  `mapstructure:"GEOIP_ASN_DB_PATH"`
+	ImpossibleTravelSpeedKmh      float64 `mapstructure:"IMPOSSIBLE_TRAVEL_SPEED_KMH"`
+	ImpossibleTravelMinDistanceKm float64 `mapstructure:"IMPOSSIBLE_TRAVEL_MIN_DISTANCE_KM"`
+
+	// Emails about sign-ins from new devices and locations
+	LoginAlertTokenTTL time.Duration `mapstructure:"LOGIN_ALERT_TOKEN_TTL"`
+
+	// Outbound webhooks
+	WebhookTimeout time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+
+	// Outbox relay to the message bus, see events.NewEventPublisher
+	EventPublisher         string        `mapstructure:"EVENT_PUBLISHER"`
+	EventFilePath          string        `mapstructure:"EVENT_FILE_PATH"`
+	NATSURL                string        `mapstructure:"NATS_URL"`
+	NATSSubjectPrefix      string        `mapstructure:"NATS_SUBJECT_PREFIX"`
+	KafkaBrokers           []string      `mapstructure:"KAFKA_BROKERS"`
+	KafkaTopic             string        `mapstructure:"KAFKA_TOPIC"`
+	EventRedisStream       string        `mapstructure:"EVENT_REDIS_STREAM"`
+	EventRedisStreamMaxLen int64         `mapstructure:"EVENT_REDIS_STREAM_MAX_LEN"`
+	OutboxPollInterval     time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	OutboxPublishTimeout   time.Duration `mapstructure:"OUTBOX_PUBLISH_TIMEOUT"`
+	OutboxRetention        time.Duration `mapstructure:"OUTBOX_RETENTION"`
+
+	// CAPTCHA challenges after repeated failed logins
+	CaptchaProvider      string        `mapstructure:"CAPTCHA_PROVIDER"`
+	CaptchaSiteKey       string        `mapstructure:"CAPTCHA_SITE_KEY"`
+	CaptchaSecret        string        `mapstructure:"CAPTCHA_SECRET"`
+	CaptchaMinScore      float64       `mapstructure:"CAPTCHA_MIN_SCORE"`
+	CaptchaTimeout       time.Duration `mapstructure:"CAPTCHA_TIMEOUT"`
+	CaptchaWindow        time.Duration `mapstructure:"CAPTCHA_WINDOW"`
+	CaptchaEmailFailures int64         `mapstructure:"CAPTCHA_EMAIL_FAILURES"`
+	CaptchaIPFailures    int64         `mapstructure:"CAPTCHA_IP_FAILURES"`
+
+	// Session storage
+	SessionStore        string `mapstructure:"SESSION_STORE"`
+	SessionCacheEnabled bool   `mapstructure:"SESSION_CACHE_ENABLED"`
+
+	// Session lifetimes, overridable per role as "role=idle/absolute,..."
+	SessionIdleTimeout     time.Duration `mapstructure:"SESSION_IDLE_TIMEOUT"`
+	SessionAbsoluteTimeout time.Duration `mapstructure:"SESSION_ABSOLUTE_TIMEOUT"`
+	SessionRoleTimeouts    string        `mapstructure:"SESSION_ROLE_TIMEOUTS"`
+
+	// Concurrent session limits, overridable per role as "role=max,..."
+	SessionMaxActive     int    `mapstructure:"SESSION_MAX_ACTIVE"`
+	SessionRoleMaxActive string `mapstructure:"SESSION_ROLE_MAX_ACTIVE"`
+	SessionLimitPolicy   string `mapstructure:"SESSION_LIMIT_POLICY"`
+
+	// Cookie mode
+	CookieAuthEnabled        bool   `mapstructure:"COOKIE_AUTH_ENABLED"`
+	CookieAccessTokenEnabled bool   `mapstructure:"COOKIE_ACCESS_TOKEN_ENABLED"`
+	CookieAccessTokenPath    string `mapstructure:"COOKIE_ACCESS_TOKEN_PATH"`
+	CookieDomain             string `mapstructure:"COOKIE_DOMAIN"`
+	CookieSameSite           string `mapstructure:"COOKIE_SAME_SITE"`
+
+	// gRPC identity service, disabled when GRPC_PORT is 0. GRPC_ALLOW_INSECURE
+	// serves it without TLS, for local development only
+	GRPCPort          int      `mapstructure:"GRPC_PORT"`
+	GRPCServiceTokens []string `mapstructure:"GRPC_SERVICE_TOKENS"`
+	GRPCTLSCertFile   string   `mapstructure:"GRPC_TLS_CERT_FILE"`
+	GRPCTLSKeyFile    string   `mapstructure:"GRPC_TLS_KEY_FILE"`
+	GRPCClientCAFile  string   `mapstructure:"GRPC_CLIENT_CA_FILE"`
+	GRPCAllowInsecure bool     `mapstructure:"GRPC_ALLOW_INSECURE"`
+
+	// Prometheus metrics, served on METRICS_ADDRESS and/or on the public
+	// listener to callers presenting METRICS_TOKEN, not at all otherwise
+	MetricsAddress string `mapstructure:"METRICS_ADDRESS"`
+	MetricsToken   string `mapstructure:"METRICS_TOKEN"`
+
+	// Rate limit policies, reloaded when the file changes
+	RateLimitPoliciesFile           string        `mapstructure:"RATE_LIMIT_POLICIES_FILE"`
+	RateLimitPoliciesReloadInterval time.Duration `mapstructure:"RATE_LIMIT_POLICIES_RELOAD_INTERVAL"`
+	RateLimitFailureMode            string        `mapstructure:"RATE_LIMIT_FAILURE_MODE"`
+
+	// Client IP resolution behind reverse proxies
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+	ClientIPHeader string   `mapstructure:"CLIENT_IP_HEADER"`
+
+	// Account lockout escalation, see services.LockoutPolicy
+	LockoutMaxFailures      int64         `mapstructure:"LOCKOUT_MAX_FAILURES"`
+	LockoutFailureWindow    time.Duration `mapstructure:"LOCKOUT_FAILURE_WINDOW"`
+	LockoutBaseDuration     time.Duration `mapstructure:"LOCKOUT_BASE_DURATION"`
+	LockoutMaxDuration      time.Duration `mapstructure:"LOCKOUT_MAX_DURATION"`
+	LockoutEscalationWindow time.Duration `mapstructure:"LOCKOUT_ESCALATION_WINDOW"`
+	LockoutPermanentAfter   int64         `mapstructure:"LOCKOUT_PERMANENT_AFTER"`
+	LockoutDelayAfter       int64         `mapstructure:"LOCKOUT_DELAY_AFTER"`
+	LockoutBaseDelay        time.Duration `mapstructure:"LOCKOUT_BASE_DELAY"`
+	LockoutMaxDelay         time.Duration `mapstructure:"LOCKOUT_MAX_DELAY"`
+	LockoutHistoryRetention time.Duration `mapstructure:"LOCKOUT_HISTORY_RETENTION"`
+
+	// IP lockouts from failed-login velocity across accounts, 0 disables a check
+	IPLockoutWindow            time.Duration `mapstructure:"IP_LOCKOUT_WINDOW"`
+	IPLockoutDuration          time.Duration `mapstructure:"IP_LOCKOUT_DURATION"`
+	IPLockoutMaxFailures       int64         `mapstructure:"IP_LOCKOUT_MAX_FAILURES"`
+	IPLockoutMinAccounts       int64         `mapstructure:"IP_LOCKOUT_MIN_ACCOUNTS"`
+	IPLockoutSubnetMaxFailures int64         `mapstructure:"IP_LOCKOUT_SUBNET_MAX_FAILURES"`
+
+	// Credential stuffing detection across accounts, 0 disables a detector
+	PasswordFingerprintKey               string        `mapstructure:"PASSWORD_FINGERPRINT_KEY"`
+	CredentialStuffingWindow             time.Duration `mapstructure:"CREDENTIAL_STUFFING_WINDOW"`
+	CredentialStuffingCaptchaDuration    time.Duration `mapstructure:"CREDENTIAL_STUFFING_CAPTCHA_DURATION"`
+	CredentialStuffingIPMaxEmails        int64         `mapstructure:"CREDENTIAL_STUFFING_IP_MAX_EMAILS"`
+	CredentialStuffingASNMinAttempts     int64         `mapstructure:"CREDENTIAL_STUFFING_ASN_MIN_ATTEMPTS"`
+	CredentialStuffingASNMaxFailureRatio float64       `mapstructure:"CREDENTIAL_STUFFING_ASN_MAX_FAILURE_RATIO"`
+	CredentialStuffingPasswordMaxEmails  int64         `mapstructure:"CREDENTIAL_STUFFING_PASSWORD_MAX_EMAILS"`
+
+	// Back-channel logout tokens sent to registered applications
+	LogoutTokenIssuer string `mapstructure:"LOGOUT_TOKEN_ISSUER"`
+
+	// Signed checkpoints of the audit log hash chain, 0 disables the schedule
+	AuditCheckpointKey      string        `mapstructure:"AUDIT_CHECKPOINT_KEY"`
+	AuditCheckpointInterval time.Duration `mapstructure:"AUDIT_CHECKPOINT_INTERVAL"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -94,6 +213,119 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.BindEnv("TLS_CERT_FILE")
 	viper.BindEnv("TLS_KEY_FILE")
 
+	//Legacy identity store
+	viper.BindEnv("LEGACY_AUTH_URL")
+	viper.BindEnv("LEGACY_AUTH_SECRET")
+	viper.BindEnv("LEGACY_AUTH_TIMEOUT")
+
+	//GeoIP
+	viper.BindEnv("GEOIP_CITY_DB_PATH")
+	viper.BindEnv("GEOIP_ASN_DB_PATH")
+	viper.BindEnv("IMPOSSIBLE_TRAVEL_SPEED_KMH")
+	viper.BindEnv("IMPOSSIBLE_TRAVEL_MIN_DISTANCE_KM")
+
+	//Sign-in alerts
+	viper.BindEnv("LOGIN_ALERT_TOKEN_TTL")
+
+	//Webhooks
+	viper.BindEnv("WEBHOOK_TIMEOUT")
+
+	//Event outbox
+	viper.BindEnv("EVENT_PUBLISHER")
+	viper.BindEnv("EVENT_FILE_PATH")
+	viper.BindEnv("NATS_URL")
+	viper.BindEnv("NATS_SUBJECT_PREFIX")
+	viper.BindEnv("KAFKA_BROKERS")
+	viper.BindEnv("KAFKA_TOPIC")
+	viper.BindEnv("EVENT_REDIS_STREAM")
+	viper.BindEnv("EVENT_REDIS_STREAM_MAX_LEN")
+	viper.BindEnv("OUTBOX_POLL_INTERVAL")
+	viper.BindEnv("OUTBOX_PUBLISH_TIMEOUT")
+	viper.BindEnv("OUTBOX_RETENTION")
+
+	//CAPTCHA
+	viper.BindEnv("CAPTCHA_PROVIDER")
+	viper.BindEnv("CAPTCHA_SITE_KEY")
+	viper.BindEnv("CAPTCHA_SECRET")
+	viper.BindEnv("CAPTCHA_MIN_SCORE")
+	viper.BindEnv("CAPTCHA_TIMEOUT")
+	viper.BindEnv("CAPTCHA_WINDOW")
+	viper.BindEnv("CAPTCHA_EMAIL_FAILURES")
+	viper.BindEnv("CAPTCHA_IP_FAILURES")
+
+	//Session storage
+	viper.BindEnv("SESSION_STORE")
+	viper.BindEnv("SESSION_CACHE_ENABLED")
+	viper.BindEnv("SESSION_IDLE_TIMEOUT")
+	viper.BindEnv("SESSION_ABSOLUTE_TIMEOUT")
+	viper.BindEnv("SESSION_ROLE_TIMEOUTS")
+	viper.BindEnv("SESSION_MAX_ACTIVE")
+	viper.BindEnv("SESSION_ROLE_MAX_ACTIVE")
+	viper.BindEnv("SESSION_LIMIT_POLICY")
+
+	//Cookie mode
+	viper.BindEnv("COOKIE_AUTH_ENABLED")
+	viper.BindEnv("COOKIE_ACCESS_TOKEN_ENABLED")
+	viper.BindEnv("COOKIE_ACCESS_TOKEN_PATH")
+	viper.BindEnv("COOKIE_DOMAIN")
+	viper.BindEnv("COOKIE_SAME_SITE")
+
+	//gRPC
+	viper.BindEnv("GRPC_PORT")
+	viper.BindEnv("GRPC_SERVICE_TOKENS")
+	viper.BindEnv("GRPC_TLS_CERT_FILE")
+	viper.BindEnv("GRPC_TLS_KEY_FILE")
+	viper.BindEnv("GRPC_CLIENT_CA_FILE")
+	viper.BindEnv("GRPC_ALLOW_INSECURE")
+
+	//Metrics
+	viper.BindEnv("METRICS_ADDRESS")
+	viper.BindEnv("METRICS_TOKEN")
+
+	//Rate limit policies
+	viper.BindEnv("RATE_LIMIT_POLICIES_FILE")
+	viper.BindEnv("RATE_LIMIT_POLICIES_RELOAD_INTERVAL")
+	viper.BindEnv("RATE_LIMIT_FAILURE_MODE")
+
+	//Client IP
+	viper.BindEnv("TRUSTED_PROXIES")
+	viper.BindEnv("CLIENT_IP_HEADER")
+
+	//Account lockouts
+	viper.BindEnv("LOCKOUT_MAX_FAILURES")
+	viper.BindEnv("LOCKOUT_FAILURE_WINDOW")
+	viper.BindEnv("LOCKOUT_BASE_DURATION")
+	viper.BindEnv("LOCKOUT_MAX_DURATION")
+	viper.BindEnv("LOCKOUT_ESCALATION_WINDOW")
+	viper.BindEnv("LOCKOUT_PERMANENT_AFTER")
+	viper.BindEnv("LOCKOUT_DELAY_AFTER")
+	viper.BindEnv("LOCKOUT_BASE_DELAY")
+	viper.BindEnv("LOCKOUT_MAX_DELAY")
+	viper.BindEnv("LOCKOUT_HISTORY_RETENTION")
+
+	//IP lockouts
+	viper.BindEnv("IP_LOCKOUT_WINDOW")
+	viper.BindEnv("IP_LOCKOUT_DURATION")
+	viper.BindEnv("IP_LOCKOUT_MAX_FAILURES")
+	viper.BindEnv("IP_LOCKOUT_MIN_ACCOUNTS")
+	viper.BindEnv("IP_LOCKOUT_SUBNET_MAX_FAILURES")
+
+	//Credential stuffing
+	viper.BindEnv("PASSWORD_FINGERPRINT_KEY")
+	viper.BindEnv("CREDENTIAL_STUFFING_WINDOW")
+	viper.BindEnv("CREDENTIAL_STUFFING_CAPTCHA_DURATION")
+	viper.BindEnv("CREDENTIAL_STUFFING_IP_MAX_EMAILS")
+	viper.BindEnv("CREDENTIAL_STUFFING_ASN_MIN_ATTEMPTS")
+	viper.BindEnv("CREDENTIAL_STUFFING_ASN_MAX_FAILURE_RATIO")
+	viper.BindEnv("CREDENTIAL_STUFFING_PASSWORD_MAX_EMAILS")
+
+	//Back-channel logout
+	viper.BindEnv("LOGOUT_TOKEN_ISSUER")
+
+	//Audit log integrity
+	viper.BindEnv("AUDIT_CHECKPOINT_KEY")
+	viper.BindEnv("AUDIT_CHECKPOINT_INTERVAL")
+
 	err = viper.Unmarshal(&config)
 	if err != nil {
 		log.Fatalf("Error unmarshalling config: %v", err)
@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const scanBatchSize = 500
+
+// ScanKeys walks the keys matching pattern with SCAN and hands them to fn in
+// batches, so callers never block Redis the way KEYS does.
+func ScanKeys(ctx context.Context, client *redis.Client, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// DeleteMatching removes every key matching pattern, batch by batch, using
+// UNLINK so large values are freed in the background.
+func DeleteMatching(ctx context.Context, client *redis.Client, pattern string) (int64, error) {
+	var deleted int64
+	err := ScanKeys(ctx, client, pattern, func(keys []string) error {
+		n, err := client.Unlink(ctx, keys...).Result()
+		deleted += n
+		return err
+	})
+	return deleted, err
+}
+
+// AddToIndex records key in the index set and keeps the set alive for at
+// least ttl without shortening a longer expiry set by another member.
+func AddToIndex(ctx context.Context, pipe redis.Pipeliner, indexKey, key string, ttl time.Duration) {
+	pipe.SAdd(ctx, indexKey, key)
+	pipe.ExpireNX(ctx, indexKey, ttl)
+	pipe.ExpireGT(ctx, indexKey, ttl)
+}
+
+// DeleteIndexed removes every key listed in the index set along with the set.
+func DeleteIndexed(ctx context.Context, client *redis.Client, indexKey string) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.SScan(ctx, indexKey, cursor, "", scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := client.Unlink(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return client.Unlink(ctx, indexKey).Err()
+}
@@ -0,0 +1,179 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestClient connects to the Redis in TESTING_REDIS_URL and returns a key
+// prefix unique to the test.
+func newTestClient(t *testing.T) (*redis.Client, string) {
+	redisURL := os.Getenv("TESTING_REDIS_URL")
+	if redisURL == "" {
+		t.Skip("TESTING_REDIS_URL is not set")
+	}
+
+	client, err := NewRedisClient(redisURL)
+	if err != nil {
+		t.Fatalf("failed to create Redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	prefix := fmt.Sprintf("test:scan:%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		if _, err := DeleteMatching(context.Background(), client, prefix+":*"); err != nil {
+			t.Errorf("failed to clean up test keys: %v", err)
+		}
+	})
+
+	return client, prefix
+}
+
+func TestScanKeysVisitsEveryMatchInBatches(t *testing.T) {
+	client, prefix := newTestClient(t)
+	ctx := context.Background()
+
+	// More keys than one SCAN batch, plus some that must not match
+	const matching = scanBatchSize*2 + 17
+	pipe := client.Pipeline()
+	for i := 0; i < matching; i++ {
+		pipe.Set(ctx, fmt.Sprintf("%s:match:%d", prefix, i), 1, time.Minute)
+	}
+	for i := 0; i < 10; i++ {
+		pipe.Set(ctx, fmt.Sprintf("%s:other:%d", prefix, i), 1, time.Minute)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	err := ScanKeys(ctx, client, prefix+":match:*", func(keys []string) error {
+		for _, key := range keys {
+			seen[key] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanKeys() error = %v", err)
+	}
+	if len(seen) != matching {
+		t.Errorf("ScanKeys() visited %d keys, want %d", len(seen), matching)
+	}
+}
+
+func TestScanKeysStopsOnCallbackError(t *testing.T) {
+	client, prefix := newTestClient(t)
+	ctx := context.Background()
+
+	if err := client.Set(ctx, prefix+":key", 1, time.Minute).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantErr := errors.New("stop")
+	err := ScanKeys(ctx, client, prefix+":*", func(keys []string) error {
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("ScanKeys() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestDeleteMatching(t *testing.T) {
+	client, prefix := newTestClient(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"a:1", "a:2", "a:3", "b:1"} {
+		if err := client.Set(ctx, prefix+":"+key, 1, time.Minute).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := DeleteMatching(ctx, client, prefix+":a:*")
+	if err != nil {
+		t.Fatalf("DeleteMatching() error = %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("DeleteMatching() deleted %d keys, want 3", deleted)
+	}
+	if n := client.Exists(ctx, prefix+":b:1").Val(); n != 1 {
+		t.Error("DeleteMatching() removed a key outside the pattern")
+	}
+}
+
+func TestAddToIndexOnlyExtendsTTL(t *testing.T) {
+	client, prefix := newTestClient(t)
+	ctx := context.Background()
+	indexKey := prefix + ":index"
+
+	add := func(key string, ttl time.Duration) {
+		t.Helper()
+		pipe := client.TxPipeline()
+		AddToIndex(ctx, pipe, indexKey, key, ttl)
+		if _, err := pipe.Exec(ctx); err != nil {
+			t.Fatalf("AddToIndex() error = %v", err)
+		}
+	}
+
+	add(prefix+":long", time.Hour)
+	add(prefix+":short", time.Minute)
+	if ttl := client.TTL(ctx, indexKey).Val(); ttl <= 59*time.Minute {
+		t.Errorf("index TTL = %v after a shorter member was added, want about 1h", ttl)
+	}
+
+	add(prefix+":longer", 2*time.Hour)
+	if ttl := client.TTL(ctx, indexKey).Val(); ttl <= time.Hour {
+		t.Errorf("index TTL = %v after a longer member was added, want about 2h", ttl)
+	}
+
+	members := client.SMembers(ctx, indexKey).Val()
+	sort.Strings(members)
+	want := []string{prefix + ":long", prefix + ":longer", prefix + ":short"}
+	if fmt.Sprint(members) != fmt.Sprint(want) {
+		t.Errorf("index members = %v, want %v", members, want)
+	}
+}
+
+func TestDeleteIndexed(t *testing.T) {
+	client, prefix := newTestClient(t)
+	ctx := context.Background()
+	indexKey := prefix + ":index"
+
+	pipe := client.TxPipeline()
+	for i := 0; i < scanBatchSize+5; i++ {
+		key := fmt.Sprintf("%s:indexed:%d", prefix, i)
+		pipe.Set(ctx, key, 1, time.Minute)
+		AddToIndex(ctx, pipe, indexKey, key, time.Minute)
+	}
+	pipe.Set(ctx, prefix+":unindexed", 1, time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteIndexed(ctx, client, indexKey); err != nil {
+		t.Fatalf("DeleteIndexed() error = %v", err)
+	}
+
+	var left int
+	err := ScanKeys(ctx, client, prefix+":*", func(keys []string) error {
+		for _, key := range keys {
+			if key != prefix+":unindexed" {
+				t.Errorf("key %s survived DeleteIndexed()", key)
+			}
+			left++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left != 1 {
+		t.Errorf("%d keys left, want only the unindexed one", left)
+	}
+}